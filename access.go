@@ -0,0 +1,117 @@
+package boltdb
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Access is the kind of access of a session operation to a bucket path, see AccessPolicy.
+type Access int
+
+const (
+	AccessRead   Access = iota + 1 // reads keys, buckets or indexes
+	AccessWrite                    // writes keys, sequences or buckets
+	AccessDelete                   // deletes keys or buckets
+)
+
+func (a Access) String() string {
+	switch a {
+	case AccessRead:
+		return "read"
+	case AccessWrite:
+		return "write"
+	case AccessDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// AccessRequest is the access of a session operation checked by an AccessPolicy.
+type AccessRequest struct {
+	Principal string   // principal of the session, see Store.ReadSessionAs and Store.WriteSessionAs
+	Access    Access   // kind of access
+	Op        string   // operation, e.g. "Read" or "DeleteBucket", see Op
+	Path      []string // bucket path accessed, nil for operations on indexes
+	Key       string   // key, key prefix or index name, empty for bucket operations
+}
+
+// AccessPolicy decides whether a session operation is allowed. Operations moving or copying keys
+// across buckets are checked once per bucket path accessed.
+type AccessPolicy func(req AccessRequest) bool
+
+// opAccess is the kind of access of the operations to their path, AccessRead when not listed.
+var opAccess = map[string]Access{
+	"Write":            AccessWrite,
+	"WriteMany":        AccessWrite,
+	"WriteIfMatch":     AccessWrite,
+	"WriteIfNoneMatch": AccessWrite,
+	"WriteStream":      AccessWrite,
+	"Increment":        AccessWrite,
+	"Append":           AccessWrite,
+	"Merge":            AccessWrite,
+	"PatchJSON":        AccessWrite,
+	"MoveKey":          AccessWrite,
+	"NextSeq":          AccessWrite,
+	"SetSeq":           AccessWrite,
+	"CreateBucket":     AccessWrite,
+	"RebuildIndex":     AccessWrite,
+
+	"DeleteKey":               AccessDelete,
+	"DeleteMany":              AccessDelete,
+	"DeleteBucket":            AccessDelete,
+	"TruncateBucket":          AccessDelete,
+	"TruncateBucketRecursive": AccessDelete,
+	"MoveBucket":              AccessDelete,
+	"MoveKeyAcross":           AccessDelete,
+}
+
+// SetAccessPolicy sets the policy checking the operations of the sessions started on behalf of a principal,
+// by Store.ReadSessionAs, Store.WriteSessionAs, Store.ViewAs and Store.UpdateAs. Operations the policy
+// denies fail with ErrAccessDenied before running any interceptor. Sessions without principal, such as
+// those of the store itself, are not checked. A nil policy allows every operation.
+func (s *Store) SetAccessPolicy(policy AccessPolicy) {
+	s.interceptorsMu.Lock()
+	defer s.interceptorsMu.Unlock()
+
+	s.policy = policy
+}
+
+// authorize checks op against the access policy, when the session has a principal.
+func (s *Session) authorize(op *Op) error {
+	if s.principal == "" {
+		return nil
+	}
+
+	s.store.interceptorsMu.RLock()
+	policy := s.store.policy
+	s.store.interceptorsMu.RUnlock()
+
+	if policy == nil {
+		return nil
+	}
+
+	access, ok := opAccess[op.Name]
+	if !ok {
+		access = AccessRead
+	}
+
+	if err := s.allowed(policy, access, op.Name, op.Path, op.Key); err != nil {
+		return err
+	}
+	if op.Target != nil {
+		return s.allowed(policy, AccessWrite, op.Name, op.Target, op.Key)
+	}
+
+	return nil
+}
+
+func (s *Session) allowed(policy AccessPolicy, access Access, op string, path []string, key string) error {
+	req := AccessRequest{Principal: s.principal, Access: access, Op: op, Path: path, Key: key}
+	if policy(req) {
+		return nil
+	}
+
+	s.store.logger.Debug("Session::authorize", "principal", s.principal, "access", access.String(), "op", op, "path", path)
+
+	return errors.Wrapf(ErrAccessDenied, "%s access of %s by %s", access, op, s.principal)
+}
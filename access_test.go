@@ -0,0 +1,119 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantPolicy allows principals to read and write the buckets of their tenant, and admin to delete them.
+func tenantPolicy(req boltdb.AccessRequest) bool {
+	if req.Principal == "admin" {
+		return true
+	}
+	if len(req.Path) < 2 || req.Path[0] != "tenants" || req.Path[1] != req.Principal {
+		return false
+	}
+	return req.Access != boltdb.AccessDelete
+}
+
+func TestAccessPolicy(t *testing.T) {
+	s := newTestStore(t)
+	s.SetAccessPolicy(tenantPolicy)
+
+	acme := []string{"tenants", "acme", "users"}
+	globex := []string{"tenants", "globex", "users"}
+
+	require.NoError(t, s.UpdateAs("acme", func(w boltdb.Writer) error {
+		return w.Write(acme, "alice", []byte("v"))
+	}))
+	require.NoError(t, s.ViewAs("acme", func(r boltdb.Reader) error {
+		_, err := r.Read(acme, "alice")
+		return err
+	}))
+
+	assert.ErrorIs(t, s.UpdateAs("acme", func(w boltdb.Writer) error {
+		return w.Write(globex, "alice", []byte("v"))
+	}), boltdb.ErrAccessDenied)
+	assert.ErrorIs(t, s.ViewAs("globex", func(r boltdb.Reader) error {
+		_, err := r.Read(acme, "alice")
+		return err
+	}), boltdb.ErrAccessDenied)
+	assert.ErrorIs(t, s.UpdateAs("acme", func(w boltdb.Writer) error {
+		return w.DeleteKey(acme, "alice")
+	}), boltdb.ErrAccessDenied)
+
+	// both the source and the destination of copies are checked
+	assert.ErrorIs(t, s.UpdateAs("acme", func(w boltdb.Writer) error {
+		return w.CopyBucket(acme, globex)
+	}), boltdb.ErrAccessDenied)
+	assert.NoError(t, s.UpdateAs("admin", func(w boltdb.Writer) error {
+		return w.CopyBucket(acme, globex)
+	}))
+
+	// sessions without principal are not checked
+	assert.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.DeleteKey(acme, "alice")
+	}))
+	assert.Equal(t, "v", readValue(t, s, globex, "alice"))
+}
+
+func TestAccessPolicyReads(t *testing.T) {
+	s := newTestStore(t)
+	s.SetAccessPolicy(tenantPolicy)
+
+	acme := []string{"tenants", "acme", "users"}
+	write(t, s, acme, "alice")
+
+	// existence checks and streams are checked like the other operations
+	require.NoError(t, s.ViewAs("acme", func(r boltdb.Reader) error {
+		assert.True(t, r.KeyExists(acme, "alice"))
+		return nil
+	}))
+	require.NoError(t, s.ViewAs("globex", func(r boltdb.Reader) error {
+		assert.False(t, r.KeyExists(acme, "alice"))
+		assert.False(t, r.KeyExistsB(acme, []byte("alice")))
+		return nil
+	}))
+
+	session, closer, err := s.WriteSessionAs("globex")
+	require.NoError(t, err)
+	defer closer()
+
+	_, err = session.WriteStream(acme, "bob")
+	assert.ErrorIs(t, err, boltdb.ErrAccessDenied)
+}
+
+func TestAccessPolicyBeforeInterceptors(t *testing.T) {
+	s := newTestStore(t)
+	s.SetAccessPolicy(func(req boltdb.AccessRequest) bool {
+		return req.Access == boltdb.AccessRead
+	})
+
+	var ops []string
+	s.Use(func(next boltdb.OpHandler) boltdb.OpHandler {
+		return func(op *boltdb.Op) error {
+			ops = append(ops, op.Name)
+			return next(op)
+		}
+	})
+
+	session, closer, err := s.WriteSessionAs("bob")
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	err = session.Write([]string{"a"}, "k", []byte("v"))
+	assert.ErrorIs(t, err, boltdb.ErrAccessDenied)
+
+	var se *boltdb.StoreError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, []string{"a"}, se.Path)
+
+	assert.False(t, session.KeyExists([]string{"a"}, "k"))
+	assert.Equal(t, []string{"KeyExists"}, ops)
+
+	s.SetAccessPolicy(nil)
+	assert.NoError(t, session.Write([]string{"a"}, "k", []byte("v")))
+}
@@ -0,0 +1,139 @@
+package boltdb
+
+import "io"
+
+//go:generate mockgen -source=api.go -destination=mock/mock.go -package=mock
+
+// Reader is the read-only surface of a Session.
+type Reader interface {
+	Revision() uint64
+
+	Read(path []string, key string) ([]byte, error)
+	ReadB(path []string, key []byte) ([]byte, error)
+	ReadUint64Key(path []string, key uint64) ([]byte, error)
+	ReadWithETag(path []string, key string) ([]byte, string, error)
+	ReadField(path []string, key, pointer string) ([]byte, error)
+	ReadAt(path []string, key string, rev uint64) ([]byte, error)
+	History(path []string, key string, limit int) ([]Version, error)
+	Metadata(path []string, key string) (*KeyMetadata, error)
+	ReadStream(path []string, key string) (io.ReadCloser, error)
+
+	KeyExists(path []string, key string) bool
+	KeyExistsB(path []string, key []byte) bool
+	PrefixExists(path []string, prefix string) (bool, error)
+	BucketExists(path []string) bool
+	CurrentSeq(path []string) (uint64, error)
+	BucketInfo(path []string) (*BucketInfo, error)
+	Sample(path []string, n int) ([]string, [][]byte, error)
+	KeyHistogram(path []string, prefixDepth int) (*KeyHistogram, error)
+	ValueSizeStats(path []string) (*ValueSizeStats, error)
+
+	List(path []string, pageToken string) ([]string, [][]byte, string, error)
+	ListEntries(path []string, pageToken string) ([]Entry, string, error)
+	ListKeys(path []string, pageToken string) ([]string, string, error)
+	ListBuckets(path []string, pageToken string) ([]string, string, error)
+	Paginate(path []string, opts PaginateOptions) *Paginator
+	Tree(path []string, depth int) (*Node, error)
+	ReadScan(path []string, prefix string) ([]string, [][]byte, error)
+	ScanB(path []string, start []byte, fn func(key, value []byte) bool) error
+	ScanMatch(path []string, pattern string, kind MatchKind, pageToken string) ([]string, [][]byte, string, error)
+
+	QueryIndex(name string, q IndexQuery, pageToken string) ([]IndexEntry, string, error)
+	SearchTokens(path []string, query, pageToken string) ([]IndexEntry, string, error)
+	AuditLog(q AuditQuery, pageToken string) ([]AuditRecord, string, error)
+}
+
+// Writer is the read-write surface of a Session.
+type Writer interface {
+	Reader
+
+	Principal() string
+	Savepoint() (rollbackTo func(), err error)
+	OnCommit(fn func())
+	OnRollback(fn func())
+
+	Write(path []string, key string, value []byte) error
+	WriteB(path []string, key, value []byte) error
+	WriteUint64Key(path []string, key uint64, value []byte) error
+	WriteMany(path []string, values map[string][]byte) error
+	WriteIfMatch(path []string, key string, value []byte, etag string) error
+	WriteIfNoneMatch(path []string, key string, value []byte, etag string) error
+	WriteStream(path []string, key string) (io.WriteCloser, error)
+
+	DeleteKey(path []string, key string) error
+	DeleteKeyB(path []string, key []byte) error
+	DeleteUint64Key(path []string, key uint64) error
+	DeleteMany(path []string, keys []string) error
+
+	MoveKey(path []string, oldKey, newKey string, overwrite bool) error
+	MoveKeyAcross(srcPath, dstPath []string, key string, opts MoveOptions) error
+
+	Increment(path []string, key string, delta int64) (int64, error)
+	Append(path []string, key string, data []byte) error
+	Merge(path []string, key string, partial []byte) error
+	PatchJSON(path []string, key string, patch []byte, mode PatchMode) error
+
+	NextSeq(path []string) (uint64, error)
+	SetSeq(path []string, v uint64) error
+	ResetSeq(path []string) error
+
+	CreateBucket(path []string) error
+	DeleteBucket(path []string) error
+	TruncateBucket(path []string) error
+	TruncateBucketRecursive(path []string) error
+	CopyBucket(src, dst []string) error
+	MoveBucket(src, dst []string) error
+
+	RebuildIndex(name string) error
+}
+
+// StoreAPI is the surface of a Store used by consumers, running sessions through closures
+// so they can be replaced by fakes.
+type StoreAPI interface {
+	View(fn func(Reader) error) error
+	Update(fn func(Writer) error) error
+	Revision() uint64
+	WatchFrom(rev uint64, prefix Path) (<-chan Event, func())
+}
+
+var (
+	_ Writer   = (*Session)(nil)
+	_ StoreAPI = (*Store)(nil)
+)
+
+// View runs fn in a read session.
+func (s *Store) View(fn func(Reader) error) error {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return fn(session)
+}
+
+// Update runs fn in a write session, committed when neither fn nor the session failed.
+func (s *Store) Update(fn func(Writer) error) error {
+	return s.update(func(session *Session) error {
+		return fn(session)
+	})
+}
+
+// ViewAs runs fn in a read session on behalf of principal, see Store.ReadSessionAs.
+func (s *Store) ViewAs(principal string, fn func(Reader) error) error {
+	session, closer, err := s.ReadSessionAs(principal)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return fn(session)
+}
+
+// UpdateAs runs fn in a write session on behalf of principal, see Store.WriteSessionAs.
+func (s *Store) UpdateAs(principal string, fn func(Writer) error) error {
+	return s.update(func(session *Session) error {
+		session.principal = principal
+		return fn(session)
+	})
+}
@@ -0,0 +1,72 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewUpdate(t *testing.T) {
+	store := newTestStore(t)
+	path := []string{"api"}
+
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		return w.Write(path, "a", []byte("1"))
+	}))
+
+	errAbort := errors.New("abort")
+	err := store.Update(func(w boltdb.Writer) error {
+		if err := w.Write(path, "b", []byte("2")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	require.NoError(t, store.View(func(r boltdb.Reader) error {
+		value, err := r.Read(path, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(value))
+		assert.False(t, r.KeyExists(path, "b"))
+		return nil
+	}))
+}
+
+// countKeys is a consumer of the StoreAPI interface.
+func countKeys(api boltdb.StoreAPI, path []string) (int, error) {
+	var n int
+	err := api.View(func(r boltdb.Reader) error {
+		keys, _, err := r.ListKeys(path, "")
+		n = len(keys)
+		return err
+	})
+	return n, err
+}
+
+func TestStoreAPIMock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	reader := mock.NewMockReader(ctrl)
+	reader.EXPECT().ListKeys([]string{"api"}, "").Return([]string{"a", "b"}, "", nil)
+
+	api := mock.NewMockStoreAPI(ctrl)
+	api.EXPECT().View(gomock.Any()).DoAndReturn(func(fn func(boltdb.Reader) error) error {
+		return fn(reader)
+	})
+
+	n, err := countKeys(api, []string{"api"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	store := newTestStore(t)
+	write(t, store, []string{"api"}, "a")
+
+	n, err = countKeys(store, []string{"api"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
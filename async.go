@@ -0,0 +1,291 @@
+package boltdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultAsyncMaxBatch = 1000                  // operations committed per batch
+	defaultAsyncMaxDelay = 10 * time.Millisecond // delay before a partial batch is committed
+)
+
+// AsyncWriterConfig configures the writer returned by Store.NewAsyncWriter.
+type AsyncWriterConfig struct {
+	MaxBatch  int                         // operations committed per batch, defaultAsyncMaxBatch when zero
+	MaxDelay  time.Duration               // delay before a partial batch is committed, defaultAsyncMaxDelay when zero
+	QueueSize int                         // operations queued before Put and Delete block, MaxBatch when zero
+	OnError   func(op AsyncOp, err error) // called for every operation which failed, optional
+	Priority  WritePriority               // priority of the write sessions committing batches, see WithWritePriority
+}
+
+// AsyncOp is an operation queued by an AsyncWriter.
+type AsyncOp struct {
+	Path   []string
+	Key    string
+	Value  []byte // nil for deletes
+	Delete bool
+}
+
+// AsyncWriterStats reports the operations of an AsyncWriter.
+type AsyncWriterStats struct {
+	Queued    int    // operations waiting for a batch
+	Written   uint64 // operations committed
+	Failed    uint64 // operations which failed
+	Batches   uint64 // batches committed
+	LastError error  // error of the last failed operation
+}
+
+// AsyncWriter coalesces writes queued from any goroutine into batches committed in a single write session,
+// see Store.NewAsyncWriter.
+type AsyncWriter struct {
+	store *Store
+	cfg   AsyncWriterConfig
+
+	mu     sync.RWMutex // held by Put and Delete while queueing, write-locked by Close
+	closed bool
+
+	ops     chan AsyncOp
+	flushes chan chan error
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	statsMu sync.Mutex
+	stats   AsyncWriterStats
+	err     error // first error since the last Flush
+}
+
+// NewAsyncWriter returns a writer committing the operations queued by Put and Delete in batches of
+// cfg.MaxBatch operations, or after cfg.MaxDelay for partial batches, rather than one commit per operation.
+// Put and Delete block while cfg.QueueSize operations are queued. A batch which fails is committed again
+// one operation at a time, so a single failing operation only fails itself.
+// The writer is closed by AsyncWriter.Close and when the store is closed, committing the queued operations.
+func (s *Store) NewAsyncWriter(cfg AsyncWriterConfig) *AsyncWriter {
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = defaultAsyncMaxBatch
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultAsyncMaxDelay
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = cfg.MaxBatch
+	}
+
+	w := &AsyncWriter{
+		store:   s,
+		cfg:     cfg,
+		ops:     make(chan AsyncOp, cfg.QueueSize),
+		flushes: make(chan chan error),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go w.run()
+
+	s.addStopper(func() { _ = w.Close() })
+
+	return w
+}
+
+// Put queues the write of value for key in bucket path, blocking while the queue is full until ctx is done.
+// It fails with ErrWriterClosed once the writer has been closed.
+func (w *AsyncWriter) Put(ctx context.Context, path []string, key string, value []byte) error {
+	return w.queue(ctx, AsyncOp{Path: path, Key: key, Value: append([]byte{}, value...)})
+}
+
+// Delete queues the delete of key in bucket path, blocking while the queue is full until ctx is done.
+// It fails with ErrWriterClosed once the writer has been closed.
+func (w *AsyncWriter) Delete(ctx context.Context, path []string, key string) error {
+	return w.queue(ctx, AsyncOp{Path: path, Key: key, Delete: true})
+}
+
+func (w *AsyncWriter) queue(ctx context.Context, op AsyncOp) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	select {
+	case w.ops <- op:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush commits the operations queued before the call, returning the first error of the operations
+// which failed since the previous Flush.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+
+	select {
+	case w.flushes <- reply:
+	case <-w.done:
+		return w.takeErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close commits the queued operations and stops the writer, returning the first error of the operations
+// which failed since the previous Flush.
+func (w *AsyncWriter) Close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+
+		close(w.stop)
+		<-w.done
+	})
+
+	return w.takeErr()
+}
+
+// Stats returns the operations of the writer.
+func (w *AsyncWriter) Stats() AsyncWriterStats {
+	w.statsMu.Lock()
+	stats := w.stats
+	w.statsMu.Unlock()
+
+	stats.Queued = len(w.ops)
+
+	return stats
+}
+
+func (w *AsyncWriter) takeErr() error {
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+
+	err := w.err
+	w.err = nil
+
+	return err
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	batch := make([]AsyncOp, 0, w.cfg.MaxBatch)
+
+	timer := time.NewTimer(w.cfg.MaxDelay)
+	timer.Stop()
+
+	// drain queues the operations already queued, up to a full batch
+	drain := func() {
+		for len(batch) < w.cfg.MaxBatch {
+			select {
+			case op := <-w.ops:
+				batch = append(batch, op)
+			default:
+				return
+			}
+		}
+	}
+
+	commit := func() {
+		timer.Stop()
+		w.commit(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case op := <-w.ops:
+			if len(batch) == 0 {
+				timer.Reset(w.cfg.MaxDelay)
+			}
+			batch = append(batch, op)
+			if len(batch) >= w.cfg.MaxBatch {
+				commit()
+			}
+
+		case <-timer.C:
+			commit()
+
+		case reply := <-w.flushes:
+			for drain(); len(batch) > 0; drain() {
+				commit()
+			}
+			reply <- w.takeErr()
+
+		case <-w.stop:
+			for drain(); len(batch) > 0; drain() {
+				commit()
+			}
+			return
+		}
+	}
+}
+
+// commit commits batch in a single write session, or one operation at a time when the batch fails.
+func (w *AsyncWriter) commit(batch []AsyncOp) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx := WithWritePriority(context.Background(), w.cfg.Priority)
+
+	err := w.store.updateContext(ctx, func(session *Session) error {
+		for i := range batch {
+			if err := session.applyAsync(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil || len(batch) == 1 {
+		w.committed(batch, err)
+		return
+	}
+
+	for i := range batch {
+		op := batch[i : i+1]
+		w.committed(op, w.store.updateContext(ctx, func(session *Session) error {
+			return session.applyAsync(&op[0])
+		}))
+	}
+}
+
+func (s *Session) applyAsync(op *AsyncOp) error {
+	if op.Delete {
+		return s.DeleteKey(op.Path, op.Key)
+	}
+	return s.Write(op.Path, op.Key, op.Value)
+}
+
+func (w *AsyncWriter) committed(batch []AsyncOp, err error) {
+	w.statsMu.Lock()
+	if err == nil {
+		w.stats.Written += uint64(len(batch))
+		w.stats.Batches++
+	} else {
+		err = errors.Wrapf(err, "async write of %q in %v", batch[0].Key, batch[0].Path)
+		w.stats.Failed++
+		w.stats.LastError = err
+		if w.err == nil {
+			w.err = err
+		}
+	}
+	w.statsMu.Unlock()
+
+	if err != nil {
+		w.store.logger.Warn("async::boltdb", "path", batch[0].Path, "key", batch[0].Key, "error", err)
+		if w.cfg.OnError != nil {
+			w.cfg.OnError(batch[0], err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package boltdb_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncWriter(t *testing.T) {
+	s := newTestStore(t)
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{MaxBatch: 100, MaxDelay: time.Hour})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				assert.NoError(t, w.Put(ctx, []string{"telemetry"}, fmt.Sprintf("g%d-%03d", g, i), []byte("v")))
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.NoError(t, w.Delete(ctx, []string{"telemetry"}, "g0-000"))
+	require.NoError(t, w.Flush(ctx))
+
+	stats := w.Stats()
+	assert.Equal(t, uint64(1001), stats.Written)
+	assert.LessOrEqual(t, stats.Batches, uint64(11))
+	assert.Equal(t, 0, stats.Queued)
+
+	assert.Equal(t, "v", readValue(t, s, []string{"telemetry"}, "g3-249"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	var n int
+	require.NoError(t, session.ScanB([]string{"telemetry"}, nil, func(key, value []byte) bool {
+		n++
+		return true
+	}))
+	assert.Equal(t, 999, n)
+}
+
+func TestAsyncWriterDelay(t *testing.T) {
+	s := newTestStore(t)
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{MaxDelay: time.Millisecond})
+
+	require.NoError(t, w.Put(context.Background(), []string{"telemetry"}, "k", []byte("v")))
+
+	assert.Eventually(t, func() bool {
+		return w.Stats().Written == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestAsyncWriterFailure(t *testing.T) {
+	s := newTestStore(t)
+
+	var failed []boltdb.AsyncOp
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{
+		MaxDelay: time.Hour,
+		OnError:  func(op boltdb.AsyncOp, err error) { failed = append(failed, op) },
+	})
+	ctx := context.Background()
+
+	require.NoError(t, w.Put(ctx, []string{"telemetry"}, "k1", []byte("v")))
+	require.NoError(t, w.Put(ctx, []string{"telemetry"}, "", []byte("v")))
+	require.NoError(t, w.Put(ctx, []string{"telemetry"}, "k2", []byte("v")))
+
+	assert.Error(t, w.Flush(ctx))
+	assert.NoError(t, w.Flush(ctx))
+
+	require.Len(t, failed, 1)
+	assert.Equal(t, "", failed[0].Key)
+	assert.Equal(t, []string{"k1", "k2"}, listKeys(t, s, []string{"telemetry"}))
+
+	stats := w.Stats()
+	assert.Equal(t, uint64(2), stats.Written)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Error(t, stats.LastError)
+}
+
+func TestAsyncWriterClose(t *testing.T) {
+	s := newTestStore(t)
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{MaxDelay: time.Hour})
+	ctx := context.Background()
+
+	require.NoError(t, w.Put(ctx, []string{"telemetry"}, "k", []byte("v")))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "v", readValue(t, s, []string{"telemetry"}, "k"))
+	assert.ErrorIs(t, w.Put(ctx, []string{"telemetry"}, "k", []byte("v")), boltdb.ErrWriterClosed)
+	assert.NoError(t, w.Flush(ctx))
+}
+
+func TestAsyncWriterBackpressure(t *testing.T) {
+	s := newTestStore(t)
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{MaxBatch: 1, QueueSize: 1})
+
+	// the open write session blocks the commits of the writer
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	for i := 0; ; i++ {
+		err = w.Put(ctx, []string{"telemetry"}, fmt.Sprintf("k%d", i), []byte("v"))
+		if err != nil {
+			break
+		}
+		require.Less(t, i, 3)
+	}
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	closer()
+	require.NoError(t, w.Flush(context.Background()))
+	assert.Equal(t, []string{"k0", "k1"}, listKeys(t, s, []string{"telemetry"}))
+}
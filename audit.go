@@ -0,0 +1,345 @@
+package boltdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var auditBucket = []byte("audit")
+
+// AuditRecord records a mutation committed by a write session.
+//
+// Records are chained: Prev holds the hash of the previous record and Hash the hex encoded SHA-256
+// of the JSON encoding of the record with an empty Hash, so altering or removing a record breaks
+// the chain, see Store.VerifyAudit.
+type AuditRecord struct {
+	Seq       uint64    `json:"seq"`                 // position in the audit log, starting at 1
+	Revision  uint64    `json:"revision"`            // store revision committed by the session
+	Time      time.Time `json:"time"`                // commit time
+	Principal string    `json:"principal,omitempty"` // writer, see Store.WriteSessionAs
+	Op        string    `json:"op"`                  // mutation, see EventOp
+	Path      []string  `json:"path"`
+	Key       string    `json:"key,omitempty"`
+	Prev      string    `json:"prev"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditQuery selects audit records, zero fields match every record.
+type AuditQuery struct {
+	Principal string    // writer
+	Path      []string  // bucket path prefix
+	From      time.Time // inclusive lower bound of the commit time
+	To        time.Time // exclusive upper bound of the commit time
+}
+
+func (q *AuditQuery) match(r *AuditRecord) bool {
+	return (q.Principal == "" || r.Principal == q.Principal) &&
+		hasPathPrefix(r.Path, q.Path) &&
+		(q.From.IsZero() || !r.Time.Before(q.From)) &&
+		(q.To.IsZero() || r.Time.Before(q.To))
+}
+
+// digest returns the hash of the record.
+func (r *AuditRecord) digest() (string, error) {
+	c := *r
+	c.Hash = ""
+
+	buf, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditSink receives the audit records of the write sessions once they committed, e.g. to forward them to a
+// SIEM, see Store.SetAuditSink. Sinks are called in commit order, one session at a time.
+type AuditSink interface {
+	WriteAudit(records []AuditRecord) error
+}
+
+// AuditFunc is an AuditSink calling the function.
+type AuditFunc func(records []AuditRecord) error
+
+// WriteAudit calls f.
+func (f AuditFunc) WriteAudit(records []AuditRecord) error {
+	return f(records)
+}
+
+// auditWriter is an AuditSink writing a JSON line for every record.
+type auditWriter struct {
+	w io.Writer
+}
+
+func (a auditWriter) WriteAudit(records []AuditRecord) error {
+	enc := json.NewEncoder(a.w)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetAuditSink sets sink to receive the audit records of every committed mutation, independently of the audit
+// bucket. Records are passed whether or not the audit bucket is enabled, without their hash chain unless it is,
+// a nil sink stops passing them. Failures of the sink are logged, the sessions having committed.
+func (s *Store) SetAuditSink(sink AuditSink) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	s.auditSink = sink
+}
+
+// SetAuditWriter sets w to receive a JSON line for every audit record once its session committed, e.g. an
+// AuditFile, see SetAuditSink. A nil w stops writing them.
+func (s *Store) SetAuditWriter(w io.Writer) {
+	if w == nil {
+		s.SetAuditSink(nil)
+		return
+	}
+	s.SetAuditSink(auditWriter{w: w})
+}
+
+func (s *Store) auditing() bool {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	return s.config.Audit || s.auditSink != nil
+}
+
+// appendAudit turns the events of the session, committing revision, into audit records and, when
+// the audit bucket is enabled, appends them to it.
+func (s *Session) appendAudit(revision uint64) error {
+	if len(s.events) == 0 || !s.store.auditing() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	var (
+		b    *bolt.Bucket
+		prev string
+	)
+	if s.store.config.Audit {
+		var err error
+		if b, err = createMetaChild(s.tx, auditBucket); err != nil {
+			return err
+		}
+		if _, v := b.Cursor().Last(); v != nil {
+			var last AuditRecord
+			if err := json.Unmarshal(v, &last); err != nil {
+				return errors.Wrapf(ErrAuditTampered, "last record: %v", err)
+			}
+			prev = last.Hash
+		}
+	}
+
+	s.audit = make([]AuditRecord, 0, len(s.events))
+
+	for _, event := range s.events {
+		record := AuditRecord{
+			Revision:  revision,
+			Time:      now,
+			Principal: s.principal,
+			Op:        event.Op.String(),
+			Path:      event.Path,
+			Key:       string(event.Key),
+		}
+
+		if b != nil {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			record.Seq = seq
+			record.Prev = prev
+
+			if record.Hash, err = record.digest(); err != nil {
+				return err
+			}
+			prev = record.Hash
+
+			buf, err := json.Marshal(&record)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(auditKey(seq), buf); err != nil {
+				return err
+			}
+		}
+
+		s.audit = append(s.audit, record)
+	}
+
+	return nil
+}
+
+// writeAudit passes the audit records of a committed session to the audit sink.
+func (s *Store) writeAudit(records []AuditRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	if s.auditSink == nil {
+		return
+	}
+
+	if err := s.auditSink.WriteAudit(records); err != nil {
+		s.logger.Error("audit::write", "error", err)
+	}
+}
+
+func auditKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// AuditLog returns a paged collection of the audit records matching q, oldest first.
+// Records are only kept when the audit is enabled in the store configuration.
+func (s *Session) AuditLog(q AuditQuery, pageToken string) ([]AuditRecord, string, error) {
+	s.store.logger.Trace("Session::AuditLog", "path", q.Path, "principal", q.Principal)
+
+	var (
+		records   = make([]AuditRecord, 0)
+		nextToken string
+	)
+
+	read := func(tx *bolt.Tx) error {
+		b := metaChild(tx, auditBucket)
+		if b == nil {
+			return nil
+		}
+
+		var (
+			decodeErr error
+			err       error
+		)
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			var r AuditRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				decodeErr = errors.Wrapf(ErrAuditTampered, "record %d: %v", binary.BigEndian.Uint64(k), err)
+				return false
+			}
+			if !q.match(&r) {
+				return false
+			}
+			records = append(records, r)
+			return true
+		})
+		if err == nil {
+			err = decodeErr
+		}
+
+		return err
+	}
+
+	err := s.intercept(newOp("AuditLog", q.Path, ""), func() error { return s.view(read) })
+	if err != nil {
+		return []AuditRecord{}, "", wrapError("AuditLog", q.Path, "", err)
+	}
+
+	return records, nextToken, nil
+}
+
+// PruneAudit deletes the audit records committed before t and returns the number of records deleted.
+// The oldest remaining record keeps the hash of its deleted predecessor, so the chain verified by
+// VerifyAudit starts at the oldest remaining record. Records are deleted in a write session, waiting
+// for the writer of the store like other writes, without changing the revision of the store.
+func (s *Store) PruneAudit(t time.Time) (int, error) {
+	s.logger.Trace("Store::PruneAudit", "before", t)
+
+	var n int
+	err := s.update(func(session *Session) error {
+		b := metaChild(session.tx, auditBucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			var r AuditRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return errors.Wrapf(ErrAuditTampered, "record %d: %v", binary.BigEndian.Uint64(k), err)
+			}
+			if !r.Time.Before(t) {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			n++
+		}
+
+		return nil
+	})
+
+	return n, err
+}
+
+// VerifyAudit checks the hash chain of the audit records, returning ErrAuditTampered when a record
+// has been altered, or records have been inserted or removed, other than by PruneAudit.
+// Removing the most recent records cannot be detected from the chain alone.
+func (s *Store) VerifyAudit(ctx context.Context) error {
+	s.logger.Trace("Store::VerifyAudit")
+
+	db := s.database()
+	if db == nil {
+		return bolt.ErrDatabaseNotOpen
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := metaChild(tx, auditBucket)
+		if b == nil {
+			return nil
+		}
+
+		var (
+			prev    string
+			prevSeq uint64
+		)
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			seq := binary.BigEndian.Uint64(k)
+
+			var r AuditRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return errors.Wrapf(ErrAuditTampered, "record %d: %v", seq, err)
+			}
+
+			hash, err := r.digest()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case r.Seq != seq || hash != r.Hash:
+				return errors.Wrapf(ErrAuditTampered, "record %d altered", seq)
+			case prevSeq != 0 && (seq != prevSeq+1 || r.Prev != prev):
+				return errors.Wrapf(ErrAuditTampered, "record %d does not follow record %d", seq, prevSeq)
+			}
+
+			prev, prevSeq = r.Hash, seq
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,176 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func auditLog(t *testing.T, s *boltdb.Store, q boltdb.AuditQuery) []string {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	records, next, err := session.AuditLog(q, "")
+	require.NoError(t, err)
+	assert.Empty(t, next)
+
+	result := []string{}
+	for _, r := range records {
+		result = append(result, r.Principal+":"+r.Op+":"+boltdb.Path(r.Path).String()+":"+r.Key)
+	}
+	return result
+}
+
+func TestAudit(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{Audit: true})
+
+	session, closer, err := store.WriteSessionAs("alice")
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"users"}, "alice", []byte("a")))
+	require.NoError(t, session.Write([]string{"groups"}, "admins", []byte("alice")))
+	closer()
+
+	session, closer, err = store.WriteSessionAs("bob")
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey([]string{"users"}, "alice"))
+	require.NoError(t, session.DeleteBucket([]string{"groups"}))
+	closer()
+
+	// rolled back sessions are not audited
+	session, closer, err = store.WriteSessionAs("mallory")
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"users"}, "mallory", []byte("m")))
+	_, err = session.Read([]string{"missing"}, "k")
+	require.Error(t, err)
+	closer()
+
+	assert.Equal(t, []string{
+		"alice:put:users:alice",
+		"alice:put:groups:admins",
+		"bob:delete:users:alice",
+		"bob:delete_bucket:groups:",
+	}, auditLog(t, store, boltdb.AuditQuery{}))
+
+	assert.Equal(t, []string{"bob:delete:users:alice", "bob:delete_bucket:groups:"},
+		auditLog(t, store, boltdb.AuditQuery{Principal: "bob"}))
+	assert.Equal(t, []string{"alice:put:users:alice", "bob:delete:users:alice"},
+		auditLog(t, store, boltdb.AuditQuery{Path: []string{"users"}}))
+	assert.Empty(t, auditLog(t, store, boltdb.AuditQuery{From: time.Now().Add(time.Hour)}))
+
+	require.NoError(t, store.VerifyAudit(context.Background()))
+
+	// pruning waits for the writer of the store, without changing its revision
+	revision := store.Revision()
+	_, closer, err = store.WriteSession()
+	require.NoError(t, err)
+
+	pruned := make(chan int, 1)
+	go func() {
+		n, err := store.PruneAudit(time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+		pruned <- n
+	}()
+
+	waitForQueue(t, store, 1)
+	closer()
+
+	assert.Equal(t, 4, <-pruned)
+	assert.Empty(t, auditLog(t, store, boltdb.AuditQuery{}))
+	assert.Equal(t, revision, store.Revision())
+
+	write(t, store, []string{"users"}, "carol")
+	assert.Equal(t, []string{":put:users:carol"}, auditLog(t, store, boltdb.AuditQuery{}))
+	require.NoError(t, store.VerifyAudit(context.Background()))
+}
+
+func TestAuditTampered(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db"), Audit: true}
+
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	write(t, store, []string{"a"}, "1")
+	write(t, store, []string{"a"}, "2")
+	write(t, store, []string{"a"}, "3")
+	store.Close()
+
+	db, err := bolt.Open(cfg.DBPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("__meta")).Bucket([]byte("audit"))
+		key := []byte{0, 0, 0, 0, 0, 0, 0, 2}
+		v := b.Get(key)
+		return b.Put(key, bytes.Replace(v, []byte(`"key":"2"`), []byte(`"key":"x"`), 1))
+	}))
+	require.NoError(t, db.Close())
+
+	store = boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	assert.ErrorIs(t, store.VerifyAudit(context.Background()), boltdb.ErrAuditTampered)
+}
+
+func TestAuditWriter(t *testing.T) {
+	store := newTestStore(t)
+
+	var buf bytes.Buffer
+	store.SetAuditWriter(&buf)
+
+	session, closer, err := store.WriteSessionAs("alice")
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"users"}, "alice", []byte("a")))
+	assert.Zero(t, buf.Len())
+	closer()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var record boltdb.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "alice", record.Principal)
+	assert.Equal(t, "put", record.Op)
+	assert.Equal(t, []string{"users"}, record.Path)
+	assert.Equal(t, uint64(1), record.Revision)
+
+	// the audit bucket is not enabled
+	assert.Empty(t, auditLog(t, store, boltdb.AuditQuery{}))
+}
+
+func TestAuditSink(t *testing.T) {
+	store := newTestStore(t)
+
+	var records []boltdb.AuditRecord
+	store.SetAuditSink(boltdb.AuditFunc(func(r []boltdb.AuditRecord) error {
+		records = append(records, r...)
+		return errors.New("sink failed")
+	}))
+
+	// failures of the sink do not fail the sessions, which committed
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		if err := w.Write([]string{"users"}, "alice", []byte("a")); err != nil {
+			return err
+		}
+		return w.DeleteKey([]string{"users"}, "alice")
+	}))
+	require.Len(t, records, 2)
+	assert.Equal(t, "put", records[0].Op)
+	assert.Equal(t, "delete", records[1].Op)
+
+	store.SetAuditSink(nil)
+	write(t, store, []string{"users"}, "bob")
+	assert.Len(t, records, 2)
+}
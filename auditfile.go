@@ -0,0 +1,185 @@
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultAuditFileMaxSize is the size above which an AuditFile is rotated, used when its max size is zero.
+const DefaultAuditFileMaxSize = 100 << 20
+
+// auditFileTimeFormat names the rotated audit files, sorting lexically.
+const auditFileTimeFormat = "20060102T150405.000000000Z"
+
+// AuditFile is an io.WriteCloser appending to a file, rotated once writing would grow it above its max size:
+// the file is renamed after its path and the rotation time, e.g. audit.log.20240102T150405.000000000Z, and
+// a new file is created, the oldest rotated files beyond max backups being deleted. Writes are not split
+// across files, so the JSON lines written by the audit sink of the store, see Store.SetAuditWriter and
+// Config.AuditFile, are never split.
+type AuditFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewAuditFile opens the file at path, created along with its directory when missing, rotated once it would
+// grow above maxSize, DefaultAuditFileMaxSize when zero, keeping the maxBackups most recent rotated files,
+// or all of them when zero.
+func NewAuditFile(path string, maxSize int64, maxBackups int) (*AuditFile, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultAuditFileMaxSize
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create audit directory '%s'", filepath.Dir(path))
+	}
+
+	f := &AuditFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *AuditFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open audit file '%s'", f.path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the file, rotating it first when p would grow it above its max size.
+func (f *AuditFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the file and opens a new one, deleting the oldest rotated files beyond max backups.
+func (f *AuditFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	rotated := f.path + "." + time.Now().UTC().Format(auditFileTimeFormat)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return errors.Wrapf(err, "failed to rotate audit file '%s'", f.path)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.prune()
+}
+
+// prune deletes the oldest rotated files beyond max backups.
+func (f *AuditFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := f.Backups()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(backups)-f.maxBackups; i++ {
+		if err := os.Remove(backups[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Backups returns the paths of the rotated files, oldest first: the files named after the path of the file
+// and a rotation time, other files of its directory, e.g. audit.log.old, being ignored.
+func (f *AuditFile) Backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(f.path) + "."
+
+	var backups []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		if _, err := time.Parse(auditFileTimeFormat, strings.TrimPrefix(e.Name(), prefix)); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.path), e.Name()))
+	}
+	// timestamps sort lexically
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// Close closes the file, failing the following writes.
+func (f *AuditFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// openAuditFile sets the audit sink of the store to Config.AuditFile, closed when the store is closed.
+func (s *Store) openAuditFile() error {
+	f, err := NewAuditFile(s.config.AuditFile, s.config.AuditFileMaxSize, s.config.AuditFileMaxBackups)
+	if err != nil {
+		return err
+	}
+
+	s.SetAuditWriter(f)
+	s.addStopper(func() {
+		s.SetAuditSink(nil)
+		if err := f.Close(); err != nil {
+			s.logger.Warn("audit::close", "error", err)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package boltdb_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+
+	f, err := boltdb.NewAuditFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	// every write rotated the file, writes larger than the max size being kept whole
+	backups, err := f.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	contents := func(path string) string {
+		buf, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(buf)
+	}
+	assert.Equal(t, "second\n", contents(backups[0]))
+	assert.Equal(t, "third\n", contents(backups[1]))
+	assert.Equal(t, "fourth\n", contents(path))
+
+	require.NoError(t, f.Close())
+	_, err = f.Write([]byte("closed\n"))
+	assert.Error(t, err)
+}
+
+func TestAuditFileUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	// files sharing the name of the audit file are not rotated files
+	for _, name := range []string{"audit.log.old", "audit.log.gz", "audit.log.1"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("keep\n"), 0600))
+	}
+
+	f, err := boltdb.NewAuditFile(path, 10, 1)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	backups, err := f.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.NotContains(t, backups[0], "audit.log.old")
+
+	for _, name := range []string{"audit.log.old", "audit.log.gz", "audit.log.1"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+}
+
+func TestAuditFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	f, err := boltdb.NewAuditFile(path, 0, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = boltdb.NewAuditFile(path, 0, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(buf))
+}
+
+func TestConfigAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	store := newTestStoreWithConfig(t, &boltdb.Config{AuditFile: path})
+
+	writeAs(t, store, "alice", []string{"users"}, "alice")
+	store.Close()
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	require.Len(t, lines, 1)
+
+	var record boltdb.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "alice", record.Principal)
+	assert.Equal(t, []string{"users"}, record.Path)
+}
@@ -0,0 +1,314 @@
+package boltdb
+
+import (
+	"context"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// backupTaskPrefix prefixes the names of the janitor tasks of backup schedules, followed by their prefix.
+const backupTaskPrefix = "backup:"
+
+// BackupInfo describes a backup held by a BackupSink.
+type BackupInfo struct {
+	Name     string // backup name, unique within the sink
+	Size     int64  // backup size in bytes
+	Checksum uint32 // CRC-32C of the backup as stored by the sink, zero when unknown
+}
+
+// BackupSink stores backups, e.g. a local directory or an object storage bucket.
+type BackupSink interface {
+	// Put stores the backup read from r as name, replacing any backup of the same name.
+	// The returned checksum is the CRC-32C of the data as stored by the sink, which
+	// Store.BackupTo compares to the checksum of the snapshot it streamed.
+	Put(ctx context.Context, name string, r io.Reader) (BackupInfo, error)
+	// List returns the backups held by the sink, in any order.
+	List(ctx context.Context) ([]BackupInfo, error)
+	// Delete removes the backup name.
+	Delete(ctx context.Context, name string) error
+}
+
+// BackupSchedule configures the periodic backups started by Store.StartBackupSchedule.
+type BackupSchedule struct {
+	Dir       string                        // directory the backups are written to, when Sink is nil
+	Sink      BackupSink                    // sink the backups are written to
+	Interval  time.Duration                 // time between backups
+	Retain    int                           // number of most recent backups kept, zero keeps all backups
+	Prefix    string                        // backup name prefix, "backup" when empty
+	OnSuccess func(name string, size int64) // called with the backup name after each backup, optional
+	OnFailure func(err error)               // called when a backup fails, optional
+}
+
+// Backup writes a consistent snapshot of the database to w in a read transaction, so writers are not
+// blocked while the snapshot is written. It returns the number of bytes written.
+func (s *Store) Backup(w io.Writer) (int64, error) {
+	s.logger.Trace("Store::Backup")
+
+	db := s.database()
+	if db == nil {
+		return 0, bolt.ErrDatabaseNotOpen
+	}
+
+	var n int64
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+
+	return n, err
+}
+
+// BackupFile writes a snapshot of the database to the file at path, see Backup.
+// The snapshot is written to a temporary file renamed to path once complete, so path never
+// holds a partial snapshot.
+func (s *Store) BackupFile(path string) (int64, error) {
+	s.logger.Trace("Store::BackupFile", "path", path)
+
+	n, err := writeFileAtomic(path, func(w io.Writer) (int64, error) { return s.Backup(w) })
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to write backup %s", path)
+	}
+
+	return n, nil
+}
+
+// BackupTo streams a snapshot of the database to sink as name, see Backup.
+// The CRC-32C of the snapshot is computed while streaming and compared to the checksum
+// reported by the sink; on mismatch the backup is deleted and ErrBackupCorrupt is returned.
+func (s *Store) BackupTo(ctx context.Context, sink BackupSink, name string) (BackupInfo, error) {
+	s.logger.Trace("Store::BackupTo", "name", name)
+
+	pr, pw := io.Pipe()
+	hash := crc32.New(castagnoli)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Backup(io.MultiWriter(pw, hash))
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	info, err := sink.Put(ctx, name, pr)
+	// unblock the snapshot when the sink stopped reading early
+	pr.CloseWithError(errors.New("backup sink closed"))
+	if berr := <-done; err == nil {
+		err = berr
+	}
+	if err != nil {
+		return BackupInfo{}, errors.Wrapf(err, "failed to write backup %s", name)
+	}
+
+	if info.Checksum != hash.Sum32() {
+		err := errors.Wrapf(ErrBackupCorrupt, "backup %s checksum %08x, expected %08x", name, info.Checksum, hash.Sum32())
+		if derr := sink.Delete(ctx, name); derr != nil {
+			s.logger.Error("backup::boltdb", "error", derr, "name", name)
+		}
+		return BackupInfo{}, err
+	}
+
+	return info, nil
+}
+
+// StartBackupSchedule starts writing a backup of the database to cfg.Sink, or to the directory cfg.Dir,
+// every cfg.Interval, named after cfg.Prefix and the backup time, and deleting the oldest backups beyond cfg.Retain.
+// It returns a function stopping the schedule, which is also stopped when the store is closed, and fails when
+// a schedule of cfg.Prefix is already running. The schedule runs as a janitor task, see Store.JanitorStats.
+func (s *Store) StartBackupSchedule(cfg BackupSchedule) (func(), error) {
+	if (cfg.Dir == "" && cfg.Sink == nil) || cfg.Interval <= 0 {
+		return nil, errors.New("backup schedule requires a sink or directory and a positive interval")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "backup"
+	}
+	if cfg.Sink == nil {
+		sink, err := NewDirSink(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Sink = sink
+	}
+
+	return s.Schedule(JanitorTask{
+		Name:     backupTaskPrefix + cfg.Prefix,
+		Interval: cfg.Interval,
+		Run: func(ctx context.Context) error {
+			return s.scheduledBackup(ctx, &cfg)
+		},
+	})
+}
+
+// scheduledBackup writes a backup of cfg, its failures being logged by the janitor.
+func (s *Store) scheduledBackup(ctx context.Context, cfg *BackupSchedule) error {
+	name := cfg.Prefix + "-" + time.Now().UTC().Format(backupTimeFormat) + ".db"
+
+	info, err := s.BackupTo(ctx, cfg.Sink, name)
+	if err == nil {
+		err = rotateBackups(ctx, cfg.Sink, cfg.Prefix, cfg.Retain)
+	}
+
+	if err != nil {
+		if ctx.Err() == nil && cfg.OnFailure != nil {
+			cfg.OnFailure(err)
+		}
+		return err
+	}
+
+	s.logger.Info("backup::boltdb", "name", name, "size", info.Size)
+	if cfg.OnSuccess != nil {
+		cfg.OnSuccess(name, info.Size)
+	}
+	return nil
+}
+
+// rotateBackups deletes the oldest backups named after prefix in sink beyond the retain most recent ones.
+func rotateBackups(ctx context.Context, sink BackupSink, prefix string, retain int) error {
+	if retain <= 0 {
+		return nil
+	}
+
+	infos, err := sink.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name, prefix+"-") && strings.HasSuffix(info.Name, ".db") {
+			backups = append(backups, info.Name)
+		}
+	}
+	// timestamps sort lexically
+	sort.Strings(backups)
+
+	for i := 0; i < len(backups)-retain; i++ {
+		if err := sink.Delete(ctx, backups[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DirSink is a BackupSink storing backups as files in a local directory.
+type DirSink struct {
+	dir string
+}
+
+var _ BackupSink = (*DirSink)(nil)
+
+// NewDirSink returns a sink storing backups in dir, created when missing.
+func NewDirSink(dir string) (*DirSink, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create backup directory '%s'", dir)
+	}
+	return &DirSink{dir: dir}, nil
+}
+
+// Put writes the backup to a temporary file renamed to name once complete.
+// The checksum is computed by reading the file back after it has been synced.
+func (d *DirSink) Put(ctx context.Context, name string, r io.Reader) (BackupInfo, error) {
+	path := filepath.Join(d.dir, name)
+
+	n, err := writeFileAtomic(path, func(w io.Writer) (int64, error) { return io.Copy(w, r) })
+	if err != nil {
+		return BackupInfo{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	defer f.Close()
+
+	hash := crc32.New(castagnoli)
+	if _, err := io.Copy(hash, f); err != nil {
+		return BackupInfo{}, err
+	}
+
+	return BackupInfo{Name: name, Size: n, Checksum: hash.Sum32()}, nil
+}
+
+// List returns the files of the directory, without checksums.
+func (d *DirSink) List(ctx context.Context) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []BackupInfo
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, BackupInfo{Name: e.Name(), Size: fi.Size()})
+	}
+
+	return infos, nil
+}
+
+// Delete removes the file name.
+func (d *DirSink) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(d.dir, name))
+}
+
+// writeFileAtomic writes the file at path with write, through a temporary file renamed to path once
+// written and synced.
+func writeFileAtomic(path string, write func(w io.Writer) (int64, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// addStopper registers fn to be called when the store is closed.
+func (s *Store) addStopper(fn func()) {
+	s.stoppersMu.Lock()
+	defer s.stoppersMu.Unlock()
+
+	s.stoppers = append(s.stoppers, fn)
+}
+
+// stopBackground calls the functions registered with addStopper.
+func (s *Store) stopBackground() {
+	s.stoppersMu.Lock()
+	stoppers := s.stoppers
+	s.stoppers = nil
+	s.stoppersMu.Unlock()
+
+	for _, stop := range stoppers {
+		stop()
+	}
+}
@@ -0,0 +1,130 @@
+// Package azblobsink provides a boltdb.BackupSink storing backups in an Azure Blob Storage container.
+//
+// Backups are streamed as block blobs in blocks of Config.BlockSize, so snapshots are never buffered
+// in full. Every block is uploaded with a CRC-64 verified by the service. Block blobs have no
+// object checksum computed by the service, so the CRC-32C of the uploaded data is stored in the
+// blob metadata and reported by List.
+package azblobsink
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+)
+
+// checksumKey is the metadata key holding the hex encoded CRC-32C of a backup.
+const checksumKey = "crc32c"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Config configures a Sink.
+type Config struct {
+	Prefix      string // blob name prefix of the backups, e.g. "backups/"
+	BlockSize   int64  // size of the uploaded blocks, the client default when zero
+	Concurrency int    // number of blocks uploaded concurrently, the client default when zero
+}
+
+// Sink stores backups as block blobs of a container.
+type Sink struct {
+	container *container.Client
+	cfg       Config
+}
+
+var _ boltdb.BackupSink = (*Sink)(nil)
+
+// New returns a sink storing backups in the container of client.
+func New(client *container.Client, cfg Config) *Sink {
+	return &Sink{container: client, cfg: cfg}
+}
+
+// Put uploads the backup read from r.
+func (s *Sink) Put(ctx context.Context, name string, r io.Reader) (boltdb.BackupInfo, error) {
+	bb := s.container.NewBlockBlobClient(s.cfg.Prefix + name)
+
+	hash := crc32.New(castagnoli)
+	cr := &countingReader{r: io.TeeReader(r, hash)}
+
+	_, err := bb.UploadStream(ctx, cr, &blockblob.UploadStreamOptions{
+		BlockSize:               s.cfg.BlockSize,
+		Concurrency:             s.cfg.Concurrency,
+		TransactionalValidation: blob.TransferValidationTypeComputeCRC64(),
+	})
+	if err != nil {
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to upload %s", name)
+	}
+
+	sum := hash.Sum32()
+	metadata := map[string]*string{checksumKey: to(fmt.Sprintf("%08x", sum))}
+	if _, err := bb.SetMetadata(ctx, metadata, nil); err != nil {
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to record checksum of %s", name)
+	}
+
+	return boltdb.BackupInfo{Name: name, Size: cr.n, Checksum: sum}, nil
+}
+
+// List returns the blobs below Config.Prefix with the checksums recorded by Put.
+func (s *Sink) List(ctx context.Context) ([]boltdb.BackupInfo, error) {
+	var infos []boltdb.BackupInfo
+
+	pager := s.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+		Prefix:  to(s.cfg.Prefix),
+		Include: container.ListBlobsInclude{Metadata: true},
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list backups")
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			info := boltdb.BackupInfo{Name: strings.TrimPrefix(deref(item.Name), s.cfg.Prefix)}
+			if item.Properties != nil && item.Properties.ContentLength != nil {
+				info.Size = *item.Properties.ContentLength
+			}
+			if sum, err := strconv.ParseUint(deref(item.Metadata[checksumKey]), 16, 32); err == nil {
+				info.Checksum = uint32(sum)
+			}
+			infos = append(infos, info)
+		}
+	}
+
+	return infos, nil
+}
+
+// Delete removes the blob of backup name.
+func (s *Sink) Delete(ctx context.Context, name string) error {
+	_, err := s.container.NewBlobClient(s.cfg.Prefix+name).Delete(ctx, nil)
+	return errors.Wrapf(err, "failed to delete %s", name)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func to(s string) *string {
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,89 @@
+// Package gcssink provides a boltdb.BackupSink storing backups in a Google Cloud Storage bucket.
+//
+// Backups are streamed with resumable uploads in chunks of Config.ChunkSize, so snapshots are never
+// buffered in full. The CRC-32C of the object computed by Cloud Storage is reported to
+// boltdb.Store.BackupTo for verification.
+package gcssink
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+)
+
+// Config configures a Sink.
+type Config struct {
+	Prefix    string // object name prefix of the backups, e.g. "backups/"
+	ChunkSize int    // size of the uploaded chunks, the storage client default when zero
+}
+
+// Sink stores backups as objects of a Cloud Storage bucket.
+type Sink struct {
+	bucket *storage.BucketHandle
+	cfg    Config
+}
+
+var _ boltdb.BackupSink = (*Sink)(nil)
+
+// New returns a sink storing backups in bucket.
+func New(bucket *storage.BucketHandle, cfg Config) *Sink {
+	return &Sink{bucket: bucket, cfg: cfg}
+}
+
+// Put uploads the backup read from r. The upload is discarded when reading r fails.
+func (s *Sink) Put(ctx context.Context, name string, r io.Reader) (boltdb.BackupInfo, error) {
+	// cancelling the writer context is the only way to abandon an upload
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.bucket.Object(s.cfg.Prefix + name).NewWriter(ctx)
+	if s.cfg.ChunkSize != 0 {
+		w.ChunkSize = s.cfg.ChunkSize
+	}
+	w.ContentType = "application/octet-stream"
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		cancel()
+		_ = w.Close()
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to upload %s", name)
+	}
+	if err := w.Close(); err != nil {
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to upload %s", name)
+	}
+
+	return boltdb.BackupInfo{Name: name, Size: n, Checksum: w.Attrs().CRC32C}, nil
+}
+
+// List returns the objects below Config.Prefix with their checksums.
+func (s *Sink) List(ctx context.Context) ([]boltdb.BackupInfo, error) {
+	var infos []boltdb.BackupInfo
+
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: s.cfg.Prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return infos, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list backups")
+		}
+
+		infos = append(infos, boltdb.BackupInfo{
+			Name:     strings.TrimPrefix(attrs.Name, s.cfg.Prefix),
+			Size:     attrs.Size,
+			Checksum: attrs.CRC32C,
+		})
+	}
+}
+
+// Delete removes the object of backup name.
+func (s *Sink) Delete(ctx context.Context, name string) error {
+	err := s.bucket.Object(s.cfg.Prefix + name).Delete(ctx)
+	return errors.Wrapf(err, "failed to delete %s", name)
+}
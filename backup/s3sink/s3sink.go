@@ -0,0 +1,210 @@
+// Package s3sink provides a boltdb.BackupSink storing backups in an Amazon S3 bucket.
+//
+// Backups are streamed with multipart uploads, so snapshots are never buffered in full.
+// Every part is uploaded with a CRC-32C checksum verified by S3, and the full object
+// CRC-32C computed by S3 is reported to boltdb.Store.BackupTo for verification.
+package s3sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+// DefaultPartSize is the size of the uploaded parts when Config.PartSize is zero.
+const DefaultPartSize = 16 << 20
+
+// minPartSize is the minimum size of every part but the last one accepted by S3.
+const minPartSize = 5 << 20
+
+// Client is the subset of the S3 API used by the sink, implemented by *s3.Client.
+type Client interface {
+	CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, opts ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, in *s3.UploadPartInput, opts ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, opts ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, opts ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Config configures a Sink.
+type Config struct {
+	Bucket   string // bucket the backups are stored in
+	Prefix   string // key prefix of the backups, e.g. "backups/"
+	PartSize int64  // size of the uploaded parts, DefaultPartSize when zero
+}
+
+// Sink stores backups as objects of an S3 bucket.
+type Sink struct {
+	client Client
+	cfg    Config
+}
+
+var _ boltdb.BackupSink = (*Sink)(nil)
+
+// New returns a sink storing backups in cfg.Bucket through client.
+func New(client Client, cfg Config) (*Sink, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 backup sink requires a bucket")
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < minPartSize {
+		return nil, errors.Errorf("s3 backup sink part size %d below minimum %d", cfg.PartSize, minPartSize)
+	}
+	return &Sink{client: client, cfg: cfg}, nil
+}
+
+// Put uploads the backup read from r in parts of Config.PartSize.
+// The upload is aborted when reading r or uploading a part fails.
+func (s *Sink) Put(ctx context.Context, name string, r io.Reader) (boltdb.BackupInfo, error) {
+	key := aws.String(s.cfg.Prefix + name)
+
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.cfg.Bucket),
+		Key:               key,
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32c,
+		ChecksumType:      types.ChecksumTypeFullObject,
+	})
+	if err != nil {
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to start upload of %s", name)
+	}
+
+	size, parts, err := s.uploadParts(ctx, key, created.UploadId, r)
+	if err != nil {
+		s.abort(key, created.UploadId)
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to upload %s", name)
+	}
+
+	completed, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.cfg.Bucket),
+		Key:             key,
+		UploadId:        created.UploadId,
+		ChecksumType:    types.ChecksumTypeFullObject,
+		MpuObjectSize:   aws.Int64(size),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		s.abort(key, created.UploadId)
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "failed to complete upload of %s", name)
+	}
+
+	sum, err := decodeChecksum(completed.ChecksumCRC32C)
+	if err != nil {
+		return boltdb.BackupInfo{}, errors.Wrapf(err, "invalid checksum of %s", name)
+	}
+
+	return boltdb.BackupInfo{Name: name, Size: size, Checksum: sum}, nil
+}
+
+func (s *Sink) uploadParts(ctx context.Context, key, uploadID *string, r io.Reader) (int64, []types.CompletedPart, error) {
+	var (
+		size  int64
+		parts []types.CompletedPart
+	)
+
+	buf := make([]byte, s.cfg.PartSize)
+	for num := int32(1); ; num++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF && num > 1 {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, nil, err
+		}
+
+		out, uerr := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            aws.String(s.cfg.Bucket),
+			Key:               key,
+			UploadId:          uploadID,
+			PartNumber:        aws.Int32(num),
+			Body:              bytes.NewReader(buf[:n]),
+			ChecksumAlgorithm: types.ChecksumAlgorithmCrc32c,
+		})
+		if uerr != nil {
+			return 0, nil, uerr
+		}
+
+		size += int64(n)
+		parts = append(parts, types.CompletedPart{
+			PartNumber:     aws.Int32(num),
+			ETag:           out.ETag,
+			ChecksumCRC32C: out.ChecksumCRC32C,
+		})
+
+		if err != nil {
+			// short read, r is exhausted
+			break
+		}
+	}
+
+	return size, parts, nil
+}
+
+// abort releases the parts of a failed upload, independently of the cancellation of the upload context.
+func (s *Sink) abort(key, uploadID *string) {
+	_, _ = s.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.cfg.Bucket),
+		Key:      key,
+		UploadId: uploadID,
+	})
+}
+
+// List returns the objects below Config.Prefix, without checksums.
+func (s *Sink) List(ctx context.Context) ([]boltdb.BackupInfo, error) {
+	var infos []boltdb.BackupInfo
+
+	in := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(s.cfg.Prefix),
+	}
+	for {
+		out, err := s.client.ListObjectsV2(ctx, in)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list backups")
+		}
+
+		for _, obj := range out.Contents {
+			infos = append(infos, boltdb.BackupInfo{
+				Name: strings.TrimPrefix(aws.ToString(obj.Key), s.cfg.Prefix),
+				Size: aws.ToInt64(obj.Size),
+			})
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			return infos, nil
+		}
+		in.ContinuationToken = out.NextContinuationToken
+	}
+}
+
+// Delete removes the object of backup name.
+func (s *Sink) Delete(ctx context.Context, name string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(s.cfg.Prefix + name),
+	})
+	return errors.Wrapf(err, "failed to delete %s", name)
+}
+
+// decodeChecksum decodes a base64 encoded, big-endian CRC-32C as returned by S3.
+func decodeChecksum(sum *string) (uint32, error) {
+	b, err := base64.StdEncoding.DecodeString(aws.ToString(sum))
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 4 {
+		return 0, errors.Errorf("checksum %q is not a CRC-32C", aws.ToString(sum))
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
@@ -0,0 +1,185 @@
+package s3sink_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/backup/s3sink"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func encodeChecksum(data []byte) *string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, crc32.Checksum(data, castagnoli))
+	return aws.String(base64.StdEncoding.EncodeToString(b))
+}
+
+// fakeS3 is an in-memory S3 implementing the multipart upload API used by the sink.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string][][]byte
+	aborted  int
+	failPart int32
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string][][]byte{}}
+}
+
+func (f *fakeS3) CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := aws.ToString(in.Key)
+	f.uploads[id] = nil
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, in *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if aws.ToInt32(in.PartNumber) == f.failPart {
+		return nil, io.ErrClosedPipe
+	}
+
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	id := aws.ToString(in.UploadId)
+	f.uploads[id] = append(f.uploads[id], data)
+	return &s3.UploadPartOutput{ETag: aws.String("etag"), ChecksumCRC32C: encodeChecksum(data)}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := aws.ToString(in.UploadId)
+	object := bytes.Join(f.uploads[id], nil)
+	delete(f.uploads, id)
+	f.objects[aws.ToString(in.Key)] = object
+	return &s3.CompleteMultipartUploadOutput{ChecksumCRC32C: encodeChecksum(object)}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.uploads, aws.ToString(in.UploadId))
+	f.aborted++
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, aws.ToString(in.Prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	for _, k := range keys {
+		out.Contents = append(out.Contents, types.Object{Key: aws.String(k), Size: aws.Int64(int64(len(f.objects[k])))})
+	}
+	return out, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.objects, aws.ToString(in.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func newStore(t *testing.T) *boltdb.Store {
+	logger := zerolog.Nop()
+	store := boltdb.NewStore(&boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db")}, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 1000; i++ {
+			if err := w.Write([]string{"a"}, fmt.Sprintf("k%04d", i), bytes.Repeat([]byte{byte(i)}, 10<<10)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	return store
+}
+
+func TestPut(t *testing.T) {
+	store := newStore(t)
+	client := newFakeS3()
+
+	sink, err := s3sink.New(client, s3sink.Config{Bucket: "bucket", Prefix: "backups/", PartSize: 5 << 20})
+	require.NoError(t, err)
+
+	var snapshot bytes.Buffer
+	_, err = store.Backup(&snapshot)
+	require.NoError(t, err)
+	require.Greater(t, snapshot.Len(), 5<<20, "snapshot spans several parts")
+
+	info, err := store.BackupTo(context.Background(), sink, "b1.db")
+	require.NoError(t, err)
+	assert.Equal(t, int64(snapshot.Len()), info.Size)
+	assert.Equal(t, snapshot.Bytes(), client.objects["backups/b1.db"])
+
+	infos, err := sink.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.BackupInfo{{Name: "b1.db", Size: info.Size}}, infos)
+
+	require.NoError(t, sink.Delete(context.Background(), "b1.db"))
+	assert.Empty(t, client.objects)
+}
+
+func TestPutAborts(t *testing.T) {
+	store := newStore(t)
+	client := newFakeS3()
+	client.failPart = 2
+
+	sink, err := s3sink.New(client, s3sink.Config{Bucket: "bucket", PartSize: 5 << 20})
+	require.NoError(t, err)
+
+	_, err = store.BackupTo(context.Background(), sink, "b1.db")
+	assert.Error(t, err)
+	assert.Equal(t, 1, client.aborted)
+	assert.Empty(t, client.uploads)
+	assert.Empty(t, client.objects)
+}
+
+func TestNew(t *testing.T) {
+	_, err := s3sink.New(newFakeS3(), s3sink.Config{})
+	assert.Error(t, err)
+
+	_, err = s3sink.New(newFakeS3(), s3sink.Config{Bucket: "bucket", PartSize: 1 << 20})
+	assert.Error(t, err)
+}
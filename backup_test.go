@@ -0,0 +1,159 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBackupFile(t *testing.T) {
+	store := newTestStore(t)
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	n, err := store.BackupFile(path)
+	require.NoError(t, err)
+	assert.Greater(t, n, int64(0))
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("a"))
+		require.NotNil(t, b)
+		assert.Equal(t, []byte("v"), b.Get([]byte("k")))
+		return nil
+	}))
+
+	var buf bytes.Buffer
+	n, err = store.Backup(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+}
+
+// corruptSink is a BackupSink reporting the wrong checksum for every backup.
+type corruptSink struct {
+	*boltdb.DirSink
+}
+
+func (c corruptSink) Put(ctx context.Context, name string, r io.Reader) (boltdb.BackupInfo, error) {
+	info, err := c.DirSink.Put(ctx, name, r)
+	info.Checksum++
+	return info, err
+}
+
+func TestBackupTo(t *testing.T) {
+	store := newTestStore(t)
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	dir := t.TempDir()
+	sink, err := boltdb.NewDirSink(dir)
+	require.NoError(t, err)
+
+	info, err := store.BackupTo(context.Background(), sink, "snap.db")
+	require.NoError(t, err)
+	assert.Equal(t, "snap.db", info.Name)
+	assert.NotZero(t, info.Checksum)
+
+	fi, err := os.Stat(filepath.Join(dir, "snap.db"))
+	require.NoError(t, err)
+	assert.Equal(t, fi.Size(), info.Size)
+
+	infos, err := sink.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "snap.db", infos[0].Name)
+
+	_, err = store.BackupTo(context.Background(), corruptSink{sink}, "bad.db")
+	assert.ErrorIs(t, err, boltdb.ErrBackupCorrupt)
+	assert.NoFileExists(t, filepath.Join(dir, "bad.db"))
+}
+
+func TestBackupSchedule(t *testing.T) {
+	store := newTestStore(t)
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	stop, err := store.StartBackupSchedule(boltdb.BackupSchedule{
+		Dir:      dir,
+		Interval: 10 * time.Millisecond,
+		Retain:   2,
+		OnSuccess: func(path string, size int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, path)
+		},
+		OnFailure: func(err error) { t.Error(err) },
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(paths) >= 4
+	}, 5*time.Second, 5*time.Millisecond)
+
+	stop()
+	stop()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, paths[len(paths)-1], entries[1].Name())
+	assert.Regexp(t, `^backup-\d{8}T\d{6}\.\d{9}Z\.db$`, entries[1].Name())
+}
+
+func TestBackupScheduleStopsOnClose(t *testing.T) {
+	store := newTestStore(t)
+
+	var (
+		mu       sync.Mutex
+		failures int
+	)
+	_, err := store.StartBackupSchedule(boltdb.BackupSchedule{
+		Dir:      t.TempDir(),
+		Interval: time.Millisecond,
+		OnFailure: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures++
+		},
+	})
+	require.NoError(t, err)
+
+	store.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Zero(t, failures)
+}
+
+func TestBackupScheduleInvalid(t *testing.T) {
+	store := newTestStore(t)
+
+	_, err := store.StartBackupSchedule(boltdb.BackupSchedule{Interval: time.Second})
+	assert.Error(t, err)
+
+	_, err = store.StartBackupSchedule(boltdb.BackupSchedule{Dir: t.TempDir()})
+	assert.Error(t, err)
+}
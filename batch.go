@@ -0,0 +1,97 @@
+package boltdb
+
+import (
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// WriteMany writes every key-value pair of values in bucket path.
+// Keys and values are checked, and validated, before anything is written,
+// and a failure aborts the session, so either all pairs are written or none are.
+func (s *Session) WriteMany(path []string, values map[string][]byte) error {
+	s.store.logger.Trace("Session::WriteMany", "path", path, "count", len(values))
+
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	write := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		for _, k := range names {
+			if err := checkKeyValue([]byte(k), values[k]); err != nil {
+				return wrapError("WriteMany", path, k, err)
+			}
+			if err := s.validate(path, []byte(k), values[k]); err != nil {
+				return wrapError("WriteMany", path, k, err)
+			}
+		}
+
+		for _, k := range names {
+			if err := s.put(path, []byte(k), values[k]); err != nil {
+				return wrapError("WriteMany", path, k, err)
+			}
+		}
+
+		return nil
+	}
+
+	op := newOp("WriteMany", path, "")
+	op.keys = len(values)
+	for _, v := range values {
+		op.size += len(v)
+	}
+
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("WriteMany", path, "", err)
+}
+
+// DeleteMany deletes every key of keys present in bucket path.
+// Keys are checked before anything is deleted, and a failure
+// aborts the session, so either all keys are deleted or none are.
+func (s *Session) DeleteMany(path []string, keys []string) error {
+	s.store.logger.Trace("Session::DeleteMany", "path", path, "count", len(keys))
+
+	del := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := checkKeyValue([]byte(k), nil); err != nil {
+				return wrapError("DeleteMany", path, k, err)
+			}
+		}
+
+		for _, k := range keys {
+			if err := s.delete(path, []byte(k)); err != nil {
+				return wrapError("DeleteMany", path, k, err)
+			}
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("DeleteMany", path, ""), func() error { return s.update(del) })
+
+	return wrapError("DeleteMany", path, "", err)
+}
+
+// checkKeyValue returns the error bolt would fail writing value for key with.
+func checkKeyValue(key, value []byte) error {
+	switch {
+	case len(key) == 0:
+		return bolt.ErrKeyRequired
+	case len(key) > bolt.MaxKeySize:
+		return bolt.ErrKeyTooLarge
+	case int64(len(value)) > bolt.MaxValueSize:
+		return bolt.ErrValueTooLarge
+	}
+	return checkValue(value)
+}
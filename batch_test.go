@@ -0,0 +1,70 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestWriteDeleteMany(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.WriteMany(path, map[string][]byte{
+		"k1": []byte("v1"),
+		"k2": []byte("v2"),
+		"k3": []byte("v3"),
+	}))
+	closer()
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteMany(path, []string{"k1", "k3", "missing"}))
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, _, err := session.ListKeys(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k2"}, keys)
+}
+
+func TestWriteManyAllOrNothing(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+	write(t, s, path, "k1")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	err = session.WriteMany(path, map[string][]byte{
+		"k1": []byte("v1"),
+		"k2": []byte("v2"),
+		"":   []byte("v3"),
+	})
+	closer()
+
+	assert.True(t, errors.Is(err, bolt.ErrKeyRequired))
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, values, _, err := session.List(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, keys)
+	assert.Equal(t, [][]byte{[]byte("k1")}, values)
+	assert.Equal(t, uint64(1), s.Revision())
+
+	_, err = session.Read(path, "k2")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
@@ -0,0 +1,242 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+)
+
+var (
+	benchSizes  = []int{64, 1 << 10, 16 << 10}
+	benchDepths = []int{1, 4}
+)
+
+// benchPath returns a bucket path of depth segments.
+func benchPath(depth int) []string {
+	path := make([]string, depth)
+	for i := range path {
+		path[i] = fmt.Sprintf("level%d", i)
+	}
+	return path
+}
+
+func benchKey(i int) string {
+	return fmt.Sprintf("key%08d", i)
+}
+
+// benchStore returns a store holding n keys of size bytes in bucket path.
+func benchStore(b *testing.B, path []string, n, size int) *boltdb.Store {
+	b.Helper()
+
+	s := boltdbtest.NewTestStore(b)
+	value := bytes.Repeat([]byte{'v'}, size)
+
+	for start := 0; start < n; start += 1000 {
+		err := s.Update(func(w boltdb.Writer) error {
+			for i := start; i < start+1000 && i < n; i++ {
+				if err := w.Write(path, benchKey(i), value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return s
+}
+
+// BenchmarkWrite commits one write per session, the cost of a write outside of batches.
+func BenchmarkWrite(b *testing.B) {
+	for _, depth := range benchDepths {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("depth=%d/size=%d", depth, size), func(b *testing.B) {
+				s := boltdbtest.NewTestStore(b)
+				path := benchPath(depth)
+				value := bytes.Repeat([]byte{'v'}, size)
+
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					err := s.Update(func(w boltdb.Writer) error {
+						return w.Write(path, benchKey(i), value)
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkWriteBatch commits batches of writes in a single session, reporting the cost per write.
+func BenchmarkWriteBatch(b *testing.B) {
+	for _, batch := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("batch=%d", batch), func(b *testing.B) {
+			s := boltdbtest.NewTestStore(b)
+			path := benchPath(2)
+			value := bytes.Repeat([]byte{'v'}, 256)
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i += batch {
+				err := s.Update(func(w boltdb.Writer) error {
+					for j := i; j < i+batch && j < b.N; j++ {
+						if err := w.Write(path, benchKey(j), value); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAsyncWriter queues writes coalesced by an AsyncWriter.
+func BenchmarkAsyncWriter(b *testing.B) {
+	s := boltdbtest.NewTestStore(b)
+	w := s.NewAsyncWriter(boltdb.AsyncWriterConfig{})
+	path := benchPath(2)
+	value := bytes.Repeat([]byte{'v'}, 256)
+	ctx := context.Background()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := w.Put(ctx, path, benchKey(i), value); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := w.Flush(ctx); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkRead reads keys of a bucket of 10000 keys, one read session per read.
+func BenchmarkRead(b *testing.B) {
+	const n = 10000
+
+	for _, depth := range benchDepths {
+		for _, size := range benchSizes {
+			b.Run(fmt.Sprintf("depth=%d/size=%d", depth, size), func(b *testing.B) {
+				path := benchPath(depth)
+				s := benchStore(b, path, n, size)
+
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					err := s.View(func(r boltdb.Reader) error {
+						_, err := r.Read(path, benchKey(i*7919%n))
+						return err
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkList lists every page of buckets of growing sizes. Pages hold up to 100 keys,
+// so the benchmarks vary the number of pages walked.
+func BenchmarkList(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			path := benchPath(2)
+			s := benchStore(b, path, n, 256)
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				err := s.View(func(r boltdb.Reader) error {
+					token := ""
+					for {
+						_, _, next, err := r.List(path, token)
+						if err != nil || next == "" {
+							return err
+						}
+						token = next
+					}
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScan walks the keys of a prefix holding a tenth of a bucket of 10000 keys.
+func BenchmarkScan(b *testing.B) {
+	const n = 10000
+
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			path := benchPath(2)
+			s := benchStore(b, path, n, size)
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				err := s.View(func(r boltdb.Reader) error {
+					_, _, err := r.ReadScan(path, "key00001")
+					return err
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParallelScan walks a bucket of 100000 keys with growing numbers of workers.
+func BenchmarkParallelScan(b *testing.B) {
+	path := benchPath(1)
+	s := benchStore(b, path, 100000, 64)
+
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := s.ParallelScan(context.Background(), path, "", workers, func(key, value []byte) error {
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReadPool reads keys of a bucket of 10000 keys through a ReadPool, see BenchmarkRead.
+func BenchmarkReadPool(b *testing.B) {
+	const n = 10000
+
+	path := benchPath(1)
+	s := benchStore(b, path, n, 64)
+	pool := s.NewReadPool(boltdb.ReadPoolConfig{})
+	defer pool.Close()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.Read(path, benchKey(i*7919%n)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Values larger than the blob threshold are stored by the blob provider of the store under
+// the hex-encoded SHA-256 hash of their content, the key holding a reference to the blob.
+// References are counted by hash in the blob reference bucket, so identical values share
+// their blob, which is deleted from the provider once the last reference has been committed.
+// Sessions uploading blobs pin them until they end, so blobs are not deleted between their
+// upload by a session and the commit of the reference.
+
+const blobRefBucket = "blobrefs"
+
+// blobMagic starts the references to external blobs.
+var blobMagic = []byte{0, 0xff, 'b', 'd', 'b', 'r', 'e', 'f'}
+
+// blobRefSize is the length of a blob reference: magic, SHA-256 hash and size.
+const blobRefSize = 8 + sha256.Size + 8
+
+// BlobProvider stores the values externalized by a store, see Store.SetBlobProvider.
+// Blobs are content-addressed, a blob written twice has the same content.
+type BlobProvider interface {
+	// Put stores the size bytes read from r as the blob named hash.
+	Put(ctx context.Context, hash string, r io.Reader, size int64) error
+	// Get returns a reader of the blob named hash.
+	Get(ctx context.Context, hash string) (io.ReadCloser, error)
+	// Delete deletes the blob named hash, without failing when it does not exist.
+	Delete(ctx context.Context, hash string) error
+}
+
+type blobRef struct {
+	hash [sha256.Size]byte
+	size uint64
+}
+
+func (r *blobRef) encode() []byte {
+	buf := make([]byte, blobRefSize)
+	copy(buf, blobMagic)
+	copy(buf[8:], r.hash[:])
+	binary.BigEndian.PutUint64(buf[8+sha256.Size:], r.size)
+	return buf
+}
+
+func (r *blobRef) name() string {
+	return hex.EncodeToString(r.hash[:])
+}
+
+// parseBlobRef returns the reference held by value, nil when value is not a blob reference.
+func parseBlobRef(value []byte) *blobRef {
+	if len(value) != blobRefSize || !bytes.HasPrefix(value, blobMagic) {
+		return nil
+	}
+
+	var r blobRef
+	copy(r.hash[:], value[8:])
+	r.size = binary.BigEndian.Uint64(value[8+sha256.Size:])
+
+	return &r
+}
+
+// SetBlobProvider stores the values longer than threshold bytes written from now on in provider,
+// keeping a reference in bolt. Values are read back transparently by reads, lists and scans, and by
+// the operations modifying them. Values of indexed and versioned paths are kept in bolt. A nil
+// provider keeps new values in bolt, existing references are then unreadable and fail with
+// ErrBlobUnavailable.
+//
+// Blobs are uploaded while the write session runs, blobs uploaded by sessions rolled back are
+// left in the provider.
+func (s *Store) SetBlobProvider(provider BlobProvider, threshold int) {
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	s.blobs = provider
+	s.blobThreshold = threshold
+}
+
+func (s *Store) blobProvider() (BlobProvider, int) {
+	s.blobMu.RLock()
+	defer s.blobMu.RUnlock()
+
+	return s.blobs, s.blobThreshold
+}
+
+// externalize returns the value stored in bolt for value written to path: a reference to
+// the blob holding value when it is larger than the blob threshold, value itself otherwise.
+func (s *Session) externalize(path []string, value []byte) ([]byte, error) {
+	provider, threshold := s.store.blobProvider()
+	if provider == nil || len(value) <= threshold || isRef(value) {
+		return value, nil
+	}
+	if s.store.versioned(path) || len(s.store.indexes.matching(path)) > 0 {
+		return value, nil
+	}
+
+	ref := blobRef{hash: sha256.Sum256(value), size: uint64(len(value))}
+
+	s.pinBlobs()
+
+	// blobs already referenced are not uploaded again
+	if s.getShadow(blobRefBucket, nil, ref.hash[:]) == nil {
+		err := provider.Put(context.Background(), ref.name(), bytes.NewReader(value), int64(len(value)))
+		if err != nil {
+			return nil, errors.Wrapf(ErrBlobUnavailable, "failed to store blob %s: %v", ref.name(), err)
+		}
+	}
+
+	return ref.encode(), nil
+}
+
+// openBlob returns a reader of the blob referenced by ref, failing with ErrValueCorrupt
+// once read when its content does not match ref.
+func (s *Session) openBlob(ref *blobRef) (io.ReadCloser, error) {
+	provider, _ := s.store.blobProvider()
+	if provider == nil {
+		return nil, errors.Wrapf(ErrBlobUnavailable, "blob %s: no blob provider", ref.name())
+	}
+
+	r, err := provider.Get(context.Background(), ref.name())
+	if err != nil {
+		return nil, errors.Wrapf(ErrBlobUnavailable, "blob %s: %v", ref.name(), err)
+	}
+
+	return &blobReader{r: r, ref: ref, hash: sha256.New()}, nil
+}
+
+// readBlob returns the content of the blob referenced by ref.
+func (s *Session) readBlob(ref *blobRef) ([]byte, error) {
+	r, err := s.openBlob(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	buf := bytes.NewBuffer(make([]byte, 0, ref.size))
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// blobReader verifies the content of a blob once read.
+type blobReader struct {
+	r    io.ReadCloser
+	ref  *blobRef
+	hash hash.Hash
+	read uint64
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	_, _ = b.hash.Write(p[:n])
+	b.read += uint64(n)
+
+	if err == io.EOF {
+		if b.read != b.ref.size || !bytes.Equal(b.hash.Sum(nil), b.ref.hash[:]) {
+			return n, errors.Wrapf(ErrValueCorrupt, "blob %s: content does not match its hash", b.ref.name())
+		}
+	}
+
+	return n, err
+}
+
+func (b *blobReader) Close() error {
+	return b.r.Close()
+}
+
+// addBlobRef adds delta to the reference count of the blob referenced by ref,
+// deleting the blob from the provider once the session committed when no reference remains.
+func (s *Session) addBlobRef(ref *blobRef, delta int64) error {
+	var count int64
+	if v := s.getShadow(blobRefBucket, nil, ref.hash[:]); len(v) == 8 {
+		count = int64(binary.BigEndian.Uint64(v))
+	}
+	count += delta
+
+	if count > 0 {
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(count))
+		return s.putShadow(blobRefBucket, nil, ref.hash[:], buf)
+	}
+
+	if err := s.deleteShadow(blobRefBucket, nil, ref.hash[:]); err != nil {
+		return err
+	}
+
+	provider, _ := s.store.blobProvider()
+	if provider == nil {
+		return nil
+	}
+
+	hash := append([]byte{}, ref.hash[:]...)
+	s.OnCommit(func() {
+		s.store.deleteBlob(provider, hash)
+	})
+
+	return nil
+}
+
+// pinBlobs keeps the blobs with no committed reference from being deleted until the session ends.
+func (s *Session) pinBlobs() {
+	if !s.blobsPinned {
+		s.store.blobGC.RLock()
+		s.blobsPinned = true
+	}
+}
+
+func (s *Session) unpinBlobs() {
+	if s.blobsPinned {
+		s.blobsPinned = false
+		s.store.blobGC.RUnlock()
+	}
+}
+
+// deleteBlob deletes the blob named after hash from provider in the background, once no session
+// pins blobs, unless it has been referenced again meanwhile. Store.Close waits for the deletions.
+func (s *Store) deleteBlob(provider BlobProvider, hash []byte) {
+	s.blobDeletes.Add(1)
+
+	go func() {
+		defer s.blobDeletes.Done()
+
+		s.blobGC.Lock()
+		defer s.blobGC.Unlock()
+
+		db := s.database()
+		if db == nil {
+			return
+		}
+
+		var referenced bool
+		_ = db.View(func(tx *bolt.Tx) error {
+			if b := metaChild(tx, []byte(blobRefBucket)); b != nil {
+				referenced = b.Get(hash) != nil
+			}
+			return nil
+		})
+		if referenced {
+			return
+		}
+
+		name := hex.EncodeToString(hash)
+		if err := provider.Delete(context.Background(), name); err != nil {
+			s.logger.Warn("blob::delete", "blob", name, "error", err)
+		}
+	}()
+}
+
+// DirBlobs is a BlobProvider storing blobs as files of a directory, see NewDirBlobs.
+type DirBlobs struct {
+	dir string
+}
+
+var _ BlobProvider = (*DirBlobs)(nil)
+
+// NewDirBlobs returns a blob provider storing blobs in dir, created when missing.
+// Blobs are spread over subdirectories named after the first two characters of their hash.
+func NewDirBlobs(dir string) (*DirBlobs, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create blob directory '%s'", dir)
+	}
+	return &DirBlobs{dir: dir}, nil
+}
+
+func (d *DirBlobs) path(hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(d.dir, hash)
+	}
+	return filepath.Join(d.dir, hash[:2], hash)
+}
+
+// Put writes the blob atomically, blobs which already exist are not written again.
+func (d *DirBlobs) Put(ctx context.Context, hash string, r io.Reader, size int64) error {
+	path := d.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	_, err := writeFileAtomic(path, func(w io.Writer) (int64, error) {
+		return io.Copy(w, r)
+	})
+
+	return err
+}
+
+// Get opens the blob file.
+func (d *DirBlobs) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	return os.Open(d.path(hash))
+}
+
+// Delete removes the blob file.
+func (d *DirBlobs) Delete(ctx context.Context, hash string) error {
+	if err := os.Remove(d.path(hash)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package s3blob provides a boltdb.BlobProvider storing blobs in an Amazon S3 bucket.
+//
+// Blobs are named after the hex-encoded SHA-256 hash of their content, which is sent
+// along with every upload so S3 rejects blobs corrupted in transit.
+package s3blob
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+// Client is the subset of the S3 API used by the provider, implemented by *s3.Client.
+type Client interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Config configures a Provider.
+type Config struct {
+	Bucket string // bucket the blobs are stored in
+	Prefix string // key prefix of the blobs, e.g. "blobs/"
+}
+
+// Provider stores blobs as objects of an S3 bucket.
+type Provider struct {
+	client Client
+	cfg    Config
+}
+
+var _ boltdb.BlobProvider = (*Provider)(nil)
+
+// New returns a provider storing blobs in cfg.Bucket through client.
+func New(client Client, cfg Config) (*Provider, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 blob provider requires a bucket")
+	}
+	return &Provider{client: client, cfg: cfg}, nil
+}
+
+// Put uploads the blob with its SHA-256 checksum.
+func (p *Provider) Put(ctx context.Context, hash string, r io.Reader, size int64) error {
+	sum, err := hex.DecodeString(hash)
+	if err != nil {
+		return errors.Wrapf(err, "invalid blob name %s", hash)
+	}
+
+	_, err = p.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:            aws.String(p.cfg.Bucket),
+		Key:               aws.String(p.cfg.Prefix + hash),
+		Body:              r,
+		ContentLength:     aws.Int64(size),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(base64.StdEncoding.EncodeToString(sum)),
+	})
+	return errors.Wrapf(err, "failed to upload %s", hash)
+}
+
+// Get downloads the blob.
+func (p *Provider) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	out, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.cfg.Bucket),
+		Key:    aws.String(p.cfg.Prefix + hash),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", hash)
+	}
+	return out.Body, nil
+}
+
+// Delete deletes the blob, S3 does not fail when it does not exist.
+func (p *Provider) Delete(ctx context.Context, hash string) error {
+	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(p.cfg.Bucket),
+		Key:    aws.String(p.cfg.Prefix + hash),
+	})
+	return errors.Wrapf(err, "failed to delete %s", hash)
+}
@@ -0,0 +1,111 @@
+package s3blob_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/blob/s3blob"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in-memory S3 implementing the object API used by the provider.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}}
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if aws.ToString(in.ChecksumSHA256) != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.objects[aws.ToString(in.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[aws.ToString(in.Key)]
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.objects, aws.ToString(in.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.objects)
+}
+
+func TestProvider(t *testing.T) {
+	client := newFakeS3()
+	provider, err := s3blob.New(client, s3blob.Config{Bucket: "bucket", Prefix: "blobs/"})
+	require.NoError(t, err)
+
+	logger := zerolog.Nop()
+	store := boltdb.NewStore(&boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db")}, &logger)
+	require.NoError(t, store.Open())
+	store.SetBlobProvider(provider, 16)
+
+	value := []byte(strings.Repeat("blob", 100))
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"a"}, "k", value)
+	}))
+	assert.Equal(t, 1, client.len())
+
+	require.NoError(t, store.View(func(r boltdb.Reader) error {
+		got, err := r.Read([]string{"a"}, "k")
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+		return nil
+	}))
+
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		return w.DeleteKey([]string{"a"}, "k")
+	}))
+
+	store.Close()
+	assert.Equal(t, 0, client.len())
+}
+
+func TestNew(t *testing.T) {
+	_, err := s3blob.New(newFakeS3(), s3blob.Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blobFiles returns the blob files of dir.
+func blobFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+			files = append(files, path)
+		}
+		return err
+	}))
+	return files
+}
+
+func newBlobStore(t *testing.T) (*boltdb.Store, string) {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "blobs")
+	blobs, err := boltdb.NewDirBlobs(dir)
+	require.NoError(t, err)
+
+	s := newTestStore(t)
+	s.SetBlobProvider(blobs, 16)
+
+	return s, dir
+}
+
+func TestDirBlobs(t *testing.T) {
+	blobs, err := boltdb.NewDirBlobs(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, blobs.Put(ctx, "abcdef", strings.NewReader("content"), 7))
+
+	r, err := blobs.Get(ctx, "abcdef")
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "content", string(got))
+
+	require.NoError(t, blobs.Delete(ctx, "abcdef"))
+	require.NoError(t, blobs.Delete(ctx, "abcdef"))
+
+	_, err = blobs.Get(ctx, "abcdef")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBlob(t *testing.T) {
+	s, dir := newBlobStore(t)
+
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+	writeValue(t, s, []string{"blobs"}, "b2", "plain")
+
+	assert.Len(t, blobFiles(t, dir), 1)
+	assert.Equal(t, streamed, readValue(t, s, []string{"blobs"}, "b1"))
+	assert.Equal(t, "plain", readValue(t, s, []string{"blobs"}, "b2"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	r, err := session.ReadStream([]string{"blobs"}, "b1")
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, streamed, string(got))
+}
+
+func TestBlobModify(t *testing.T) {
+	s, _ := newBlobStore(t)
+
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Append([]string{"blobs"}, "b1", []byte("!")))
+	closer()
+
+	assert.Equal(t, streamed+"!", readValue(t, s, []string{"blobs"}, "b1"))
+}
+
+func TestBlobList(t *testing.T) {
+	s, _ := newBlobStore(t)
+
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+	writeValue(t, s, []string{"blobs"}, "b2", "plain")
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, values, _, err := session.List([]string{"blobs"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "b2"}, keys)
+	assert.Equal(t, [][]byte{[]byte(streamed), []byte("plain")}, values)
+
+	keys, values, err = session.ReadScan([]string{"blobs"}, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "b2"}, keys)
+	assert.Equal(t, [][]byte{[]byte(streamed), []byte("plain")}, values)
+}
+
+func TestBlobDeleted(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blobs")
+	blobs, err := boltdb.NewDirBlobs(dir)
+	require.NoError(t, err)
+
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: filepath.Join(t.TempDir(), "blob.db")}, nil)
+	require.NoError(t, s.Open())
+	s.SetBlobProvider(blobs, 16)
+
+	// identical values share their blob
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+	writeValue(t, s, []string{"blobs"}, "b2", streamed)
+	assert.Len(t, blobFiles(t, dir), 1)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey([]string{"blobs"}, "b1"))
+	closer()
+
+	assert.Equal(t, streamed, readValue(t, s, []string{"blobs"}, "b2"))
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"blobs"}, "b2", []byte("plain")))
+	closer()
+
+	// Close waits for the deletion of the blobs
+	s.Close()
+	assert.Empty(t, blobFiles(t, dir))
+}
+
+func TestBlobUnavailable(t *testing.T) {
+	s, _ := newBlobStore(t)
+
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+	s.SetBlobProvider(nil, 0)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, err = session.Read([]string{"blobs"}, "b1")
+	assert.ErrorIs(t, err, boltdb.ErrBlobUnavailable)
+}
+
+func TestBlobCorrupt(t *testing.T) {
+	s, dir := newBlobStore(t)
+
+	writeValue(t, s, []string{"blobs"}, "b1", streamed)
+
+	files := blobFiles(t, dir)
+	require.Len(t, files, 1)
+	require.NoError(t, os.WriteFile(files[0], bytes.ToUpper([]byte(streamed)), 0600))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, err = session.Read([]string{"blobs"}, "b1")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+}
@@ -0,0 +1,182 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"github.com/aserto-dev/boltdb/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultBloomKeys              = 100000
+	defaultBloomFalsePositiveRate = 0.01
+)
+
+// BloomFilter configures an in-memory bloom filter over the keys of a bucket, answering most
+// KeyExists and PrefixExists misses without a database lookup. The filter is rebuilt when the store
+// is opened and grows stale entries as keys are deleted, which only costs database lookups.
+type BloomFilter struct {
+	Path []string `json:"path"` // bucket path of the filtered keys, nested buckets are not filtered
+	// ExpectedKeys sizes the filter, 100000 when zero.
+	ExpectedKeys int `json:"expected_keys"`
+	// FalsePositiveRate is the rate of misses which still go to the database, 0.01 when zero.
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+	// PrefixDelimiter, when set, also adds the prefixes of every key ending with the delimiter to the filter,
+	// so PrefixExists misses for such prefixes are answered by the filter as well, e.g. "tenant:" for "tenant:object".
+	PrefixDelimiter string `json:"prefix_delimiter"`
+}
+
+// bloomFor returns the bloom filter of the bucket at path, nil when not filtered.
+func (s *Store) bloomFor(path []string) *bloomFilter {
+	if len(s.blooms) == 0 {
+		return nil
+	}
+	return s.blooms[string(keys.JoinStrings(path...))]
+}
+
+// loadBloomFilters fills the bloom filters with the keys of their bucket.
+func (s *Store) loadBloomFilters(db *bolt.DB) error {
+	if len(s.blooms) == 0 {
+		return nil
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		session := Session{store: s, ctx: context.Background(), tx: tx}
+
+		for _, f := range s.blooms {
+			f.reset()
+
+			b, err := session.setBucket(f.config.Path)
+			if err != nil {
+				continue
+			}
+
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				if v != nil {
+					f.addKey(k)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// bloomAdd adds key to the bloom filter of the bucket at path. Keys are added as they are written,
+// before the session commits, so concurrent readers never miss a committed key.
+func (s *Session) bloomAdd(path []string, key []byte) {
+	if f := s.store.bloomFor(path); f != nil {
+		f.addKey(key)
+	}
+}
+
+// bloomAbsent reports whether key is known not to exist in the bucket at path.
+func (s *Session) bloomAbsent(path []string, key []byte) bool {
+	f := s.store.bloomFor(path)
+	return f != nil && !f.contains(key)
+}
+
+// bloomPrefixAbsent reports whether no key of the bucket at path is known to start with prefix.
+func (s *Session) bloomPrefixAbsent(path []string, prefix []byte) bool {
+	f := s.store.bloomFor(path)
+	if f == nil || f.config.PrefixDelimiter == "" || !bytes.HasSuffix(prefix, []byte(f.config.PrefixDelimiter)) {
+		return false
+	}
+	return !f.contains(prefix)
+}
+
+// bloomFilter is a concurrency safe bloom filter using double hashing.
+type bloomFilter struct {
+	config BloomFilter
+
+	mu     sync.RWMutex
+	bits   []uint64
+	m      uint64 // number of bits
+	hashes uint64 // number of hash functions
+}
+
+func newBloomFilter(config BloomFilter) *bloomFilter {
+	n := float64(config.ExpectedKeys)
+	if n <= 0 {
+		n = defaultBloomKeys
+	}
+	p := config.FalsePositiveRate
+	if p <= 0 || p >= 1 {
+		p = defaultBloomFalsePositiveRate
+	}
+
+	m := uint64(math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+
+	f := &bloomFilter{config: config, m: m, hashes: hashes}
+	f.reset()
+
+	return f
+}
+
+func (f *bloomFilter) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.bits = make([]uint64, (f.m+63)/64)
+}
+
+// addKey adds key and, when configured, its delimited prefixes.
+func (f *bloomFilter) addKey(key []byte) {
+	f.add(key)
+
+	if delim := []byte(f.config.PrefixDelimiter); len(delim) > 0 {
+		for i := bytes.Index(key, delim); i >= 0; {
+			f.add(key[:i+len(delim)])
+
+			next := bytes.Index(key[i+len(delim):], delim)
+			if next < 0 {
+				break
+			}
+			i += len(delim) + next
+		}
+	}
+}
+
+func (f *bloomFilter) add(v []byte) {
+	h1, h2 := bloomHash(v)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (f *bloomFilter) contains(v []byte) bool {
+	h1, h2 := bloomHash(v)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHash(v []byte) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write(v)
+	h1 := h.Sum64()
+
+	_, _ = h.Write([]byte{0xff})
+	h2 := h.Sum64() | 1
+
+	return h1, h2
+}
@@ -0,0 +1,60 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	cfg := &boltdb.Config{
+		BloomFilters: []boltdb.BloomFilter{{
+			Path:            []string{"overrides"},
+			ExpectedKeys:    1000,
+			PrefixDelimiter: ":",
+		}},
+	}
+	s := newTestStoreWithConfig(t, cfg)
+
+	path := []string{"overrides"}
+	for i := 0; i < 100; i++ {
+		write(t, s, path, fmt.Sprintf("tenant%d:object%d", i%10, i))
+	}
+
+	check := func(s *boltdb.Store) {
+		session, closer, err := s.ReadSession()
+		require.NoError(t, err)
+		defer closer()
+
+		for i := 0; i < 100; i++ {
+			assert.True(t, session.KeyExists(path, fmt.Sprintf("tenant%d:object%d", i%10, i)))
+			assert.False(t, session.KeyExists(path, fmt.Sprintf("tenant%d:missing%d", i%10, i)))
+		}
+
+		for prefix, expected := range map[string]bool{
+			"tenant3:":    true,
+			"tenant3:obj": true,
+			"tenant42:":   false,
+			"tenant3:zzz": false,
+			"nope":        false,
+		} {
+			exists, err := session.PrefixExists(path, prefix)
+			require.NoError(t, err)
+			assert.Equal(t, expected, exists, prefix)
+		}
+
+		_, err = session.PrefixExists([]string{"missing"}, "tenant3:")
+		assert.Error(t, err)
+	}
+
+	check(s)
+
+	// filters are rebuilt from the database on open
+	s.Close()
+	require.NoError(t, s.Open())
+
+	check(s)
+}
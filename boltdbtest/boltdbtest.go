@@ -0,0 +1,218 @@
+// Package boltdbtest provides helpers for tests of boltdb stores and their consumers.
+//
+// Every store returned by NewTestStore is backed by its own database file, so tests
+// do not depend on the state left behind by other tests nor on their execution order.
+package boltdbtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+)
+
+// base64Prefix marks golden file values which are not valid UTF-8.
+const base64Prefix = "base64:"
+
+// update is namespaced, so it does not collide with the -update flag of the tests importing the package.
+var update = flag.Bool("boltdbtest.update", false, "update boltdbtest golden files")
+
+// Fixtures maps bucket paths, with segments joined by "/", to the key-values of the bucket.
+type Fixtures = map[string]map[string][]byte
+
+// NewTestStore opens a store backed by a database file in a temporary directory.
+// The store is closed and the file removed when the test ends.
+func NewTestStore(t testing.TB) *boltdb.Store {
+	t.Helper()
+
+	return NewTestStoreWithConfig(t, &boltdb.Config{})
+}
+
+// NewTestStoreWithConfig is NewTestStore using cfg, with the database path set by the helper.
+func NewTestStoreWithConfig(t testing.TB, cfg *boltdb.Config) *boltdb.Store {
+	t.Helper()
+
+	logger := zerolog.New(io.Discard)
+
+	cfg.DBPath = filepath.Join(t.TempDir(), "test.db")
+	store := boltdb.NewStore(cfg, &logger)
+
+	if err := store.Open(); err != nil {
+		t.Fatalf("open test store: %v", err)
+	}
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+// Seed writes fixtures to store in a single write session.
+// A bucket without key-values is created empty.
+func Seed(t testing.TB, store *boltdb.Store, fixtures Fixtures) {
+	t.Helper()
+
+	paths := make([]string, 0, len(fixtures))
+	for path := range fixtures {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	err := store.Update(func(w boltdb.Writer) error {
+		for _, path := range paths {
+			segments := SplitPath(path)
+			if err := w.CreateBucket(segments); err != nil {
+				return err
+			}
+			if err := w.WriteMany(segments, fixtures[path]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("seed test store: %v", err)
+	}
+}
+
+// Dump returns the content of store in the format accepted by Seed.
+// Buckets holding no key-values are only included when they have no nested buckets either.
+func Dump(t testing.TB, store *boltdb.Store) Fixtures {
+	t.Helper()
+
+	fixtures := Fixtures{}
+
+	err := store.View(func(r boltdb.Reader) error {
+		roots, _, err := r.ListBuckets(nil, "")
+		if err != nil {
+			return err
+		}
+		for _, root := range roots {
+			if err := dumpBucket(r, []string{root}, fixtures); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("dump test store: %v", err)
+	}
+
+	return fixtures
+}
+
+func dumpBucket(r boltdb.Reader, path []string, fixtures Fixtures) error {
+	values := map[string][]byte{}
+	var children []string
+
+	for token := ""; ; {
+		entries, next, err := r.ListEntries(path, token)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Kind == boltdb.EntryBucket {
+				children = append(children, entry.Key)
+				continue
+			}
+			values[entry.Key] = entry.Value
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	if len(values) > 0 || len(children) == 0 {
+		fixtures[strings.Join(path, "/")] = values
+	}
+
+	for _, child := range children {
+		if err := dumpBucket(r, append(append([]string{}, path...), child), fixtures); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SplitPath returns the segments of a "/" joined bucket path.
+func SplitPath(path string) []string {
+	return strings.Split(path, "/")
+}
+
+// AssertGolden compares the content of store to the golden file testdata/<name>.golden.
+// Values are written as strings, or base64 encoded with a "base64:" prefix when they are
+// not valid UTF-8. Run the tests with -boltdbtest.update to rewrite the golden file.
+func AssertGolden(t testing.TB, store *boltdb.Store, name string) {
+	t.Helper()
+
+	dump := map[string]map[string]string{}
+	for path, values := range Dump(t, store) {
+		bucket := map[string]string{}
+		for k, v := range values {
+			if utf8.Valid(v) && !strings.HasPrefix(string(v), base64Prefix) {
+				bucket[k] = string(v)
+			} else {
+				bucket[k] = base64Prefix + base64.StdEncoding.EncodeToString(v)
+			}
+		}
+		dump[path] = bucket
+	}
+
+	got, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal dump: %v", err)
+	}
+
+	AssertGoldenBytes(t, name, append(got, '\n'))
+}
+
+// AssertGoldenDump compares the output of Store.DumpDeterministic to the golden file testdata/<name>.golden.
+// Unlike AssertGolden, the dump holds the sequences of the buckets and the hashes of the values.
+// Run the tests with -boltdbtest.update to rewrite the golden file.
+func AssertGoldenDump(t testing.TB, store *boltdb.Store, name string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := store.DumpDeterministic(&buf); err != nil {
+		t.Fatalf("dump test store: %v", err)
+	}
+
+	AssertGoldenBytes(t, name, buf.Bytes())
+}
+
+// AssertGoldenBytes compares got to the golden file testdata/<name>.golden.
+// Run the tests with -boltdbtest.update to rewrite the golden file.
+func AssertGoldenBytes(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	file := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch, run with -boltdbtest.update to accept the changes\n--- want\n%s\n--- got\n%s", file, want, got)
+	}
+}
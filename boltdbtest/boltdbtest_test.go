@@ -0,0 +1,57 @@
+package boltdbtest_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixtures = boltdbtest.Fixtures{
+	"users":             {"alice": []byte(`{"name":"alice"}`), "bob": []byte(`{"name":"bob"}`)},
+	"users/groups":      {"admins": []byte("alice")},
+	"binary":            {"k": {0xff, 0x00}},
+	"empty":             {},
+	"nested/only/child": {"c": []byte("1")},
+}
+
+func TestSeedDump(t *testing.T) {
+	store := boltdbtest.NewTestStore(t)
+	boltdbtest.Seed(t, store, fixtures)
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	value, err := session.Read([]string{"users", "groups"}, "admins")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", string(value))
+	assert.True(t, session.BucketExists([]string{"empty"}))
+
+	assert.Equal(t, fixtures, boltdbtest.Dump(t, store))
+}
+
+func TestStoresAreIsolated(t *testing.T) {
+	a := boltdbtest.NewTestStore(t)
+	b := boltdbtest.NewTestStore(t)
+
+	boltdbtest.Seed(t, a, boltdbtest.Fixtures{"a": {"k": []byte("v")}})
+
+	assert.NotEqual(t, a.DBPath(), b.DBPath())
+	assert.Empty(t, boltdbtest.Dump(t, b))
+}
+
+func TestAssertGolden(t *testing.T) {
+	store := boltdbtest.NewTestStore(t)
+	boltdbtest.Seed(t, store, fixtures)
+
+	boltdbtest.AssertGolden(t, store, "fixtures")
+}
+
+func TestAssertGoldenDump(t *testing.T) {
+	store := boltdbtest.NewTestStore(t)
+	boltdbtest.Seed(t, store, fixtures)
+
+	boltdbtest.AssertGoldenDump(t, store, "dump")
+}
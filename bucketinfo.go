@@ -0,0 +1,64 @@
+package boltdb
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// BucketInfo describes a bucket, see Session.BucketInfo.
+type BucketInfo struct {
+	Seq     uint64 // current sequence of the bucket
+	Keys    int    // number of keys of the bucket, those of nested buckets excluded
+	Buckets int    // number of buckets nested directly in the bucket
+	Depth   int    // number of segments of the path of the bucket
+	Size    int64  // approximate size in bytes of the pages in use by the bucket and its nested buckets
+}
+
+// BucketInfo returns the sequence, the number of keys and nested buckets, the depth and the approximate
+// size of bucket path, in a single walk of its keys. The size accounts for the pages holding the keys and
+// values of the bucket and of its nested buckets, not for streamed or external values.
+// Returns ErrPathNotFound when the bucket path does not exist.
+func (s *Session) BucketInfo(path []string) (*BucketInfo, error) {
+	s.store.logger.Trace("Session::BucketInfo", "path", path)
+
+	var info *BucketInfo
+
+	describe := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		info = &BucketInfo{Seq: b.Sequence(), Depth: len(path)}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil {
+				info.Buckets++
+			} else {
+				info.Keys++
+			}
+		}
+
+		stats := b.Stats()
+		// small buckets are inlined in the page of their parent rather than having pages of their own
+		info.Size = int64(stats.BranchInuse + stats.LeafInuse + stats.InlineBucketInuse)
+
+		return nil
+	}
+
+	err := s.intercept(newOp("BucketInfo", path, ""), func() error { return s.view(describe) })
+
+	if err != nil {
+		s.store.logger.Trace("BucketInfo", "error", err)
+		return nil, wrapError("BucketInfo", path, "", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,77 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketInfo(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"tenants", "acme"}
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 50; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%03d", i), bytes.Repeat([]byte{'v'}, 100)); err != nil {
+				return err
+			}
+		}
+		if err := w.CreateBucket(append(path, "users")); err != nil {
+			return err
+		}
+		if err := w.Write(append(path, "groups"), "admins", []byte("v")); err != nil {
+			return err
+		}
+		return w.SetSeq(path, 42)
+	}))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	info, err := session.BucketInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), info.Seq)
+	assert.Equal(t, 50, info.Keys)
+	assert.Equal(t, 2, info.Buckets)
+	assert.Equal(t, 2, info.Depth)
+	assert.Greater(t, info.Size, int64(50*100))
+
+	info, err = session.BucketInfo(append(path, "users"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Keys)
+	assert.Zero(t, info.Buckets)
+	assert.Equal(t, 3, info.Depth)
+
+	// small buckets are inlined in the page of their parent
+	info, err = session.BucketInfo(append(path, "groups"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.Keys)
+	assert.Greater(t, info.Size, int64(0))
+
+	_, err = session.BucketInfo([]string{"missing"})
+	assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+}
+
+func TestBucketInfoNamespace(t *testing.T) {
+	s := newTestStore(t)
+
+	acme, err := boltdb.Namespace(s, "acme")
+	require.NoError(t, err)
+
+	require.NoError(t, acme.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users"}, "alice", []byte("v"))
+	}))
+
+	require.NoError(t, acme.View(func(r boltdb.Reader) error {
+		info, err := r.BucketInfo([]string{"users"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, info.Keys)
+		assert.Equal(t, 1, info.Depth)
+		return nil
+	}))
+}
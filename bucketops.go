@@ -0,0 +1,272 @@
+package boltdb
+
+import (
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucketContainer is implemented by bolt.Tx and bolt.Bucket, both of which hold buckets.
+type bucketContainer interface {
+	Bucket(name []byte) *bolt.Bucket
+	CreateBucket(name []byte) (*bolt.Bucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// TruncateBucket deletes all keys of the bucket at the tail of path,
+// preserving the bucket itself, its sequence and its nested buckets.
+// The call does not return an error when the bucket does not exist.
+func (s *Session) TruncateBucket(path []string) error {
+	s.store.logger.Trace("Session::TruncateBucket", "path", path)
+
+	truncate := func(tx *bolt.Tx) error {
+		return s.truncate(path, false)
+	}
+
+	err := s.intercept(newOp("TruncateBucket", path, ""), func() error { return s.update(truncate) })
+
+	return wrapError("TruncateBucket", path, "", err)
+}
+
+// TruncateBucketRecursive deletes all keys of the bucket at the tail of path and of
+// its nested buckets, preserving the buckets themselves and their sequences.
+// The call does not return an error when the bucket does not exist.
+func (s *Session) TruncateBucketRecursive(path []string) error {
+	s.store.logger.Trace("Session::TruncateBucketRecursive", "path", path)
+
+	truncate := func(tx *bolt.Tx) error {
+		return s.truncate(path, true)
+	}
+
+	err := s.intercept(newOp("TruncateBucketRecursive", path, ""), func() error { return s.update(truncate) })
+
+	return wrapError("TruncateBucketRecursive", path, "", err)
+}
+
+func (s *Session) truncate(path []string, recursive bool) error {
+	if err := Path(path).Validate(); err != nil {
+		return err
+	}
+
+	b, err := s.setBucket(path)
+	if err != nil {
+		return nil
+	}
+
+	s.journalBucket(path)
+	s.touchBucket(path)
+
+	if s.store.config.TrackMetadata || s.store.config.Checksums || len(s.store.config.VersionedPaths) > 0 {
+		if err := s.recordTruncate(b, path, recursive); err != nil {
+			return err
+		}
+	}
+
+	if err := s.unindexBucket(b, path, recursive); err != nil {
+		return err
+	}
+
+	if err := s.releaseBucketRefs(b, path, recursive); err != nil {
+		return err
+	}
+
+	var parent bucketContainer = s.tx
+	if len(path) > 1 {
+		if parent, err = s.setBucket(path[:len(path)-1]); err != nil {
+			return err
+		}
+	}
+
+	// buckets without nested buckets are recreated, invalidating the buckets resolved so far
+	s.bucketEpoch++
+	if err := truncateBucket(parent, []byte(path[len(path)-1]), recursive); err != nil {
+		return err
+	}
+
+	flag := []byte{0}
+	if recursive {
+		flag[0] = 1
+	}
+	s.emit(EventTruncateBucket, path, nil, flag)
+
+	return nil
+}
+
+// recordTruncate records the deletion of the keys about to be truncated from bucket b at path
+// in the key history, metadata and checksums.
+func (s *Session) recordTruncate(b *bolt.Bucket, path []string, recursive bool) error {
+	var (
+		paths [][]string
+		names [][]byte
+	)
+	collect := func(p []string, k, _ []byte) error {
+		paths = append(paths, p)
+		names = append(names, append([]byte{}, k...))
+		return nil
+	}
+
+	if recursive {
+		_ = walkBucket(b, path, collect)
+	} else {
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v != nil {
+				_ = collect(path, k, v)
+			}
+		}
+	}
+
+	for i, key := range names {
+		if err := s.recordVersion(paths[i], key, nil); err != nil {
+			return err
+		}
+		if err := s.deleteMetadata(paths[i], key); err != nil {
+			return err
+		}
+		if err := s.deleteChecksum(paths[i], key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncateBucket deletes the keys of bucket name held by parent.
+// Buckets without nested buckets are deleted and recreated, which releases
+// their pages at once instead of deleting keys one by one.
+func truncateBucket(parent bucketContainer, name []byte, recursive bool) error {
+	b := parent.Bucket(name)
+
+	var keys, nested [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			nested = append(nested, append([]byte{}, k...))
+		} else {
+			keys = append(keys, append([]byte{}, k...))
+		}
+	}
+
+	if len(nested) == 0 {
+		seq := b.Sequence()
+		if err := parent.DeleteBucket(name); err != nil {
+			return err
+		}
+		b, err := parent.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+		return b.SetSequence(seq)
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	if recursive {
+		for _, n := range nested {
+			if err := truncateBucket(b, n, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CopyBucket recursively copies the keys, nested buckets and sequences of the bucket at src to a new bucket at dst.
+// Returns ErrPathNotFound when src does not exist, bolt.ErrBucketExists when dst already exists,
+// and ErrInvalidPath when dst is located below src.
+func (s *Session) CopyBucket(src, dst []string) error {
+	s.store.logger.Trace("Session::CopyBucket", "src", src, "dst", dst)
+
+	cp := func(tx *bolt.Tx) error {
+		return s.copyBucket(src, dst)
+	}
+
+	op := newOp("CopyBucket", src, "")
+	op.Target = dst
+
+	err := s.intercept(op, func() error { return s.update(cp) })
+
+	return wrapError("CopyBucket", src, "", err)
+}
+
+// MoveBucket recursively moves the keys, nested buckets and sequences of the bucket at src to a new bucket at dst,
+// deleting src. Errors are the same as for CopyBucket.
+func (s *Session) MoveBucket(src, dst []string) error {
+	s.store.logger.Trace("Session::MoveBucket", "src", src, "dst", dst)
+
+	move := func(tx *bolt.Tx) error {
+		if err := s.copyBucket(src, dst); err != nil {
+			return err
+		}
+		return s.deleteBucket(src)
+	}
+
+	op := newOp("MoveBucket", src, "")
+	op.Target = dst
+
+	err := s.intercept(op, func() error { return s.update(move) })
+
+	return wrapError("MoveBucket", src, "", err)
+}
+
+func (s *Session) copyBucket(src, dst []string) error {
+	if err := Path(src).Validate(); err != nil {
+		return err
+	}
+	if err := Path(dst).Validate(); err != nil {
+		return err
+	}
+	if hasPathPrefix(dst, src) {
+		return errors.Wrapf(ErrInvalidPath, "destination %s is below source %s", Path(dst), Path(src))
+	}
+
+	b, err := s.setBucket(src)
+	if err != nil {
+		return err
+	}
+	if _, err := s.setBucket(dst); err == nil {
+		return &StoreError{Path: dst, Err: bolt.ErrBucketExists}
+	}
+
+	// copy from a snapshot, so writing dst never disturbs the iteration of src
+	return s.restoreBucket(dst, snapshotBucket(b))
+}
+
+// restoreBucket writes snap to the bucket at path through the session, so the
+// copied keys are journaled, versioned and published like regular writes.
+func (s *Session) restoreBucket(path []string, snap *bucketSnapshot) error {
+	s.journalCreate(path)
+
+	b, err := s.setBucketIfNotExist(path)
+	if err != nil {
+		return err
+	}
+	s.emit(EventCreateBucket, path, nil, nil)
+
+	if snap.sequence != 0 {
+		if err := b.SetSequence(snap.sequence); err != nil {
+			return err
+		}
+		s.emitSequence(path, snap.sequence)
+	}
+
+	for i, k := range snap.keys {
+		if err := s.putStored(path, k, snap.values[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, name := range snap.names {
+		child := append(append([]string{}, path...), string(name))
+		if err := s.restoreBucket(child, snap.buckets[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
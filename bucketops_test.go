@@ -0,0 +1,139 @@
+package boltdb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func listKeys(t *testing.T, s *boltdb.Store, path []string) []string {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, _, err := session.ListKeys(path, "")
+	require.NoError(t, err)
+	return keys
+}
+
+func TestTruncateBucket(t *testing.T) {
+	s := newTestStore(t)
+
+	write(t, s, []string{"flat"}, "k1")
+	write(t, s, []string{"flat"}, "k2")
+	write(t, s, []string{"tree"}, "k1")
+	write(t, s, []string{"tree", "nested"}, "k2")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	_, err = session.NextSeq([]string{"flat"})
+	require.NoError(t, err)
+	require.NoError(t, session.TruncateBucket([]string{"flat"}))
+	require.NoError(t, session.TruncateBucket([]string{"tree"}))
+	require.NoError(t, session.TruncateBucket([]string{"missing"}))
+	closer()
+
+	assert.Empty(t, listKeys(t, s, []string{"flat"}))
+	assert.Empty(t, listKeys(t, s, []string{"tree"}))
+	assert.Equal(t, []string{"k2"}, listKeys(t, s, []string{"tree", "nested"}))
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	seq, err := session.CurrentSeq([]string{"flat"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), seq)
+	assert.False(t, session.BucketExists([]string{"missing"}))
+
+	require.NoError(t, session.TruncateBucketRecursive([]string{"tree"}))
+	closer()
+
+	assert.Empty(t, listKeys(t, s, []string{"tree", "nested"}))
+}
+
+func TestTruncateBucketHistory(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		VersionedPaths: [][]string{{"objects"}},
+		TrackMetadata:  true,
+	})
+
+	write(t, s, []string{"objects"}, "k1")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.TruncateBucket([]string{"objects"}))
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	history, err := session.History([]string{"objects"}, "k1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{{Revision: 2, Deleted: true}}, history)
+
+	_, err = session.Metadata([]string{"objects"}, "k1")
+	assert.Error(t, err)
+}
+
+func TestCopyMoveBucket(t *testing.T) {
+	s := newTestStore(t)
+
+	write(t, s, []string{"tenant-a"}, "k1")
+	write(t, s, []string{"tenant-a", "users"}, "u1")
+	write(t, s, []string{"tenant-a", "users", "groups"}, "g1")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	_, err = session.NextSeq([]string{"tenant-a", "users"})
+	require.NoError(t, err)
+	require.NoError(t, session.CreateBucket([]string{"tenant-a", "empty"}))
+	require.NoError(t, session.CopyBucket([]string{"tenant-a"}, []string{"tenant-b"}))
+	closer()
+
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		assert.Equal(t, []string{"k1"}, listKeys(t, s, []string{tenant}))
+		assert.Equal(t, []string{"u1"}, listKeys(t, s, []string{tenant, "users"}))
+		assert.Equal(t, []string{"g1"}, listKeys(t, s, []string{tenant, "users", "groups"}))
+	}
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	assert.True(t, session.BucketExists([]string{"tenant-b", "empty"}))
+	seq, err := session.CurrentSeq([]string{"tenant-b", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), seq)
+
+	require.NoError(t, session.MoveBucket([]string{"tenant-b"}, []string{"tenant-c"}))
+	assert.False(t, session.BucketExists([]string{"tenant-b"}))
+	assert.True(t, session.BucketExists([]string{"tenant-c", "users", "groups"}))
+	closer()
+}
+
+func TestCopyBucketErrors(t *testing.T) {
+	s := newTestStore(t)
+
+	write(t, s, []string{"src"}, "k1")
+	write(t, s, []string{"dst"}, "k1")
+
+	for dst, expected := range map[string]error{
+		"dst":      bolt.ErrBucketExists,
+		"src/nest": boltdb.ErrInvalidPath,
+	} {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		err = session.CopyBucket([]string{"src"}, strings.Split(dst, "/"))
+		assert.True(t, errors.Is(err, expected), "%s: %v", dst, err)
+		closer()
+	}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	defer closer()
+	err = session.MoveBucket([]string{"missing"}, []string{"other"})
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+}
@@ -0,0 +1,207 @@
+package boltdb
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aserto-dev/boltdb/keys"
+)
+
+// CacheStats reports the activity of the read cache.
+type CacheStats struct {
+	Hits      uint64 // reads served from the cache
+	Misses    uint64 // reads which had to go to the database
+	Evictions uint64 // entries evicted to respect the cache size
+	Entries   int    // entries currently cached
+}
+
+// CacheStats returns the statistics of the read cache, zero when the cache is disabled.
+func (s *Store) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.stats()
+}
+
+// cached returns the read cache consulted by the session, nil for write sessions,
+// which must observe their own uncommitted writes, for snapshot sessions, which must not observe
+// later writes, or when the cache is disabled.
+func (s *Session) cached() *lruCache {
+	if s.store.cache == nil || s.tx == nil || s.tx.Writable() || s.snapshot {
+		return nil
+	}
+	return s.store.cache
+}
+
+// cacheGet returns a copy of the cached value of key in path, unless a write session committed since
+// the session started, in which case the cached value may be newer than the session snapshot.
+func (s *Session) cacheGet(path []string, key []byte) ([]byte, bool) {
+	c := s.cached()
+	if c == nil || atomic.LoadUint64(&s.store.revision) != s.revision {
+		return nil, false
+	}
+
+	v, ok := c.get(cacheKey(path, key))
+	if !ok {
+		return nil, false
+	}
+	return append([]byte{}, v...), true
+}
+
+// cacheAdd caches value for key in path, unless a write session committed since the session started,
+// in which case value may be stale.
+func (s *Session) cacheAdd(path []string, key, value []byte) {
+	c := s.cached()
+	if c == nil {
+		return
+	}
+
+	c.add(cacheKey(path, key), append([]byte{}, value...), func() bool {
+		return atomic.LoadUint64(&s.store.revision) == s.revision
+	})
+}
+
+// touchKey records key in path as modified, so its cache entry is evicted once the session commits.
+func (s *Session) touchKey(path []string, key []byte) {
+	if s.store.cache == nil {
+		return
+	}
+	s.touched = append(s.touched, touch{key: cacheKey(path, key)})
+}
+
+// touchBucket records the bucket at path as modified, so the cache entries of its keys, including those
+// of nested buckets, are evicted once the session commits.
+func (s *Session) touchBucket(path []string) {
+	if s.store.cache == nil {
+		return
+	}
+	s.touched = append(s.touched, touch{key: string(keys.JoinStrings(path...)), bucket: true})
+}
+
+// invalidate evicts the cache entries modified by a committed session.
+func (s *Store) invalidate(touched []touch) {
+	if s.cache == nil {
+		return
+	}
+	for _, t := range touched {
+		if t.bucket {
+			s.cache.removePrefix(t.key)
+		} else {
+			s.cache.remove(t.key)
+		}
+	}
+}
+
+// touch is a key, or bucket, modified by a write session.
+type touch struct {
+	key    string
+	bucket bool
+}
+
+func cacheKey(path []string, key []byte) string {
+	segments := make([][]byte, 0, len(path)+1)
+	for _, p := range path {
+		segments = append(segments, []byte(p))
+	}
+	return string(keys.Join(append(segments, key)...))
+}
+
+// lruCache is a size bounded least recently used cache of values.
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+
+	hits, misses, evictions uint64
+}
+
+type cacheEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+func (c *lruCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.ll.MoveToFront(e)
+
+	return e.Value.(*cacheEntry).value, true
+}
+
+// add caches value for key when valid, which is evaluated while holding the cache lock.
+func (c *lruCache) add(key string, value []byte, valid func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !valid() {
+		return
+	}
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*cacheEntry).value = value
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value})
+
+	for c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *lruCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+func (c *lruCache) removePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.ll.Remove(e)
+			delete(c.items, key)
+		}
+	}
+}
+
+func (c *lruCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.ll.Len(),
+	}
+}
@@ -0,0 +1,95 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readValue(t *testing.T, s *boltdb.Store, path []string, key string) string {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(path, key)
+	require.NoError(t, err)
+	return string(value)
+}
+
+func TestReadCache(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{CacheSize: 2})
+
+	path := []string{"objects", "users"}
+	write(t, s, path, "k1")
+
+	assert.Equal(t, "k1", readValue(t, s, path, "k1"))
+	assert.Equal(t, "k1", readValue(t, s, path, "k1"))
+	assert.Equal(t, boltdb.CacheStats{Hits: 1, Misses: 1, Entries: 1}, s.CacheStats())
+
+	writeValue(t, s, path, "k1", "v2")
+	assert.Equal(t, "v2", readValue(t, s, path, "k1"))
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket([]string{"objects"}))
+	closer()
+	assert.Equal(t, 0, s.CacheStats().Entries)
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	assert.False(t, session.KeyExists(path, "k1"))
+	closer()
+
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("e%d", i)
+		write(t, s, path, key)
+		assert.Equal(t, key, readValue(t, s, path, key))
+	}
+	stats := s.CacheStats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Evictions)
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	assert.True(t, session.KeyExists(path, "e2"))
+	closer()
+	assert.Equal(t, stats.Hits+1, s.CacheStats().Hits)
+}
+
+func TestReadCacheStaleSession(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{CacheSize: 10})
+
+	path := []string{"objects"}
+	write(t, s, path, "k1")
+	// grow the memory map up front, committing while a read transaction is open cannot remap it
+	writeValue(t, s, []string{"filler"}, "k1", strings.Repeat("x", 1<<20))
+
+	// a read session predating a commit must not cache the values it observes
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+
+	writeValue(t, s, path, "k1", "v2")
+
+	value, err := session.Read(path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", string(value))
+	closer()
+
+	assert.Equal(t, "v2", readValue(t, s, path, "k1"))
+
+	// nor be served the values cached by later sessions
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	writeValue(t, s, path, "k1", "v3")
+	assert.Equal(t, "v3", readValue(t, s, path, "k1"))
+
+	value, err = session.Read(path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(value))
+}
@@ -0,0 +1,379 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var changelogBucket = []byte("changelog")
+
+const (
+	watchBufferSize   = 1024 // live events buffered per watcher before it falls back to the changelog
+	changelogReadSize = 256  // changelog events read per read transaction while replaying
+)
+
+// EventOp identifies the kind of mutation described by an Event.
+type EventOp int
+
+const (
+	EventPut            EventOp = iota + 1 // key written
+	EventDelete                            // key deleted
+	EventCreateBucket                      // bucket path created
+	EventDeleteBucket                      // bucket deleted, including its keys and nested buckets
+	EventSetSequence                       // bucket sequence changed, Value holds the big-endian uint64 sequence
+	EventTruncateBucket                    // keys of a bucket deleted, Value is 1 when nested buckets were truncated as well
+)
+
+var eventOpNames = map[EventOp]string{
+	EventPut:            "put",
+	EventDelete:         "delete",
+	EventCreateBucket:   "create_bucket",
+	EventDeleteBucket:   "delete_bucket",
+	EventSetSequence:    "set_sequence",
+	EventTruncateBucket: "truncate_bucket",
+}
+
+func (op EventOp) String() string {
+	if name, ok := eventOpNames[op]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Event describes a committed mutation.
+type Event struct {
+	Revision uint64   `json:"revision"`
+	Op       EventOp  `json:"op"`
+	Path     []string `json:"path"`
+	Key      []byte   `json:"key,omitempty"`
+	Value    []byte   `json:"value,omitempty"`
+}
+
+// collecting reports whether the session collects events: when the changelog or audit is enabled,
+// someone is watching or the session records them.
+func (s *Session) collecting() bool {
+	return s.store.config.EnableChangelog || s.store.watchers.active() || s.recording || s.store.auditing()
+}
+
+// emit records a mutation of the session, published once the session commits.
+func (s *Session) emit(op EventOp, path []string, key, value []byte) {
+	if !s.collecting() {
+		return
+	}
+
+	event := Event{
+		Op:   op,
+		Path: append([]string{}, path...),
+	}
+	if key != nil {
+		event.Key = append([]byte{}, key...)
+	}
+	if value != nil {
+		event.Value = append([]byte{}, value...)
+	}
+
+	s.events = append(s.events, event)
+}
+
+func (s *Session) emitSequence(path []string, seq uint64) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, seq)
+	s.emit(EventSetSequence, path, nil, buf)
+}
+
+// appendChangelog stamps the session events with revision and, when the changelog
+// is enabled, appends them to the changelog bucket.
+func (s *Session) appendChangelog(revision uint64) error {
+	for i := range s.events {
+		s.events[i].Revision = revision
+	}
+
+	if !s.store.config.EnableChangelog || len(s.events) == 0 {
+		return nil
+	}
+
+	b, err := createMetaChild(s.tx, changelogBucket)
+	if err != nil {
+		return err
+	}
+
+	for i := range s.events {
+		buf, err := json.Marshal(&s.events[i])
+		if err != nil {
+			return err
+		}
+		if err := b.Put(changelogKey(revision, uint32(i)), buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func changelogKey(revision uint64, index uint32) []byte {
+	key := make([]byte, 12)
+	binary.BigEndian.PutUint64(key, revision)
+	binary.BigEndian.PutUint32(key[8:], index)
+	return key
+}
+
+// indexedEvent is an event along with its index within the events of its revision.
+type indexedEvent struct {
+	Event
+	index uint32
+}
+
+// readChangelog returns up to limit changelog events with a revision greater than rev,
+// or, when index > 0, the events of revision rev starting at index.
+func (s *Store) readChangelog(rev uint64, index uint32, limit int) ([]indexedEvent, error) {
+	var events []indexedEvent
+
+	db := s.database()
+	if db == nil {
+		return nil, bolt.ErrDatabaseNotOpen
+	}
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := metaChild(tx, changelogBucket)
+		if b == nil {
+			return nil
+		}
+
+		start := changelogKey(rev+1, 0)
+		if index > 0 {
+			start = changelogKey(rev, index)
+		}
+
+		c := b.Cursor()
+		for k, v := c.Seek(start); k != nil && len(events) < limit; k, v = c.Next() {
+			event := indexedEvent{index: binary.BigEndian.Uint32(k[8:])}
+			if err := json.Unmarshal(v, &event.Event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+
+		return nil
+	})
+
+	return events, err
+}
+
+// WatchFrom returns a channel delivering the committed events below prefix with a revision
+// greater than rev, replaying historical events from the changelog before switching to live
+// notifications, and a function to stop watching which closes the channel.
+//
+// Subscribers which disconnect can resume without a full resync by calling WatchFrom with the
+// revision of the last event they processed. When the changelog is disabled only live events are
+// delivered. Watchers which do not keep up with live events transparently catch up from the changelog;
+// without a changelog their channel is closed instead.
+func (s *Store) WatchFrom(rev uint64, prefix Path) (<-chan Event, func()) {
+	s.logger.Trace("Store::WatchFrom", "rev", rev, "prefix", prefix)
+
+	out := make(chan Event)
+	done := make(chan struct{})
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { close(done) })
+	}
+
+	go s.watch(rev, append(Path{}, prefix...), out, done)
+
+	return out, stop
+}
+
+func (s *Store) watch(rev uint64, prefix Path, out chan<- Event, done <-chan struct{}) {
+	defer close(out)
+
+	send := func(event Event) bool {
+		if !hasPathPrefix(event.Path, prefix) {
+			return true
+		}
+		select {
+		case out <- event:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	pos := position{rev: rev}
+
+	for {
+		// subscribe before replaying, so no event committed in between gets lost
+		w := s.watchers.add()
+
+		var ok bool
+		if pos, ok = s.replay(pos, send); !ok {
+			s.watchers.remove(w)
+			return
+		}
+
+		if pos, ok = s.forward(w, pos, send, done); !ok || !s.config.EnableChangelog {
+			s.watchers.remove(w)
+			return
+		}
+		// the watcher overflowed, catch up from the changelog
+	}
+}
+
+// position identifies the last event delivered to a watcher: count events of revision rev
+// have been delivered, and count is zero when all events up to rev have been delivered.
+type position struct {
+	rev   uint64
+	count uint32
+}
+
+// delivered reports whether the event at index of revision rev has already been delivered.
+func (p position) delivered(rev uint64, index uint32) bool {
+	return rev < p.rev || (rev == p.rev && (p.count == 0 || index < p.count))
+}
+
+// replay sends the changelog events following pos and returns the position of the last event sent.
+// It returns false when sending was aborted.
+func (s *Store) replay(pos position, send func(Event) bool) (position, bool) {
+	if !s.config.EnableChangelog {
+		return pos, true
+	}
+
+	for {
+		events, err := s.readChangelog(pos.rev, pos.count, changelogReadSize)
+		if err != nil {
+			s.logger.Error("WatchFrom::replay", "error", err)
+			return pos, false
+		}
+		if len(events) == 0 {
+			return pos, true
+		}
+
+		for _, event := range events {
+			if !send(event.Event) {
+				return pos, false
+			}
+			pos = position{rev: event.Revision, count: event.index + 1}
+		}
+	}
+}
+
+// forward sends the live events of w following pos, until w overflows or watching is stopped.
+// It returns the position of the last event sent and false when watching has been stopped.
+func (s *Store) forward(w *watcher, pos position, send func(Event) bool, done <-chan struct{}) (position, bool) {
+	for {
+		select {
+		case <-done:
+			return pos, false
+		case event, ok := <-w.ch:
+			if !ok {
+				return pos, true
+			}
+			if pos.delivered(event.Revision, event.index) {
+				continue
+			}
+			if !send(event.Event) {
+				return pos, false
+			}
+			pos = position{rev: event.Revision, count: event.index + 1}
+		}
+	}
+}
+
+// publish notifies watchers of the events of a committed session.
+func (s *Store) publish(events []Event) {
+	if len(events) > 0 {
+		s.watchers.publish(events)
+	}
+}
+
+func hasPathPrefix(path []string, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// eventUnder reports whether event changes the buckets below prefix: events of paths below prefix, and the
+// deletion or recursive truncation of a bucket holding prefix.
+func eventUnder(event Event, prefix []string) bool {
+	if hasPathPrefix(event.Path, prefix) {
+		return true
+	}
+
+	switch event.Op {
+	case EventDeleteBucket:
+		return hasPathPrefix(prefix, event.Path)
+	case EventTruncateBucket:
+		return len(event.Value) == 1 && event.Value[0] == 1 && hasPathPrefix(prefix, event.Path)
+	}
+
+	return false
+}
+
+// watcher is a live event subscription.
+type watcher struct {
+	ch chan indexedEvent
+}
+
+// watcherSet fans out committed events to live watchers.
+type watcherSet struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+func (ws *watcherSet) active() bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return len(ws.watchers) > 0
+}
+
+func (ws *watcherSet) add() *watcher {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.watchers == nil {
+		ws.watchers = map[*watcher]struct{}{}
+	}
+
+	w := &watcher{ch: make(chan indexedEvent, watchBufferSize)}
+	ws.watchers[w] = struct{}{}
+
+	return w
+}
+
+func (ws *watcherSet) remove(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.watchers[w]; ok {
+		delete(ws.watchers, w)
+		close(w.ch)
+	}
+}
+
+// publish queues events on every watcher; watchers whose buffer is full are dropped
+// and their channel closed.
+func (ws *watcherSet) publish(events []Event) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	for w := range ws.watchers {
+		for i, event := range events {
+			select {
+			case w.ch <- indexedEvent{Event: event, index: uint32(i)}:
+				continue
+			default:
+			}
+			delete(ws.watchers, w)
+			close(w.ch)
+			break
+		}
+	}
+}
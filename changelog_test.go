@@ -0,0 +1,85 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, events <-chan boltdb.Event) boltdb.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "events channel closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for event")
+	}
+	return boltdb.Event{}
+}
+
+func TestWatchFromChangelog(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	write(t, s, []string{"watch", "a"}, "k1")
+	write(t, s, []string{"watch", "b"}, "k2")
+	write(t, s, []string{"watch", "a"}, "k3")
+
+	events, stop := s.WatchFrom(1, boltdb.Path{"watch", "a"})
+	t.Cleanup(stop)
+
+	event := receive(t, events)
+	assert.Equal(t, uint64(3), event.Revision)
+	assert.Equal(t, boltdb.EventPut, event.Op)
+	assert.Equal(t, []string{"watch", "a"}, event.Path)
+	assert.Equal(t, []byte("k3"), event.Key)
+	assert.Equal(t, []byte("k3"), event.Value)
+
+	write(t, s, []string{"watch", "b"}, "k4")
+	write(t, s, []string{"watch", "a"}, "k5")
+
+	event = receive(t, events)
+	assert.Equal(t, uint64(5), event.Revision)
+	assert.Equal(t, []byte("k5"), event.Key)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey([]string{"watch", "a"}, "k1"))
+	require.NoError(t, session.DeleteBucket([]string{"watch", "a"}))
+	closer()
+
+	event = receive(t, events)
+	assert.Equal(t, boltdb.EventDelete, event.Op)
+	assert.Equal(t, []byte("k1"), event.Key)
+	event = receive(t, events)
+	assert.Equal(t, boltdb.EventDeleteBucket, event.Op)
+	assert.Equal(t, uint64(6), event.Revision)
+
+	stop()
+	for range events {
+	}
+}
+
+func TestWatchFromLive(t *testing.T) {
+	s := newTestStore(t)
+
+	write(t, s, []string{"watch"}, "k1")
+
+	events, stop := s.WatchFrom(s.Revision(), nil)
+	t.Cleanup(stop)
+
+	// wait for the watcher to subscribe
+	require.Eventually(t, func() bool {
+		write(t, s, []string{"watch"}, "k2")
+		select {
+		case event := <-events:
+			return string(event.Key) == "k2"
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,236 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ExportChanges writes the changes committed after revision sinceRev to w from a single read session, in the
+// format of Store.Export, and returns the revision of the session, passed as sinceRev by the next export.
+// The keys modified since sinceRev are written once, with their current value or as deleted, along with the
+// current sequences of the buckets created or resequenced, after the bucket deletes and truncates, so the
+// export is applied by ImportChanges without shipping the keys left unchanged. Changes are read from the
+// changelog, ExportChanges failing with ErrChangelogDisabled unless Config.EnableChangelog is set.
+func (s *Store) ExportChanges(sinceRev uint64, w io.Writer) (uint64, error) {
+	s.logger.Trace("Store::ExportChanges", "since", sinceRev)
+
+	if !s.config.EnableChangelog {
+		return 0, ErrChangelogDisabled
+	}
+
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	var stats ExportStats
+
+	ew, finish, err := newExportWriter(context.Background(), w, exportFlagChanges, &stats)
+	if err != nil {
+		return 0, err
+	}
+
+	ew.byte(exportRevisions)
+	ew.uvarint(sinceRev)
+	ew.uvarint(session.revision)
+
+	err = session.view(func(tx *bolt.Tx) error {
+		changes, err := readChanges(tx, sinceRev)
+		if err != nil {
+			return err
+		}
+
+		for _, reset := range changes.resets {
+			ew.byte(reset.typ)
+			ew.path(reset.path)
+		}
+
+		for _, memo := range changes.sortedBuckets() {
+			path := changes.buckets[memo]
+
+			// buckets deleted since are deleted by the resets
+			b, err := session.setBucket(path)
+			if err != nil {
+				continue
+			}
+			if err := session.exportChangedKeys(ew, b, path, changes.keys[memo]); err != nil {
+				return err
+			}
+		}
+
+		return ew.err
+	})
+	if err != nil {
+		return 0, wrapError("ExportChanges", nil, "", err)
+	}
+
+	return session.revision, finish()
+}
+
+// exportChangedKeys writes the record of bucket b at path, followed by the current values of keys,
+// or their deletes.
+func (s *Session) exportChangedKeys(w *exportWriter, b *bolt.Bucket, path []string, keys map[string]bool) error {
+	w.byte(exportBucket)
+	w.path(path)
+	w.uvarint(b.Sequence())
+	w.stats.Buckets++
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		v := b.Get([]byte(k))
+		if v == nil {
+			w.byte(exportDeleteKey)
+			w.bytes([]byte(k))
+			w.stats.Keys++
+			continue
+		}
+
+		value, err := s.resolveRef(v)
+		if err != nil {
+			return wrapError("ExportChanges", path, k, err)
+		}
+
+		w.byte(exportKey)
+		w.bytes([]byte(k))
+		w.bytes(value)
+		w.stats.Keys++
+	}
+
+	return w.err
+}
+
+// ImportChanges applies the changes exported by Store.ExportChanges in a single write session started by
+// UpdateContext, and returns the revision they were exported at, passed as sinceRev by the next export.
+// Exports are applied in the order they were exported, each starting at the revision the previous one
+// ended at. Imports which are truncated, or whose records do not match the checksum of their trailer,
+// fail with ErrExportCorrupt and are rolled back.
+func (s *Store) ImportChanges(ctx context.Context, r io.Reader) (uint64, error) {
+	s.logger.Trace("Store::ImportChanges")
+
+	er, flags, closer, err := openExport(r)
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	if flags&exportFlagChanges == 0 {
+		return 0, errors.Wrap(ErrExportCorrupt, "exports are imported by Import")
+	}
+
+	typ, err := er.byte()
+	if err != nil {
+		return 0, err
+	}
+	if typ != exportRevisions {
+		return 0, errors.Wrapf(ErrExportCorrupt, "unexpected record type %d", typ)
+	}
+	if _, err := er.uvarint(); err != nil {
+		return 0, err
+	}
+	rev, err := er.uvarint()
+	if err != nil {
+		return 0, err
+	}
+
+	im := &importer{r: er}
+	err = s.UpdateContext(ctx, func(w Writer) error {
+		for !im.done {
+			if _, err := im.next(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, wrapError("ImportChanges", nil, "", err)
+	}
+
+	return rev, nil
+}
+
+// changeSet holds the changes recorded by the changelog since a revision.
+type changeSet struct {
+	resets  []bucketReset
+	buckets map[string][]string        // buckets created, resequenced or holding modified keys, by memo key
+	keys    map[string]map[string]bool // keys modified, by memo key of their bucket
+}
+
+// bucketReset is the delete or truncate of a bucket, in the order they were committed.
+type bucketReset struct {
+	typ  byte
+	path []string
+}
+
+func readChanges(tx *bolt.Tx, sinceRev uint64) (*changeSet, error) {
+	changes := &changeSet{buckets: map[string][]string{}, keys: map[string]map[string]bool{}}
+
+	b := metaChild(tx, changelogBucket)
+	if b == nil {
+		return changes, nil
+	}
+
+	c := b.Cursor()
+	for k, v := c.Seek(changelogKey(sinceRev+1, 0)); k != nil; k, v = c.Next() {
+		var event Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			return nil, err
+		}
+		if len(event.Path) == 0 || event.Path[0] == metaRoot {
+			continue
+		}
+
+		memo := bucketMemoKey(event.Path)
+
+		switch event.Op {
+		case EventPut, EventDelete:
+			changes.buckets[memo] = event.Path
+			if changes.keys[memo] == nil {
+				changes.keys[memo] = map[string]bool{}
+			}
+			changes.keys[memo][string(event.Key)] = true
+
+		case EventCreateBucket, EventSetSequence:
+			changes.buckets[memo] = event.Path
+
+		case EventDeleteBucket:
+			changes.reset(exportDeleteBucket, event.Path)
+
+		case EventTruncateBucket:
+			typ := byte(exportTruncate)
+			if len(event.Value) == 1 && event.Value[0] == 1 {
+				typ = exportTruncateRecursive
+			}
+			changes.reset(typ, event.Path)
+		}
+	}
+
+	return changes, nil
+}
+
+// reset records the delete or truncate of bucket path, unless it repeats the last one.
+func (c *changeSet) reset(typ byte, path []string) {
+	if n := len(c.resets); n > 0 && c.resets[n-1].typ == typ && bucketMemoKey(c.resets[n-1].path) == bucketMemoKey(path) {
+		return
+	}
+	c.resets = append(c.resets, bucketReset{typ: typ, path: path})
+}
+
+func (c *changeSet) sortedBuckets() []string {
+	memos := make([]string, 0, len(c.buckets))
+	for memo := range c.buckets {
+		memos = append(memos, memo)
+	}
+	sort.Strings(memos)
+	return memos
+}
@@ -0,0 +1,111 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func syncChanges(t *testing.T, src, dst *boltdb.Store, since uint64) (uint64, int) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	rev, err := src.ExportChanges(since, &buf)
+	require.NoError(t, err)
+	size := buf.Len()
+
+	imported, err := dst.ImportChanges(context.Background(), &buf)
+	require.NoError(t, err)
+	assert.Equal(t, rev, imported)
+
+	return rev, size
+}
+
+func TestExportChanges(t *testing.T) {
+	cfg := &boltdb.Config{EnableChangelog: true}
+	src := newTestStoreWithConfig(t, cfg)
+	dst := newTestStore(t)
+
+	writeKeys(t, src, []string{"dirs", "a"}, 200)
+	require.NoError(t, src.Update(func(w boltdb.Writer) error {
+		if err := w.SetSeq([]string{"dirs", "a"}, 7); err != nil {
+			return err
+		}
+		return w.Write([]string{"dirs", "b", "nested"}, "k", []byte("v"))
+	}))
+
+	rev, full := syncChanges(t, src, dst, 0)
+	assert.Equal(t, boltdbtest.Dump(t, src), boltdbtest.Dump(t, dst))
+
+	require.NoError(t, src.Update(func(w boltdb.Writer) error {
+		if err := w.Write([]string{"dirs", "a"}, "key-000", []byte("changed")); err != nil {
+			return err
+		}
+		if err := w.DeleteKey([]string{"dirs", "a"}, "key-001"); err != nil {
+			return err
+		}
+		if err := w.Write([]string{"dirs", "a"}, "key-002", []byte("transient")); err != nil {
+			return err
+		}
+		return w.DeleteKey([]string{"dirs", "a"}, "key-002")
+	}))
+	require.NoError(t, src.Update(func(w boltdb.Writer) error {
+		if err := w.DeleteBucket([]string{"dirs", "b"}); err != nil {
+			return err
+		}
+		if err := w.Write([]string{"dirs", "b"}, "recreated", []byte("v")); err != nil {
+			return err
+		}
+		return w.TruncateBucket([]string{"dirs", "c"})
+	}))
+
+	next, incremental := syncChanges(t, src, dst, rev)
+	assert.Greater(t, next, rev)
+	assert.Less(t, incremental, full/10)
+	assert.Equal(t, boltdbtest.Dump(t, src), boltdbtest.Dump(t, dst))
+
+	// nothing changed since the last export
+	last, _ := syncChanges(t, src, dst, next)
+	assert.Equal(t, next, last)
+	assert.Equal(t, boltdbtest.Dump(t, src), boltdbtest.Dump(t, dst))
+}
+
+func TestExportChangesErrors(t *testing.T) {
+	s := newTestStore(t)
+
+	_, err := s.ExportChanges(0, &bytes.Buffer{})
+	assert.ErrorIs(t, err, boltdb.ErrChangelogDisabled)
+
+	var buf bytes.Buffer
+	_, err = s.Export(context.Background(), &buf, nil, boltdb.ExportOptions{})
+	require.NoError(t, err)
+
+	_, err = s.ImportChanges(context.Background(), &buf)
+	assert.ErrorIs(t, err, boltdb.ErrExportCorrupt)
+}
+
+func TestExportChangesBinaryKeys(t *testing.T) {
+	src := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	dst := newTestStore(t)
+
+	writeValue(t, src, []string{"ids"}, "plain", "v")
+	rev, _ := syncChanges(t, src, dst, 0)
+
+	require.NoError(t, src.Update(func(w boltdb.Writer) error {
+		return w.WriteUint64Key([]string{"ids"}, 255, []byte("v255"))
+	}))
+	syncChanges(t, src, dst, rev)
+
+	session, closer, err := dst.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.ReadUint64Key([]string{"ids"}, 255)
+	require.NoError(t, err)
+	assert.Equal(t, "v255", string(value))
+}
@@ -0,0 +1,223 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CheckKind identifies the kind of problem found by Store.Check.
+type CheckKind int
+
+const (
+	CheckPage      CheckKind = iota + 1 // bolt page structure, reported by bolt's consistency check
+	CheckValue                          // value rejected by its checksum or validator
+	CheckIndex                          // index entry missing, or left for a key which does not match it
+	CheckChangelog                      // changelog event which cannot be decoded or does not match its revision
+)
+
+// CheckProblem is an inconsistency found by Store.Check.
+type CheckProblem struct {
+	Kind    CheckKind
+	Path    []string // bucket path of the key, or name of the index for CheckIndex problems
+	Key     string
+	Message string
+}
+
+// CheckReport is the result of Store.Check.
+type CheckReport struct {
+	Revision uint64 // store revision checked
+	Buckets  int    // buckets walked, not including the reserved ones
+	Keys     int    // keys walked, not including the reserved ones
+	Problems []CheckProblem
+}
+
+// OK reports whether the check found no problem.
+func (r *CheckReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *CheckReport) add(kind CheckKind, path []string, key []byte, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, CheckProblem{
+		Kind:    kind,
+		Path:    append([]string{}, path...),
+		Key:     string(key),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Check verifies the integrity of the store in a read session: it runs bolt's consistency check
+// of the database pages, verifies the values of all keys against their checksum and the registered
+// validators, and that the registered indexes and the changelog are consistent with the store content.
+// Problems found are listed in the report, the error is only set when the check could not complete.
+func (s *Store) Check(ctx context.Context) (CheckReport, error) {
+	s.logger.Trace("Store::Check")
+
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return CheckReport{}, err
+	}
+	defer closer()
+
+	report := CheckReport{Revision: session.revision}
+
+	for err := range session.tx.Check() {
+		report.add(CheckPage, nil, nil, "%v", err)
+	}
+
+	if err := session.checkBuckets(ctx, &report); err != nil {
+		return report, err
+	}
+	if err := session.checkIndexes(ctx, &report); err != nil {
+		return report, err
+	}
+	session.checkChangelog(&report)
+
+	return report, nil
+}
+
+func (s *Session) checkBuckets(ctx context.Context, report *CheckReport) error {
+	var roots [][]byte
+	_ = s.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !bytes.Equal(name, metaBucket) {
+			roots = append(roots, name)
+		}
+		return nil
+	})
+
+	for _, name := range roots {
+		if err := s.checkBucket(ctx, s.tx.Bucket(name), []string{string(name)}, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Session) checkBucket(ctx context.Context, b *bolt.Bucket, path []string, report *CheckReport) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	report.Buckets++
+	validate := s.store.validator(path)
+
+	var children [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			children = append(children, k)
+			continue
+		}
+
+		report.Keys++
+		if err := s.verifyChecksum(path, k, v); err != nil {
+			report.add(CheckValue, path, k, "%v", err)
+		}
+		if validate != nil {
+			// validators see the values referred to by references, as they do on write
+			value, err := s.resolveRef(v)
+			if err == nil {
+				err = validate(path, k, value)
+			}
+			if err != nil {
+				report.add(CheckValue, path, k, "%v", err)
+			}
+		}
+	}
+
+	for _, name := range children {
+		child := append(append([]string{}, path...), string(name))
+		if err := s.checkBucket(ctx, b.Bucket(name), child, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkIndexes compares the entries of every registered index with the entries computed from the keys it covers.
+func (s *Session) checkIndexes(ctx context.Context, report *CheckReport) error {
+	for _, idx := range s.store.indexes.all() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		expected := map[string]bool{}
+		if b, err := s.setBucket(idx.Path); err == nil {
+			for _, e := range s.collectIndexEntries(b, idx.Path, true, []*Index{idx}) {
+				expected[string(e.entry)] = true
+			}
+		}
+
+		if b, err := s.setBucket(idx.bucket()); err == nil {
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if expected[string(k)] {
+					delete(expected, string(k))
+					continue
+				}
+				report.add(CheckIndex, []string{idx.Name}, indexEntryKey(idx, k), "stale index entry")
+			}
+		}
+
+		missing := make([]string, 0, len(expected))
+		for entry := range expected {
+			missing = append(missing, entry)
+		}
+		sort.Strings(missing)
+
+		for _, entry := range missing {
+			report.add(CheckIndex, []string{idx.Name}, indexEntryKey(idx, []byte(entry)), "missing index entry")
+		}
+	}
+
+	return nil
+}
+
+// indexEntryKey describes the key of an index entry for reports.
+func indexEntryKey(idx *Index, entry []byte) []byte {
+	e, err := idx.decode(entry)
+	if err != nil {
+		return entry
+	}
+	return []byte(Path(append(append([]string{}, e.Path...), e.Key)).String())
+}
+
+// checkChangelog verifies that every changelog event decodes and belongs to a committed revision.
+func (s *Session) checkChangelog(report *CheckReport) {
+	b := metaChild(s.tx, changelogBucket)
+	if b == nil {
+		return
+	}
+
+	path := []string{metaRoot, string(changelogBucket)}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(k) != 12 {
+			report.add(CheckChangelog, path, k, "invalid event key length %d", len(k))
+			continue
+		}
+
+		rev := binary.BigEndian.Uint64(k)
+
+		var event Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			report.add(CheckChangelog, path, k, "invalid event: %v", err)
+			continue
+		}
+		if event.Revision != rev {
+			report.add(CheckChangelog, path, k, "event revision %d stored at revision %d", event.Revision, rev)
+		}
+		if rev > s.revision {
+			report.add(CheckChangelog, path, k, "event revision %d newer than store revision %d", rev, s.revision)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package boltdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func jsonValidator(path []string, key, value []byte) error {
+	if !json.Valid(value) {
+		return errors.New("invalid JSON")
+	}
+	return nil
+}
+
+func TestCheckValid(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	store.RegisterValidator([]string{"objects"}, jsonValidator)
+	require.NoError(t, store.RegisterIndex(boltdb.Index{
+		Name:   "by-type",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type")},
+	}))
+
+	writeObject(t, store, []string{"objects", "users"}, "alice", "user", day)
+	writeObject(t, store, []string{"objects", "groups"}, "admins", "group", day)
+	write(t, store, []string{"other"}, "not-json")
+
+	report, err := store.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, 4, report.Buckets)
+	assert.Equal(t, 3, report.Keys)
+	assert.Equal(t, store.Revision(), report.Revision)
+}
+
+func TestCheckReferences(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true, ChunkSize: 8})
+	store.RegisterValidator([]string{"objects"}, jsonValidator)
+
+	writeValue(t, store, []string{"objects"}, "deduped", `{"description":"a value long enough to be deduplicated"}`)
+	writeStream(t, store, []string{"objects"}, "streamed", `{"description":"a streamed value"}`)
+
+	report, err := store.Check(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, 2, report.Keys)
+}
+
+func TestCheckProblems(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db"), EnableChangelog: true}
+
+	// write without the index and validator, so the store is inconsistent with them
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	writeObject(t, store, []string{"objects"}, "alice", "user", day)
+	writeValue(t, store, []string{"objects"}, "broken", "{")
+	store.Close()
+
+	db, err := bolt.Open(cfg.DBPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("__meta")).Bucket([]byte("changelog"))
+		require.NotNil(t, b)
+		return b.Put([]byte("garbage"), []byte("{"))
+	}))
+	require.NoError(t, db.Close())
+
+	store = boltdb.NewStore(cfg, &logger)
+	store.RegisterValidator([]string{"objects"}, jsonValidator)
+	require.NoError(t, store.RegisterIndex(boltdb.Index{
+		Name:   "by-type",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type")},
+	}))
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	report, err := store.Check(context.Background())
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []boltdb.CheckProblem{
+		{Kind: boltdb.CheckValue, Path: []string{"objects"}, Key: "broken", Message: "invalid JSON"},
+		{Kind: boltdb.CheckIndex, Path: []string{"by-type"}, Key: "objects/alice", Message: "missing index entry"},
+		{Kind: boltdb.CheckChangelog, Path: []string{"__meta", "changelog"}, Key: "garbage", Message: "invalid event key length 7"},
+	}, report.Problems)
+
+	// rebuilding the index and fixing the value resolves their problems
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.RebuildIndex("by-type"))
+	require.NoError(t, session.Write([]string{"objects"}, "alice", []byte(`{"type":"admin","created":"`+day.Format(time.RFC3339)+`"}`)))
+	require.NoError(t, session.Write([]string{"objects"}, "broken", []byte(`{}`)))
+	closer()
+
+	report, err = store.Check(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.Problems, 1)
+}
+
+func TestCheckCanceled(t *testing.T) {
+	store := newTestStore(t)
+	write(t, store, []string{"a"}, "k")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := store.Check(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
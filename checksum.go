@@ -0,0 +1,64 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+const checksumBucket = "checksum"
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum returns the CRC-32C checksum of value, big-endian encoded.
+func checksum(value []byte) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, crc32.Checksum(value, castagnoli))
+	return buf
+}
+
+// recordChecksum stores the checksum of value, written to key in path by the session.
+func (s *Session) recordChecksum(path []string, key, value []byte) error {
+	if !s.store.config.Checksums {
+		return nil
+	}
+	return s.putShadow(checksumBucket, path, key, checksum(value))
+}
+
+// deleteChecksum removes the checksum of key in path after it has been deleted by the session.
+func (s *Session) deleteChecksum(path []string, key []byte) error {
+	if !s.store.config.Checksums {
+		return nil
+	}
+	return s.deleteShadow(checksumBucket, path, key)
+}
+
+// deleteBucketChecksums removes the checksums of every key below the bucket at path.
+func (s *Session) deleteBucketChecksums(path []string) error {
+	if !s.store.config.Checksums {
+		return nil
+	}
+	return s.deleteShadowBucket(checksumBucket, path)
+}
+
+// verifyChecksum returns ErrValueCorrupt when value, read from key in path, does not match its checksum.
+// Keys without a checksum, written before checksums were enabled, are not verified.
+func (s *Session) verifyChecksum(path []string, key, value []byte) error {
+	if !s.store.config.Checksums {
+		return nil
+	}
+
+	want := s.getShadow(checksumBucket, path, key)
+	if len(want) != 4 {
+		return nil
+	}
+
+	got := checksum(value)
+	if binary.BigEndian.Uint32(got) != binary.BigEndian.Uint32(want) {
+		return errors.Wrapf(ErrValueCorrupt, "checksum %08x, expected %08x, value length %d",
+			binary.BigEndian.Uint32(got), binary.BigEndian.Uint32(want), len(value))
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package boltdb_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+// corrupt overwrites the value of key in the root bucket name of the closed database at dbPath.
+func corrupt(t *testing.T, dbPath, name, key, value string) {
+	db, err := bolt.Open(dbPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(name)).Put([]byte(key), []byte(value))
+	}))
+	require.NoError(t, db.Close())
+}
+
+func TestChecksums(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db"), Checksums: true}
+	path := []string{"records"}
+
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	writeValue(t, store, path, "a", "alpha")
+	writeValue(t, store, path, "b", "beta")
+	store.Close()
+
+	corrupt(t, cfg.DBPath, "records", "b", "garbage")
+
+	store = boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+
+	value, err := session.Read(path, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(value))
+
+	_, err = session.Read(path, "b")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+
+	var se *boltdb.StoreError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, "b", se.Key)
+
+	_, _, _, err = session.List(path, "")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+
+	err = session.ScanB(path, nil, func(key, value []byte) bool { return true })
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+
+	h, err := session.Bucket(path)
+	require.NoError(t, err)
+	_, err = h.Read("b")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+	_, _, _, err = h.List("")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+	closer()
+
+	report, err := store.Check(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, boltdb.CheckValue, report.Problems[0].Kind)
+	assert.Equal(t, "b", report.Problems[0].Key)
+
+	// rewriting the value records a new checksum
+	writeValue(t, store, path, "b", "beta")
+	assert.Equal(t, "beta", readValue(t, store, path, "b"))
+
+	// keys deleted, or written before checksums were enabled, are not verified
+	deleteKey(t, store, path, "b")
+	store.Close()
+	corrupt(t, cfg.DBPath, "records", "b", "new")
+
+	store = boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+	assert.Equal(t, "new", readValue(t, store, path, "b"))
+}
+
+func TestChecksumsCached(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db"), Checksums: true, CacheSize: 10}
+	path := []string{"records"}
+
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	writeValue(t, store, path, "a", "alpha")
+	store.Close()
+
+	corrupt(t, cfg.DBPath, "records", "a", "garbage")
+
+	store = boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	// corrupt values found by KeyExists are not cached, and fail reads
+	assert.True(t, session.KeyExists(path, "a"))
+	_, err = session.Read(path, "a")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+}
@@ -0,0 +1,263 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// cloneTxSize is the number of bytes written by Store.CloneTo before committing a transaction of the clone,
+// bounding the memory held by the dirty pages of large clones.
+const cloneTxSize = 32 << 20
+
+// CloneTo writes the buckets accepted by filter, along with their keys and nested buckets, from a single read
+// session to a new database file at dstPath, e.g. to extract the data of a single tenant. Filter is called with
+// the path of every bucket, depth first, until it accepts it, a nil filter accepting the whole store; the buckets
+// holding the accepted ones are created in the clone as well. The clone is written compactly, without the free
+// pages of the store, to a temporary file renamed to dstPath once complete, and can be opened by a Store.
+//
+// Streamed, external and deduplicated values are written as the values they refer to. The schema version,
+// along with the metadata and the index entries of the keys written, are written as well, so migrations and
+// index queries work on the clone. The changelog, key history and other state maintained by the store are not.
+// CloneTo fails when dstPath exists.
+func (s *Store) CloneTo(ctx context.Context, dstPath string, filter func(path []string) bool) (ExportStats, error) {
+	s.logger.Trace("Store::CloneTo", "path", dstPath)
+
+	var stats ExportStats
+
+	if _, err := os.Stat(dstPath); err == nil {
+		return stats, errors.Wrapf(os.ErrExist, "clone %s", dstPath)
+	}
+	if filter == nil {
+		filter = func([]string) bool { return true }
+	}
+
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return stats, err
+	}
+	defer closer()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".*.tmp")
+	if err != nil {
+		return stats, err
+	}
+	tmpPath := tmp.Name()
+	_ = tmp.Close()
+	defer os.Remove(tmpPath)
+
+	db, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: s.config.RequestTimeout})
+	if err != nil {
+		return stats, errors.Wrapf(err, "failed to open clone '%s'", tmpPath)
+	}
+
+	cw := &cloneWriter{ctx: ctx, db: db, stats: &stats}
+
+	err = session.view(func(tx *bolt.Tx) error {
+		if b := metaChild(tx, schemaBucket); b != nil {
+			if err := b.ForEach(func(k, v []byte) error {
+				return cw.write(shadowPath(string(schemaBucket), nil), k, v)
+			}); err != nil {
+				return err
+			}
+		}
+
+		c := tx.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if bytes.Equal(k, metaBucket) {
+				continue
+			}
+			if err := session.cloneBucket(cw, tx.Bucket(k), []string{string(k)}, filter); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = cw.commit()
+	} else {
+		cw.rollback()
+	}
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return stats, wrapError("CloneTo", nil, "", err)
+	}
+
+	return stats, os.Rename(tmpPath, dstPath)
+}
+
+// cloneBucket writes bucket b at path to the clone when filter accepts it, or the nested buckets of b accepted
+// by filter otherwise.
+func (s *Session) cloneBucket(w *cloneWriter, b *bolt.Bucket, path []string, filter func([]string) bool) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	accepted := filter(path)
+
+	var children [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			children = append(children, append([]byte{}, k...))
+			continue
+		}
+		if !accepted {
+			continue
+		}
+
+		value, err := s.resolveRef(v)
+		if err != nil {
+			return wrapError("CloneTo", path, string(k), err)
+		}
+		if err := w.put(path, k, value); err != nil {
+			return err
+		}
+		if err := s.cloneDerived(w, path, k, value); err != nil {
+			return err
+		}
+	}
+
+	if accepted {
+		if err := w.bucket(path, b.Sequence()); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range children {
+		child := append(append([]string{}, path...), string(k))
+		if accepted {
+			// nested buckets of accepted buckets are accepted as well
+			if err := s.cloneBucket(w, b.Bucket(k), child, acceptAll); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.cloneBucket(w, b.Bucket(k), child, filter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneDerived writes the metadata and the index entries of key in path, holding value, to the clone.
+func (s *Session) cloneDerived(w *cloneWriter, path []string, key, value []byte) error {
+	if md := s.getShadow(keymetaBucket, path, key); md != nil {
+		if err := w.write(shadowPath(keymetaBucket, path), key, md); err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range s.store.indexes.matching(path) {
+		for _, entry := range idx.entries(path, key, value) {
+			if err := w.write(idx.bucket(), entry, []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func acceptAll([]string) bool {
+	return true
+}
+
+// cloneWriter writes the buckets and keys of a clone, committing a transaction every cloneTxSize bytes.
+type cloneWriter struct {
+	ctx   context.Context
+	db    *bolt.DB
+	stats *ExportStats
+
+	tx      *bolt.Tx
+	size    int
+	path    []string     // path of the bucket of the last key written
+	current *bolt.Bucket // bucket of the last key written, nil once the transaction has been committed
+}
+
+// bucketOf returns the bucket at path in the clone, creating it when missing.
+func (w *cloneWriter) bucketOf(path []string) (*bolt.Bucket, error) {
+	if w.current != nil && bucketMemoKey(w.path) == bucketMemoKey(path) {
+		return w.current, nil
+	}
+
+	if w.tx == nil {
+		tx, err := w.db.Begin(true)
+		if err != nil {
+			return nil, err
+		}
+		w.tx = tx
+	}
+
+	b, err := w.tx.CreateBucketIfNotExists([]byte(path[0]))
+	for _, segment := range path[1:] {
+		if err != nil {
+			break
+		}
+		b, err = b.CreateBucketIfNotExists([]byte(segment))
+	}
+	if err != nil {
+		return nil, &StoreError{Path: path, Err: err}
+	}
+
+	w.path, w.current = path, b
+	return b, nil
+}
+
+// put writes value for key in the bucket at path of the clone.
+func (w *cloneWriter) put(path []string, key, value []byte) error {
+	w.stats.Keys++
+	return w.write(path, key, value)
+}
+
+// write is put, for the keys maintained by the store, which are not counted.
+func (w *cloneWriter) write(path []string, key, value []byte) error {
+	b, err := w.bucketOf(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Put(key, value); err != nil {
+		return &StoreError{Path: path, Key: string(key), Err: err}
+	}
+
+	if w.size += len(key) + len(value); w.size >= cloneTxSize {
+		return w.commit()
+	}
+	return nil
+}
+
+// bucket creates the bucket at path in the clone, once its keys have been written, with sequence seq.
+func (w *cloneWriter) bucket(path []string, seq uint64) error {
+	b, err := w.bucketOf(path)
+	if err != nil {
+		return err
+	}
+	w.stats.Buckets++
+	return b.SetSequence(seq)
+}
+
+func (w *cloneWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+
+	tx := w.tx
+	w.tx, w.current, w.size = nil, nil, 0
+
+	return tx.Commit()
+}
+
+func (w *cloneWriter) rollback() {
+	if w.tx != nil {
+		_ = w.tx.Rollback()
+		w.tx, w.current = nil, nil
+	}
+}
@@ -0,0 +1,115 @@
+package boltdb_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneTo(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{TrackMetadata: true})
+	writeKeys(t, s, []string{"tenants", "a", "users"}, 20)
+	writeKeys(t, s, []string{"tenants", "b", "users"}, 20)
+	writeValue(t, s, []string{"tenants", "a"}, "name", "tenant a")
+	writeValue(t, s, []string{"tenants"}, "count", "2")
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.SetSeq([]string{"tenants", "a", "users"}, 20)
+	}))
+
+	dst := filepath.Join(t.TempDir(), "tenant-a.db")
+	stats, err := s.CloneTo(context.Background(), dst, func(path []string) bool {
+		return len(path) == 2 && path[0] == "tenants" && path[1] == "a"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, boltdb.ExportStats{Buckets: 2, Keys: 21}, stats)
+
+	clone := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dst}, nil)
+	require.NoError(t, clone.Open())
+	defer clone.Close()
+
+	require.NoError(t, clone.View(func(r boltdb.Reader) error {
+		v, err := r.Read([]string{"tenants", "a"}, "name")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant a", string(v))
+
+		keys, _, err := r.ListKeys([]string{"tenants", "a", "users"}, "")
+		require.NoError(t, err)
+		assert.Len(t, keys, 20)
+
+		seq, err := r.CurrentSeq([]string{"tenants", "a", "users"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(20), seq)
+
+		// the buckets holding the accepted ones are created without their keys
+		assert.False(t, r.KeyExists([]string{"tenants"}, "count"))
+		assert.False(t, r.BucketExists([]string{"tenants", "b"}))
+		return nil
+	}))
+
+	_, err = s.CloneTo(context.Background(), dst, nil)
+	assert.ErrorIs(t, err, os.ErrExist)
+}
+
+func TestCloneToDerived(t *testing.T) {
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "src.db"), TrackMetadata: true}
+	index := boltdb.Index{Name: "by-type", Path: []string{"objects"}, Fields: []boltdb.IndexField{boltdb.StringField("/type")}}
+
+	s := boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, s.RegisterIndex(index))
+	require.NoError(t, s.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, s.Open())
+	t.Cleanup(s.Close)
+
+	writeObject(t, s, []string{"objects", "users"}, "alice", "user", day)
+	writeObject(t, s, []string{"objects", "groups"}, "admins", "group", day)
+
+	dst := filepath.Join(t.TempDir(), "users.db")
+	_, err := s.CloneTo(context.Background(), dst, func(path []string) bool {
+		return len(path) == 2 && path[1] == "users"
+	})
+	require.NoError(t, err)
+
+	// the migrations already applied are not run again on the clone
+	clone := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dst, TrackMetadata: true}, nil)
+	require.NoError(t, clone.RegisterIndex(index))
+	require.NoError(t, clone.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, clone.Open())
+	defer clone.Close()
+
+	version, err := clone.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+	assert.Equal(t, []string{"objects/users:alice"}, queryIndex(t, clone, "by-type", boltdb.IndexQuery{}))
+
+	session, closer, err := clone.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	// migration 1 writes to the schema bucket, which is not cloned
+	assert.False(t, session.BucketExists([]string{"schema"}))
+
+	md, err := session.Metadata([]string{"objects", "users"}, "alice")
+	require.NoError(t, err)
+	assert.False(t, md.CreatedAt.IsZero())
+}
+
+func TestCloneToCanceled(t *testing.T) {
+	s := newTestStore(t)
+	writeKeys(t, s, []string{"tenants", "a"}, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dir := t.TempDir()
+	_, err := s.CloneTo(ctx, filepath.Join(dir, "clone.db"), nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,107 @@
+// Command boltdb-diff compares two boltdb database files, e.g. the backups of a leader and its replica,
+// printing the keys and buckets added, removed and changed, and exits with status 1 when they differ.
+//
+//	boltdb-diff [-path a/b] [-values] [-max n] a.db b.db
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+)
+
+func main() {
+	path := flag.String("path", "", "compared bucket path, segments separated by '/', the whole databases when empty")
+	values := flag.Bool("values", false, "print the values of the keys added, removed and changed")
+	max := flag.Int("max", 0, "stop after max differences, zero for no limit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] a.db b.db\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	report, err := diff(flag.Arg(0), flag.Arg(1), boltdb.DiffOptions{
+		Path:       splitPath(*path),
+		Values:     *values,
+		MaxEntries: *max,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for i := range report.Entries {
+		fmt.Println(format(&report.Entries[i]))
+	}
+	if report.Truncated {
+		fmt.Printf("stopped after %d differences\n", len(report.Entries))
+	}
+
+	if !report.Equal() {
+		fmt.Printf("%d added, %d removed, %d changed\n", report.Added, report.Removed, report.Changed)
+		os.Exit(1)
+	}
+}
+
+func diff(a, b string, opts boltdb.DiffOptions) (boltdb.DiffReport, error) {
+	sa, err := open(a)
+	if err != nil {
+		return boltdb.DiffReport{}, err
+	}
+	defer sa.Close()
+
+	sb, err := open(b)
+	if err != nil {
+		return boltdb.DiffReport{}, err
+	}
+	defer sb.Close()
+
+	return boltdb.Diff(sa, sb, opts)
+}
+
+// open opens the database file at path read-only, sharing its lock with other read-only processes.
+func open(path string) (*boltdb.Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	store := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: path, RequestTimeout: time.Second, ReadOnly: true}, nil)
+	if err := store.Open(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func format(e *boltdb.DiffEntry) string {
+	line := fmt.Sprintf("%-8s %s", e.Kind, strings.Join(e.Path, "/"))
+
+	switch {
+	case e.Bucket && e.Kind == boltdb.DiffChanged:
+		return fmt.Sprintf("%s/ seq %d -> %d", line, e.SeqA, e.SeqB)
+	case e.Bucket:
+		return line + "/"
+	}
+
+	line += fmt.Sprintf(" %q", e.Key)
+	if e.A != nil || e.B != nil {
+		line += fmt.Sprintf(" %q -> %q", e.A, e.B)
+	}
+	return line
+}
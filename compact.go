@@ -0,0 +1,159 @@
+package boltdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// compactTxSize is the number of bytes copied by Store.Compact before committing a transaction of the copy.
+const compactTxSize = 32 << 20
+
+// compactTask is the name of the janitor task compacting the database, see Config.CompactInterval.
+const compactTask = "compact"
+
+// compactMinFree is the fraction of the database file made of free pages above which the compaction task
+// compacts the database.
+const compactMinFree = 0.25
+
+// CompactStats reports the size of the database file before and after Store.Compact.
+type CompactStats struct {
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Compact rewrites the database file without its free pages, shrinking the file once large buckets have been
+// deleted, and drops the deleted values the free pages still hold. The database is copied to a temporary file
+// while holding the writer of the store, so write sessions wait for the copy while read sessions continue,
+// then the copy replaces the database file. Sessions starting from then on use the copy, while read sessions
+// already open, including snapshots and read pools, keep reading the replaced database, identical to the copy,
+// which is closed once they have ended.
+func (s *Store) Compact(ctx context.Context) (CompactStats, error) {
+	s.logger.Trace("Store::Compact")
+
+	var stats CompactStats
+
+	if err := s.writer.acquire(ctx, 0); err != nil {
+		return stats, err
+	}
+	defer s.writer.release()
+
+	db := s.database()
+	if db == nil {
+		return stats, bolt.ErrDatabaseNotOpen
+	}
+
+	if fi, err := os.Stat(s.config.DBPath); err == nil {
+		stats.SizeBefore = fi.Size()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.config.DBPath), "."+filepath.Base(s.config.DBPath)+".*.compact")
+	if err != nil {
+		return stats, err
+	}
+	tmpPath := tmp.Name()
+	_ = tmp.Close()
+	defer os.Remove(tmpPath)
+
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: s.config.RequestTimeout})
+	if err != nil {
+		return stats, errors.Wrapf(err, "failed to open compacted copy '%s'", tmpPath)
+	}
+	err = bolt.Compact(dst, db, compactTxSize)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return stats, errors.Wrap(err, "failed to compact database")
+	}
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+
+	s.dbMu.Lock()
+	if s.db != db {
+		s.dbMu.Unlock()
+		return stats, errors.Wrap(bolt.ErrDatabaseNotOpen, "database closed while compacting")
+	}
+
+	// db keeps its file open, so the copy replaces it without waiting for the read sessions of db
+	if err := os.Rename(tmpPath, s.config.DBPath); err != nil {
+		s.dbMu.Unlock()
+		return stats, err
+	}
+
+	s.db = nil
+	err = s.openLocked()
+	s.dbMu.Unlock()
+
+	s.retireDB(db)
+
+	if err != nil {
+		return stats, errors.Wrap(err, "failed to reopen compacted database")
+	}
+
+	if fi, err := os.Stat(s.config.DBPath); err == nil {
+		stats.SizeAfter = fi.Size()
+	}
+
+	s.logger.Info("Store::Compact", "before", stats.SizeBefore, "after", stats.SizeAfter)
+
+	return stats, nil
+}
+
+// retireDB closes db, replaced by Compact, in the background once its read sessions have ended,
+// bolt waiting for them to close the database. Store.Close waits for retired databases to be closed.
+func (s *Store) retireDB(db *bolt.DB) {
+	s.retired.Add(1)
+	go func() {
+		defer s.retired.Done()
+		if err := db.Close(); err != nil {
+			s.logger.Warn("Store::Compact", "error", err)
+		}
+	}()
+}
+
+// startCompaction compacts the database every Config.CompactInterval once its free pages make up
+// compactMinFree of the file, until the store is closed.
+func (s *Store) startCompaction() {
+	_, _ = s.Schedule(JanitorTask{
+		Name:     compactTask,
+		Interval: s.config.CompactInterval,
+		Jitter:   s.config.CompactInterval / 10,
+		Run: func(ctx context.Context) error {
+			if !s.compactable() {
+				return nil
+			}
+			_, err := s.Compact(ctx)
+			return err
+		},
+	})
+}
+
+// compactable reports whether the free pages of the database make up compactMinFree of its file.
+func (s *Store) compactable() bool {
+	db := s.database()
+	if db == nil {
+		return false
+	}
+
+	fi, err := os.Stat(s.config.DBPath)
+	if err != nil || fi.Size() == 0 {
+		return false
+	}
+
+	// the memory map described by Info is only stable within a transaction
+	var pageSize int
+	if err := db.View(func(tx *bolt.Tx) error {
+		pageSize = tx.DB().Info().PageSize
+		return nil
+	}); err != nil {
+		return false
+	}
+
+	free := int64(db.Stats().FreePageN) * int64(pageSize)
+	return float64(free) >= compactMinFree*float64(fi.Size())
+}
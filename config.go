@@ -5,6 +5,131 @@ import "time"
 const pageSize int32 = 100
 
 type Config struct {
-	DBPath         string        `json:"db_path"`
+	DBPath string `json:"db_path"`
+	// RequestTimeout bounds the time spent waiting for the file lock when opening the store,
+	// and the time spent by every session operation: scans and lists running past it are aborted
+	// with ErrDeadlineExceeded. Zero waits and runs without limit.
 	RequestTimeout time.Duration `json:"request_timeout_in_seconds"`
+
+	// AutoReopen, when set, reopens the database when a session starts after Open failed to open it,
+	// and when a health probe finds it unusable, so transient filesystem failures do not require
+	// a restart. Sessions are not reopened once the store has been closed, see ReopenPolicy.
+	AutoReopen *ReopenPolicy `json:"auto_reopen"`
+	// TakeoverStaleLock, when set, makes Open wait for a lock still held once its holder exited, by
+	// processes which inherited it, until these processes release it, see LockInfo.Stale. Without it,
+	// Open fails with a LockError once Config.RequestTimeout expired.
+	TakeoverStaleLock bool `json:"takeover_stale_lock"`
+
+	// MaxDBSize, when set, is the size of the database file above which the disk guardrails are breached.
+	MaxDBSize int64 `json:"max_db_size"`
+	// MinFreeSpace, when set, is the number of bytes available on the volume of the database
+	// below which the disk guardrails are breached.
+	MinFreeSpace uint64 `json:"min_free_space"`
+	// DiskCheckInterval is the delay between two checks of the disk guardrails,
+	// DefaultDiskCheckInterval when zero. Breaches are logged and fail Store.HealthCheck, see Store.DiskStats.
+	DiskCheckInterval time.Duration `json:"disk_check_interval"`
+	// ReadOnlyOnDiskFull rejects write sessions with ErrReadOnly while the disk guardrails are breached,
+	// rather than letting writes fail once the volume is full.
+	ReadOnlyOnDiskFull bool `json:"read_only_on_disk_full"`
+
+	// RootBuckets lists the bucket paths created by Store.Open when missing, so the paths services require
+	// exist before their first session. Replicas receive them from their leader.
+	RootBuckets [][]string `json:"root_buckets"`
+
+	// RateLimits bounds the rate of the session operations on the whole store or on bucket path prefixes,
+	// see RateLimit.
+	RateLimits []RateLimit `json:"rate_limits"`
+
+	// PageTokenSecret, when set, is used to sign page tokens so tampered tokens are rejected.
+	PageTokenSecret string `json:"page_token_secret"`
+
+	// EnableChangelog records every committed mutation in a changelog, see Store.WatchFrom.
+	EnableChangelog bool `json:"enable_changelog"`
+
+	// VersionedPaths lists the bucket paths, including their nested buckets, whose writes are kept
+	// as key history, see Session.ReadAt and Session.History.
+	VersionedPaths [][]string `json:"versioned_paths"`
+	// VersionRetention is the maximum number of versions kept per key, zero keeps all versions.
+	VersionRetention int `json:"version_retention"`
+
+	// Retention bounds the keys kept in buckets by age or count, see RetentionPolicy. The policies are enforced
+	// every RetentionInterval, DefaultRetentionInterval when zero, except by replicas, which receive the
+	// deletions from their leader.
+	Retention         []RetentionPolicy `json:"retention"`
+	RetentionInterval time.Duration     `json:"retention_interval"`
+
+	// TrackMetadata records created-at and updated-at timestamps, along with the writer, of every key,
+	// see Session.Metadata.
+	TrackMetadata bool `json:"track_metadata"`
+
+	// TokenIndexes configures inverted indexes over string fields of JSON values, see Session.SearchTokens.
+	TokenIndexes []TokenIndex `json:"token_indexes"`
+
+	// CacheSize is the maximum number of values kept in the read cache consulted by read sessions,
+	// zero disables the cache, see Store.CacheStats.
+	CacheSize int `json:"cache_size"`
+
+	// SingleFlightReads collapses the concurrent reads of the same key by read sessions at the same revision,
+	// such as those missing the read cache once it has been invalidated, into a single read of the database,
+	// see Store.FlightStats. Values read by Session.Read are then copies.
+	SingleFlightReads bool `json:"single_flight_reads"`
+
+	// BloomFilters configures in-memory bloom filters answering KeyExists and PrefixExists misses.
+	BloomFilters []BloomFilter `json:"bloom_filters"`
+
+	// Checksums stores a CRC-32C checksum of every value written, verified when the value is read.
+	// Reads of values which do not match their checksum fail with ErrValueCorrupt.
+	Checksums bool `json:"checksums"`
+
+	// Audit appends a tamper-evident record of every committed mutation, along with the writer
+	// and commit time, to the audit log, see Session.AuditLog.
+	Audit bool `json:"audit"`
+	// AuditFile, when set, is the path of a file receiving a JSON line for every committed mutation, rotated
+	// once larger than AuditFileMaxSize, keeping AuditFileMaxBackups rotated files, see AuditFile. The file is
+	// the audit sink of the store, see Store.SetAuditSink, written whether or not Audit is set.
+	AuditFile           string `json:"audit_file"`
+	AuditFileMaxSize    int64  `json:"audit_file_max_size"`
+	AuditFileMaxBackups int    `json:"audit_file_max_backups"`
+
+	// Replica makes the store a read-only follower, only modified by applying the replication batches
+	// of its leader, see Store.ApplyReplicationStream. Write sessions fail with ErrReadOnly.
+	Replica bool `json:"replica"`
+	// ReadOnly opens the database file read-only, sharing its file lock with other read-only stores, e.g. to
+	// inspect it. Write sessions fail with ErrReadOnly, and the store neither migrates, creates root buckets,
+	// compacts, applies retention, nor records its lock holder, see ReadLockInfo.
+	ReadOnly bool `json:"read_only"`
+
+	// LogSampling logs one in LogSampling trace records, zero or one logs every trace record.
+	LogSampling int `json:"log_sampling"`
+	// RedactPaths lists the bucket paths, including their nested buckets, whose keys, key prefixes,
+	// page tokens and ETags are replaced by RedactedValue in log records, and whose errors are
+	// logged as their root cause.
+	RedactPaths [][]string `json:"redact_paths"`
+
+	// ChunkSize is the size of the chunks of values written with Session.WriteStream, DefaultChunkSize when zero.
+	ChunkSize int `json:"chunk_size"`
+
+	// InitialMmapSize is the initial size of the memory map of the database. Write transactions growing
+	// the memory map wait for the read transactions open, e.g. those of snapshots, see Store.Snapshot.
+	InitialMmapSize int `json:"initial_mmap_size"`
+	// CompactInterval, when set, is the delay between two checks of the free pages of the database file,
+	// compacting the database, see Store.Compact, once they make up a quarter of the file.
+	CompactInterval time.Duration `json:"compact_interval"`
+
+	// Dedup stores identical values once, keys holding a reference to the value, see DedupMinSize.
+	// Values of indexed and versioned paths are stored in place.
+	Dedup bool `json:"dedup"`
+	// DedupMinSize is the length below which values are stored in place, values shorter than
+	// a reference always are.
+	DedupMinSize int `json:"dedup_min_size"`
+
+	// MaxWriteQueue, when set, is the number of write sessions waiting for the single writer of the store
+	// above which write sessions fail with ErrWriteQueueFull, see Store.WriteQueueStats.
+	MaxWriteQueue int `json:"max_write_queue"`
+
+	// SlowOpThreshold, when set, logs a warning for every session operation taking longer, see Store.SlowStats.
+	SlowOpThreshold time.Duration `json:"slow_op_threshold"`
+	// LongSessionThreshold, when set, logs a warning for every session kept open longer, see Store.SlowStats.
+	// Long read sessions keep bolt from reusing the pages freed since they started.
+	LongSessionThreshold time.Duration `json:"long_session_threshold"`
 }
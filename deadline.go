@@ -0,0 +1,43 @@
+package boltdb
+
+import "time"
+
+// deadlineCheckInterval is the number of cursor steps between two deadline checks,
+// so long scans do not read the clock for every key.
+const deadlineCheckInterval = 64
+
+// startDeadline sets the deadline of the outermost operation of the session, when Config.RequestTimeout
+// is set. Operations called by interceptors run within the deadline of the operation they intercept.
+// The returned function clears the deadline once the operation which set it returned.
+func (s *Session) startDeadline() func() {
+	timeout := s.store.config.RequestTimeout
+	if timeout <= 0 || !s.deadline.IsZero() {
+		return func() {}
+	}
+
+	s.deadline = time.Now().Add(timeout)
+	s.steps = 0
+
+	return func() {
+		s.deadline = time.Time{}
+	}
+}
+
+// checkDeadline is called for every step of a cursor loop and fails with ErrDeadlineExceeded
+// once the deadline of the running operation has passed.
+func (s *Session) checkDeadline() error {
+	if s.deadline.IsZero() {
+		return nil
+	}
+
+	s.steps++
+	if s.steps%deadlineCheckInterval != 0 {
+		return nil
+	}
+
+	if time.Now().After(s.deadline) {
+		return ErrDeadlineExceeded
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RequestTimeout: time.Nanosecond})
+
+	for i := 0; i < 200; i++ {
+		write(t, s, []string{"deadline"}, fmt.Sprintf("k%03d", i))
+	}
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	_, _, err = session.ReadScan([]string{"deadline"}, "k")
+	assert.ErrorIs(t, err, boltdb.ErrDeadlineExceeded)
+
+	_, _, err = session.ListKeys([]string{"deadline"}, "")
+	assert.ErrorIs(t, err, boltdb.ErrDeadlineExceeded)
+
+	err = session.ScanB([]string{"deadline"}, nil, func(key, value []byte) bool { return true })
+	assert.ErrorIs(t, err, boltdb.ErrDeadlineExceeded)
+
+	// operations without cursor loops are not aborted
+	_, err = session.Read([]string{"deadline"}, "k100")
+	assert.NoError(t, err)
+}
+
+func TestRequestTimeoutNotExceeded(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RequestTimeout: time.Minute})
+
+	for i := 0; i < 200; i++ {
+		write(t, s, []string{"deadline"}, fmt.Sprintf("k%03d", i))
+	}
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	keys, _, err := session.ReadScan([]string{"deadline"}, "k")
+	require.NoError(t, err)
+	assert.Len(t, keys, 200)
+}
@@ -0,0 +1,92 @@
+package boltdb
+
+import (
+	"sync/atomic"
+)
+
+// SessionStats reports the sessions open on a store, see Store.SessionStats.
+type SessionStats struct {
+	Read  int64 // read sessions open, including those of snapshots and read pools
+	Write int64 // write sessions open, zero or one
+}
+
+// SessionStats returns the number of sessions currently open.
+func (s *Store) SessionStats() SessionStats {
+	return SessionStats{
+		Read:  atomic.LoadInt64(&s.readSessions),
+		Write: atomic.LoadInt64(&s.writeSessions),
+	}
+}
+
+// countOpen counts the session open until it ends.
+func (s *Session) countOpen() {
+	s.open = true
+	atomic.AddInt64(s.store.sessionCounter(s.writer), 1)
+}
+
+// countClosed stops counting the session open once it ended.
+func (s *Session) countClosed() {
+	if !s.open {
+		return
+	}
+	s.open = false
+	atomic.AddInt64(s.store.sessionCounter(s.writer), -1)
+}
+
+func (s *Store) sessionCounter(writable bool) *int64 {
+	if writable {
+		return &s.writeSessions
+	}
+	return &s.readSessions
+}
+
+// DebugVars returns the internal state of the store, e.g. to publish it with expvar, see the debug package:
+// the revision, open sessions, write queue, read cache, collapsed reads, slow operations, disk guardrails,
+// reopen attempts, janitor tasks and the transaction and free page statistics of the database.
+// Values are maps, slices and numbers encoding to JSON.
+func (s *Store) DebugVars() map[string]interface{} {
+	vars := map[string]interface{}{
+		"revision":    s.Revision(),
+		"sessions":    s.SessionStats(),
+		"write_queue": s.WriteQueueStats(),
+		"cache":       s.CacheStats(),
+		"flights":     s.FlightStats(),
+		"slow":        s.SlowStats(),
+		"disk":        s.DiskStats(),
+		"reopen":      s.ReopenStats(),
+	}
+
+	janitor := []map[string]interface{}{}
+	for _, t := range s.JanitorStats() {
+		task := map[string]interface{}{
+			"name":          t.Name,
+			"runs":          t.Runs,
+			"failures":      t.Failures,
+			"running":       t.Running,
+			"last_run":      t.LastRun,
+			"last_duration": t.LastDuration,
+			"next_run":      t.NextRun,
+		}
+		if t.LastError != nil {
+			task["last_error"] = t.LastError.Error()
+		}
+		janitor = append(janitor, task)
+	}
+	vars["janitor"] = janitor
+
+	if db := s.database(); db != nil {
+		stats := db.Stats()
+		vars["db"] = map[string]interface{}{
+			"open_tx":       stats.OpenTxN,
+			"tx":            stats.TxN,
+			"free_pages":    stats.FreePageN,
+			"pending_pages": stats.PendingPageN,
+			"free_alloc":    stats.FreeAlloc,
+			"freelist_size": stats.FreelistInuse,
+			"write_time":    stats.TxStats.WriteTime,
+			"writes":        stats.TxStats.Write,
+		}
+	}
+
+	return vars
+}
@@ -0,0 +1,53 @@
+// Package debug publishes the internal state of boltdb stores with expvar and serves it to debug HTTP endpoints,
+// alongside the pprof and expvar endpoints of the host process.
+package debug
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Source reports the internal state of a store, implemented by *boltdb.Store, see boltdb.Store.DebugVars.
+type Source interface {
+	DebugVars() map[string]interface{}
+}
+
+// Publish publishes the state of source as the expvar variable name, served with the other variables of the
+// process by /debug/vars. The state is read whenever the variable is. Expvar variables cannot be removed,
+// so Publish fails when a variable of name is already published.
+func Publish(name string, source Source) error {
+	if expvar.Get(name) != nil {
+		return errors.Errorf("expvar %s already published", name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} { return source.DebugVars() }))
+	return nil
+}
+
+// Handler returns a handler serving the state of source as JSON.
+func Handler(source Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(source.DebugVars())
+	})
+}
+
+// Register registers the handler of source on mux at pattern, e.g. "/debug/boltdb", or on
+// http.DefaultServeMux, which serves the pprof endpoints once net/http/pprof is imported, when mux is nil.
+func Register(mux *http.ServeMux, pattern string, source Source) {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.Handle(pattern, Handler(source))
+}
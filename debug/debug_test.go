@@ -0,0 +1,48 @@
+package debug_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/aserto-dev/boltdb/debug"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublish(t *testing.T) {
+	s := boltdbtest.NewTestStore(t)
+
+	require.NoError(t, debug.Publish("boltdb_test", s))
+	assert.Error(t, debug.Publish("boltdb_test", s))
+
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("boltdb_test").String()), &vars))
+	assert.Contains(t, vars, "revision")
+	assert.Contains(t, vars, "sessions")
+	assert.Contains(t, vars, "janitor")
+}
+
+func TestHandler(t *testing.T) {
+	s := boltdbtest.NewTestStore(t)
+
+	mux := http.NewServeMux()
+	debug.Register(mux, "/debug/boltdb", s)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/boltdb", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &vars))
+	assert.Contains(t, vars, "write_queue")
+	assert.Contains(t, vars, "db")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/boltdb", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,60 @@
+package boltdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStats(t *testing.T) {
+	s := newTestStore(t)
+
+	assert.Equal(t, boltdb.SessionStats{}, s.SessionStats())
+
+	_, closeRead, err := s.ReadSession()
+	require.NoError(t, err)
+	_, closeWrite, err := s.WriteSession()
+	require.NoError(t, err)
+
+	assert.Equal(t, boltdb.SessionStats{Read: 1, Write: 1}, s.SessionStats())
+
+	closeWrite()
+	closeRead()
+	assert.Equal(t, boltdb.SessionStats{}, s.SessionStats())
+
+	// failed write sessions are rolled back
+	_ = s.Update(func(w boltdb.Writer) error { return errors.New("failed") })
+	assert.Equal(t, boltdb.SessionStats{}, s.SessionStats())
+}
+
+func TestDebugVars(t *testing.T) {
+	s := newTestStore(t)
+	_, err := s.Schedule(boltdb.JanitorTask{
+		Name:     "fail",
+		Interval: time.Hour,
+		Run:      func(ctx context.Context) error { return errors.New("failed") },
+	})
+	require.NoError(t, err)
+	require.Error(t, s.RunTask(context.Background(), "fail"))
+
+	write(t, s, []string{"a"}, "k")
+
+	vars := s.DebugVars()
+	assert.Equal(t, s.Revision(), vars["revision"])
+	assert.Equal(t, s.SessionStats(), vars["sessions"])
+
+	janitor, ok := vars["janitor"].([]map[string]interface{})
+	require.True(t, ok)
+	require.Len(t, janitor, 1)
+	assert.Equal(t, "fail", janitor[0]["name"])
+	assert.Equal(t, "failed", janitor[0]["last_error"])
+
+	db, ok := vars["db"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, db, "free_pages")
+}
@@ -0,0 +1,101 @@
+package boltdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// With Config.Dedup, values are stored once in the dedup bucket, keyed by the SHA-256 hash
+// of their content, the keys holding a reference to their value. The reference count of a
+// value is keyed by its hash followed by a zero byte, values are deleted along with their
+// last reference.
+
+const dedupBucket = "blobs"
+
+// dedupMagic starts the references to deduplicated values.
+var dedupMagic = []byte{0, 0xff, 'b', 'd', 'b', 'd', 'u', 'p'}
+
+// dedupRefSize is the length of a reference to a deduplicated value: magic and SHA-256 hash.
+const dedupRefSize = 8 + sha256.Size
+
+// parseDedupRef returns the hash referenced by value, nil when value is not a reference
+// to a deduplicated value.
+func parseDedupRef(value []byte) []byte {
+	if len(value) != dedupRefSize || !bytes.HasPrefix(value, dedupMagic) {
+		return nil
+	}
+	return value[8:]
+}
+
+// dedupCountKey returns the key of the reference count of the value of hash.
+func dedupCountKey(hash []byte) []byte {
+	return append(append(make([]byte, 0, len(hash)+1), hash...), 0)
+}
+
+// dedupe returns the value stored in bolt for value written to path: a reference to the
+// deduplicated value with Config.Dedup, value itself otherwise. The reference is counted
+// once stored, see swapRefs.
+func (s *Session) dedupe(path []string, value []byte) ([]byte, error) {
+	if !s.store.config.Dedup || len(value) < dedupRefSize || len(value) < s.store.config.DedupMinSize || isRef(value) {
+		return value, nil
+	}
+	if s.store.versioned(path) || len(s.store.indexes.matching(path)) > 0 {
+		return value, nil
+	}
+
+	hash := sha256.Sum256(value)
+	if s.getShadow(dedupBucket, nil, hash[:]) == nil {
+		if err := s.putShadow(dedupBucket, nil, hash[:], value); err != nil {
+			return nil, err
+		}
+	}
+
+	ref := make([]byte, dedupRefSize)
+	copy(ref, dedupMagic)
+	copy(ref[8:], hash[:])
+
+	return ref, nil
+}
+
+// deduped returns the value referenced by value, value itself when it is not a reference
+// to a deduplicated value. The value is only valid during the session transaction.
+func (s *Session) deduped(value []byte) ([]byte, error) {
+	hash := parseDedupRef(value)
+	if hash == nil {
+		return value, nil
+	}
+
+	v := s.getShadow(dedupBucket, nil, hash)
+	if v == nil {
+		return nil, errors.Wrapf(ErrValueCorrupt, "deduplicated value %x not found", hash)
+	}
+
+	return v, nil
+}
+
+// addDedupRef adds delta to the reference count of the value of hash, deleting the value
+// once no reference remains.
+func (s *Session) addDedupRef(hash []byte, delta int64) error {
+	countKey := dedupCountKey(hash)
+
+	var count int64
+	if v := s.getShadow(dedupBucket, nil, countKey); len(v) == 8 {
+		count = int64(binary.BigEndian.Uint64(v))
+	}
+	count += delta
+
+	if count > 0 {
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(count))
+		return s.putShadow(dedupBucket, nil, countKey, buf)
+	}
+
+	if err := s.deleteShadow(dedupBucket, nil, countKey); err != nil {
+		return err
+	}
+
+	return s.deleteShadow(dedupBucket, nil, hash)
+}
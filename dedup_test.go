@@ -0,0 +1,131 @@
+package boltdb_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+var payload = strings.Repeat("relation payload ", 4)
+
+// dedupEntries returns the number of keys of the dedup bucket of the closed store at dbPath.
+func dedupEntries(t *testing.T, dbPath string) int {
+	t.Helper()
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var n int
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket([]byte("__meta")); meta != nil {
+			if b := meta.Bucket([]byte("blobs")); b != nil {
+				n = b.Stats().KeyN
+			}
+		}
+		return nil
+	}))
+	return n
+}
+
+func TestDedup(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true, Checksums: true})
+
+	writeValue(t, s, []string{"a"}, "k1", payload)
+	writeValue(t, s, []string{"a"}, "k2", payload)
+	writeValue(t, s, []string{"a"}, "short", "short")
+
+	assert.Equal(t, payload, readValue(t, s, []string{"a"}, "k1"))
+	assert.Equal(t, "short", readValue(t, s, []string{"a"}, "short"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, values, _, err := session.List([]string{"a"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k2", "short"}, keys)
+	assert.Equal(t, [][]byte{[]byte(payload), []byte(payload), []byte("short")}, values)
+
+	_, values, err = session.ReadScan([]string{"a"}, "k")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(payload), []byte(payload)}, values)
+
+	var scanned []string
+	require.NoError(t, session.ScanB([]string{"a"}, nil, func(key, value []byte) bool {
+		scanned = append(scanned, string(value))
+		return true
+	}))
+	assert.Equal(t, []string{payload, payload, "short"}, scanned)
+}
+
+func TestDedupModify(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true})
+
+	writeValue(t, s, []string{"a"}, "k1", payload)
+	writeValue(t, s, []string{"a"}, "k2", payload)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Append([]string{"a"}, "k1", []byte("!")))
+	closer()
+
+	assert.Equal(t, payload+"!", readValue(t, s, []string{"a"}, "k1"))
+	assert.Equal(t, payload, readValue(t, s, []string{"a"}, "k2"))
+}
+
+func TestDedupReleased(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "dedup.db")
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dbPath, Dedup: true}, nil)
+	require.NoError(t, s.Open())
+
+	writeValue(t, s, []string{"a"}, "k1", payload)
+	writeValue(t, s, []string{"a"}, "k2", payload)
+	writeValue(t, s, []string{"b"}, "k3", payload)
+
+	s.Close()
+	// a single value, along with its reference count
+	assert.Equal(t, 2, dedupEntries(t, dbPath))
+
+	require.NoError(t, s.Open())
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey([]string{"a"}, "k1"))
+	require.NoError(t, session.Write([]string{"a"}, "k2", []byte("plain")))
+	closer()
+
+	assert.Equal(t, payload, readValue(t, s, []string{"b"}, "k3"))
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket([]string{"b"}))
+	closer()
+	s.Close()
+
+	assert.Equal(t, 0, dedupEntries(t, dbPath))
+}
+
+func TestDedupCopyValidated(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true})
+	s.RegisterValidator([]string{"objects"}, boltdb.ValidJSON)
+	s.RegisterValidator([]string{"copies"}, boltdb.ValidJSON)
+	s.RegisterValidator([]string{"moved"}, boltdb.ValidJSON)
+
+	value := `{"description":"` + payload + `"}`
+	writeValue(t, s, []string{"objects"}, "k1", value)
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		if err := w.CopyBucket([]string{"objects"}, []string{"copies"}); err != nil {
+			return err
+		}
+		return w.MoveBucket([]string{"copies"}, []string{"moved"})
+	}))
+
+	assert.Equal(t, value, readValue(t, s, []string{"objects"}, "k1"))
+	assert.Equal(t, value, readValue(t, s, []string{"moved"}, "k1"))
+}
@@ -0,0 +1,327 @@
+package boltdb
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DiffKind identifies the kind of a difference reported by Diff.
+type DiffKind int
+
+const (
+	DiffAdded   DiffKind = iota + 1 // key or bucket present in b only
+	DiffRemoved                     // key or bucket present in a only
+	DiffChanged                     // value of a key, or sequence of a bucket, differing between a and b
+)
+
+var diffKindNames = map[DiffKind]string{
+	DiffAdded:   "added",
+	DiffRemoved: "removed",
+	DiffChanged: "changed",
+}
+
+func (k DiffKind) String() string {
+	if name, ok := diffKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// Path of the compared bucket, including its nested buckets, the whole stores when empty.
+	Path []string
+	// Values reports the values of the keys added, removed and changed.
+	Values bool
+	// MaxEntries, when set, stops comparing once MaxEntries differences have been found.
+	MaxEntries int
+}
+
+// DiffEntry is a difference between the stores compared by Diff.
+type DiffEntry struct {
+	Kind   DiffKind
+	Path   []string // bucket path
+	Key    string   // key, empty for buckets
+	Bucket bool     // the entry is Path itself, added or removed along with its keys and nested buckets, or resequenced
+	A, B   []byte   // values in a and b, nil when missing or without DiffOptions.Values
+	SeqA   uint64   // sequences of resequenced buckets in a and b
+	SeqB   uint64
+}
+
+// DiffReport holds the differences found by Diff, in the byte order of their bucket paths and keys.
+type DiffReport struct {
+	Entries   []DiffEntry
+	Added     int
+	Removed   int
+	Changed   int
+	Truncated bool // DiffOptions.MaxEntries differences were found before the comparison completed
+}
+
+// Equal reports whether no difference was found.
+func (r *DiffReport) Equal() bool {
+	return len(r.Entries) == 0
+}
+
+// Diff compares the buckets and key-values of a and b, each from a single read session, reporting the keys and
+// buckets added to b, removed from a, and changed between them, e.g. to verify that a replica matches its
+// leader. Streamed, external and deduplicated values are compared as the values they refer to. Reserved
+// buckets, holding the changelog, metadata and other state maintained by the stores, are not compared.
+func Diff(a, b *Store, opts DiffOptions) (DiffReport, error) {
+	sa, closeA, err := a.ReadSession()
+	if err != nil {
+		return DiffReport{}, err
+	}
+	defer closeA()
+
+	sb, closeB, err := b.ReadSession()
+	if err != nil {
+		return DiffReport{}, err
+	}
+	defer closeB()
+
+	return diffSessions(sa, sb, opts)
+}
+
+// DiffSnapshots compares the revisions held by snapshots a and b, of the same store or of different stores,
+// see Diff. Calls using a snapshot are serialized with the comparison.
+func DiffSnapshots(a, b *Snapshot, opts DiffOptions) (DiffReport, error) {
+	var report DiffReport
+
+	if a == b {
+		return report, a.View(func(Reader) error { return nil })
+	}
+
+	err := a.View(func(Reader) error {
+		return b.View(func(Reader) error {
+			var err error
+			report, err = diffSessions(a.session, b.session, opts)
+			return err
+		})
+	})
+
+	return report, err
+}
+
+func diffSessions(a, b *Session, opts DiffOptions) (DiffReport, error) {
+	d := &differ{a: a, b: b, opts: opts}
+
+	err := a.view(func(ta *bolt.Tx) error {
+		return b.view(func(tb *bolt.Tx) error {
+			if len(opts.Path) > 0 {
+				ba, _ := a.setBucket(opts.Path)
+				bb, _ := b.setBucket(opts.Path)
+				return d.bucket(opts.Path, ba, bb)
+			}
+			return d.roots(ta, tb)
+		})
+	})
+	if err == errDiffTruncated {
+		d.report.Truncated = true
+		err = nil
+	}
+
+	return d.report, wrapError("Diff", opts.Path, "", err)
+}
+
+// errDiffTruncated stops the comparison once DiffOptions.MaxEntries differences have been found.
+var errDiffTruncated = errors.New("diff truncated")
+
+type differ struct {
+	a, b   *Session
+	opts   DiffOptions
+	report DiffReport
+}
+
+func (d *differ) add(e DiffEntry) error {
+	switch e.Kind {
+	case DiffAdded:
+		d.report.Added++
+	case DiffRemoved:
+		d.report.Removed++
+	case DiffChanged:
+		d.report.Changed++
+	}
+	d.report.Entries = append(d.report.Entries, e)
+
+	if d.opts.MaxEntries > 0 && len(d.report.Entries) >= d.opts.MaxEntries {
+		return errDiffTruncated
+	}
+	return nil
+}
+
+func (d *differ) roots(ta, tb *bolt.Tx) error {
+	ca, cb := ta.Cursor(), tb.Cursor()
+	ka, _ := ca.First()
+	kb, _ := cb.First()
+
+	for ka != nil || kb != nil {
+		if ka != nil && bytes.Equal(ka, metaBucket) {
+			ka, _ = ca.Next()
+			continue
+		}
+		if kb != nil && bytes.Equal(kb, metaBucket) {
+			kb, _ = cb.Next()
+			continue
+		}
+
+		var (
+			name   []byte
+			ba, bb *bolt.Bucket
+		)
+		switch cmp := compareKeys(ka, kb); {
+		case cmp < 0:
+			name, ba = ka, ta.Bucket(ka)
+			ka, _ = ca.Next()
+		case cmp > 0:
+			name, bb = kb, tb.Bucket(kb)
+			kb, _ = cb.Next()
+		default:
+			name, ba, bb = ka, ta.Bucket(ka), tb.Bucket(kb)
+			ka, _ = ca.Next()
+			kb, _ = cb.Next()
+		}
+
+		if err := d.bucket([]string{string(name)}, ba, bb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bucket compares the buckets at path, either of which may be nil when missing.
+func (d *differ) bucket(path []string, ba, bb *bolt.Bucket) error {
+	switch {
+	case ba == nil && bb == nil:
+		return nil
+	case ba == nil:
+		return d.add(DiffEntry{Kind: DiffAdded, Path: path, Bucket: true})
+	case bb == nil:
+		return d.add(DiffEntry{Kind: DiffRemoved, Path: path, Bucket: true})
+	}
+
+	if sa, sb := ba.Sequence(), bb.Sequence(); sa != sb {
+		if err := d.add(DiffEntry{Kind: DiffChanged, Path: path, Bucket: true, SeqA: sa, SeqB: sb}); err != nil {
+			return err
+		}
+	}
+
+	var children [][]byte
+
+	ca, cb := ba.Cursor(), bb.Cursor()
+	ka, va := ca.First()
+	kb, vb := cb.First()
+
+	for ka != nil || kb != nil {
+		cmp := compareKeys(ka, kb)
+		key := ka
+		if cmp > 0 {
+			key = kb
+		}
+
+		// nested buckets present in a, in b or in both
+		bucketA := cmp <= 0 && va == nil
+		bucketB := cmp >= 0 && vb == nil
+
+		var err error
+		switch {
+		case (bucketA || cmp > 0) && (bucketB || cmp < 0):
+			// nested buckets are compared after the keys, whether or not they exist in both stores
+			children = append(children, append([]byte{}, key...))
+		case cmp == 0 && va != nil && vb != nil:
+			err = d.key(path, key, va, vb)
+		case cmp == 0:
+			// a key in one store and a nested bucket in the other
+			err = d.kindMismatch(path, key, va, vb)
+		case cmp < 0:
+			err = d.missing(DiffRemoved, d.a, path, key, va)
+		default:
+			err = d.missing(DiffAdded, d.b, path, key, vb)
+		}
+		if err != nil {
+			return err
+		}
+
+		if cmp <= 0 {
+			ka, va = ca.Next()
+		}
+		if cmp >= 0 {
+			kb, vb = cb.Next()
+		}
+	}
+
+	for _, k := range children {
+		child := append(append([]string{}, path...), string(k))
+		if err := d.bucket(child, ba.Bucket(k), bb.Bucket(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *differ) key(path []string, key, va, vb []byte) error {
+	if bytes.Equal(va, vb) {
+		return nil
+	}
+
+	a, err := d.a.resolveRef(va)
+	if err != nil {
+		return wrapError("Diff", path, string(key), err)
+	}
+	b, err := d.b.resolveRef(vb)
+	if err != nil {
+		return wrapError("Diff", path, string(key), err)
+	}
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	e := DiffEntry{Kind: DiffChanged, Path: path, Key: string(key)}
+	if d.opts.Values {
+		e.A, e.B = append([]byte{}, a...), append([]byte{}, b...)
+	}
+	return d.add(e)
+}
+
+// missing reports the key, or nested bucket when v is nil, of session s missing from the other store.
+func (d *differ) missing(kind DiffKind, s *Session, path []string, key, v []byte) error {
+	if v == nil {
+		return d.add(DiffEntry{Kind: kind, Path: append(append([]string{}, path...), string(key)), Bucket: true})
+	}
+
+	e := DiffEntry{Kind: kind, Path: path, Key: string(key)}
+	if d.opts.Values {
+		value, err := s.resolveRef(v)
+		if err != nil {
+			return wrapError("Diff", path, string(key), err)
+		}
+		if kind == DiffAdded {
+			e.B = append([]byte{}, value...)
+		} else {
+			e.A = append([]byte{}, value...)
+		}
+	}
+	return d.add(e)
+}
+
+// kindMismatch reports key, a key in one store and a nested bucket in the other, as removed and added.
+func (d *differ) kindMismatch(path []string, key, va, vb []byte) error {
+	if err := d.missing(DiffRemoved, d.a, path, key, va); err != nil {
+		return err
+	}
+	return d.missing(DiffAdded, d.b, path, key, vb)
+}
+
+// compareKeys compares cursor keys, a nil key, past the last key, sorting last.
+func compareKeys(a, b []byte) int {
+	switch {
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return bytes.Compare(a, b)
+}
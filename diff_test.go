@@ -0,0 +1,79 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := newTestStore(t)
+	b := newTestStore(t)
+
+	for _, s := range []*boltdb.Store{a, b} {
+		writeKeys(t, s, []string{"dirs", "users"}, 10)
+		writeValue(t, s, []string{"dirs", "groups"}, "admins", "alice")
+	}
+
+	report, err := boltdb.Diff(a, b, boltdb.DiffOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Equal())
+
+	writeValue(t, a, []string{"dirs", "users"}, "key-000", "changed")
+	writeValue(t, a, []string{"dirs", "only-a"}, "k", "v")
+	writeValue(t, b, []string{"dirs", "users"}, "key-100", "added")
+	require.NoError(t, b.Update(func(w boltdb.Writer) error {
+		if err := w.DeleteKey([]string{"dirs", "users"}, "key-001"); err != nil {
+			return err
+		}
+		return w.SetSeq([]string{"dirs", "groups"}, 3)
+	}))
+
+	report, err = boltdb.Diff(a, b, boltdb.DiffOptions{Values: true})
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.DiffEntry{
+		{Kind: boltdb.DiffChanged, Path: []string{"dirs", "groups"}, Bucket: true, SeqB: 3},
+		{Kind: boltdb.DiffRemoved, Path: []string{"dirs", "only-a"}, Bucket: true},
+		{Kind: boltdb.DiffChanged, Path: []string{"dirs", "users"}, Key: "key-000", A: []byte("changed"), B: []byte("value")},
+		{Kind: boltdb.DiffRemoved, Path: []string{"dirs", "users"}, Key: "key-001", A: []byte("value")},
+		{Kind: boltdb.DiffAdded, Path: []string{"dirs", "users"}, Key: "key-100", B: []byte("added")},
+	}, report.Entries)
+	assert.Equal(t, 1, report.Added)
+	assert.Equal(t, 2, report.Removed)
+	assert.Equal(t, 2, report.Changed)
+
+	report, err = boltdb.Diff(a, b, boltdb.DiffOptions{Path: []string{"dirs", "users"}, MaxEntries: 2})
+	require.NoError(t, err)
+	assert.True(t, report.Truncated)
+	require.Len(t, report.Entries, 2)
+	assert.Equal(t, "key-000", report.Entries[0].Key)
+	assert.Nil(t, report.Entries[0].A)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{InitialMmapSize: 16 << 20})
+	writeKeys(t, s, []string{"dirs"}, 5)
+
+	before, err := s.Snapshot(time.Minute)
+	require.NoError(t, err)
+	defer before.Close()
+
+	writeValue(t, s, []string{"dirs"}, "key-005", "value")
+
+	after, err := s.Snapshot(time.Minute)
+	require.NoError(t, err)
+	defer after.Close()
+
+	report, err := boltdb.DiffSnapshots(before, after, boltdb.DiffOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 1)
+	assert.Equal(t, boltdb.DiffAdded, report.Entries[0].Kind)
+	assert.Equal(t, "key-005", report.Entries[0].Key)
+
+	report, err = boltdb.DiffSnapshots(after, after, boltdb.DiffOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Equal())
+}
@@ -0,0 +1,133 @@
+package boltdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDiskCheckInterval is the delay between two checks of the disk guardrails,
+// used when Config.DiskCheckInterval is zero.
+const DefaultDiskCheckInterval = 30 * time.Second
+
+// diskCheckTask is the name of the janitor task checking the disk guardrails.
+const diskCheckTask = "disk-check"
+
+// DiskStats reports the last check of the disk guardrails configured by Config.MaxDBSize and
+// Config.MinFreeSpace, see Store.DiskStats.
+type DiskStats struct {
+	DBSize    int64     // size of the database file
+	FreeSpace uint64    // bytes available on the volume of the database
+	Breached  bool      // a guardrail is breached
+	ReadOnly  bool      // write sessions are rejected, see Config.ReadOnlyOnDiskFull
+	Reason    string    // guardrail breached, empty when none is
+	Checked   time.Time // time of the check, zero before the first check
+}
+
+// diskGuard holds the state of the disk guardrails of a store.
+type diskGuard struct {
+	mu       sync.Mutex
+	stats    DiskStats
+	readOnly uint32 // write sessions are rejected, accessed atomically
+}
+
+// DiskStats returns the last check of the disk guardrails.
+func (s *Store) DiskStats() DiskStats {
+	s.disk.mu.Lock()
+	defer s.disk.mu.Unlock()
+
+	return s.disk.stats
+}
+
+// diskGuarded reports whether the store has disk guardrails configured.
+func (s *Store) diskGuarded() bool {
+	return s.config.MaxDBSize > 0 || s.config.MinFreeSpace > 0
+}
+
+// checkDisk checks the disk guardrails, failing with ErrDBSizeExceeded or ErrLowDiskSpace
+// when one is breached. Breaches and recoveries are logged.
+func (s *Store) checkDisk() error {
+	stats := DiskStats{Checked: time.Now()}
+
+	if info, err := os.Stat(s.config.DBPath); err == nil {
+		stats.DBSize = info.Size()
+	}
+
+	free, err := freeSpace(filepath.Dir(s.config.DBPath))
+	if err != nil && s.config.MinFreeSpace > 0 {
+		return errors.Wrap(err, "failed to determine free space")
+	}
+	stats.FreeSpace = free
+
+	switch {
+	case s.config.MaxDBSize > 0 && stats.DBSize > s.config.MaxDBSize:
+		err = errors.Wrapf(ErrDBSizeExceeded, "%d bytes, %d allowed", stats.DBSize, s.config.MaxDBSize)
+	case s.config.MinFreeSpace > 0 && stats.FreeSpace < s.config.MinFreeSpace:
+		err = errors.Wrapf(ErrLowDiskSpace, "%d bytes available, %d required", stats.FreeSpace, s.config.MinFreeSpace)
+	default:
+		err = nil
+	}
+
+	if err != nil {
+		stats.Breached = true
+		stats.ReadOnly = s.config.ReadOnlyOnDiskFull
+		stats.Reason = err.Error()
+	}
+
+	s.setDiskStats(stats)
+
+	return err
+}
+
+func (s *Store) setDiskStats(stats DiskStats) {
+	s.disk.mu.Lock()
+	prev := s.disk.stats
+	s.disk.stats = stats
+	s.disk.mu.Unlock()
+
+	var readOnly uint32
+	if stats.ReadOnly {
+		readOnly = 1
+	}
+	atomic.StoreUint32(&s.disk.readOnly, readOnly)
+
+	switch {
+	case stats.Breached && !prev.Breached:
+		s.logger.Warn("disk::breached", "DBPath", s.config.DBPath, "reason", stats.Reason, "readOnly", stats.ReadOnly)
+	case !stats.Breached && prev.Breached:
+		s.logger.Info("disk::recovered", "DBPath", s.config.DBPath, "size", stats.DBSize, "free", stats.FreeSpace)
+	}
+}
+
+// diskReadOnly returns the error of write sessions started while the disk guardrails are breached
+// with Config.ReadOnlyOnDiskFull, nil otherwise.
+func (s *Store) diskReadOnly() error {
+	if atomic.LoadUint32(&s.disk.readOnly) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(ErrReadOnly, s.DiskStats().Reason)
+}
+
+// startDiskMonitor checks the disk guardrails every Config.DiskCheckInterval, until the store is closed.
+func (s *Store) startDiskMonitor() {
+	interval := s.config.DiskCheckInterval
+	if interval <= 0 {
+		interval = DefaultDiskCheckInterval
+	}
+
+	// breaches are logged and reported by DiskStats rather than as failures of the task
+	_, _ = s.Schedule(JanitorTask{
+		Name:     diskCheckTask,
+		Interval: interval,
+		Run: func(context.Context) error {
+			_ = s.checkDisk()
+			return nil
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package boltdb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskGuardrails(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{MaxDBSize: 1})
+
+	stats := s.DiskStats()
+	assert.True(t, stats.Breached)
+	assert.False(t, stats.ReadOnly)
+	assert.Greater(t, stats.DBSize, int64(1))
+	assert.Contains(t, stats.Reason, "database size exceeded")
+
+	assert.ErrorIs(t, s.HealthCheck(context.Background()), boltdb.ErrDBSizeExceeded)
+
+	// breached guardrails only warn by default
+	write(t, s, []string{"disk"}, "k1")
+}
+
+func TestDiskGuardrailsReadOnly(t *testing.T) {
+	cfg := &boltdb.Config{MaxDBSize: 1, ReadOnlyOnDiskFull: true, DiskCheckInterval: time.Hour}
+	s := newTestStoreWithConfig(t, cfg)
+
+	assert.True(t, s.DiskStats().ReadOnly)
+
+	_, _, err := s.WriteSession()
+	assert.ErrorIs(t, err, boltdb.ErrReadOnly)
+
+	_, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	closer()
+
+	cfg.MaxDBSize = 1 << 40
+	require.NoError(t, s.HealthCheck(context.Background()))
+
+	assert.False(t, s.DiskStats().Breached)
+	write(t, s, []string{"disk"}, "k1")
+}
+
+func TestDiskGuardrailsMonitor(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{MaxDBSize: 1 << 40, DiskCheckInterval: time.Millisecond})
+
+	checked := s.DiskStats().Checked
+	require.Eventually(t, func() bool {
+		return s.DiskStats().Checked.After(checked)
+	}, 5*time.Second, time.Millisecond)
+	assert.False(t, s.DiskStats().Breached)
+	assert.NotZero(t, s.DiskStats().FreeSpace)
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package boltdb
+
+import "syscall"
+
+// freeSpace returns the number of bytes available to unprivileged users on the volume of dir.
+// Statfs_t field types differ across platforms, hence the conversions.
+func freeSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package boltdb
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the number of bytes available to the caller on the volume of dir.
+func freeSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,103 @@
+package boltdb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dumpHeader starts the output of Store.DumpDeterministic, versioning its format.
+const dumpHeader = "boltdb-dump 1"
+
+// dumpInlineSize is the length up to which valid UTF-8 values are written along with their hash.
+const dumpInlineSize = 64
+
+// DumpDeterministic writes a canonical textual representation of the buckets and key-values of the store
+// to w, from a single read session, for golden-file tests. The output only depends on the content of the
+// store: buckets are written depth first in byte order, each as a line holding its quoted path segments
+// and its sequence, followed by a line per key holding the quoted key, the length and the SHA-256 hash of
+// its value, and the quoted value when it is valid UTF-8 of up to 64 bytes.
+//
+// Streamed, external and deduplicated values are written as the values they refer to. The __meta bucket,
+// holding the changelog, metadata and other state maintained by the store, is not written.
+func (s *Store) DumpDeterministic(w io.Writer) error {
+	s.logger.Trace("Store::DumpDeterministic")
+
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintln(bw, dumpHeader); err != nil {
+		return err
+	}
+
+	err = session.view(func(tx *bolt.Tx) error {
+		c := tx.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if bytes.Equal(k, metaBucket) {
+				continue
+			}
+			if err := session.dumpBucket(bw, tx.Bucket(k), []string{string(k)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (s *Session) dumpBucket(w *bufio.Writer, b *bolt.Bucket, path []string) error {
+	segments := make([]string, len(path))
+	for i, p := range path {
+		segments[i] = strconv.Quote(p)
+	}
+	if _, err := fmt.Fprintf(w, "bucket %s seq=%d\n", strings.Join(segments, "/"), b.Sequence()); err != nil {
+		return err
+	}
+
+	var children [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			children = append(children, append([]byte{}, k...))
+			continue
+		}
+
+		value, err := s.resolveRef(v)
+		if err != nil {
+			return wrapError("DumpDeterministic", path, string(k), err)
+		}
+
+		line := fmt.Sprintf("  %q size=%d sha256=%x", k, len(value), sha256.Sum256(value))
+		if len(value) <= dumpInlineSize && utf8.Valid(value) {
+			line += " value=" + strconv.Quote(string(value))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range children {
+		child := append(append([]string{}, path...), string(k))
+		if err := s.dumpBucket(w, b.Bucket(k), child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
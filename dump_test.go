@@ -0,0 +1,59 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dump(t *testing.T, s *boltdb.Store) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, s.DumpDeterministic(&buf))
+	return buf.String()
+}
+
+func TestDumpDeterministic(t *testing.T) {
+	s := newTestStore(t)
+
+	writeValue(t, s, []string{"users"}, "bob", "b")
+	writeValue(t, s, []string{"users", "groups"}, "admins", "alice")
+	writeValue(t, s, []string{"users"}, "alice", "a")
+	writeValue(t, s, []string{"binary"}, "k", "\xff")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	_, err = session.NextSeq([]string{"users"})
+	require.NoError(t, err)
+	closer()
+
+	assert.Equal(t, `boltdb-dump 1
+bucket "binary" seq=0
+  "k" size=1 sha256=a8100ae6aa1940d0b663bb31cd466142ebbdbd5187131b92d93818987832eb89
+bucket "users" seq=1
+  "alice" size=1 sha256=ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb value="a"
+  "bob" size=1 sha256=3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d value="b"
+bucket "users"/"groups" seq=0
+  "admins" size=5 sha256=2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90 value="alice"
+`, dump(t, s))
+}
+
+func TestDumpDeterministicStorage(t *testing.T) {
+	plain := newTestStore(t)
+	stored := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true, ChunkSize: 8, EnableChangelog: true})
+
+	// values written in another order, streamed and deduplicated dump the same
+	writeValue(t, plain, []string{"a"}, "k1", payload)
+	writeValue(t, plain, []string{"a"}, "k2", payload)
+	writeValue(t, plain, []string{"a"}, "k3", streamed)
+
+	writeStream(t, stored, []string{"a"}, "k3", streamed)
+	writeValue(t, stored, []string{"a"}, "k2", payload)
+	writeValue(t, stored, []string{"a"}, "k1", payload)
+
+	assert.Equal(t, dump(t, plain), dump(t, stored))
+}
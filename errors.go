@@ -1,9 +1,112 @@
 package boltdb
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 var (
 	ErrPathNotFound = errors.New("path not found")
 	ErrKeyNotFound  = errors.New("key not found")
 	ErrKeyExists    = errors.New("key already exists")
+
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidPath      = errors.New("invalid path")
+	ErrNotVersioned     = errors.New("path not versioned")
+	ErrEtagMismatch     = errors.New("etag mismatch")
+	ErrNotNumeric       = errors.New("value is not numeric")
+	ErrOverflow         = errors.New("numeric overflow")
+	ErrNoMergeOperator  = errors.New("no merge operator")
+	ErrInvalidPatch     = errors.New("invalid patch")
+	ErrInvalidPointer   = errors.New("invalid JSON pointer")
+	ErrFieldNotFound    = errors.New("field not found")
+	ErrInvalidPattern   = errors.New("invalid pattern")
+
+	ErrIndexNotFound     = errors.New("index not found")
+	ErrInvalidIndexQuery = errors.New("invalid index query")
+	ErrValueCorrupt      = errors.New("value corrupt")
+	ErrValidation        = errors.New("validation failed")
+	ErrAuditTampered     = errors.New("audit log tampered")
+	ErrBackupCorrupt     = errors.New("backup corrupt")
+	ErrReadOnly          = errors.New("store is read-only")
+	ErrStoreNotFound     = errors.New("store not found")
+	ErrDeadlineExceeded  = errors.New("operation deadline exceeded")
+	ErrDatabaseLocked    = errors.New("database locked")
+	ErrLowDiskSpace      = errors.New("low disk space")
+	ErrDBSizeExceeded    = errors.New("database size exceeded")
+	ErrBlobUnavailable   = errors.New("blob unavailable")
+	ErrWriterClosed      = errors.New("writer closed")
+	ErrSnapshotExpired   = errors.New("snapshot expired")
+	ErrUndeclaredPath    = errors.New("path not declared")
+	ErrAccessDenied      = errors.New("access denied")
+	ErrWriteQueueFull    = errors.New("write queue full")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrExportCorrupt     = errors.New("export corrupt")
+	ErrChangelogDisabled = errors.New("changelog disabled")
+	ErrReservedValue     = errors.New("value uses a reserved encoding")
 )
+
+// StoreError describes a failed store operation.
+// It wraps the underlying cause, so errors.Is(err, ErrKeyNotFound) and friends keep working.
+type StoreError struct {
+	Op   string   // session operation, e.g. "Read"
+	Path []string // bucket path
+	Key  string   // key or key prefix, empty for bucket operations
+	Err  error    // underlying cause
+}
+
+func (e *StoreError) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString(e.Op)
+	if e.Path != nil {
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("path [" + pathStr(e.Path) + "]")
+	}
+	if e.Key != "" {
+		sb.WriteString(" key [" + e.Key + "]")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Err.Error())
+
+	return sb.String()
+}
+
+// Unwrap returns the underlying cause.
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying cause, for compatibility with errors.Cause.
+func (e *StoreError) Cause() error {
+	return e.Err
+}
+
+// wrapError annotates err with the operation, path and key it occurred in.
+// When err already carries a StoreError the missing fields are filled in instead of nesting.
+func wrapError(op string, path []string, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var se *StoreError
+	if errors.As(err, &se) {
+		if se.Op == "" {
+			se.Op = op
+		}
+		if se.Path == nil {
+			se.Path = path
+		}
+		if se.Key == "" {
+			se.Key = key
+		}
+		return err
+	}
+
+	return &StoreError{Op: op, Path: path, Key: key, Err: err}
+}
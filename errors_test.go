@@ -0,0 +1,38 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreError(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	require.NoError(t, session.Write([]string{"errors", "a"}, "k1", []byte("v1")))
+
+	_, err = session.Read([]string{"errors", "a"}, "k2")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+
+	var se *boltdb.StoreError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, "Read", se.Op)
+	assert.Equal(t, []string{"errors", "a"}, se.Path)
+	assert.Equal(t, "k2", se.Key)
+	assert.Equal(t, "Read path [errors/a] key [k2]: key not found", err.Error())
+
+	_, _, err = session.ListKeys([]string{"errors", "b"}, "")
+	require.True(t, errors.As(err, &se))
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+	assert.Equal(t, "ListKeys", se.Op)
+	assert.Equal(t, []string{"errors", "b"}, se.Path)
+	assert.Empty(t, se.Key)
+}
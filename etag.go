@@ -0,0 +1,126 @@
+package boltdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AnyETag matches the ETag of any existing value, like the HTTP "*" entity tag.
+const AnyETag = "*"
+
+// ETag returns the entity tag of value, a hex encoded SHA-256 content hash.
+func ETag(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadWithETag returns the value of key in bucket path along with its ETag.
+func (s *Session) ReadWithETag(path []string, key string) ([]byte, string, error) {
+	s.store.logger.Trace("Session::ReadWithETag", "path", path, "key", key)
+
+	var (
+		result []byte
+		etag   string
+	)
+
+	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrKeyNotFound
+		}
+
+		if err := s.verifyChecksum(path, []byte(key), v); err != nil {
+			return err
+		}
+
+		// ETags of streamed values and external blobs are the ETags of their reference
+		etag = ETag(v)
+		if v, err = s.resolveRef(v); err != nil {
+			return err
+		}
+		result = append([]byte{}, v...)
+
+		return nil
+	}
+
+	op := newOp("ReadWithETag", path, key)
+	err := s.intercept(op, func() error {
+		err := s.view(read)
+		op.Value = result
+		return err
+	})
+
+	return op.Value, etag, wrapError("ReadWithETag", path, key, err)
+}
+
+// WriteIfMatch writes value for key in bucket path when the current value of key has the given etag,
+// or, when etag is AnyETag, when key exists. Returns ErrEtagMismatch otherwise.
+func (s *Session) WriteIfMatch(path []string, key string, value []byte, etag string) error {
+	s.store.logger.Trace("Session::WriteIfMatch", "path", path, "key", key, "etag", etag)
+
+	op := newOp("WriteIfMatch", path, key)
+	op.Value = value
+
+	write := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		current := s.currentValue(path, []byte(key))
+		if current == nil || (etag != AnyETag && ETag(current) != etag) {
+			return ErrEtagMismatch
+		}
+
+		return s.put(path, []byte(key), op.Value)
+	}
+
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("WriteIfMatch", path, key, err)
+}
+
+// WriteIfNoneMatch writes value for key in bucket path unless the current value of key has the given etag,
+// or, when etag is AnyETag, unless key exists. Returns ErrEtagMismatch otherwise.
+func (s *Session) WriteIfNoneMatch(path []string, key string, value []byte, etag string) error {
+	s.store.logger.Trace("Session::WriteIfNoneMatch", "path", path, "key", key, "etag", etag)
+
+	op := newOp("WriteIfNoneMatch", path, key)
+	op.Value = value
+
+	write := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		current := s.currentValue(path, []byte(key))
+		if current != nil && (etag == AnyETag || ETag(current) == etag) {
+			return ErrEtagMismatch
+		}
+
+		return s.put(path, []byte(key), op.Value)
+	}
+
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("WriteIfNoneMatch", path, key, err)
+}
+
+// currentValue returns the value of key in bucket path, nil when the key or path does not exist.
+func (s *Session) currentValue(path []string, key []byte) []byte {
+	b, err := s.setBucket(path)
+	if err != nil {
+		return nil
+	}
+	return b.Get(key)
+}
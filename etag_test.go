@@ -0,0 +1,74 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+
+	update := func(fn func(*boltdb.Session) error) error {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return fn(session)
+	}
+
+	// create only when absent
+	err := update(func(session *boltdb.Session) error {
+		return session.WriteIfNoneMatch(path, "k1", []byte("v1"), boltdb.AnyETag)
+	})
+	require.NoError(t, err)
+
+	err = update(func(session *boltdb.Session) error {
+		return session.WriteIfNoneMatch(path, "k1", []byte("v1"), boltdb.AnyETag)
+	})
+	assert.True(t, errors.Is(err, boltdb.ErrEtagMismatch))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	value, etag, err := session.ReadWithETag(path, "k1")
+	closer()
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+	assert.Equal(t, boltdb.ETag([]byte("v1")), etag)
+
+	err = update(func(session *boltdb.Session) error {
+		return session.WriteIfMatch(path, "k1", []byte("v2"), boltdb.ETag([]byte("stale")))
+	})
+	assert.True(t, errors.Is(err, boltdb.ErrEtagMismatch))
+
+	err = update(func(session *boltdb.Session) error {
+		return session.WriteIfMatch(path, "k1", []byte("v2"), etag)
+	})
+	require.NoError(t, err)
+
+	err = update(func(session *boltdb.Session) error {
+		return session.WriteIfMatch(path, "k2", []byte("v2"), boltdb.AnyETag)
+	})
+	assert.True(t, errors.Is(err, boltdb.ErrEtagMismatch))
+
+	err = update(func(session *boltdb.Session) error {
+		return session.WriteIfNoneMatch(path, "k1", []byte("v3"), etag)
+	})
+	require.NoError(t, err)
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, etag, err = session.ReadWithETag(path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "v3", string(value))
+	assert.Equal(t, boltdb.ETag([]byte("v3")), etag)
+
+	_, _, err = session.ReadWithETag(path, "k2")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
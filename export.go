@@ -0,0 +1,528 @@
+package boltdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// exportMagic starts the output of Store.Export, followed by the version and the flags of the format.
+const exportMagic = "boltdb-export\x00"
+
+const (
+	exportVersion     = 1
+	exportFlagDeflate = 1 << 0 // records are compressed with DEFLATE
+	exportFlagChanges = 1 << 1 // records hold the changes since a revision, see Store.ExportChanges
+)
+
+// export record types
+const (
+	exportEnd    = 0 // trailer holding the bucket and key counts and the CRC-32C of the records before it
+	exportBucket = 1 // bucket path relative to the exported bucket, and its sequence
+	exportKey    = 2 // key and value of the last bucket written
+
+	exportDeleteKey         = 3 // key deleted from the last bucket written
+	exportDeleteBucket      = 4 // bucket path deleted
+	exportTruncate          = 5 // bucket path truncated
+	exportTruncateRecursive = 6 // bucket path truncated, along with its nested buckets
+	exportRevisions         = 7 // revisions the changes were exported between
+)
+
+// maxExportSegment bounds the lengths of the path segments and keys read by Store.Import, the maximum
+// key size of bolt, so corrupt exports do not allocate arbitrary amounts of memory.
+const maxExportSegment = bolt.MaxKeySize
+
+// ExportOptions configures Store.Export.
+type ExportOptions struct {
+	// Compress compresses the records with DEFLATE, trading CPU time for the bytes transferred.
+	Compress bool
+}
+
+// ImportOptions configures Store.Import.
+type ImportOptions struct {
+	// BatchSize, when set, commits a write session every BatchSize keys rather than a single write session
+	// for the whole import, so other write sessions run between the batches. A failed import then leaves
+	// the batches committed before the failure.
+	BatchSize int
+}
+
+// ExportStats reports the buckets and keys written by Store.Export, Store.Import or Store.CloneTo.
+type ExportStats struct {
+	Buckets int
+	Keys    int
+}
+
+// Export writes the buckets and key-values of bucket path, including its nested buckets, to w from a single
+// read session, in a compact binary format read by Store.Import: length-prefixed records holding the bucket
+// paths relative to path with their sequences, and the keys and values of each bucket, followed by a trailer
+// checksumming the records. An empty path exports the whole store.
+//
+// Streamed, external and deduplicated values are written as the values they refer to. The __meta bucket,
+// holding the changelog, metadata and other state maintained by the store, is not written.
+func (s *Store) Export(ctx context.Context, w io.Writer, path []string, opts ExportOptions) (ExportStats, error) {
+	s.logger.Trace("Store::Export", "path", path)
+
+	var stats ExportStats
+
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return stats, err
+	}
+	defer closer()
+
+	flags := byte(0)
+	if opts.Compress {
+		flags |= exportFlagDeflate
+	}
+
+	ew, finish, err := newExportWriter(ctx, w, flags, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	err = session.view(func(tx *bolt.Tx) error {
+		if len(path) > 0 {
+			b, err := session.setBucket(path)
+			if err != nil {
+				return err
+			}
+			return session.exportBucket(ew, b, path, nil)
+		}
+
+		c := tx.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if bytes.Equal(k, metaBucket) {
+				continue
+			}
+			if err := session.exportBucket(ew, tx.Bucket(k), []string{string(k)}, []string{string(k)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, wrapError("Export", path, "", err)
+	}
+
+	return stats, finish()
+}
+
+// newExportWriter writes the header of an export with flags to w, returning the writer of its records and
+// a function writing the trailer once the records have been written.
+func newExportWriter(ctx context.Context, w io.Writer, flags byte, stats *ExportStats) (*exportWriter, func() error, error) {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString(exportMagic); err != nil {
+		return nil, nil, err
+	}
+	if _, err := bw.Write([]byte{exportVersion, flags}); err != nil {
+		return nil, nil, err
+	}
+
+	ew := &exportWriter{w: bw, hash: crc32.New(castagnoli), ctx: ctx, stats: stats}
+
+	var fw *flate.Writer
+	if flags&exportFlagDeflate != 0 {
+		var err error
+		if fw, err = flate.NewWriter(bw, flate.DefaultCompression); err != nil {
+			return nil, nil, err
+		}
+		ew.w = fw
+	}
+
+	finish := func() error {
+		if err := ew.end(); err != nil {
+			return err
+		}
+		if fw != nil {
+			if err := fw.Close(); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+
+	return ew, finish, nil
+}
+
+// exportBucket writes the record of bucket b at path, rel relative to the exported bucket, followed by its keys
+// and its nested buckets.
+func (s *Session) exportBucket(w *exportWriter, b *bolt.Bucket, path, rel []string) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	w.byte(exportBucket)
+	w.path(rel)
+	w.uvarint(b.Sequence())
+	w.stats.Buckets++
+
+	var children [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			children = append(children, append([]byte{}, k...))
+			continue
+		}
+
+		value, err := s.resolveRef(v)
+		if err != nil {
+			return wrapError("Export", path, string(k), err)
+		}
+
+		w.byte(exportKey)
+		w.bytes(k)
+		w.bytes(value)
+		w.stats.Keys++
+
+		if w.err != nil {
+			return w.err
+		}
+	}
+
+	for _, k := range children {
+		child := append(append([]string{}, path...), string(k))
+		childRel := append(append([]string{}, rel...), string(k))
+		if err := s.exportBucket(w, b.Bucket(k), child, childRel); err != nil {
+			return err
+		}
+	}
+
+	return w.err
+}
+
+// exportWriter writes export records, checksumming them, keeping the first error.
+type exportWriter struct {
+	w     io.Writer
+	hash  hash.Hash32
+	ctx   context.Context
+	stats *ExportStats
+	buf   [binary.MaxVarintLen64]byte
+	err   error
+}
+
+func (w *exportWriter) write(p []byte) {
+	if w.err != nil {
+		return
+	}
+	_, _ = w.hash.Write(p)
+	_, w.err = w.w.Write(p)
+}
+
+func (w *exportWriter) byte(b byte) {
+	w.buf[0] = b
+	w.write(w.buf[:1])
+}
+
+func (w *exportWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(w.buf[:], v)
+	w.write(w.buf[:n])
+}
+
+func (w *exportWriter) bytes(p []byte) {
+	w.uvarint(uint64(len(p)))
+	w.write(p)
+}
+
+func (w *exportWriter) path(path []string) {
+	w.uvarint(uint64(len(path)))
+	for _, segment := range path {
+		w.bytes([]byte(segment))
+	}
+}
+
+func (w *exportWriter) end() error {
+	w.byte(exportEnd)
+	w.uvarint(uint64(w.stats.Buckets))
+	w.uvarint(uint64(w.stats.Keys))
+
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, w.hash.Sum32())
+	w.write(sum)
+
+	return w.err
+}
+
+// Import writes the buckets and key-values exported by Store.Export under bucket path, creating the buckets
+// and restoring their sequences, in a write session started by UpdateContext, so ctx may hint its priority,
+// see WithWritePriority. Keys already present are overwritten. Imports which are truncated, or whose records
+// do not match the checksum of their trailer, fail with ErrExportCorrupt and are rolled back, unless
+// opts.BatchSize committed part of them.
+func (s *Store) Import(ctx context.Context, r io.Reader, path []string, opts ImportOptions) (ExportStats, error) {
+	s.logger.Trace("Store::Import", "path", path)
+
+	var stats ExportStats
+
+	er, flags, closer, err := openExport(r)
+	if err != nil {
+		return stats, err
+	}
+	defer closer()
+
+	if flags&exportFlagChanges != 0 {
+		return stats, errors.Wrap(ErrExportCorrupt, "changes are imported by ImportChanges")
+	}
+
+	im := &importer{r: er, path: path}
+	for !im.done {
+		batched := 0
+
+		// wait for the rate limits before holding the writer, rather than while holding it
+		if err := s.WaitRateLimit(ctx, path); err != nil {
+			return im.stats, wrapError("Import", path, "", err)
+		}
+
+		err := s.UpdateContext(ctx, func(w Writer) error {
+			for !im.done && (opts.BatchSize <= 0 || batched < opts.BatchSize) {
+				keyed, err := im.next(w)
+				if err != nil {
+					return err
+				}
+				if keyed {
+					batched++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return im.stats, wrapError("Import", path, "", err)
+		}
+	}
+
+	return im.stats, nil
+}
+
+// openExport reads the header of an export from r, returning the reader of its records, its flags,
+// and a function releasing the reader.
+func openExport(r io.Reader) (*exportReader, byte, func(), error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(exportMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(exportMagic)]) != exportMagic {
+		return nil, 0, nil, errors.Wrap(ErrExportCorrupt, "invalid header")
+	}
+	if header[len(exportMagic)] != exportVersion {
+		return nil, 0, nil, errors.Wrapf(ErrExportCorrupt, "unsupported version %d", header[len(exportMagic)])
+	}
+
+	flags := header[len(exportMagic)+1]
+	if flags&exportFlagDeflate == 0 {
+		return &exportReader{r: br, hash: crc32.New(castagnoli)}, flags, func() {}, nil
+	}
+
+	fr := flate.NewReader(br)
+	closer := func() { _ = fr.Close() }
+
+	return &exportReader{r: bufio.NewReader(fr), hash: crc32.New(castagnoli)}, flags, closer, nil
+}
+
+// importer applies the records of an export under a bucket path.
+type importer struct {
+	r      *exportReader
+	path   []string // bucket the records are imported under
+	bucket []string // bucket of the following key records
+	stats  ExportStats
+	done   bool // the trailer has been read
+}
+
+// next applies the next record with w, reporting whether it wrote or deleted a key.
+func (im *importer) next(w Writer) (bool, error) {
+	typ, err := im.r.byte()
+	if err != nil {
+		return false, err
+	}
+
+	switch typ {
+	case exportBucket:
+		rel, seq, err := im.r.bucket()
+		if err != nil {
+			return false, err
+		}
+		im.bucket = append(append([]string{}, im.path...), rel...)
+		im.stats.Buckets++
+		if len(im.bucket) == 0 {
+			return false, nil
+		}
+		if seq > 0 {
+			return false, w.SetSeq(im.bucket, seq)
+		}
+		return false, w.CreateBucket(im.bucket)
+
+	case exportKey, exportDeleteKey:
+		key, value, err := im.r.key(typ == exportKey)
+		if err != nil {
+			return false, err
+		}
+		if len(im.bucket) == 0 {
+			return false, errors.Wrap(ErrExportCorrupt, "key outside of a bucket")
+		}
+		im.stats.Keys++
+		if typ == exportDeleteKey {
+			return true, w.DeleteKeyB(im.bucket, key)
+		}
+		return true, w.WriteB(im.bucket, key, value)
+
+	case exportDeleteBucket, exportTruncate, exportTruncateRecursive:
+		rel, err := im.r.path()
+		if err != nil {
+			return false, err
+		}
+		path := append(append([]string{}, im.path...), rel...)
+		switch {
+		case typ == exportDeleteBucket:
+			return false, w.DeleteBucket(path)
+		case !w.BucketExists(path):
+			// truncated before being deleted, or before the bucket was created
+			return false, nil
+		case typ == exportTruncate:
+			return false, w.TruncateBucket(path)
+		}
+		return false, w.TruncateBucketRecursive(path)
+
+	case exportEnd:
+		im.done = true
+		return false, im.r.end(im.stats)
+	}
+
+	return false, errors.Wrapf(ErrExportCorrupt, "unknown record type %d", typ)
+}
+
+// exportReader reads the records written by an exportWriter, checksumming them.
+type exportReader struct {
+	r    *bufio.Reader
+	hash hash.Hash32
+}
+
+func (r *exportReader) byte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, corruptExport(err)
+	}
+	_, _ = r.hash.Write([]byte{b})
+	return b, nil
+}
+
+func (r *exportReader) uvarint() (uint64, error) {
+	var (
+		v     uint64
+		shift uint
+	)
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if b < 0x80 {
+			return v | uint64(b)<<shift, nil
+		}
+		v |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+	return 0, errors.Wrap(ErrExportCorrupt, "invalid length")
+}
+
+func (r *exportReader) bytes(max uint64) ([]byte, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > max {
+		return nil, errors.Wrapf(ErrExportCorrupt, "record of %d bytes", n)
+	}
+
+	p := make([]byte, n)
+	if _, err := io.ReadFull(r.r, p); err != nil {
+		return nil, corruptExport(err)
+	}
+	_, _ = r.hash.Write(p)
+
+	return p, nil
+}
+
+func (r *exportReader) path() ([]string, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxExportSegment {
+		return nil, errors.Wrapf(ErrExportCorrupt, "path of %d segments", n)
+	}
+
+	rel := make([]string, n)
+	for i := range rel {
+		segment, err := r.bytes(maxExportSegment)
+		if err != nil {
+			return nil, err
+		}
+		rel[i] = string(segment)
+	}
+
+	return rel, nil
+}
+
+func (r *exportReader) bucket() ([]string, uint64, error) {
+	rel, err := r.path()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	seq, err := r.uvarint()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return rel, seq, nil
+}
+
+// key reads a key, followed by its value when withValue is set.
+func (r *exportReader) key(withValue bool) ([]byte, []byte, error) {
+	key, err := r.bytes(maxExportSegment)
+	if err != nil || !withValue {
+		return key, nil, err
+	}
+	value, err := r.bytes(bolt.MaxValueSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+// end reads the trailer, checking the counts and the checksum of the records read against it.
+func (r *exportReader) end(stats ExportStats) error {
+	buckets, err := r.uvarint()
+	if err != nil {
+		return err
+	}
+	keys, err := r.uvarint()
+	if err != nil {
+		return err
+	}
+	sum := r.hash.Sum32()
+
+	expected := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, expected); err != nil {
+		return corruptExport(err)
+	}
+
+	if buckets != uint64(stats.Buckets) || keys != uint64(stats.Keys) || binary.BigEndian.Uint32(expected) != sum {
+		return errors.Wrap(ErrExportCorrupt, "checksum mismatch")
+	}
+
+	return nil
+}
+
+func corruptExport(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.Wrap(ErrExportCorrupt, "truncated")
+	}
+	return err
+}
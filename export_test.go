@@ -0,0 +1,115 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedExport(t *testing.T, s *boltdb.Store) {
+	t.Helper()
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 50; i++ {
+			if err := w.Write([]string{"dirs", "a", "users"}, fmt.Sprintf("user-%03d", i), bytes.Repeat([]byte("v"), i)); err != nil {
+				return err
+			}
+		}
+		if err := w.WriteB([]string{"dirs", "a"}, []byte{0, 0xff}, []byte("binary")); err != nil {
+			return err
+		}
+		if err := w.SetSeq([]string{"dirs", "a", "users"}, 42); err != nil {
+			return err
+		}
+		if err := w.CreateBucket([]string{"dirs", "a", "empty"}); err != nil {
+			return err
+		}
+		return w.Write([]string{"dirs", "b"}, "k", []byte("v"))
+	}))
+}
+
+func TestExportImport(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			src := newTestStore(t)
+			seedExport(t, src)
+
+			var buf bytes.Buffer
+			stats, err := src.Export(context.Background(), &buf, []string{"dirs", "a"}, boltdb.ExportOptions{Compress: compress})
+			require.NoError(t, err)
+			assert.Equal(t, boltdb.ExportStats{Buckets: 3, Keys: 51}, stats)
+
+			dst := newTestStore(t)
+			imported, err := dst.Import(context.Background(), &buf, []string{"copy"}, boltdb.ImportOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, stats, imported)
+
+			require.NoError(t, dst.View(func(r boltdb.Reader) error {
+				v, err := r.ReadB([]string{"copy"}, []byte{0, 0xff})
+				require.NoError(t, err)
+				assert.Equal(t, "binary", string(v))
+
+				v, err = r.Read([]string{"copy", "users"}, "user-049")
+				require.NoError(t, err)
+				assert.Len(t, v, 49)
+
+				seq, err := r.CurrentSeq([]string{"copy", "users"})
+				require.NoError(t, err)
+				assert.Equal(t, uint64(42), seq)
+
+				assert.True(t, r.BucketExists([]string{"copy", "empty"}))
+				return nil
+			}))
+		})
+	}
+}
+
+func TestExportImportStore(t *testing.T) {
+	src := newTestStore(t)
+	seedExport(t, src)
+
+	var buf bytes.Buffer
+	_, err := src.Export(context.Background(), &buf, nil, boltdb.ExportOptions{Compress: true})
+	require.NoError(t, err)
+
+	dst := newTestStore(t)
+	_, err = dst.Import(context.Background(), &buf, nil, boltdb.ImportOptions{BatchSize: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, boltdbtest.Dump(t, src), boltdbtest.Dump(t, dst))
+}
+
+func TestImportCorrupt(t *testing.T) {
+	src := newTestStore(t)
+	seedExport(t, src)
+
+	var buf bytes.Buffer
+	_, err := src.Export(context.Background(), &buf, []string{"dirs"}, boltdb.ExportOptions{})
+	require.NoError(t, err)
+	export := buf.Bytes()
+
+	dst := newTestStore(t)
+
+	_, err = dst.Import(context.Background(), bytes.NewReader(export[:len(export)/2]), []string{"copy"}, boltdb.ImportOptions{})
+	assert.ErrorIs(t, err, boltdb.ErrExportCorrupt)
+
+	tampered := append([]byte{}, export...)
+	tampered[len(tampered)-10] ^= 0xff
+	_, err = dst.Import(context.Background(), bytes.NewReader(tampered), []string{"copy"}, boltdb.ImportOptions{})
+	assert.ErrorIs(t, err, boltdb.ErrExportCorrupt)
+
+	_, err = dst.Import(context.Background(), bytes.NewReader([]byte("not an export")), []string{"copy"}, boltdb.ImportOptions{})
+	assert.ErrorIs(t, err, boltdb.ErrExportCorrupt)
+
+	// failed imports are rolled back
+	require.NoError(t, dst.View(func(r boltdb.Reader) error {
+		assert.False(t, r.BucketExists([]string{"copy"}))
+		return nil
+	}))
+}
@@ -0,0 +1,92 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+)
+
+// FlightStats reports the reads collapsed by Config.SingleFlightReads, see Store.FlightStats.
+type FlightStats struct {
+	InFlight int    // keys being read on behalf of concurrent reads
+	Shared   uint64 // reads served the value read by a concurrent read of the same key
+}
+
+// FlightStats returns the statistics of the reads collapsed by Config.SingleFlightReads.
+func (s *Store) FlightStats() FlightStats {
+	return s.flights.stats()
+}
+
+// flightGroup collapses the concurrent reads of the same key at the same revision into one read.
+// Groups are allocated apart from the store, to keep their counter 64-bit aligned on 32-bit platforms.
+type flightGroup struct {
+	shared uint64 // accessed atomically
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// do returns the value returned by fn, called once for the concurrent calls of the same key.
+// Calls sharing the value of another call are only served values, and call fn themselves when
+// the shared call failed, so that errors, which sessions decorate, are never shared.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		<-c.done
+		if c.err != nil {
+			return fn()
+		}
+		atomic.AddUint64(&g.shared, 1)
+		return append([]byte{}, c.value...), nil
+	}
+
+	c := &flight{done: make(chan struct{})}
+	if g.calls == nil {
+		g.calls = map[string]*flight{}
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = fn()
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	// every call is served its own copy of the value, which callers may modify
+	return append([]byte{}, c.value...), nil
+}
+
+func (g *flightGroup) stats() FlightStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return FlightStats{InFlight: len(g.calls), Shared: atomic.LoadUint64(&g.shared)}
+}
+
+// flight returns whether the reads of the session are collapsed with those of concurrent read sessions,
+// see Config.SingleFlightReads. Write sessions must observe their own uncommitted writes.
+func (s *Session) flight() bool {
+	return s.store.config.SingleFlightReads && !s.tx.Writable()
+}
+
+// flightKey identifies the reads of key in bucket path at the revision of the session.
+func (s *Session) flightKey(path []string, key []byte) string {
+	var rev [8]byte
+	binary.BigEndian.PutUint64(rev[:], s.revision)
+	return string(rev[:]) + cacheKey(path, key)
+}
@@ -0,0 +1,86 @@
+package boltdb_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedBlobs is a BlobProvider whose reads are blocked until the gate is opened.
+type gatedBlobs struct {
+	boltdb.BlobProvider
+	gate chan struct{}
+	gets int32
+}
+
+func (g *gatedBlobs) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	atomic.AddInt32(&g.gets, 1)
+	<-g.gate
+	return g.BlobProvider.Get(ctx, hash)
+}
+
+func TestSingleFlightReads(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{SingleFlightReads: true})
+
+	dir, err := boltdb.NewDirBlobs(t.TempDir())
+	require.NoError(t, err)
+	blobs := &gatedBlobs{BlobProvider: dir, gate: make(chan struct{})}
+	s.SetBlobProvider(blobs, 16)
+
+	path := []string{"objects"}
+	value := strings.Repeat("popular", 16)
+	writeValue(t, s, path, "doc", value)
+
+	const readers = 8
+
+	var (
+		wg     sync.WaitGroup
+		values [readers][]byte
+	)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.View(func(r boltdb.Reader) error {
+				v, err := r.Read(path, "doc")
+				values[i] = v
+				return err
+			}))
+		}(i)
+	}
+
+	// a single read reaches the blob provider while the others wait for it
+	require.Eventually(t, func() bool {
+		return s.FlightStats().InFlight == 1 && atomic.LoadInt32(&blobs.gets) == 1
+	}, 5*time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	close(blobs.gate)
+	wg.Wait()
+
+	// every read is served its own copy of the value
+	values[0][0] = 'X'
+	for _, v := range values[1:] {
+		assert.Equal(t, value, string(v))
+	}
+
+	stats := s.FlightStats()
+	assert.Zero(t, stats.InFlight)
+	assert.Equal(t, uint64(readers)-uint64(atomic.LoadInt32(&blobs.gets)), stats.Shared)
+	assert.Greater(t, stats.Shared, uint64(0))
+
+	// errors are not shared
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		_, err := r.Read(path, "missing")
+		assert.ErrorIs(t, err, boltdb.ErrKeyNotFound)
+		return nil
+	}))
+}
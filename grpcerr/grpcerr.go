@@ -0,0 +1,151 @@
+// Package grpcerr translates boltdb store errors into gRPC status errors.
+package grpcerr
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the ErrorInfo domain attached to translated errors.
+const Domain = "boltdb.aserto.com"
+
+type mapping struct {
+	err    error
+	code   codes.Code
+	reason string
+}
+
+// mappings is evaluated in order, the first match wins.
+var mappings = []mapping{
+	{boltdb.ErrKeyNotFound, codes.NotFound, "KEY_NOT_FOUND"},
+	{boltdb.ErrPathNotFound, codes.NotFound, "PATH_NOT_FOUND"},
+	{boltdb.ErrKeyExists, codes.AlreadyExists, "KEY_EXISTS"},
+	{boltdb.ErrInvalidPageToken, codes.InvalidArgument, "INVALID_PAGE_TOKEN"},
+	{boltdb.ErrInvalidPath, codes.InvalidArgument, "INVALID_PATH"},
+	{boltdb.ErrNotVersioned, codes.FailedPrecondition, "NOT_VERSIONED"},
+	{boltdb.ErrEtagMismatch, codes.FailedPrecondition, "ETAG_MISMATCH"},
+	{boltdb.ErrNotNumeric, codes.FailedPrecondition, "NOT_NUMERIC"},
+	{boltdb.ErrOverflow, codes.OutOfRange, "OVERFLOW"},
+	{boltdb.ErrNoMergeOperator, codes.FailedPrecondition, "NO_MERGE_OPERATOR"},
+	{boltdb.ErrInvalidPatch, codes.InvalidArgument, "INVALID_PATCH"},
+	{boltdb.ErrInvalidPointer, codes.InvalidArgument, "INVALID_POINTER"},
+	{boltdb.ErrFieldNotFound, codes.NotFound, "FIELD_NOT_FOUND"},
+	{boltdb.ErrInvalidPattern, codes.InvalidArgument, "INVALID_PATTERN"},
+	{boltdb.ErrIndexNotFound, codes.NotFound, "INDEX_NOT_FOUND"},
+	{boltdb.ErrInvalidIndexQuery, codes.InvalidArgument, "INVALID_INDEX_QUERY"},
+	{boltdb.ErrValueCorrupt, codes.DataLoss, "VALUE_CORRUPT"},
+	{boltdb.ErrValidation, codes.InvalidArgument, "VALIDATION_FAILED"},
+	{boltdb.ErrAuditTampered, codes.DataLoss, "AUDIT_TAMPERED"},
+	{boltdb.ErrBackupCorrupt, codes.DataLoss, "BACKUP_CORRUPT"},
+	{boltdb.ErrReadOnly, codes.FailedPrecondition, "READ_ONLY"},
+	{boltdb.ErrStoreNotFound, codes.NotFound, "STORE_NOT_FOUND"},
+	{boltdb.ErrDeadlineExceeded, codes.DeadlineExceeded, "OP_DEADLINE_EXCEEDED"},
+	{boltdb.ErrDatabaseLocked, codes.Unavailable, "DATABASE_LOCKED"},
+	{boltdb.ErrLowDiskSpace, codes.ResourceExhausted, "LOW_DISK_SPACE"},
+	{boltdb.ErrDBSizeExceeded, codes.ResourceExhausted, "DB_SIZE_EXCEEDED"},
+	{boltdb.ErrBlobUnavailable, codes.Unavailable, "BLOB_UNAVAILABLE"},
+	{boltdb.ErrWriterClosed, codes.FailedPrecondition, "WRITER_CLOSED"},
+	{boltdb.ErrSnapshotExpired, codes.FailedPrecondition, "SNAPSHOT_EXPIRED"},
+	{boltdb.ErrUndeclaredPath, codes.InvalidArgument, "UNDECLARED_PATH"},
+	{boltdb.ErrAccessDenied, codes.PermissionDenied, "ACCESS_DENIED"},
+	{boltdb.ErrWriteQueueFull, codes.ResourceExhausted, "WRITE_QUEUE_FULL"},
+	{boltdb.ErrRateLimited, codes.ResourceExhausted, "RATE_LIMITED"},
+	{boltdb.ErrExportCorrupt, codes.DataLoss, "EXPORT_CORRUPT"},
+	{boltdb.ErrChangelogDisabled, codes.FailedPrecondition, "CHANGELOG_DISABLED"},
+	{boltdb.ErrReservedValue, codes.InvalidArgument, "RESERVED_VALUE"},
+	{bolt.ErrBucketExists, codes.AlreadyExists, "BUCKET_EXISTS"},
+	{bolt.ErrTimeout, codes.Aborted, "LOCK_TIMEOUT"},
+	{bolt.ErrDatabaseNotOpen, codes.Unavailable, "DATABASE_NOT_OPEN"},
+	{bolt.ErrTxNotWritable, codes.FailedPrecondition, "TX_NOT_WRITABLE"},
+	{bolt.ErrDatabaseReadOnly, codes.FailedPrecondition, "DATABASE_READ_ONLY"},
+	{bolt.ErrIncompatibleValue, codes.FailedPrecondition, "INCOMPATIBLE_VALUE"},
+	{bolt.ErrBucketNameRequired, codes.InvalidArgument, "BUCKET_NAME_REQUIRED"},
+	{bolt.ErrKeyRequired, codes.InvalidArgument, "KEY_REQUIRED"},
+	{bolt.ErrKeyTooLarge, codes.InvalidArgument, "KEY_TOO_LARGE"},
+	{bolt.ErrValueTooLarge, codes.InvalidArgument, "VALUE_TOO_LARGE"},
+	{context.Canceled, codes.Canceled, "CANCELED"},
+	{context.DeadlineExceeded, codes.DeadlineExceeded, "DEADLINE_EXCEEDED"},
+}
+
+// Code returns the gRPC code for err, codes.OK for nil and codes.Internal for unknown errors.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if m, ok := lookup(err); ok {
+		return m.code
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+	return codes.Internal
+}
+
+// Status converts err into a gRPC status.
+// The status carries an ErrorInfo detail with the operation, bucket path and key
+// when err wraps a *boltdb.StoreError. Errors which already are gRPC status errors
+// are returned unchanged.
+func Status(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	m, ok := lookup(err)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+		m = mapping{code: codes.Internal, reason: "INTERNAL"}
+	}
+
+	st := status.New(m.code, err.Error())
+
+	info := &errdetails.ErrorInfo{
+		Reason: m.reason,
+		Domain: Domain,
+	}
+
+	var se *boltdb.StoreError
+	if errors.As(err, &se) {
+		info.Metadata = map[string]string{}
+		if se.Op != "" {
+			info.Metadata["op"] = se.Op
+		}
+		if se.Path != nil {
+			info.Metadata["path"] = strings.Join(se.Path, "/")
+		}
+		if se.Key != "" {
+			info.Metadata["key"] = se.Key
+		}
+	}
+
+	if withDetails, err := st.WithDetails(info); err == nil {
+		return withDetails
+	}
+
+	return st
+}
+
+// FromError converts err into a gRPC status error, see Status.
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return Status(err).Err()
+}
+
+func lookup(err error) (mapping, bool) {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m, true
+		}
+	}
+	return mapping{}, false
+}
@@ -0,0 +1,41 @@
+package grpcerr_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/grpcerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, codes.OK, grpcerr.Code(nil))
+	assert.Equal(t, codes.NotFound, grpcerr.Code(boltdb.ErrKeyNotFound))
+	assert.Equal(t, codes.NotFound, grpcerr.Code(errors.Wrap(boltdb.ErrPathNotFound, "wrapped")))
+	assert.Equal(t, codes.AlreadyExists, grpcerr.Code(boltdb.ErrKeyExists))
+	assert.Equal(t, codes.Aborted, grpcerr.Code(bolt.ErrTimeout))
+	assert.Equal(t, codes.PermissionDenied, grpcerr.Code(status.Error(codes.PermissionDenied, "denied")))
+	assert.Equal(t, codes.Internal, grpcerr.Code(errors.New("boom")))
+}
+
+func TestStatusDetails(t *testing.T) {
+	err := &boltdb.StoreError{Op: "Read", Path: []string{"a", "b"}, Key: "k", Err: boltdb.ErrKeyNotFound}
+
+	st := grpcerr.Status(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "KEY_NOT_FOUND", info.Reason)
+	assert.Equal(t, grpcerr.Domain, info.Domain)
+	assert.Equal(t, map[string]string{"op": "Read", "path": "a/b", "key": "k"}, info.Metadata)
+
+	assert.NoError(t, grpcerr.FromError(nil))
+}
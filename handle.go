@@ -0,0 +1,184 @@
+package boltdb
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// BucketHandle is a bucket resolved once for the life of a session, so repeated operations
+// on a deep bucket path do not walk the path again.
+// A handle is only valid within the session which returned it.
+type BucketHandle struct {
+	session *Session
+	path    []string
+	bucket  *bolt.Bucket
+	epoch   uint64
+}
+
+// Bucket returns a handle on the existing bucket at path.
+// Returns ErrPathNotFound when the bucket does not exist.
+func (s *Session) Bucket(path []string) (*BucketHandle, error) {
+	s.store.logger.Trace("Session::Bucket", "path", path)
+
+	h := &BucketHandle{session: s, path: append([]string{}, path...)}
+
+	resolve := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		_, err := h.resolve()
+		return err
+	}
+
+	err := s.intercept(newOp("Bucket", path, ""), func() error { return s.view(resolve) })
+	if err != nil {
+		return nil, wrapError("Bucket", path, "", err)
+	}
+
+	return h, nil
+}
+
+// Path returns the bucket path of the handle.
+func (h *BucketHandle) Path() []string {
+	return h.path
+}
+
+// Read returns the value of key.
+func (h *BucketHandle) Read(key string) ([]byte, error) {
+	s := h.session
+	s.store.logger.Trace("BucketHandle::Read", "path", h.path, "key", key)
+
+	var result []byte
+
+	read := func(tx *bolt.Tx) error {
+		if v, ok := s.cacheGet(h.path, []byte(key)); ok {
+			result = v
+			return nil
+		}
+
+		b, err := h.resolve()
+		if err != nil {
+			return err
+		}
+
+		result = b.Get([]byte(key))
+		if result == nil {
+			return ErrKeyNotFound
+		}
+
+		if err := s.verifyChecksum(h.path, []byte(key), result); err != nil {
+			return err
+		}
+
+		if result, err = s.resolveRef(result); err != nil {
+			return err
+		}
+
+		s.cacheAdd(h.path, []byte(key), result)
+
+		return nil
+	}
+
+	op := newOp("Read", h.path, key)
+	err := s.intercept(op, func() error {
+		err := s.view(read)
+		op.Value = result
+		return err
+	})
+
+	return op.Value, wrapError("Read", h.path, key, err)
+}
+
+// Write writes value for key.
+func (h *BucketHandle) Write(key string, value []byte) error {
+	s := h.session
+	s.store.logger.Trace("BucketHandle::Write", "path", h.path, "key", key)
+
+	op := newOp("Write", h.path, key)
+	op.Value = value
+
+	write := func(tx *bolt.Tx) error {
+		b, err := h.resolve()
+		if err != nil {
+			return err
+		}
+
+		if err := checkValue(op.Value); err != nil {
+			return err
+		}
+
+		s.journalKey(h.path, []byte(key))
+
+		return s.putBucket(b, h.path, []byte(key), op.Value)
+	}
+
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("Write", h.path, key, err)
+}
+
+// List returns a paged collection of the keys, and their values, of the bucket.
+func (h *BucketHandle) List(pageToken string) ([]string, [][]byte, string, error) {
+	s := h.session
+	s.store.logger.Trace("BucketHandle::List", "path", h.path, "pageToken", pageToken)
+
+	var (
+		keys      = make([]string, 0)
+		values    = make([][]byte, 0)
+		nextToken string
+	)
+
+	list := func(tx *bolt.Tx) error {
+		b, err := h.resolve()
+		if err != nil {
+			return err
+		}
+
+		var corrupt error
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			if v == nil {
+				return false // nested bucket
+			}
+			if corrupt == nil {
+				corrupt = s.verifyChecksum(h.path, k, v)
+			}
+			value, err := s.listedValue(v)
+			if corrupt == nil {
+				corrupt = err
+			}
+			keys = append(keys, string(k))
+			values = append(values, value)
+			return true
+		})
+		if err == nil {
+			err = corrupt
+		}
+
+		return err
+	}
+
+	err := s.intercept(newOp("List", h.path, ""), func() error { return s.view(list) })
+
+	if err != nil {
+		return []string{}, [][]byte{}, "", wrapError("List", h.path, "", err)
+	}
+
+	return keys, values, nextToken, nil
+}
+
+// resolve returns the bucket of the handle, resolving it again when buckets of the session
+// have been deleted since it was last resolved.
+func (h *BucketHandle) resolve() (*bolt.Bucket, error) {
+	if h.bucket != nil && h.epoch == h.session.bucketEpoch {
+		return h.bucket, nil
+	}
+
+	b, err := h.session.setBucket(h.path)
+	if err != nil {
+		h.bucket = nil
+		return nil, err
+	}
+
+	h.bucket, h.epoch = b, h.session.bucketEpoch
+
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketHandle(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{VersionedPaths: [][]string{{"objects"}}})
+
+	path := []string{"objects", "tenants", "acme", "users"}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	_, err = session.Bucket(path)
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+
+	require.NoError(t, session.CreateBucket(path))
+
+	h, err := session.Bucket(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, h.Path())
+
+	require.NoError(t, h.Write("u1", []byte("v1")))
+	require.NoError(t, h.Write("u2", []byte("v2")))
+
+	value, err := h.Read("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+
+	// the handle survives the bucket being truncated, which recreates it
+	require.NoError(t, session.TruncateBucket(path))
+	_, err = h.Read("u1")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+	require.NoError(t, h.Write("u3", []byte("v3")))
+
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	h, err = session.Bucket(path)
+	require.NoError(t, err)
+
+	keys, values, next, err := h.List("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, keys)
+	assert.Equal(t, [][]byte{[]byte("v3")}, values)
+	assert.Empty(t, next)
+
+	history, err := session.History(path, "u1", 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.True(t, history[0].Deleted)
+}
+
+func TestBucketHandleDeleted(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+	write(t, s, path, "k1")
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	defer closer()
+
+	h, err := session.Bucket(path)
+	require.NoError(t, err)
+
+	require.NoError(t, session.DeleteBucket(path))
+
+	_, err = h.Read("k1")
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+}
@@ -0,0 +1,51 @@
+package boltdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// HealthCheck checks the store is able to serve sessions: it runs a read transaction, checks a file
+// can be created next to the database file, and checks the disk guardrails, failing with ErrDBSizeExceeded
+// or ErrLowDiskSpace when one is breached, see Store.DiskStats.
+func (s *Store) HealthCheck(ctx context.Context) error {
+	db := s.database()
+	if db == nil {
+		return bolt.ErrDatabaseNotOpen
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := db.View(func(tx *bolt.Tx) error {
+		_ = readRevision(tx)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "read transaction failed")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.config.DBPath)
+	f, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return errors.Wrapf(err, "directory '%s' not writable", dir)
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return errors.Wrapf(err, "directory '%s' not writable", dir)
+	}
+
+	if s.diskGuarded() {
+		return s.checkDisk()
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package health reports the health of boltdb stores to gRPC health clients and HTTP probes.
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchInterval is the delay between two checks of the Watch streams of a Server.
+const DefaultWatchInterval = 5 * time.Second
+
+// Checker checks the health of a store, implemented by *boltdb.Store.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Server implements grpc_health_v1.HealthServer, reporting a service as serving while its checker succeeds.
+type Server struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	service  string
+	checker  Checker
+	interval time.Duration
+}
+
+var _ grpc_health_v1.HealthServer = (*Server)(nil)
+
+// NewServer returns a health server reporting the health of checker for service, and for the
+// empty service name denoting the server overall. Watch streams check checker every interval,
+// or every DefaultWatchInterval when interval is zero.
+func NewServer(service string, checker Checker, interval time.Duration) *Server {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	return &Server{service: service, checker: checker, interval: interval}
+}
+
+// Check returns the serving status of the requested service.
+func (s *Server) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() != "" && req.GetService() != s.service {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: s.status(ctx)}, nil
+}
+
+// Watch streams the serving status of the requested service whenever it changes.
+// Unknown services are reported as SERVICE_UNKNOWN, as required by the health protocol.
+func (s *Server) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+
+	if req.GetService() != "" && req.GetService() != s.service {
+		resp := &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for {
+		if current := s.status(ctx); current != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) status(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if err := s.checker.HealthCheck(ctx); err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+// Handler returns an HTTP handler for readiness probes, responding 200 while checker succeeds,
+// and 503 along with the error otherwise. Checks are bound by the context of the request.
+func Handler(checker Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if err := checker.HealthCheck(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(err.Error() + "\n"))
+			return
+		}
+
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
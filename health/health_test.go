@@ -0,0 +1,102 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type fakeChecker struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (c *fakeChecker) HealthCheck(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *fakeChecker) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (s *watchStream) Context() context.Context { return s.ctx }
+
+func (s *watchStream) Send(resp *grpc_health_v1.HealthCheckResponse) error {
+	s.sent <- resp.Status
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	checker := &fakeChecker{}
+	srv := health.NewServer("store", checker, 0)
+
+	for _, service := range []string{"", "store"} {
+		resp, err := srv.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	checker.fail(errors.New("down"))
+	resp, err := srv.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "store"})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	_, err = srv.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "other"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWatch(t *testing.T) {
+	checker := &fakeChecker{}
+	srv := health.NewServer("store", checker, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 8)}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Watch(&grpc_health_v1.HealthCheckRequest{Service: "store"}, stream) }()
+
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, <-stream.sent)
+
+	checker.fail(errors.New("down"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, <-stream.sent)
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+}
+
+func TestHandler(t *testing.T) {
+	checker := &fakeChecker{}
+	handler := health.Handler(checker)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	checker.fail(errors.New("down"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "down")
+}
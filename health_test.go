@@ -0,0 +1,30 @@
+package boltdb_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestHealthCheck(t *testing.T) {
+	s := newTestStore(t)
+
+	assert.NoError(t, s.HealthCheck(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, s.HealthCheck(ctx), context.Canceled)
+
+	s.Close()
+	assert.ErrorIs(t, s.HealthCheck(context.Background()), bolt.ErrDatabaseNotOpen)
+}
+
+func TestHealthCheckFreeSpace(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{MinFreeSpace: math.MaxUint64})
+
+	assert.ErrorIs(t, s.HealthCheck(context.Background()), boltdb.ErrLowDiskSpace)
+}
@@ -0,0 +1,124 @@
+package boltdb
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// keyDelimiters delimit the tokens of the keys grouped by Session.KeyHistogram, other than composite keys.
+const keyDelimiters = ":/|"
+
+// maxKeyFamilies is the number of families reported by Session.KeyHistogram, the keys of further families
+// being counted in KeyHistogram.Other.
+const maxKeyFamilies = 1000
+
+// KeyHistogram reports the keys of a bucket grouped by prefix, see Session.KeyHistogram.
+type KeyHistogram struct {
+	Keys     int         // keys of the bucket, those of nested buckets excluded
+	Families []KeyFamily // families of keys, by decreasing number of keys then by prefix
+	Other    KeyFamily   // keys of the families beyond maxKeyFamilies, with an empty prefix
+}
+
+// KeyFamily reports the keys of a bucket sharing a prefix.
+type KeyFamily struct {
+	Prefix string
+	Keys   int
+	Size   int64 // bytes of the keys and of their values as stored
+}
+
+// KeyHistogram returns the number of keys of bucket path grouped by their first prefixDepth tokens, in a single
+// walk of its keys, to identify hot or skewed families of keys in oversized buckets. Composite keys, see
+// keys.Join, are grouped by their first segments, the prefix being that of keys.Prefix, other keys by the
+// tokens delimited by ':', '/' or '|', the prefix including the last delimiter. Keys with fewer tokens are
+// their own family. Returns ErrPathNotFound when the bucket path does not exist.
+func (s *Session) KeyHistogram(path []string, prefixDepth int) (*KeyHistogram, error) {
+	s.store.logger.Trace("Session::KeyHistogram", "path", path, "depth", prefixDepth)
+
+	var histogram *KeyHistogram
+
+	count := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		if prefixDepth <= 0 {
+			return errors.Errorf("invalid prefix depth %d", prefixDepth)
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		histogram = &KeyHistogram{}
+		families := map[string]*KeyFamily{}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
+			histogram.Keys++
+
+			prefix := keyFamily(k, prefixDepth)
+
+			family := &histogram.Other
+			if f, ok := families[string(prefix)]; ok {
+				family = f
+			} else if len(families) < maxKeyFamilies {
+				family = &KeyFamily{Prefix: string(prefix)}
+				families[family.Prefix] = family
+			}
+			family.Keys++
+			family.Size += int64(len(k) + len(v))
+		}
+
+		for _, f := range families {
+			histogram.Families = append(histogram.Families, *f)
+		}
+		sort.Slice(histogram.Families, func(i, j int) bool {
+			fi, fj := &histogram.Families[i], &histogram.Families[j]
+			if fi.Keys != fj.Keys {
+				return fi.Keys > fj.Keys
+			}
+			return fi.Prefix < fj.Prefix
+		})
+
+		return nil
+	}
+
+	err := s.intercept(newOp("KeyHistogram", path, ""), func() error { return s.view(count) })
+
+	if err != nil {
+		s.store.logger.Trace("KeyHistogram", "error", err)
+		return nil, wrapError("KeyHistogram", path, "", err)
+	}
+
+	return histogram, nil
+}
+
+// keyFamily returns the prefix of key made of its first depth tokens, or key when it has fewer tokens.
+func keyFamily(key []byte, depth int) []byte {
+	if segments, err := keys.Split(key); err == nil && len(segments) > 0 {
+		if len(segments) > depth {
+			segments = segments[:depth]
+		}
+		return keys.Prefix(segments...)
+	}
+
+	for i, c := range key {
+		if bytes.IndexByte([]byte(keyDelimiters), c) < 0 {
+			continue
+		}
+		if depth--; depth == 0 {
+			return key[:i+1]
+		}
+	}
+	return key
+}
@@ -0,0 +1,87 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyHistogram(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"objects"}
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 6; i++ {
+			if err := w.Write(path, fmt.Sprintf("user:%d:profile", i), []byte("v")); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if err := w.Write(path, fmt.Sprintf("group/%d", i), []byte("v")); err != nil {
+				return err
+			}
+		}
+		if err := w.Write(path, "user:0:settings", []byte("v")); err != nil {
+			return err
+		}
+		if err := w.Write(path, "flat", []byte("v")); err != nil {
+			return err
+		}
+		return w.Write([]string{"objects", "nested"}, "user:x", []byte("v"))
+	}))
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		histogram, err := r.KeyHistogram(path, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 11, histogram.Keys)
+		assert.Equal(t, []boltdb.KeyFamily{
+			{Prefix: "user:", Keys: 7, Size: int64(6*len("user:0:profile") + len("user:0:settings") + 7)},
+			{Prefix: "group/", Keys: 3, Size: int64(3*len("group/0") + 3)},
+			{Prefix: "flat", Keys: 1, Size: int64(len("flat") + 1)},
+		}, histogram.Families)
+		assert.Zero(t, histogram.Other.Keys)
+
+		histogram, err = r.KeyHistogram(path, 2)
+		require.NoError(t, err)
+		require.Len(t, histogram.Families, 10)
+		assert.Equal(t, "user:0:", histogram.Families[0].Prefix)
+		assert.Equal(t, 2, histogram.Families[0].Keys)
+
+		_, err = r.KeyHistogram(path, 0)
+		assert.Error(t, err)
+
+		_, err = r.KeyHistogram([]string{"missing"}, 1)
+		assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+		return nil
+	}))
+}
+
+func TestKeyHistogramComposite(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"composite"}
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for _, tenant := range []string{"a", "b"} {
+			for i := 0; i < 3; i++ {
+				if err := w.WriteB(path, keys.JoinStrings(tenant, fmt.Sprint(i)), []byte("v")); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		histogram, err := r.KeyHistogram(path, 1)
+		require.NoError(t, err)
+		require.Len(t, histogram.Families, 2)
+		assert.Equal(t, string(keys.Prefix([]byte("a"))), histogram.Families[0].Prefix)
+		assert.Equal(t, 3, histogram.Families[0].Keys)
+		assert.Equal(t, string(keys.Prefix([]byte("b"))), histogram.Families[1].Prefix)
+		return nil
+	}))
+}
@@ -0,0 +1,465 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const indexBucket = "index"
+
+// IndexField extracts the order preserving encoding of a field of a value, see the keys package.
+// It returns false when the value does not have the field, in which case the value is not indexed.
+type IndexField func(value []byte) ([]byte, bool)
+
+// Index is a secondary index over the values of the keys below a bucket path.
+// Entries are ordered by their fields, in order, so an index supports range queries on a field
+// once the values of all the fields before it are fixed.
+type Index struct {
+	Name   string       // unique index name
+	Path   []string     // bucket path prefix of the indexed keys, including nested buckets
+	Fields []IndexField // indexed fields, in order
+
+	tokenize func(value []byte) [][]byte // inverted index tokenizer replacing Fields, see TokenIndex
+}
+
+// IndexQuery selects the entries of an index.
+type IndexQuery struct {
+	Equal [][]byte // encoded values of the leading fields
+	From  []byte   // inclusive lower bound of the encoded value of the next field, nil for unbounded
+	To    []byte   // exclusive upper bound of the encoded value of the next field, nil for unbounded
+}
+
+// IndexEntry identifies a key matching an index query.
+type IndexEntry struct {
+	Path []string
+	Key  string
+}
+
+// StringField indexes the JSON string at pointer, ordered byte-wise.
+func StringField(pointer string) IndexField {
+	return func(value []byte) ([]byte, bool) {
+		var s string
+		if !extractField(value, pointer, &s) {
+			return nil, false
+		}
+		return []byte(s), true
+	}
+}
+
+// IntField indexes the JSON integer at pointer, ordered numerically.
+func IntField(pointer string) IndexField {
+	return func(value []byte) ([]byte, bool) {
+		var n int64
+		if !extractField(value, pointer, &n) {
+			return nil, false
+		}
+		return keys.Int64(n), true
+	}
+}
+
+// TimeField indexes the RFC 3339 JSON timestamp at pointer, ordered chronologically.
+func TimeField(pointer string) IndexField {
+	return func(value []byte) ([]byte, bool) {
+		var t time.Time
+		if !extractField(value, pointer, &t) {
+			return nil, false
+		}
+		return keys.Time(t), true
+	}
+}
+
+func extractField(value []byte, pointer string, v interface{}) bool {
+	raw, err := resolvePointer(value, pointer)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// RegisterIndex adds idx to the indexes maintained by write sessions.
+// Keys written before the index was registered are only indexed once Session.RebuildIndex is called.
+func (s *Store) RegisterIndex(idx Index) error {
+	if err := idx.check(); err != nil {
+		return err
+	}
+	return s.indexes.add(idx)
+}
+
+// check verifies the name, fields and path of the index.
+func (idx *Index) check() error {
+	if idx.Name == "" || (len(idx.Fields) == 0 && idx.tokenize == nil) {
+		return errors.New("index requires a name and at least one field")
+	}
+	if err := Path(idx.Path).Validate(); err != nil {
+		return errors.Wrapf(err, "index %s", idx.Name)
+	}
+	return nil
+}
+
+// QueryIndex returns a paged collection of the keys whose index entries match q.
+func (s *Session) QueryIndex(name string, q IndexQuery, pageToken string) ([]IndexEntry, string, error) {
+	s.store.logger.Trace("Session::QueryIndex", "index", name)
+
+	var (
+		entries   = make([]IndexEntry, 0)
+		nextToken string
+	)
+
+	query := func(tx *bolt.Tx) error {
+		idx, ok := s.store.indexes.get(name)
+		if !ok {
+			return errors.Wrapf(ErrIndexNotFound, "%s", name)
+		}
+		ranged := q.From != nil || q.To != nil
+		if len(q.Equal) > idx.width() || (ranged && len(q.Equal) == idx.width()) {
+			return errors.Wrapf(ErrInvalidIndexQuery, "index %s has %d fields", name, idx.width())
+		}
+
+		start, after, err := s.store.tokens.decode(pageToken)
+		if err != nil {
+			return err
+		}
+
+		lower, upper := q.bounds()
+		if start == nil || bytes.Compare(start, lower) < 0 {
+			start, after = lower, false
+		}
+
+		b, err := s.setBucket(idx.bucket())
+		if err != nil {
+			return nil
+		}
+
+		var last []byte
+
+		c := b.Cursor()
+		k, _ := seekPage(c, start, after)
+		for ; k != nil && (upper == nil || bytes.Compare(k, upper) < 0); k, _ = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if int32(len(entries)) == pageSize {
+				nextToken = s.store.tokens.encode(last)
+				break
+			}
+			last = k
+
+			entry, err := idx.decode(k)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("QueryIndex", nil, name), func() error { return s.view(query) })
+
+	return entries, nextToken, wrapError("QueryIndex", nil, name, err)
+}
+
+// RebuildIndex recreates the entries of the index name from the keys below its path.
+func (s *Session) RebuildIndex(name string) error {
+	s.store.logger.Trace("Session::RebuildIndex", "index", name)
+
+	rebuild := func(tx *bolt.Tx) error {
+		idx, ok := s.store.indexes.get(name)
+		if !ok {
+			return errors.Wrapf(ErrIndexNotFound, "%s", name)
+		}
+
+		if err := s.deleteShadowBucket(indexBucket, []string{idx.Name}); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(idx.Path)
+		if err != nil {
+			return nil
+		}
+
+		return s.applyIndexEntries(s.collectIndexEntries(b, idx.Path, true, []*Index{idx}), true)
+	}
+
+	err := s.intercept(newOp("RebuildIndex", nil, name), func() error { return s.update(rebuild) })
+
+	return wrapError("RebuildIndex", nil, name, err)
+}
+
+// updateIndexes replaces the entries of key in path computed from old by those computed from value
+// in indexes. Either value may be nil.
+func (s *Session) updateIndexes(indexes []*Index, path []string, key, old, value []byte) error {
+	for _, idx := range indexes {
+		if old != nil {
+			for _, entry := range idx.entries(path, key, old) {
+				if err := s.setIndexEntry(idx, entry, false); err != nil {
+					return err
+				}
+			}
+		}
+		if value != nil {
+			for _, entry := range idx.entries(path, key, value) {
+				if err := s.setIndexEntry(idx, entry, true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// unindexBucket removes the index entries of the keys below bucket b at path.
+func (s *Session) unindexBucket(b *bolt.Bucket, path []string, recursive bool) error {
+	if !s.store.indexes.overlapping(path) {
+		return nil
+	}
+	return s.applyIndexEntries(s.collectIndexEntries(b, path, recursive, nil), false)
+}
+
+// indexedEntry is the entry of a key in an index.
+type indexedEntry struct {
+	idx   *Index
+	entry []byte
+}
+
+// collectIndexEntries returns the entries of the keys below bucket b at path in indexes,
+// or in the registered indexes covering them when indexes is nil.
+func (s *Session) collectIndexEntries(b *bolt.Bucket, path []string, recursive bool, indexes []*Index) []indexedEntry {
+	var entries []indexedEntry
+
+	_ = walkBucket(b, path, func(p []string, k, v []byte) error {
+		if !recursive && len(p) != len(path) {
+			return nil
+		}
+
+		matching := indexes
+		if matching == nil {
+			matching = s.store.indexes.matching(p)
+		}
+		if len(matching) == 0 {
+			return nil
+		}
+
+		// streamed values are indexed by their content
+		v, err := s.resolveRef(v)
+		if err != nil {
+			return nil
+		}
+
+		for _, idx := range matching {
+			for _, entry := range idx.entries(p, k, v) {
+				entries = append(entries, indexedEntry{idx: idx, entry: entry})
+			}
+		}
+		return nil
+	})
+
+	return entries
+}
+
+// applyIndexEntries adds, or removes, entries to their index.
+func (s *Session) applyIndexEntries(entries []indexedEntry, add bool) error {
+	for _, e := range entries {
+		if err := s.setIndexEntry(e.idx, e.entry, add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setIndexEntry adds, or removes, entry to idx.
+func (s *Session) setIndexEntry(idx *Index, entry []byte, add bool) error {
+	ipath := idx.bucket()
+	s.journalKey(ipath, entry)
+
+	b, err := s.setBucketIfNotExist(ipath)
+	if err != nil {
+		return err
+	}
+
+	if add {
+		return b.Put(entry, []byte{})
+	}
+	return b.Delete(entry)
+}
+
+func (idx *Index) bucket() []string {
+	return shadowPath(indexBucket, []string{idx.Name})
+}
+
+// entries returns the index entries of key in path with value: the encoded fields, or a token
+// of the value for inverted indexes, followed by the path segments and key.
+func (idx *Index) entries(path []string, key, value []byte) [][]byte {
+	var leads [][][]byte
+
+	if idx.tokenize != nil {
+		for _, token := range idx.tokenize(value) {
+			leads = append(leads, [][]byte{token})
+		}
+	} else {
+		fields := make([][]byte, 0, len(idx.Fields))
+		for _, field := range idx.Fields {
+			v, ok := field(value)
+			if !ok {
+				return nil
+			}
+			fields = append(fields, v)
+		}
+		leads = append(leads, fields)
+	}
+
+	entries := make([][]byte, 0, len(leads))
+	for _, lead := range leads {
+		segments := append([][]byte{}, lead...)
+		for _, p := range path {
+			segments = append(segments, []byte(p))
+		}
+		segments = append(segments, key)
+		entries = append(entries, keys.Join(segments...))
+	}
+
+	return entries
+}
+
+// width returns the number of leading segments of the entries of idx.
+func (idx *Index) width() int {
+	if idx.tokenize != nil {
+		return 1
+	}
+	return len(idx.Fields)
+}
+
+func (idx *Index) decode(entry []byte) (IndexEntry, error) {
+	segments, err := keys.SplitStrings(entry)
+	if err != nil {
+		return IndexEntry{}, err
+	}
+	if len(segments) < idx.width()+len(idx.Path)+1 {
+		return IndexEntry{}, errors.Wrapf(keys.ErrInvalidKey, "index %s entry", idx.Name)
+	}
+
+	return IndexEntry{
+		Path: segments[idx.width() : len(segments)-1],
+		Key:  segments[len(segments)-1],
+	}, nil
+}
+
+// bounds returns the inclusive lower and exclusive upper bound of the entries matching q,
+// a nil upper bound is unbounded.
+func (q IndexQuery) bounds() ([]byte, []byte) {
+	prefix := keys.Join(q.Equal...)
+
+	lower := prefix
+	if q.From != nil {
+		lower = append(append([]byte{}, prefix...), keys.Join(q.From)...)
+	}
+
+	var upper []byte
+	switch {
+	case q.To != nil:
+		upper = append(append([]byte{}, prefix...), keys.Join(q.To)...)
+	case len(prefix) > 0:
+		// first key past all entries sharing prefix
+		upper = append([]byte{}, prefix...)
+		upper[len(upper)-1]++
+	}
+
+	return lower, upper
+}
+
+// indexSet holds the registered indexes.
+type indexSet struct {
+	mu      sync.RWMutex
+	indexes []*Index
+}
+
+// add registers indexes, none of them when the name of one is already registered.
+func (is *indexSet) add(indexes ...Index) error {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	names := map[string]bool{}
+	for _, i := range is.indexes {
+		names[i.Name] = true
+	}
+	for _, idx := range indexes {
+		if names[idx.Name] {
+			return errors.Errorf("index %s already registered", idx.Name)
+		}
+		names[idx.Name] = true
+	}
+
+	for i := range indexes {
+		idx := indexes[i]
+		idx.Path = append([]string{}, idx.Path...)
+		is.indexes = append(is.indexes, &idx)
+	}
+
+	return nil
+}
+
+func (is *indexSet) all() []*Index {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	return append([]*Index{}, is.indexes...)
+}
+
+func (is *indexSet) get(name string) (*Index, bool) {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	for _, idx := range is.indexes {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return nil, false
+}
+
+// matching returns the indexes covering the keys of bucket path.
+func (is *indexSet) matching(path []string) []*Index {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	var result []*Index
+	for _, idx := range is.indexes {
+		if hasPathPrefix(path, idx.Path) {
+			result = append(result, idx)
+		}
+	}
+	return result
+}
+
+// tokenIndex returns the token index with the longest path covering the keys of bucket path.
+func (is *indexSet) tokenIndex(path []string) *Index {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	var match *Index
+	for _, idx := range is.indexes {
+		if idx.tokenize != nil && hasPathPrefix(path, idx.Path) && (match == nil || len(idx.Path) > len(match.Path)) {
+			match = idx
+		}
+	}
+	return match
+}
+
+// overlapping reports whether any index covers keys of bucket path or of its nested buckets.
+func (is *indexSet) overlapping(path []string) bool {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	for _, idx := range is.indexes {
+		if hasPathPrefix(path, idx.Path) || hasPathPrefix(idx.Path, path) {
+			return true
+		}
+	}
+	return false
+}
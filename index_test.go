@@ -0,0 +1,165 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var day = time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func writeObject(t *testing.T, s *boltdb.Store, path []string, key, typ string, created time.Time) {
+	writeValue(t, s, path, key, fmt.Sprintf(`{"type":%q,"created":%q}`, typ, created.Format(time.RFC3339)))
+}
+
+func queryIndex(t *testing.T, s *boltdb.Store, name string, q boltdb.IndexQuery) []string {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	entries, next, err := session.QueryIndex(name, q, "")
+	require.NoError(t, err)
+	assert.Empty(t, next)
+
+	result := []string{}
+	for _, e := range entries {
+		result = append(result, boltdb.Path(e.Path).String()+":"+e.Key)
+	}
+	return result
+}
+
+func TestCompositeIndex(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-type-created",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type"), boltdb.TimeField("/created")},
+	}))
+
+	users := []string{"objects", "users"}
+	groups := []string{"objects", "groups"}
+
+	writeObject(t, s, users, "u1", "user", day)
+	writeObject(t, s, users, "u2", "user", day.Add(48*time.Hour))
+	writeObject(t, s, users, "u3", "user", day.Add(24*time.Hour))
+	writeObject(t, s, groups, "g1", "group", day.Add(24*time.Hour))
+	writeValue(t, s, users, "raw", "not json")
+
+	between := boltdb.IndexQuery{
+		Equal: [][]byte{[]byte("user")},
+		From:  keys.Time(day.Add(time.Hour)),
+		To:    keys.Time(day.Add(72 * time.Hour)),
+	}
+	assert.Equal(t, []string{"objects/users:u3", "objects/users:u2"}, queryIndex(t, s, "by-type-created", between))
+
+	all := queryIndex(t, s, "by-type-created", boltdb.IndexQuery{})
+	assert.Equal(t, []string{"objects/groups:g1", "objects/users:u1", "objects/users:u3", "objects/users:u2"}, all)
+
+	// update moves the entry, delete removes it
+	writeObject(t, s, users, "u3", "user", day)
+	deleteKey(t, s, users, "u2")
+	assert.Empty(t, queryIndex(t, s, "by-type-created", between))
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket(groups))
+	closer()
+
+	users2 := queryIndex(t, s, "by-type-created", boltdb.IndexQuery{Equal: [][]byte{[]byte("user")}})
+	assert.Equal(t, []string{"objects/users:u1", "objects/users:u3"}, users2)
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, _, err = session.QueryIndex("missing", boltdb.IndexQuery{}, "")
+	assert.True(t, errors.Is(err, boltdb.ErrIndexNotFound))
+
+	_, _, err = session.QueryIndex("by-type-created", boltdb.IndexQuery{
+		Equal: [][]byte{[]byte("user"), keys.Time(day)},
+		From:  []byte("x"),
+	}, "")
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidIndexQuery))
+}
+
+func TestRebuildIndex(t *testing.T) {
+	s := newTestStore(t)
+
+	writeObject(t, s, []string{"objects"}, "o1", "user", day)
+
+	require.NoError(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-type",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type")},
+	}))
+	assert.Error(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-type",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type")},
+	}))
+
+	q := boltdb.IndexQuery{Equal: [][]byte{[]byte("user")}}
+	assert.Empty(t, queryIndex(t, s, "by-type", q))
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.RebuildIndex("by-type"))
+	closer()
+
+	assert.Equal(t, []string{"objects:o1"}, queryIndex(t, s, "by-type", q))
+
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.TruncateBucket([]string{"objects"}))
+	closer()
+
+	assert.Empty(t, queryIndex(t, s, "by-type", q))
+}
+
+func TestIndexPagination(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-rank",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.IntField("/rank")},
+	}))
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	for i := 0; i < 250; i++ {
+		require.NoError(t, session.Write([]string{"objects"}, fmt.Sprintf("k%03d", i), []byte(fmt.Sprintf(`{"rank":%d}`, 1000-i))))
+	}
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	var (
+		result []string
+		token  string
+	)
+	for {
+		entries, next, err := session.QueryIndex("by-rank", boltdb.IndexQuery{From: keys.Int64(800)}, token)
+		require.NoError(t, err)
+		for _, e := range entries {
+			result = append(result, e.Key)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	require.Len(t, result, 201)
+	assert.Equal(t, "k200", result[0])
+	assert.Equal(t, "k000", result[200])
+}
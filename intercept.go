@@ -0,0 +1,79 @@
+package boltdb
+
+import "time"
+
+// Op describes a session operation passed through the interceptors registered with Store.Use.
+type Op struct {
+	Name    string   // operation, e.g. "Read" or "DeleteBucket"
+	Path    []string // bucket path, nil for operations on indexes
+	Key     string   // key, key prefix or index name, empty for bucket operations
+	Session *Session // session running the operation
+	Target  []string // destination bucket path of CopyBucket, MoveBucket and MoveKeyAcross, nil otherwise
+
+	// Value is the value written by Write and the conditional writes, which interceptors may
+	// replace before calling the next handler, and the value returned by Read and ReadWithETag,
+	// which interceptors may replace after the next handler returned. It is nil for other operations,
+	// including those reading or writing several values, e.g. List, ListEntries, ReadScan, ScanB,
+	// ScanMatch, Sample and WriteMany, whose values interceptors can neither observe nor replace:
+	// interceptors transforming values, e.g. encrypting them, must reject these operations.
+	Value []byte
+
+	keys int // keys written, charged against the rate limits, one when zero
+	size int // bytes written, charged against the rate limits, the length of Value when zero
+}
+
+// OpHandler runs a session operation.
+type OpHandler func(op *Op) error
+
+// Interceptor wraps the handler of session operations, e.g. to audit, measure, validate or
+// transform them. An interceptor calls next to run the operation, or returns an error instead
+// to reject it. Session operations called by an interceptor are intercepted as well.
+type Interceptor func(next OpHandler) OpHandler
+
+// Use adds interceptors to the chain wrapping every session operation.
+// Interceptors run in the order they were added, the first one added being the outermost.
+func (s *Store) Use(interceptors ...Interceptor) {
+	s.interceptorsMu.Lock()
+	defer s.interceptorsMu.Unlock()
+
+	s.interceptors = append(s.interceptors, interceptors...)
+}
+
+func newOp(name string, path []string, key string) *Op {
+	return &Op{Name: name, Path: path, Key: key}
+}
+
+// intercept runs fn, the implementation of op, through the interceptor chain.
+func (s *Session) intercept(op *Op, fn func() error) error {
+	if err := s.authorize(op); err != nil {
+		return err
+	}
+
+	// waiting for the rate limits counts against the deadline of the operation
+	defer s.startDeadline()()
+
+	if err := s.rateLimit(op); err != nil {
+		return err
+	}
+
+	if s.store.config.SlowOpThreshold > 0 {
+		defer s.observeOp(op, time.Now())
+	}
+
+	s.store.interceptorsMu.RLock()
+	interceptors := s.store.interceptors
+	s.store.interceptorsMu.RUnlock()
+
+	if len(interceptors) == 0 {
+		return fn()
+	}
+
+	op.Session = s
+
+	h := func(*Op) error { return fn() }
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		h = interceptors[i](h)
+	}
+
+	return h(op)
+}
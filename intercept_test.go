@@ -0,0 +1,101 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	store := newTestStore(t)
+
+	var calls []string
+	trace := func(name string) boltdb.Interceptor {
+		return func(next boltdb.OpHandler) boltdb.OpHandler {
+			return func(op *boltdb.Op) error {
+				calls = append(calls, name+">"+op.Name+":"+boltdb.Path(op.Path).String()+":"+op.Key)
+				err := next(op)
+				calls = append(calls, name+"<"+op.Name)
+				return err
+			}
+		}
+	}
+	store.Use(trace("outer"), trace("inner"))
+
+	write(t, store, []string{"a"}, "k")
+	assert.Equal(t, []string{"outer>Write:a:k", "inner>Write:a:k", "inner<Write", "outer<Write"}, calls)
+
+	calls = nil
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket([]string{"a"}))
+	assert.False(t, session.BucketExists([]string{"a"}))
+	closer()
+	assert.Equal(t, []string{
+		"outer>DeleteBucket:a:", "inner>DeleteBucket:a:", "inner<DeleteBucket", "outer<DeleteBucket",
+		"outer>BucketExists:a:", "inner>BucketExists:a:", "inner<BucketExists", "outer<BucketExists",
+	}, calls)
+}
+
+func TestInterceptorTransform(t *testing.T) {
+	store := newTestStore(t)
+	path := []string{"secrets"}
+
+	// a reversible "encryption" of values
+	store.Use(func(next boltdb.OpHandler) boltdb.OpHandler {
+		return func(op *boltdb.Op) error {
+			if op.Name == "Write" {
+				op.Value = bytes.ToUpper(op.Value)
+			}
+			err := next(op)
+			if op.Name == "Read" && err == nil {
+				op.Value = bytes.ToLower(op.Value)
+			}
+			return err
+		}
+	})
+
+	writeValue(t, store, path, "k", "secret")
+	assert.Equal(t, "secret", readValue(t, store, path, "k"))
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, values, _, err := session.List(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET", string(values[0]))
+}
+
+func TestInterceptorReject(t *testing.T) {
+	store := newTestStore(t)
+
+	errDenied := errors.New("denied")
+	store.Use(func(next boltdb.OpHandler) boltdb.OpHandler {
+		return func(op *boltdb.Op) error {
+			if strings.HasPrefix(op.Name, "Delete") {
+				return errDenied
+			}
+			return next(op)
+		}
+	})
+
+	write(t, store, []string{"a"}, "k")
+
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+	defer closer()
+
+	err = session.DeleteKey([]string{"a"}, "k")
+	assert.ErrorIs(t, err, errDenied)
+
+	var se *boltdb.StoreError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, "DeleteKey", se.Op)
+	assert.True(t, session.KeyExists([]string{"a"}, "k"))
+}
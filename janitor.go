@@ -0,0 +1,215 @@
+package boltdb
+
+import (
+	"context"
+	"math/rand"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// JanitorTask is a maintenance task run periodically in the background by the store, see Store.Schedule.
+type JanitorTask struct {
+	// Name identifies the task in Store.JanitorStats and Store.RunTask, unique among the tasks scheduled.
+	Name string
+	// Interval is the delay between the end of a run and the start of the next one.
+	Interval time.Duration
+	// Jitter, when set, adds a random delay up to Jitter to every interval, so the tasks of the stores
+	// started together do not run in lockstep.
+	Jitter time.Duration
+	// Timeout, when set, bounds every run through its context.
+	Timeout time.Duration
+	// Run runs the task, its context being canceled once the task is stopped.
+	Run func(ctx context.Context) error
+}
+
+// JanitorTaskStats reports the runs of a task scheduled by Store.Schedule.
+type JanitorTaskStats struct {
+	Name         string
+	Runs         uint64        // runs completed
+	Failures     uint64        // runs which returned an error
+	Running      bool          // a run is in progress
+	LastRun      time.Time     // start of the last run completed, zero before the first run
+	LastDuration time.Duration // duration of the last run completed
+	LastError    error         // error of the last run completed, nil when it succeeded
+	NextRun      time.Time     // start of the next scheduled run
+}
+
+// janitor runs the tasks scheduled on a store.
+type janitor struct {
+	mu    sync.Mutex
+	tasks map[string]*janitorTask
+}
+
+type janitorTask struct {
+	JanitorTask
+
+	run    sync.Mutex // held by the run in progress, so scheduled and manual runs do not overlap
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	stats  JanitorTaskStats // guarded by janitor.mu
+}
+
+// Schedule starts running task every task.Interval in the background, the first run starting after an
+// interval, until the returned function is called or the store is closed. Stopping a task cancels the
+// context of its run in progress and waits for it to return.
+// Schedule fails when a task with the same name is already scheduled.
+func (s *Store) Schedule(task JanitorTask) (func(), error) {
+	if task.Name == "" || task.Interval <= 0 || task.Run == nil {
+		return nil, errors.New("janitor task requires a name, a positive interval and a run function")
+	}
+
+	t := &janitorTask{JanitorTask: task, done: make(chan struct{})}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	t.stats.Name = task.Name
+
+	s.janitor.mu.Lock()
+	if _, ok := s.janitor.tasks[task.Name]; ok {
+		s.janitor.mu.Unlock()
+		t.cancel()
+		return nil, errors.Errorf("janitor task '%s' already scheduled", task.Name)
+	}
+	if s.janitor.tasks == nil {
+		s.janitor.tasks = map[string]*janitorTask{}
+	}
+	s.janitor.tasks[task.Name] = t
+	s.janitor.mu.Unlock()
+
+	go s.runTask(t, s.nextRun(t))
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			t.cancel()
+			<-t.done
+
+			s.janitor.mu.Lock()
+			if s.janitor.tasks[task.Name] == t {
+				delete(s.janitor.tasks, task.Name)
+			}
+			s.janitor.mu.Unlock()
+		})
+	}
+
+	s.addStopper(stop)
+
+	return stop, nil
+}
+
+// runTask runs t after delay, then every interval until it is stopped.
+func (s *Store) runTask(t *janitorTask, delay time.Duration) {
+	defer close(t.done)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-timer.C:
+			_ = s.runOnce(t.ctx, t)
+			timer.Reset(s.nextRun(t))
+		}
+	}
+}
+
+// nextRun returns the delay before the next run of t, recorded in its stats.
+func (s *Store) nextRun(t *janitorTask) time.Duration {
+	delay := t.Interval
+	if t.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(t.Jitter)))
+	}
+
+	s.janitor.mu.Lock()
+	t.stats.NextRun = time.Now().Add(delay)
+	s.janitor.mu.Unlock()
+
+	return delay
+}
+
+// runOnce runs t with ctx, once the run in progress, if any, has returned.
+func (s *Store) runOnce(ctx context.Context, t *janitorTask) error {
+	t.run.Lock()
+	defer t.run.Unlock()
+
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+
+	s.janitor.mu.Lock()
+	t.stats.Running = true
+	s.janitor.mu.Unlock()
+
+	// runs are labeled so profiles of the host process attribute their samples to the task
+	var err error
+	start := time.Now()
+	pprof.Do(ctx, pprof.Labels("boltdb_task", t.Name), func(ctx context.Context) {
+		err = t.Run(ctx)
+	})
+	elapsed := time.Since(start)
+
+	s.janitor.mu.Lock()
+	t.stats.Running = false
+	t.stats.Runs++
+	t.stats.LastRun = start
+	t.stats.LastDuration = elapsed
+	t.stats.LastError = err
+	if err != nil {
+		t.stats.Failures++
+	}
+	s.janitor.mu.Unlock()
+
+	if err != nil && t.ctx.Err() == nil {
+		s.logger.Warn("janitor::boltdb", "task", t.Name, "error", err, "elapsed", elapsed)
+	}
+
+	return err
+}
+
+// RunTask runs the scheduled task name now, once its run in progress, if any, has returned, e.g. to compact
+// the store on demand. The run is bounded by ctx along with the timeout of the task, and is recorded in
+// Store.JanitorStats like scheduled runs. The next scheduled run is not delayed.
+func (s *Store) RunTask(ctx context.Context, name string) error {
+	s.janitor.mu.Lock()
+	t, ok := s.janitor.tasks[name]
+	s.janitor.mu.Unlock()
+
+	if !ok {
+		return errors.Errorf("janitor task '%s' not scheduled", name)
+	}
+
+	// the run is canceled once either ctx is done or the task is stopped
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return s.runOnce(ctx, t)
+}
+
+// JanitorStats returns the stats of the tasks scheduled, sorted by name.
+func (s *Store) JanitorStats() []JanitorTaskStats {
+	s.janitor.mu.Lock()
+	defer s.janitor.mu.Unlock()
+
+	stats := make([]JanitorTaskStats, 0, len(s.janitor.tasks))
+	for _, t := range s.janitor.tasks {
+		stats = append(stats, t.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+
+	return stats
+}
@@ -0,0 +1,191 @@
+package boltdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJanitorSchedule(t *testing.T) {
+	s := newTestStore(t)
+
+	var runs int32
+	stop, err := s.Schedule(boltdb.JanitorTask{
+		Name:     "count",
+		Interval: 5 * time.Millisecond,
+		Jitter:   time.Millisecond,
+		Run: func(ctx context.Context) error {
+			if atomic.AddInt32(&runs, 1)%2 == 0 {
+				return errors.New("even run")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 4 }, 5*time.Second, time.Millisecond)
+
+	stop()
+	stop()
+
+	n := atomic.LoadInt32(&runs)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, n, atomic.LoadInt32(&runs))
+
+	// stopped tasks are no longer reported
+	for _, stats := range s.JanitorStats() {
+		assert.NotEqual(t, "count", stats.Name)
+	}
+}
+
+func TestJanitorStats(t *testing.T) {
+	s := newTestStore(t)
+
+	_, err := s.Schedule(boltdb.JanitorTask{
+		Name:     "fail",
+		Interval: time.Hour,
+		Run:      func(ctx context.Context) error { return errors.New("failed") },
+	})
+	require.NoError(t, err)
+
+	_, err = s.Schedule(boltdb.JanitorTask{
+		Name:     "fail",
+		Interval: time.Hour,
+		Run:      func(ctx context.Context) error { return nil },
+	})
+	assert.Error(t, err)
+
+	assert.EqualError(t, s.RunTask(context.Background(), "fail"), "failed")
+	assert.Error(t, s.RunTask(context.Background(), "missing"))
+
+	stats := s.JanitorStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "fail", stats[0].Name)
+	assert.Equal(t, uint64(1), stats[0].Runs)
+	assert.Equal(t, uint64(1), stats[0].Failures)
+	assert.EqualError(t, stats[0].LastError, "failed")
+	assert.False(t, stats[0].LastRun.IsZero())
+	assert.True(t, stats[0].NextRun.After(time.Now()))
+}
+
+func TestJanitorStopsOnClose(t *testing.T) {
+	s := newTestStore(t)
+
+	started := make(chan struct{})
+	var canceled int32
+	_, err := s.Schedule(boltdb.JanitorTask{
+		Name:     "block",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			atomic.StoreInt32(&canceled, 1)
+			return ctx.Err()
+		},
+	})
+	require.NoError(t, err)
+
+	<-started
+	s.Close()
+
+	// Close waits for the run in progress
+	assert.Equal(t, int32(1), atomic.LoadInt32(&canceled))
+}
+
+func TestJanitorInvalid(t *testing.T) {
+	s := newTestStore(t)
+
+	run := func(ctx context.Context) error { return nil }
+
+	_, err := s.Schedule(boltdb.JanitorTask{Interval: time.Second, Run: run})
+	assert.Error(t, err)
+	_, err = s.Schedule(boltdb.JanitorTask{Name: "task", Run: run})
+	assert.Error(t, err)
+	_, err = s.Schedule(boltdb.JanitorTask{Name: "task", Interval: time.Second})
+	assert.Error(t, err)
+}
+
+func TestCompactInterval(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{CompactInterval: 5 * time.Millisecond})
+
+	path := []string{"bulk"}
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 2000; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%04d", i), make([]byte, 1024)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, s.Update(func(w boltdb.Writer) error { return w.DeleteBucket(path) }))
+
+	assert.Eventually(t, func() bool {
+		for _, stats := range s.JanitorStats() {
+			if stats.Name == "compact" && stats.Runs > 0 && stats.LastError == nil {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 5*time.Millisecond)
+
+	writeValue(t, s, []string{"kept"}, "k", "v")
+	assert.Equal(t, "v", readValue(t, s, []string{"kept"}, "k"))
+}
+
+func TestCompactWithSnapshot(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{CompactInterval: time.Hour})
+
+	path := []string{"bulk"}
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 2000; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%04d", i), make([]byte, 1024)); err != nil {
+				return err
+			}
+		}
+		return w.Write([]string{"kept"}, "k", []byte("v"))
+	}))
+	require.NoError(t, s.Update(func(w boltdb.Writer) error { return w.DeleteBucket(path) }))
+	// bolt frees the pages of the deleted bucket once the next write starts
+	writeValue(t, s, []string{"kept"}, "k", "v")
+
+	before, err := os.Stat(s.DBPath())
+	require.NoError(t, err)
+
+	snap, err := s.Snapshot(time.Minute)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- s.RunTask(context.Background(), "compact") }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("compaction waited for the snapshot")
+	}
+
+	after, err := os.Stat(s.DBPath())
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size()/2)
+
+	// sessions started while the snapshot is open use the compacted database
+	writeValue(t, s, []string{"kept"}, "k2", "v2")
+	assert.Equal(t, "v2", readValue(t, s, []string{"kept"}, "k2"))
+
+	// the snapshot keeps reading the replaced database
+	keys, _, _, err := snap.List([]string{"kept"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k"}, keys)
+}
@@ -0,0 +1,146 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PatchMode selects the format of the patch passed to Session.PatchJSON.
+type PatchMode int
+
+const (
+	MergePatch PatchMode = iota + 1 // RFC 7386 JSON merge patch
+	JSONPatch                       // RFC 6902 JSON patch
+)
+
+// PatchJSON applies patch to the JSON value of key in bucket path and writes the result.
+// A merge patch applied to a missing key creates it, a JSON patch requires the key to exist.
+// Returns ErrInvalidPatch when the patch is malformed or cannot be applied to the current value.
+func (s *Session) PatchJSON(path []string, key string, patch []byte, mode PatchMode) error {
+	s.store.logger.Trace("Session::PatchJSON", "path", path, "key", key, "mode", int(mode))
+
+	err := s.modify("PatchJSON", path, []byte(key), len(patch), func(current []byte) ([]byte, error) {
+		switch mode {
+		case MergePatch:
+			if current == nil {
+				current = []byte("{}")
+			}
+			value, err := jsonpatch.MergePatch(current, patch)
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+			}
+			return value, nil
+
+		case JSONPatch:
+			if current == nil {
+				return nil, ErrKeyNotFound
+			}
+			p, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+			}
+			value, err := p.Apply(current)
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+			}
+			return value, nil
+
+		default:
+			return nil, errors.Wrapf(ErrInvalidPatch, "unknown patch mode %d", mode)
+		}
+	})
+
+	return wrapError("PatchJSON", path, key, err)
+}
+
+// ReadField returns the member of the JSON value of key in bucket path identified by the
+// RFC 6901 JSON pointer, e.g. "/attrs/email"; the empty pointer returns the whole value.
+// Returns ErrInvalidPointer for malformed pointers and ErrFieldNotFound when the member does not exist.
+func (s *Session) ReadField(path []string, key, pointer string) ([]byte, error) {
+	s.store.logger.Trace("Session::ReadField", "path", path, "key", key, "pointer", pointer)
+
+	var result []byte
+
+	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrKeyNotFound
+		}
+
+		if v, err = s.resolveRef(v); err != nil {
+			return err
+		}
+
+		field, err := resolvePointer(v, pointer)
+		if err != nil {
+			return err
+		}
+		result = append([]byte{}, field...)
+
+		return nil
+	}
+
+	err := s.intercept(newOp("ReadField", path, key), func() error { return s.view(read) })
+
+	return result, wrapError("ReadField", path, key, err)
+}
+
+// resolvePointer returns the raw JSON member of doc identified by pointer.
+func resolvePointer(doc []byte, pointer string) (json.RawMessage, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Wrapf(ErrInvalidPointer, "%q", pointer)
+	}
+
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+
+	current := json.RawMessage(doc)
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescape.Replace(token)
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(current, &object); err == nil {
+			member, ok := object[token]
+			if !ok {
+				return nil, errors.Wrapf(ErrFieldNotFound, "%q", pointer)
+			}
+			current = member
+			continue
+		}
+
+		var array []json.RawMessage
+		if err := json.Unmarshal(current, &array); err != nil {
+			if !json.Valid(current) {
+				return nil, errors.Wrap(err, "invalid JSON value")
+			}
+			return nil, errors.Wrapf(ErrFieldNotFound, "%q", pointer)
+		}
+
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+			return nil, errors.Wrapf(ErrInvalidPointer, "%q", pointer)
+		}
+		if index >= len(array) {
+			return nil, errors.Wrapf(ErrFieldNotFound, "%q", pointer)
+		}
+		current = array[index]
+	}
+
+	return current, nil
+}
@@ -0,0 +1,83 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchJSON(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+
+	patch := func(key, patch string, mode boltdb.PatchMode) error {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return session.PatchJSON(path, key, []byte(patch), mode)
+	}
+
+	require.NoError(t, patch("u1", `{"name":"alice","attrs":{"a":1,"b":2}}`, boltdb.MergePatch))
+	require.NoError(t, patch("u1", `{"attrs":{"a":null,"c":3}}`, boltdb.MergePatch))
+	require.NoError(t, patch("u1", `[{"op":"replace","path":"/name","value":"bob"},{"op":"add","path":"/tags","value":["x"]}]`, boltdb.JSONPatch))
+
+	err := patch("u1", `[{"op":"test","path":"/name","value":"alice"}]`, boltdb.JSONPatch)
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPatch))
+
+	err = patch("u1", `not json`, boltdb.JSONPatch)
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPatch))
+
+	err = patch("u2", `[]`, boltdb.JSONPatch)
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(path, "u1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"bob","attrs":{"b":2,"c":3},"tags":["x"]}`, string(value))
+}
+
+func TestReadField(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+	writeValue(t, s, path, "u1", `{"name":"alice","attrs":{"a/b":1,"m~n":2},"tags":["x",{"y":true}]}`)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	for pointer, expected := range map[string]string{
+		"/name":       `"alice"`,
+		"/attrs/a~1b": `1`,
+		"/attrs/m~0n": `2`,
+		"/tags/1/y":   `true`,
+		"/tags":       `["x",{"y":true}]`,
+	} {
+		value, err := session.ReadField(path, "u1", pointer)
+		require.NoError(t, err, pointer)
+		assert.JSONEq(t, expected, string(value), pointer)
+	}
+
+	value, err := session.ReadField(path, "u1", "")
+	require.NoError(t, err)
+	assert.Contains(t, string(value), `"alice"`)
+
+	for pointer, expected := range map[string]error{
+		"/missing":  boltdb.ErrFieldNotFound,
+		"/tags/2":   boltdb.ErrFieldNotFound,
+		"/name/x":   boltdb.ErrFieldNotFound,
+		"/tags/01":  boltdb.ErrInvalidPointer,
+		"name":      boltdb.ErrInvalidPointer,
+		"/tags/one": boltdb.ErrInvalidPointer,
+	} {
+		_, err := session.ReadField(path, "u1", pointer)
+		assert.True(t, errors.Is(err, expected), "%s: %v", pointer, err)
+	}
+}
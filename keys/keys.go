@@ -0,0 +1,158 @@
+// Package keys provides key encoders whose byte order matches the natural order of the
+// encoded values, so keys sort correctly under bolt's lexicographic byte ordering.
+package keys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidKey = errors.New("invalid key")
+
+const (
+	escape    byte = 0x00 // escape byte of composite key segments
+	separator byte = 0x01 // follows escape to terminate a segment
+	escaped   byte = 0xff // follows escape to represent a literal 0x00
+)
+
+// Uint64 returns the 8 byte big-endian encoding of v.
+func Uint64(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	return buf
+}
+
+// ParseUint64 decodes a key produced by Uint64.
+func ParseUint64(key []byte) (uint64, error) {
+	if len(key) != 8 {
+		return 0, errors.Wrapf(ErrInvalidKey, "uint64 key length %d", len(key))
+	}
+	return binary.BigEndian.Uint64(key), nil
+}
+
+// Int64 returns an 8 byte encoding of v in which negative values sort before positive ones.
+func Int64(v int64) []byte {
+	return Uint64(uint64(v) ^ (1 << 63))
+}
+
+// ParseInt64 decodes a key produced by Int64.
+func ParseInt64(key []byte) (int64, error) {
+	u, err := ParseUint64(key)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u ^ (1 << 63)), nil
+}
+
+// Time returns an 8 byte encoding of t with nanosecond precision, ordered chronologically.
+func Time(t time.Time) []byte {
+	return Int64(t.UnixNano())
+}
+
+// ParseTime decodes a key produced by Time, in UTC.
+func ParseTime(key []byte) (time.Time, error) {
+	n, err := ParseInt64(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n).UTC(), nil
+}
+
+// Join builds a composite key out of segments.
+// Every segment is escaped and terminated, so composite keys sort segment by segment:
+// all keys sharing a first segment are adjacent and ordered by their following segments.
+func Join(segments ...[]byte) []byte {
+	size := 0
+	for _, segment := range segments {
+		size += len(segment) + 2
+	}
+
+	key := make([]byte, 0, size)
+	for _, segment := range segments {
+		for _, c := range segment {
+			if c == escape {
+				key = append(key, escape, escaped)
+				continue
+			}
+			key = append(key, c)
+		}
+		key = append(key, escape, separator)
+	}
+
+	return key
+}
+
+// JoinStrings builds a composite key out of string segments, see Join.
+func JoinStrings(segments ...string) []byte {
+	b := make([][]byte, len(segments))
+	for i, segment := range segments {
+		b[i] = []byte(segment)
+	}
+	return Join(b...)
+}
+
+// Prefix returns the key prefix shared by all composite keys starting with segments.
+func Prefix(segments ...[]byte) []byte {
+	return Join(segments...)
+}
+
+// Split decodes a composite key produced by Join into its segments.
+func Split(key []byte) ([][]byte, error) {
+	var (
+		segments [][]byte
+		current  []byte
+	)
+
+	for i := 0; i < len(key); i++ {
+		if key[i] != escape {
+			current = append(current, key[i])
+			continue
+		}
+
+		if i+1 >= len(key) {
+			return nil, errors.Wrap(ErrInvalidKey, "truncated escape sequence")
+		}
+
+		i++
+		switch key[i] {
+		case escaped:
+			current = append(current, escape)
+		case separator:
+			if current == nil {
+				current = []byte{}
+			}
+			segments = append(segments, current)
+			current = nil
+		default:
+			return nil, errors.Wrapf(ErrInvalidKey, "invalid escape sequence 0x%02x", key[i])
+		}
+	}
+
+	if current != nil {
+		return nil, errors.Wrap(ErrInvalidKey, "unterminated segment")
+	}
+
+	return segments, nil
+}
+
+// SplitStrings decodes a composite key produced by JoinStrings.
+func SplitStrings(key []byte) ([]string, error) {
+	segments, err := Split(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(segments))
+	for i, segment := range segments {
+		result[i] = string(segment)
+	}
+	return result, nil
+}
+
+// HasPrefix reports whether the composite key starts with the given segments.
+func HasPrefix(key []byte, segments ...[]byte) bool {
+	return bytes.HasPrefix(key, Prefix(segments...))
+}
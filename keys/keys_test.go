@@ -0,0 +1,96 @@
+package keys_test
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUint64(t *testing.T) {
+	values := []uint64{0, 1, 255, 256, 1 << 32, math.MaxUint64}
+	for i, v := range values {
+		got, err := keys.ParseUint64(keys.Uint64(v))
+		require.NoError(t, err)
+		assert.Equal(t, v, got)
+
+		if i > 0 {
+			assert.Equal(t, -1, bytes.Compare(keys.Uint64(values[i-1]), keys.Uint64(v)))
+		}
+	}
+
+	_, err := keys.ParseUint64([]byte{1, 2})
+	assert.ErrorIs(t, err, keys.ErrInvalidKey)
+}
+
+func TestInt64(t *testing.T) {
+	values := []int64{math.MinInt64, -256, -1, 0, 1, 256, math.MaxInt64}
+	for i, v := range values {
+		got, err := keys.ParseInt64(keys.Int64(v))
+		require.NoError(t, err)
+		assert.Equal(t, v, got)
+
+		if i > 0 {
+			assert.Equal(t, -1, bytes.Compare(keys.Int64(values[i-1]), keys.Int64(v)))
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	t1 := time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC)
+	t2 := time.Date(2022, 11, 2, 10, 0, 0, 1, time.UTC)
+
+	got, err := keys.ParseTime(keys.Time(t2))
+	require.NoError(t, err)
+	assert.True(t, t2.Equal(got))
+	assert.Equal(t, -1, bytes.Compare(keys.Time(t1), keys.Time(t2)))
+}
+
+func TestComposite(t *testing.T) {
+	composite := [][]string{
+		{"a", "b"},
+		{"a", "b\x00c"},
+		{"a\x00", "b"},
+		{"ab", ""},
+		{"a"},
+		{"", "z"},
+	}
+
+	encoded := make([][]byte, len(composite))
+	for i, segments := range composite {
+		encoded[i] = keys.JoinStrings(segments...)
+
+		got, err := keys.SplitStrings(encoded[i])
+		require.NoError(t, err)
+		assert.Equal(t, segments, got)
+	}
+
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+
+	var order [][]string
+	for _, key := range encoded {
+		segments, _ := keys.SplitStrings(key)
+		order = append(order, segments)
+	}
+	assert.Equal(t, [][]string{
+		{"", "z"},
+		{"a"},
+		{"a", "b"},
+		{"a", "b\x00c"},
+		{"a\x00", "b"},
+		{"ab", ""},
+	}, order)
+
+	assert.True(t, keys.HasPrefix(keys.JoinStrings("a", "b"), []byte("a")))
+	assert.False(t, keys.HasPrefix(keys.JoinStrings("ab", "c"), []byte("a")))
+
+	_, err := keys.Split([]byte{'a', 0x00})
+	assert.ErrorIs(t, err, keys.ErrInvalidKey)
+	_, err = keys.Split([]byte{'a'})
+	assert.ErrorIs(t, err, keys.ErrInvalidKey)
+}
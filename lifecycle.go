@@ -0,0 +1,46 @@
+package boltdb
+
+// OnCommit schedules fn to run once the session transaction has been committed, e.g. to publish
+// the changes of the session. Callbacks run in the order they were scheduled, after the store
+// revision has been updated, and never run when the session is rolled back or commit fails.
+// Callbacks scheduled after a savepoint are discarded when the session is rolled back to it.
+// Read sessions never commit.
+func (s *Session) OnCommit(fn func()) {
+	s.onCommit = append(s.onCommit, fn)
+}
+
+// OnRollback schedules fn to run once the session transaction has been rolled back, either because
+// the session failed, its commit failed or, for read sessions, because it was closed. Callbacks run
+// in the order they were scheduled. Callbacks scheduled after a savepoint run when the session
+// is rolled back to it.
+func (s *Session) OnRollback(fn func()) {
+	s.onRollback = append(s.onRollback, fn)
+}
+
+// committed runs the commit callbacks of the session and discards the rollback ones.
+func (s *Session) committed() {
+	s.finish()
+	s.releaseWriter()
+	s.unpinBlobs()
+
+	callbacks := s.onCommit
+	s.onCommit, s.onRollback = nil, nil
+	runCallbacks(callbacks)
+}
+
+// rolledBack runs the rollback callbacks of the session and discards the commit ones.
+func (s *Session) rolledBack() {
+	s.finish()
+	s.releaseWriter()
+	s.unpinBlobs()
+
+	callbacks := s.onRollback
+	s.onCommit, s.onRollback = nil, nil
+	runCallbacks(callbacks)
+}
+
+func runCallbacks(callbacks []func()) {
+	for _, fn := range callbacks {
+		fn()
+	}
+}
@@ -0,0 +1,85 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCommit(t *testing.T) {
+	store := newTestStore(t)
+
+	var calls []string
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+
+	session.OnCommit(func() { calls = append(calls, "commit 1") })
+	session.OnCommit(func() {
+		calls = append(calls, "commit 2")
+		assert.Equal(t, uint64(1), store.Revision())
+	})
+	session.OnRollback(func() { calls = append(calls, "rollback") })
+	require.NoError(t, session.Write([]string{"a"}, "k", []byte("v")))
+
+	assert.Empty(t, calls)
+	closer()
+	assert.Equal(t, []string{"commit 1", "commit 2"}, calls)
+}
+
+func TestOnRollback(t *testing.T) {
+	store := newTestStore(t)
+
+	var calls []string
+	errFailed := errors.New("failed")
+	err := store.Update(func(w boltdb.Writer) error {
+		w.OnCommit(func() { calls = append(calls, "commit") })
+		w.OnRollback(func() { calls = append(calls, "rollback") })
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, []string{"rollback"}, calls)
+
+	// a session poisoned by a failed operation rolls back when closed
+	calls = nil
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+	session.OnCommit(func() { calls = append(calls, "commit") })
+	session.OnRollback(func() { calls = append(calls, "rollback") })
+	_, err = session.Read([]string{"missing"}, "k")
+	require.Error(t, err)
+	closer()
+	assert.Equal(t, []string{"rollback"}, calls)
+
+	// read sessions roll back when closed
+	calls = nil
+	read, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	read.OnCommit(func() { calls = append(calls, "commit") })
+	read.OnRollback(func() { calls = append(calls, "rollback") })
+	closer()
+	assert.Equal(t, []string{"rollback"}, calls)
+}
+
+func TestLifecycleSavepoint(t *testing.T) {
+	store := newTestStore(t)
+
+	var calls []string
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+
+	session.OnCommit(func() { calls = append(calls, "commit kept") })
+
+	rollbackTo, err := session.Savepoint()
+	require.NoError(t, err)
+	session.OnCommit(func() { calls = append(calls, "commit discarded") })
+	session.OnRollback(func() { calls = append(calls, "rollback to savepoint") })
+	rollbackTo()
+	assert.Equal(t, []string{"rollback to savepoint"}, calls)
+
+	require.NoError(t, session.Write([]string{"a"}, "k", []byte("v")))
+	closer()
+	assert.Equal(t, []string{"rollback to savepoint", "commit kept"}, calls)
+}
@@ -0,0 +1,149 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// LockInfo describes the process holding the file lock of a database, recorded next to the
+// database file while the store is open, see ReadLockInfo.
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// Age returns the time elapsed since the lock was acquired.
+func (i *LockInfo) Age() time.Duration {
+	return time.Since(i.Acquired)
+}
+
+// Stale reports whether the holder is a process of this host which is no longer running.
+// The operating system releases the file lock of a process once it exits, so a stale lock
+// which is still held is held by a process which inherited the descriptor of the database
+// from its holder, e.g. a child process started by the holder.
+func (i *LockInfo) Stale() bool {
+	hostname, err := os.Hostname()
+	if err != nil || hostname != i.Hostname {
+		return false
+	}
+	return i.PID != os.Getpid() && !processRunning(i.PID)
+}
+
+func (i *LockInfo) String() string {
+	return fmt.Sprintf("pid %d on %s, acquired %s ago", i.PID, i.Hostname, i.Age().Round(time.Second))
+}
+
+// LockError is returned by Store.Open when the file lock of the database could not be acquired
+// within Config.RequestTimeout. It wraps ErrDatabaseLocked.
+type LockError struct {
+	Path   string    // database file
+	Holder *LockInfo // process holding the lock, nil when not recorded
+}
+
+func (e *LockError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Path, ErrDatabaseLocked.Error())
+	switch {
+	case e.Holder == nil:
+		return msg + ", holder unknown"
+	case e.Holder.Stale():
+		return msg + " by exited process " + e.Holder.String() + ", lock inherited by another process"
+	default:
+		return msg + " by " + e.Holder.String()
+	}
+}
+
+// Unwrap returns ErrDatabaseLocked.
+func (e *LockError) Unwrap() error {
+	return ErrDatabaseLocked
+}
+
+// Is reports lock errors as bolt.ErrTimeout as well, the error returned before lock errors existed.
+func (e *LockError) Is(target error) bool {
+	return target == bolt.ErrTimeout
+}
+
+// Cause returns ErrDatabaseLocked.
+func (e *LockError) Cause() error {
+	return ErrDatabaseLocked
+}
+
+// lockInfoPath returns the path of the file recording the holder of the database at dbPath.
+func lockInfoPath(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// ReadLockInfo returns the holder recorded for the database at dbPath, which is either holding
+// the database, or exited without closing it. It returns an error wrapping os.ErrNotExist
+// when no holder has been recorded.
+func ReadLockInfo(dbPath string) (*LockInfo, error) {
+	buf, err := os.ReadFile(lockInfoPath(dbPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, errors.Wrap(err, "invalid lock info")
+	}
+
+	return &info, nil
+}
+
+// lockFailed returns the error of a database whose lock could not be acquired. With
+// Config.TakeoverStaleLock, a stale lock is taken over once the processes which inherited it
+// released it: the database is never opened while any process holds its lock.
+func (s *Store) lockFailed(options *bolt.Options) (*bolt.DB, error) {
+	holder, err := ReadLockInfo(s.config.DBPath)
+	if err != nil {
+		holder = nil
+	}
+
+	if holder == nil || !holder.Stale() || !s.config.TakeoverStaleLock {
+		return nil, &LockError{Path: s.config.DBPath, Holder: holder}
+	}
+
+	s.logger.Warn("lock::takeover", "DBPath", s.config.DBPath,
+		"pid", holder.PID, "age", holder.Age())
+
+	// wait for the lock without timeout, its holder exited and cannot release it to a new holder
+	wait := *options
+	wait.Timeout = 0
+
+	return bolt.Open(s.config.DBPath, 0600, &wait)
+}
+
+// recordLockHolder records the current process as the holder of the database, logging the previous
+// holder when it exited without closing the database.
+func (s *Store) recordLockHolder() {
+	if prev, err := ReadLockInfo(s.config.DBPath); err == nil && prev.PID != os.Getpid() {
+		s.logger.Warn("lock::unclosed", "DBPath", s.config.DBPath, "pid", prev.PID, "hostname", prev.Hostname, "age", prev.Age())
+	}
+
+	hostname, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Hostname: hostname, Acquired: time.Now().UTC()}
+
+	buf, err := json.Marshal(&info)
+	if err == nil {
+		_, err = writeFileAtomic(lockInfoPath(s.config.DBPath), func(w io.Writer) (int64, error) {
+			n, err := w.Write(buf)
+			return int64(n), err
+		})
+	}
+	if err != nil {
+		s.logger.Warn("lock::boltdb", "DBPath", s.config.DBPath, "error", err)
+	}
+}
+
+// clearLockHolder removes the holder recorded by recordLockHolder.
+func (s *Store) clearLockHolder() {
+	if err := os.Remove(lockInfoPath(s.config.DBPath)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("lock::boltdb", "DBPath", s.config.DBPath, "error", err)
+	}
+}
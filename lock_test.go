@@ -0,0 +1,118 @@
+package boltdb_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestLockInfo(t *testing.T) {
+	s := newTestStore(t)
+
+	info, err := boltdb.ReadLockInfo(s.DBPath())
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), info.PID)
+	assert.False(t, info.Stale())
+
+	s.Close()
+
+	_, err = boltdb.ReadLockInfo(s.DBPath())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDatabaseLocked(t *testing.T) {
+	s := newTestStore(t)
+
+	other := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: s.DBPath(), RequestTimeout: 50 * time.Millisecond}, nil)
+	err := other.Open()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, boltdb.ErrDatabaseLocked)
+	assert.ErrorIs(t, err, bolt.ErrTimeout)
+
+	var lockErr *boltdb.LockError
+	require.ErrorAs(t, err, &lockErr)
+	require.NotNil(t, lockErr.Holder)
+	assert.Equal(t, os.Getpid(), lockErr.Holder.PID)
+}
+
+// exitedPID returns the pid of a process which exited.
+func exitedPID(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+// recordHolder replaces the holder recorded for the database at dbPath.
+func recordHolder(t *testing.T, dbPath string, pid int) {
+	t.Helper()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	buf, err := json.Marshal(&boltdb.LockInfo{PID: pid, Hostname: hostname, Acquired: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dbPath+".lock", buf, 0600))
+}
+
+func TestStaleLock(t *testing.T) {
+	s := newTestStore(t)
+	write(t, s, []string{"lock"}, "k1")
+
+	// the lock is held by s, recorded as held by a process which exited
+	recordHolder(t, s.DBPath(), exitedPID(t))
+
+	other := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: s.DBPath(), RequestTimeout: 50 * time.Millisecond}, nil)
+	err := other.Open()
+
+	var lockErr *boltdb.LockError
+	require.ErrorAs(t, err, &lockErr)
+	assert.True(t, lockErr.Holder.Stale())
+	assert.Contains(t, err.Error(), "exited process")
+}
+
+func TestTakeoverStaleLock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "takeover.db")
+
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dbPath}, nil)
+	require.NoError(t, s.Open())
+	t.Cleanup(s.Close)
+	write(t, s, []string{"lock"}, "k1")
+
+	recordHolder(t, dbPath, exitedPID(t))
+
+	other := boltdb.NewStoreWithLogger(&boltdb.Config{
+		DBPath:            dbPath,
+		RequestTimeout:    50 * time.Millisecond,
+		TakeoverStaleLock: true,
+	}, nil)
+
+	// the lock is never taken over while it is held
+	opened := make(chan error, 1)
+	go func() { opened <- other.Open() }()
+
+	select {
+	case err := <-opened:
+		t.Fatalf("opened while the lock is held: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	s.Close()
+	require.NoError(t, <-opened)
+	t.Cleanup(other.Close)
+
+	assert.Equal(t, "k1", readValue(t, other, []string{"lock"}, "k1"))
+
+	info, err := boltdb.ReadLockInfo(dbPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), info.PID)
+}
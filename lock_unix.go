@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package boltdb
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether the process pid is running, probing it with signal 0.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,229 @@
+package boltdb
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// LogLevel is the severity of a log record.
+type LogLevel int8
+
+const (
+	LogTrace LogLevel = iota - 1 // per-operation tracing
+	LogDebug                     // diagnostics
+	LogInfo                      // lifecycle events, e.g. opening the store
+	LogWarn                      // recoverable problems
+	LogError                     // failed background work
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogTrace: "trace",
+	LogDebug: "debug",
+	LogInfo:  "info",
+	LogWarn:  "warn",
+	LogError: "error",
+}
+
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Logger receives the log records of the store, so any logging library can be plugged in,
+// see ZerologLogger and SlogLogger.
+type Logger interface {
+	// Enabled reports whether records of level are logged; disabled records are not prepared.
+	Enabled(level LogLevel) bool
+	// Log logs msg at level, with fields given as alternating keys and values.
+	// Errors are passed under the "error" key.
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NopLogger returns a logger discarding every record.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Enabled(LogLevel) bool                { return false }
+func (nopLogger) Log(LogLevel, string, ...interface{}) {}
+
+// ZerologLogger adapts a zerolog logger to a Logger.
+func ZerologLogger(logger *zerolog.Logger) Logger {
+	return zerologLogger{logger: logger}
+}
+
+type zerologLogger struct {
+	logger *zerolog.Logger
+}
+
+var zerologLevels = map[LogLevel]zerolog.Level{
+	LogTrace: zerolog.TraceLevel,
+	LogDebug: zerolog.DebugLevel,
+	LogInfo:  zerolog.InfoLevel,
+	LogWarn:  zerolog.WarnLevel,
+	LogError: zerolog.ErrorLevel,
+}
+
+func (z zerologLogger) Enabled(level LogLevel) bool {
+	zl := zerologLevels[level]
+	return zl >= z.logger.GetLevel() && zl >= zerolog.GlobalLevel()
+}
+
+func (z zerologLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	event := z.logger.WithLevel(zerologLevels[level])
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 == len(keyvals) {
+			event = event.Interface(key, nil)
+			break
+		}
+
+		switch v := keyvals[i+1].(type) {
+		case string:
+			event = event.Str(key, v)
+		case error:
+			event = event.AnErr(key, v)
+		case int:
+			event = event.Int(key, v)
+		case int64:
+			event = event.Int64(key, v)
+		case uint64:
+			event = event.Uint64(key, v)
+		case bool:
+			event = event.Bool(key, v)
+		case time.Time:
+			event = event.Time(key, v)
+		case time.Duration:
+			event = event.Dur(key, v)
+		default:
+			event = event.Interface(key, v)
+		}
+	}
+	event.Msg(msg)
+}
+
+// RedactedValue replaces the redacted fields of log records, see Config.RedactPaths.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// pathFields are the fields of log records holding bucket paths.
+	pathFields = map[string]bool{"path": true, "src": true, "dst": true}
+	// redactedFields are the fields of log records redacted when their path is redacted.
+	redactedFields = map[string]bool{
+		"key": true, "start": true, "prefix": true, "old": true, "new": true,
+		"pattern": true, "query": true, "pageToken": true, "etag": true,
+	}
+)
+
+// fieldLogger logs through a Logger, adding fields to every record, sampling trace records
+// and redacting the keys of sensitive paths.
+type fieldLogger struct {
+	logger Logger
+	fields []interface{}
+
+	sampling uint64     // one in sampling trace records is logged, zero or one logs every record
+	traces   *uint64    // trace records seen, accessed atomically
+	redact   [][]string // paths whose keys are redacted
+}
+
+func newFieldLogger(logger Logger, keyvals ...interface{}) *fieldLogger {
+	if logger == nil {
+		logger = NopLogger()
+	}
+	if fl, ok := logger.(*fieldLogger); ok {
+		return fl.with(keyvals...)
+	}
+	return &fieldLogger{logger: logger, fields: keyvals, traces: new(uint64)}
+}
+
+// with returns a logger adding keyvals to the fields of l.
+func (l *fieldLogger) with(keyvals ...interface{}) *fieldLogger {
+	fl := *l
+	fl.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &fl
+}
+
+// withPolicy returns a logger logging one in sampling trace records and redacting the keys of the redact paths.
+func (l *fieldLogger) withPolicy(sampling int, redact [][]string) *fieldLogger {
+	fl := *l
+	if sampling > 1 {
+		fl.sampling = uint64(sampling)
+	}
+	fl.redact = redact
+	return &fl
+}
+
+func (l *fieldLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(level)
+}
+
+func (l *fieldLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if !l.logger.Enabled(level) {
+		return
+	}
+	if level == LogTrace && l.sampling > 1 && (atomic.AddUint64(l.traces, 1)-1)%l.sampling != 0 {
+		return
+	}
+	if len(l.redact) > 0 && l.redacted(keyvals) {
+		keyvals = redactFields(keyvals)
+	}
+	if len(l.fields) > 0 {
+		keyvals = append(append([]interface{}{}, l.fields...), keyvals...)
+	}
+	l.logger.Log(level, msg, keyvals...)
+}
+
+// redacted reports whether a path field of keyvals is below a redacted path.
+func (l *fieldLogger) redacted(keyvals []interface{}) bool {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); !ok || !pathFields[key] {
+			continue
+		}
+
+		var path []string
+		switch p := keyvals[i+1].(type) {
+		case []string:
+			path = p
+		case Path:
+			path = p
+		default:
+			continue
+		}
+
+		for _, prefix := range l.redact {
+			if hasPathPrefix(path, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactFields returns a copy of keyvals with the values of redacted fields replaced by RedactedValue.
+// Errors, whose messages may quote keys, are replaced by their root cause.
+func redactFields(keyvals []interface{}) []interface{} {
+	result := append([]interface{}{}, keyvals...)
+	for i := 0; i+1 < len(result); i += 2 {
+		key, _ := result[i].(string)
+		if err, ok := result[i+1].(error); ok && err != nil {
+			result[i+1] = errors.New(errors.Cause(err).Error())
+		} else if redactedFields[key] {
+			result[i+1] = RedactedValue
+		}
+	}
+	return result
+}
+
+func (l *fieldLogger) Trace(msg string, keyvals ...interface{}) { l.Log(LogTrace, msg, keyvals...) }
+func (l *fieldLogger) Debug(msg string, keyvals ...interface{}) { l.Log(LogDebug, msg, keyvals...) }
+func (l *fieldLogger) Info(msg string, keyvals ...interface{})  { l.Log(LogInfo, msg, keyvals...) }
+func (l *fieldLogger) Warn(msg string, keyvals ...interface{})  { l.Log(LogWarn, msg, keyvals...) }
+func (l *fieldLogger) Error(msg string, keyvals ...interface{}) { l.Log(LogError, msg, keyvals...) }
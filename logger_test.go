@@ -0,0 +1,179 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger is a Logger recording the messages and fields of the records logged at or above level.
+type recordLogger struct {
+	mu      sync.Mutex
+	level   boltdb.LogLevel
+	records []map[string]interface{}
+}
+
+func (l *recordLogger) Enabled(level boltdb.LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *recordLogger) Log(level boltdb.LogLevel, msg string, keyvals ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record := map[string]interface{}{"level": level.String(), "msg": msg}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		record[keyvals[i].(string)] = keyvals[i+1]
+	}
+	l.records = append(l.records, record)
+}
+
+func (l *recordLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	msgs := []string{}
+	for _, r := range l.records {
+		msgs = append(msgs, r["msg"].(string))
+	}
+	return msgs
+}
+
+func newTestStoreWithLogger(t *testing.T, cfg *boltdb.Config, logger boltdb.Logger) *boltdb.Store {
+	cfg.DBPath = filepath.Join(t.TempDir(), "test.db")
+
+	store := boltdb.NewStoreWithLogger(cfg, logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	return store
+}
+
+func TestStoreWithLogger(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogTrace}
+	store := newTestStoreWithLogger(t, &boltdb.Config{}, logger)
+
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	assert.Contains(t, logger.messages(), "Session::Write")
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	for _, r := range logger.records {
+		if r["msg"] == "Session::Write" {
+			assert.Equal(t, "store", r["component"])
+			assert.Equal(t, "k", r["key"])
+			assert.Equal(t, "trace", r["level"])
+		}
+	}
+}
+
+func TestStoreWithoutLogger(t *testing.T) {
+	store := newTestStoreWithLogger(t, &boltdb.Config{}, nil)
+	writeValue(t, store, []string{"a"}, "k", "v")
+	assert.Equal(t, "v", readValue(t, store, []string{"a"}, "k"))
+}
+
+func TestLoggerDisabledLevels(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogInfo}
+	store := newTestStoreWithLogger(t, &boltdb.Config{}, logger)
+
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	for _, msg := range logger.messages() {
+		assert.False(t, strings.HasPrefix(msg, "Session::"), msg)
+	}
+}
+
+func TestZerologLogger(t *testing.T) {
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf).Level(zerolog.DebugLevel)
+	logger := boltdb.ZerologLogger(&zl)
+
+	assert.False(t, logger.Enabled(boltdb.LogTrace))
+	assert.True(t, logger.Enabled(boltdb.LogDebug))
+
+	logger.Log(boltdb.LogWarn, "msg", "path", []string{"a", "b"}, "key", "k", "n", 3, "error", errors.New("failed"))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, map[string]interface{}{
+		"level":   "warn",
+		"message": "msg",
+		"path":    []interface{}{"a", "b"},
+		"key":     "k",
+		"n":       float64(3),
+		"error":   "failed",
+	}, record)
+}
+
+func TestLogSampling(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogTrace}
+	store := newTestStoreWithLogger(t, &boltdb.Config{LogSampling: 10}, logger)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.View(func(r boltdb.Reader) error {
+			r.BucketExists([]string{"a"})
+			return nil
+		}))
+	}
+
+	count := 0
+	for _, msg := range logger.messages() {
+		if msg == "PathExists" {
+			count++
+		}
+	}
+	assert.InDelta(t, 10, count, 2)
+}
+
+func TestLogRedaction(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogTrace}
+	store := newTestStoreWithLogger(t, &boltdb.Config{RedactPaths: [][]string{{"secrets"}}}, logger)
+
+	writeValue(t, store, []string{"secrets", "api"}, "token-1", "v")
+	writeValue(t, store, []string{"public"}, "name", "v")
+
+	require.NoError(t, store.View(func(r boltdb.Reader) error {
+		_, err := r.Read([]string{"secrets", "api"}, "missing-token")
+		assert.Error(t, err)
+		return nil
+	}))
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	redacted, plain := 0, 0
+	for _, r := range logger.records {
+		path, _ := r["path"].([]string)
+		if len(path) > 0 && path[0] == "secrets" {
+			if key, ok := r["key"]; ok {
+				assert.Equal(t, boltdb.RedactedValue, key)
+				redacted++
+			}
+			if err, ok := r["error"].(error); ok {
+				assert.NotContains(t, err.Error(), "missing-token")
+			}
+		}
+		if r["key"] == "name" {
+			plain++
+		}
+	}
+	assert.NotZero(t, redacted)
+	assert.NotZero(t, plain)
+
+	for _, r := range logger.records {
+		for _, v := range r {
+			assert.NotEqual(t, "token-1", v)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"github.com/aserto-dev/mage-loot/common"
 	"github.com/aserto-dev/mage-loot/deps"
+	"github.com/magefile/mage/sh"
 )
 
 func Deps() {
@@ -21,3 +22,8 @@ func Lint() error {
 func Test() error {
 	return common.Test()
 }
+
+// Bench runs the benchmarks of the package, reporting allocations.
+func Bench() error {
+	return sh.RunV("go", "test", "-run", "^$", "-bench", ".", "-benchmem", ".")
+}
@@ -0,0 +1,205 @@
+package boltdb
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ManagerConfig configures a Manager.
+type ManagerConfig struct {
+	// Stores holds the configuration of every store, by name, e.g. one database file per tenant.
+	Stores map[string]*Config `json:"stores"`
+	// MaxOpen is the maximum number of stores kept open; the least recently used idle stores are
+	// closed beyond it. Zero keeps every store open once opened.
+	MaxOpen int `json:"max_open"`
+	// Init, when set, is called once per store before it is first opened, to register migrations,
+	// validators, interceptors and the like. Registrations are kept when the store is reopened.
+	Init func(name string, store *Store) error `json:"-"`
+}
+
+// Manager opens and owns multiple named stores, opening stores lazily on first use and closing
+// the least recently used idle stores to respect ManagerConfig.MaxOpen.
+type Manager struct {
+	cfg         ManagerConfig
+	logger      *fieldLogger
+	storeLogger Logger // logger of the managed stores
+
+	mu      sync.Mutex
+	configs map[string]*Config
+	stores  map[string]*managedStore
+	lru     *list.List // open stores, most recently used first
+	closed  bool
+}
+
+// managedStore is a store owned by a Manager.
+type managedStore struct {
+	name  string
+	store *Store
+	refs  int           // callers holding the store, see Manager.Get
+	elem  *list.Element // position in the LRU list, nil while closed
+}
+
+// NewManager returns a manager of the stores configured in cfg, logging to logger. See NewManagerWithLogger.
+func NewManager(cfg ManagerConfig, logger *zerolog.Logger) *Manager {
+	return NewManagerWithLogger(cfg, ZerologLogger(logger))
+}
+
+// NewManagerWithLogger returns a manager of the stores configured in cfg, logging to logger,
+// or not logging when logger is nil.
+func NewManagerWithLogger(cfg ManagerConfig, logger Logger) *Manager {
+	if logger == nil {
+		logger = NopLogger()
+	}
+
+	configs := make(map[string]*Config, len(cfg.Stores))
+	for name, c := range cfg.Stores {
+		configs[name] = c
+	}
+
+	return &Manager{
+		cfg:         cfg,
+		logger:      newFieldLogger(logger, "component", "manager"),
+		storeLogger: logger,
+		configs:     configs,
+		stores:      map[string]*managedStore{},
+		lru:         list.New(),
+	}
+}
+
+// Add configures the store name, replacing the configuration of a store which has not been opened yet.
+// Returns ErrKeyExists when the store has already been opened.
+func (m *Manager) Add(name string, cfg *Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.stores[name]; ok {
+		return errors.Wrapf(ErrKeyExists, "store %s already opened", name)
+	}
+	m.configs[name] = cfg
+
+	return nil
+}
+
+// Names returns the names of the configured stores, sorted.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.configs))
+	for name := range m.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Get returns the store name, opening it when needed, and a function releasing it.
+// Stores are never closed while held, so callers must release the store once done with it.
+// Returns ErrStoreNotFound when no store name is configured.
+func (m *Manager) Get(name string) (*Store, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, nil, bolt.ErrDatabaseNotOpen
+	}
+
+	ms, err := m.acquire(name)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to open store %s", name)
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { m.release(ms) })
+	}
+
+	return ms.store, release, nil
+}
+
+func (m *Manager) acquire(name string) (*managedStore, error) {
+	ms, ok := m.stores[name]
+	if !ok {
+		cfg, ok := m.configs[name]
+		if !ok {
+			return nil, ErrStoreNotFound
+		}
+
+		logger := newFieldLogger(m.storeLogger, "store", name)
+		ms = &managedStore{name: name, store: NewStoreWithLogger(cfg, logger)}
+		if m.cfg.Init != nil {
+			if err := m.cfg.Init(name, ms.store); err != nil {
+				return nil, err
+			}
+		}
+		m.stores[name] = ms
+	}
+
+	if ms.elem == nil {
+		if err := ms.store.Open(); err != nil {
+			return nil, err
+		}
+		ms.elem = m.lru.PushFront(ms)
+	} else {
+		m.lru.MoveToFront(ms.elem)
+	}
+
+	ms.refs++
+	m.evict()
+
+	return ms, nil
+}
+
+func (m *Manager) release(ms *managedStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ms.refs--
+	m.evict()
+}
+
+// evict closes the least recently used idle stores beyond MaxOpen.
+func (m *Manager) evict() {
+	if m.cfg.MaxOpen <= 0 {
+		return
+	}
+
+	for e := m.lru.Back(); e != nil && m.lru.Len() > m.cfg.MaxOpen; {
+		prev := e.Prev()
+		if ms := e.Value.(*managedStore); ms.refs == 0 {
+			m.logger.Debug("evict::manager", "name", ms.name)
+			m.lru.Remove(e)
+			ms.elem = nil
+			ms.store.Close()
+		}
+		e = prev
+	}
+}
+
+// Open returns the number of open stores.
+func (m *Manager) Open() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len()
+}
+
+// Close closes every open store, including stores still held. Get fails once the manager is closed.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for e := m.lru.Front(); e != nil; e = e.Next() {
+		ms := e.Value.(*managedStore)
+		ms.elem = nil
+		ms.store.Close()
+	}
+	m.lru.Init()
+}
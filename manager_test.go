@@ -0,0 +1,135 @@
+package boltdb_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, maxOpen int, names ...string) *boltdb.Manager {
+	dir := t.TempDir()
+
+	cfg := boltdb.ManagerConfig{Stores: map[string]*boltdb.Config{}, MaxOpen: maxOpen}
+	for _, name := range names {
+		cfg.Stores[name] = &boltdb.Config{DBPath: filepath.Join(dir, name+".db")}
+	}
+
+	logger := zerolog.Nop()
+	m := boltdb.NewManager(cfg, &logger)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func TestManagerGet(t *testing.T) {
+	m := newTestManager(t, 0, "t1", "t2")
+	assert.Equal(t, []string{"t1", "t2"}, m.Names())
+	assert.Zero(t, m.Open())
+
+	s1, release, err := m.Get("t1")
+	require.NoError(t, err)
+	writeValue(t, s1, []string{"a"}, "k", "t1")
+	release()
+	release()
+
+	s2, release, err := m.Get("t2")
+	require.NoError(t, err)
+	defer release()
+	assert.NotSame(t, s1, s2)
+	assert.Equal(t, 2, m.Open())
+
+	again, release, err := m.Get("t1")
+	require.NoError(t, err)
+	defer release()
+	assert.Same(t, s1, again)
+
+	_, _, err = m.Get("t3")
+	assert.ErrorIs(t, err, boltdb.ErrStoreNotFound)
+}
+
+func TestManagerEvictsIdleStores(t *testing.T) {
+	m := newTestManager(t, 2, "t1", "t2", "t3")
+
+	s1, release1, err := m.Get("t1")
+	require.NoError(t, err)
+	writeValue(t, s1, []string{"a"}, "k", "t1")
+
+	_, release2, err := m.Get("t2")
+	require.NoError(t, err)
+	release2()
+
+	// t1 is held, so the idle t2 is closed
+	_, release3, err := m.Get("t3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Open())
+	release3()
+
+	assert.Equal(t, "t1", readValue(t, s1, []string{"a"}, "k"))
+	release1()
+
+	// held stores may exceed MaxOpen until released
+	h1, r1, err := m.Get("t1")
+	require.NoError(t, err)
+	_, r2, err := m.Get("t2")
+	require.NoError(t, err)
+	_, r3, err := m.Get("t3")
+	require.NoError(t, err)
+	assert.Equal(t, 3, m.Open())
+	r3()
+	assert.Equal(t, 2, m.Open())
+	r2()
+	r1()
+
+	// reopened stores keep their data
+	assert.Equal(t, "t1", readValue(t, h1, []string{"a"}, "k"))
+}
+
+func TestManagerInit(t *testing.T) {
+	var inits []string
+
+	logger := zerolog.Nop()
+	m := boltdb.NewManager(boltdb.ManagerConfig{
+		Stores: map[string]*boltdb.Config{
+			"t1": {DBPath: filepath.Join(t.TempDir(), "t1.db")},
+			"t2": {DBPath: filepath.Join(t.TempDir(), "t2.db")},
+		},
+		MaxOpen: 1,
+		Init: func(name string, store *boltdb.Store) error {
+			inits = append(inits, name)
+			return nil
+		},
+	}, &logger)
+	defer m.Close()
+
+	// the store is closed and reopened, but initialized once
+	for _, name := range []string{"t1", "t2", "t1"} {
+		_, release, err := m.Get(name)
+		require.NoError(t, err)
+		release()
+	}
+	assert.Equal(t, []string{"t1", "t2"}, inits)
+
+	require.NoError(t, m.Add("t3", &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "t3.db")}))
+	_, release, err := m.Get("t3")
+	require.NoError(t, err)
+	release()
+	assert.ErrorIs(t, m.Add("t3", &boltdb.Config{}), boltdb.ErrKeyExists)
+}
+
+func TestManagerClose(t *testing.T) {
+	m := newTestManager(t, 0, "t1")
+
+	_, release, err := m.Get("t1")
+	require.NoError(t, err)
+	release()
+
+	m.Close()
+	assert.Zero(t, m.Open())
+
+	_, _, err = m.Get("t1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+package boltdb
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// MatchKind selects the syntax of the pattern passed to Session.ScanMatch.
+type MatchKind int
+
+const (
+	Glob  MatchKind = iota + 1 // * matches any sequence of characters, ? any single character, [...] a character class
+	Regex                      // RE2 regular expression, see the regexp package
+)
+
+// ScanMatch returns a paged collection of the keys, and their values, of bucket path matching pattern.
+// Glob patterns must match the whole key, regular expressions are unanchored.
+// Keys are filtered during the cursor walk, which is limited to the literal prefix of the pattern.
+// Returns ErrInvalidPattern when pattern cannot be compiled.
+func (s *Session) ScanMatch(path []string, pattern string, kind MatchKind, pageToken string) ([]string, [][]byte, string, error) {
+	s.store.logger.Trace("Session::ScanMatch", "path", path, "pattern", pattern, "pageToken", pageToken)
+
+	var (
+		keys      = make([]string, 0)
+		values    = make([][]byte, 0)
+		nextToken string
+	)
+
+	scan := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		re, err := compileMatch(pattern, kind)
+		if err != nil {
+			return err
+		}
+
+		start, after, err := s.store.tokens.decode(pageToken)
+		if err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		prefix := literalPrefix(re)
+		if start == nil || bytes.Compare(start, []byte(prefix)) < 0 {
+			start, after = []byte(prefix), false
+		}
+
+		c := b.Cursor()
+		for k, v := seekPage(c, start, after); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil || !re.Match(k) {
+				continue
+			}
+			if int32(len(keys)) == pageSize {
+				nextToken = s.store.tokens.encode([]byte(keys[len(keys)-1]))
+				break
+			}
+			value, err := s.listedValue(v)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, string(k))
+			values = append(values, append([]byte{}, value...))
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("ScanMatch", path, pattern), func() error { return s.view(scan) })
+
+	if err != nil {
+		return []string{}, [][]byte{}, "", wrapError("ScanMatch", path, pattern, err)
+	}
+
+	return keys, values, nextToken, nil
+}
+
+func compileMatch(pattern string, kind MatchKind) (*regexp.Regexp, error) {
+	expr := pattern
+
+	switch kind {
+	case Regex:
+	case Glob:
+		var err error
+		if expr, err = globExpr(pattern); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Wrapf(ErrInvalidPattern, "unknown match kind %d", kind)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidPattern, err.Error())
+	}
+
+	return re, nil
+}
+
+// literalPrefix returns the literal prefix of every key matched by re, which is only known
+// for expressions anchored at the beginning of the text.
+func literalPrefix(re *regexp.Regexp) string {
+	expr, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	expr = expr.Simplify()
+
+	if expr.Op != syntax.OpConcat || len(expr.Sub) < 2 || expr.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	if lit := expr.Sub[1]; lit.Op == syntax.OpLiteral && lit.Flags&syntax.FoldCase == 0 {
+		return string(lit.Rune)
+	}
+	return ""
+}
+
+// globExpr converts a glob pattern to an anchored regular expression.
+func globExpr(pattern string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			sb.WriteString("(?s:.*)")
+		case '?':
+			sb.WriteString("(?s:.)")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", errors.Wrapf(ErrInvalidPattern, "unterminated character class in %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return sb.String(), nil
+}
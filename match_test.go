@@ -0,0 +1,91 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanMatch(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"policies"}
+	for _, key := range []string{"acme:p1:policy", "acme:p1:rule", "globex:p2:policy", "globex/x:p3:policy", "initech:p4:data"} {
+		write(t, s, path, key)
+	}
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	for _, tc := range []struct {
+		pattern  string
+		kind     boltdb.MatchKind
+		expected []string
+	}{
+		{"*:policy", boltdb.Glob, []string{"acme:p1:policy", "globex/x:p3:policy", "globex:p2:policy"}},
+		{"acme:*", boltdb.Glob, []string{"acme:p1:policy", "acme:p1:rule"}},
+		{"globex?x:*", boltdb.Glob, []string{"globex/x:p3:policy"}},
+		{"[ai]*:p[!1]:*", boltdb.Glob, []string{"initech:p4:data"}},
+		{"acme", boltdb.Glob, []string{}},
+		{`:p\d:`, boltdb.Regex, []string{"acme:p1:policy", "acme:p1:rule", "globex/x:p3:policy", "globex:p2:policy", "initech:p4:data"}},
+		{`^globex:.*policy$`, boltdb.Regex, []string{"globex:p2:policy"}},
+		{`rule$`, boltdb.Regex, []string{"acme:p1:rule"}},
+	} {
+		keys, values, next, err := session.ScanMatch(path, tc.pattern, tc.kind, "")
+		require.NoError(t, err, tc.pattern)
+		assert.Equal(t, tc.expected, keys, tc.pattern)
+		assert.Len(t, values, len(keys))
+		assert.Empty(t, next)
+	}
+
+	for _, pattern := range []string{"[unterminated", "("} {
+		_, _, _, err := session.ScanMatch(path, pattern, boltdb.Regex, "")
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPattern), pattern)
+	}
+	_, _, _, err = session.ScanMatch(path, "[abc", boltdb.Glob, "")
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPattern))
+}
+
+func TestScanMatchPagination(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"policies"}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	for i := 0; i < 300; i++ {
+		kind := "rule"
+		if i%2 == 0 {
+			kind = "policy"
+		}
+		require.NoError(t, session.Write(path, fmt.Sprintf("tenant:%03d:%s", i, kind), []byte("v")))
+	}
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	var (
+		result []string
+		token  string
+	)
+	for {
+		keys, _, next, err := session.ScanMatch(path, "tenant:*:policy", boltdb.Glob, token)
+		require.NoError(t, err)
+		result = append(result, keys...)
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	require.Len(t, result, 150)
+	assert.Equal(t, "tenant:000:policy", result[0])
+	assert.Equal(t, "tenant:298:policy", result[149])
+}
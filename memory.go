@@ -0,0 +1,30 @@
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// NewMemoryStore returns an open store backed by a temporary database file managed by the store,
+// intended for tests. Writes are not synced to disk, and the file is removed when the store is closed.
+func NewMemoryStore(logger *zerolog.Logger) (*Store, error) {
+	dir, err := os.MkdirTemp("", "boltdb-memstore-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	store := NewStore(&Config{DBPath: filepath.Join(dir, "memory.db")}, logger)
+	store.tempDir = dir
+
+	if err := store.Open(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	store.database().NoSync = true
+
+	return store, nil
+}
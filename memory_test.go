@@ -0,0 +1,31 @@
+package boltdb_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+
+	s, err := boltdb.NewMemoryStore(&logger)
+	require.NoError(t, err)
+
+	write(t, s, []string{"objects"}, "k1")
+	assert.Equal(t, "k1", readValue(t, s, []string{"objects"}, "k1"))
+
+	dbPath := s.DBPath()
+	_, err = os.Stat(dbPath)
+	require.NoError(t, err)
+
+	s.Close()
+
+	_, err = os.Stat(dbPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,82 @@
+package boltdb
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// MergeFunc combines the current value of a key, nil when the key does not exist,
+// with a partial value and returns the value to store.
+type MergeFunc func(current, partial []byte) ([]byte, error)
+
+// RegisterMerge sets the merge operator used by Session.Merge for the keys of the buckets below prefix.
+// Paths use the operator registered for their longest prefix; a nil fn removes the registration.
+func (s *Store) RegisterMerge(prefix []string, fn MergeFunc) {
+	if fn == nil {
+		s.merges.set(prefix, nil)
+		return
+	}
+	s.merges.set(prefix, fn)
+}
+
+// Merge applies the merge operator registered for bucket path to the current value of key and
+// partial, and writes the result. Returns ErrNoMergeOperator when no operator applies to path.
+func (s *Session) Merge(path []string, key string, partial []byte) error {
+	s.store.logger.Trace("Session::Merge", "path", path, "key", key)
+
+	var fn MergeFunc
+	if v, ok := s.store.merges.lookup(path); ok {
+		fn = v.(MergeFunc)
+	}
+
+	err := s.modify("Merge", path, []byte(key), len(partial), func(current []byte) ([]byte, error) {
+		if fn == nil {
+			return nil, ErrNoMergeOperator
+		}
+		return fn(current, partial)
+	})
+
+	return wrapError("Merge", path, key, err)
+}
+
+// MergeJSON is a MergeFunc deep merging JSON objects: members of partial are merged
+// recursively into the members of current, other values replace the current value.
+func MergeJSON(current, partial []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(partial, &p); err != nil {
+		return nil, errors.Wrap(err, "invalid partial value")
+	}
+
+	if current == nil {
+		return json.Marshal(p)
+	}
+
+	var c interface{}
+	if err := json.Unmarshal(current, &c); err != nil {
+		return nil, errors.Wrap(err, "invalid current value")
+	}
+
+	return json.Marshal(mergeJSON(c, p))
+}
+
+func mergeJSON(current, partial interface{}) interface{} {
+	c, ok := current.(map[string]interface{})
+	if !ok {
+		return partial
+	}
+	p, ok := partial.(map[string]interface{})
+	if !ok {
+		return partial
+	}
+
+	for k, v := range p {
+		if cv, ok := c[k]; ok {
+			c[k] = mergeJSON(cv, v)
+		} else {
+			c[k] = v
+		}
+	}
+
+	return c
+}
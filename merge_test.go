@@ -0,0 +1,53 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	s := newTestStore(t)
+
+	s.RegisterMerge([]string{"objects"}, boltdb.MergeJSON)
+	s.RegisterMerge([]string{"objects", "raw"}, func(current, partial []byte) ([]byte, error) {
+		return append(current, partial...), nil
+	})
+
+	merge := func(path []string, key, partial string) error {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return session.Merge(path, key, []byte(partial))
+	}
+
+	users := []string{"objects", "users"}
+	require.NoError(t, merge(users, "u1", `{"name":"alice","attrs":{"a":1}}`))
+	require.NoError(t, merge(users, "u1", `{"attrs":{"b":2},"active":true}`))
+
+	raw := []string{"objects", "raw", "nested"}
+	require.NoError(t, merge(raw, "r1", "ab"))
+	require.NoError(t, merge(raw, "r1", "cd"))
+
+	err := merge([]string{"other"}, "k1", "{}")
+	assert.True(t, errors.Is(err, boltdb.ErrNoMergeOperator))
+
+	s.RegisterMerge([]string{"objects", "raw"}, nil)
+	err = merge(raw, "r1", "{}")
+	assert.Error(t, err)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(users, "u1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"alice","attrs":{"a":1,"b":2},"active":true}`, string(value))
+
+	value, err = session.Read(raw, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(value))
+}
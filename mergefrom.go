@@ -0,0 +1,204 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Conflict describes a key of the bucket merged by Store.MergeFrom holding different values in both stores.
+type Conflict struct {
+	Path   []string
+	Key    string
+	Ours   []byte // value in the store merged into
+	Theirs []byte // value in the source store
+	// OursMeta and TheirsMeta hold the metadata of the key in both stores, nil unless Config.TrackMetadata
+	// is set in the store.
+	OursMeta   *KeyMetadata
+	TheirsMeta *KeyMetadata
+}
+
+// ConflictStrategy resolves a conflict of Store.MergeFrom, returning the value written for the key; returning
+// c.Ours leaves the key unchanged. Errors abort the merge, which is rolled back.
+type ConflictStrategy func(c *Conflict) ([]byte, error)
+
+var (
+	// KeepOurs keeps the values of the store merged into.
+	KeepOurs ConflictStrategy = func(c *Conflict) ([]byte, error) { return c.Ours, nil }
+	// KeepTheirs overwrites the values of the store merged into with the values of the source store.
+	KeepTheirs ConflictStrategy = func(c *Conflict) ([]byte, error) { return c.Theirs, nil }
+	// KeepNewest keeps the value updated last, according to the metadata of the key in both stores,
+	// keeping ours when either store does not track metadata.
+	KeepNewest ConflictStrategy = func(c *Conflict) ([]byte, error) {
+		if c.OursMeta != nil && c.TheirsMeta != nil && c.TheirsMeta.UpdatedAt.After(c.OursMeta.UpdatedAt) {
+			return c.Theirs, nil
+		}
+		return c.Ours, nil
+	}
+)
+
+// MergeStats reports the keys merged by Store.MergeFrom.
+type MergeStats struct {
+	Added     int // keys missing from the store merged into
+	Conflicts int // keys holding different values in both stores
+	Updated   int // conflicts resolved with a value other than ours
+}
+
+// MergeFrom merges bucket path of src, including its nested buckets, into the same bucket of the store, e.g. to
+// merge the offline edits of a copy back into the primary store, reading src from a single read session and
+// writing in a single write session. Keys and buckets missing from the store are added, keys holding different
+// values in both stores are resolved by strategy, KeepOurs when nil, and keys missing from src are kept.
+// Bucket sequences are set to the greater of both sequences. An empty path merges the whole stores.
+func (s *Store) MergeFrom(src *Store, path []string, strategy ConflictStrategy) (MergeStats, error) {
+	s.logger.Trace("Store::MergeFrom", "path", path)
+
+	var stats MergeStats
+
+	if src == s {
+		return stats, errors.New("cannot merge a store into itself")
+	}
+	if strategy == nil {
+		strategy = KeepOurs
+	}
+
+	theirs, closer, err := src.ReadSession()
+	if err != nil {
+		return stats, err
+	}
+	defer closer()
+
+	m := &merger{theirs: theirs, strategy: strategy, stats: &stats}
+
+	err = s.update(func(ours *Session) error {
+		m.ours = ours
+		return theirs.view(func(tx *bolt.Tx) error {
+			if len(path) > 0 {
+				b, err := theirs.setBucket(path)
+				if err != nil {
+					return err
+				}
+				return m.bucket(path, b)
+			}
+
+			c := tx.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if bytes.Equal(k, metaBucket) {
+					continue
+				}
+				if err := m.bucket([]string{string(k)}, tx.Bucket(k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+
+	return stats, wrapError("MergeFrom", path, "", err)
+}
+
+type merger struct {
+	ours, theirs *Session
+	strategy     ConflictStrategy
+	stats        *MergeStats
+}
+
+// bucket merges bucket b of the source store at path.
+func (m *merger) bucket(path []string, b *bolt.Bucket) error {
+	seq := b.Sequence()
+	if current, err := m.ours.setBucket(path); err == nil && current.Sequence() >= seq {
+		seq = 0
+	}
+	if seq > 0 {
+		if err := m.ours.SetSeq(path, seq); err != nil {
+			return err
+		}
+	} else if err := m.ours.CreateBucket(path); err != nil {
+		return err
+	}
+
+	var children [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			children = append(children, append([]byte{}, k...))
+			continue
+		}
+		if err := m.key(path, k, v); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range children {
+		child := append(append([]string{}, path...), string(k))
+		if err := m.bucket(child, b.Bucket(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *merger) key(path []string, key, v []byte) error {
+	theirs, err := m.theirs.resolveRef(v)
+	if err != nil {
+		return wrapError("MergeFrom", path, string(key), err)
+	}
+
+	b, err := m.ours.setBucket(path)
+	if err != nil {
+		return err
+	}
+
+	current := b.Get(key)
+	if current == nil {
+		m.stats.Added++
+		return m.ours.WriteB(path, key, theirs)
+	}
+
+	ours, err := m.ours.resolveRef(current)
+	if err != nil {
+		return wrapError("MergeFrom", path, string(key), err)
+	}
+	if bytes.Equal(ours, theirs) {
+		return nil
+	}
+
+	m.stats.Conflicts++
+
+	// values are copied, as strategies may modify them
+	c := &Conflict{Path: path, Key: string(key), Ours: append([]byte{}, ours...), Theirs: append([]byte{}, theirs...)}
+	if c.OursMeta, err = m.ours.keyMetadata(path, key); err != nil {
+		return err
+	}
+	if c.TheirsMeta, err = m.theirs.keyMetadata(path, key); err != nil {
+		return err
+	}
+
+	value, err := m.strategy(c)
+	if err != nil {
+		return wrapError("MergeFrom", path, string(key), err)
+	}
+	if bytes.Equal(value, ours) {
+		return nil
+	}
+
+	m.stats.Updated++
+	return m.ours.WriteB(path, key, value)
+}
+
+// keyMetadata returns the metadata of key in bucket path, nil when the store does not track metadata.
+func (s *Session) keyMetadata(path []string, key []byte) (*KeyMetadata, error) {
+	v := s.getShadow(keymetaBucket, path, key)
+	if v == nil {
+		return nil, nil
+	}
+
+	var md KeyMetadata
+	if err := json.Unmarshal(v, &md); err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
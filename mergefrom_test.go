@@ -0,0 +1,106 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedMerge(t *testing.T, cfg *boltdb.Config) (*boltdb.Store, *boltdb.Store) {
+	t.Helper()
+
+	primary := newTestStoreWithConfig(t, cfg)
+	offline := newTestStoreWithConfig(t, cfg)
+
+	writeValue(t, primary, []string{"dirs", "users"}, "alice", "primary")
+	writeValue(t, primary, []string{"dirs", "users"}, "carol", "primary")
+	writeValue(t, offline, []string{"dirs", "users"}, "bob", "offline")
+	writeValue(t, offline, []string{"dirs", "users"}, "carol", "offline")
+	writeValue(t, offline, []string{"dirs", "groups", "admins"}, "bob", "member")
+	writeValue(t, offline, []string{"other"}, "k", "v")
+
+	return primary, offline
+}
+
+func TestMergeFrom(t *testing.T) {
+	for name, tc := range map[string]struct {
+		strategy boltdb.ConflictStrategy
+		carol    string
+		updated  int
+	}{
+		"ours":   {boltdb.KeepOurs, "primary", 0},
+		"theirs": {boltdb.KeepTheirs, "offline", 1},
+		"nil":    {nil, "primary", 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			primary, offline := seedMerge(t, &boltdb.Config{})
+
+			stats, err := primary.MergeFrom(offline, []string{"dirs"}, tc.strategy)
+			require.NoError(t, err)
+			assert.Equal(t, boltdb.MergeStats{Added: 2, Conflicts: 1, Updated: tc.updated}, stats)
+
+			assert.Equal(t, "primary", readValue(t, primary, []string{"dirs", "users"}, "alice"))
+			assert.Equal(t, "offline", readValue(t, primary, []string{"dirs", "users"}, "bob"))
+			assert.Equal(t, tc.carol, readValue(t, primary, []string{"dirs", "users"}, "carol"))
+			assert.Equal(t, "member", readValue(t, primary, []string{"dirs", "groups", "admins"}, "bob"))
+
+			// paths outside of the merged bucket are left alone
+			require.NoError(t, primary.View(func(r boltdb.Reader) error {
+				assert.False(t, r.BucketExists([]string{"other"}))
+				return nil
+			}))
+		})
+	}
+}
+
+func TestMergeFromNewest(t *testing.T) {
+	primary, offline := seedMerge(t, &boltdb.Config{TrackMetadata: true})
+
+	// carol was updated offline last
+	time.Sleep(time.Millisecond)
+	writeValue(t, offline, []string{"dirs", "users"}, "carol", "offline-newest")
+
+	stats, err := primary.MergeFrom(offline, nil, boltdb.KeepNewest)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Updated)
+	assert.Equal(t, "offline-newest", readValue(t, primary, []string{"dirs", "users"}, "carol"))
+	assert.Equal(t, "v", readValue(t, primary, []string{"other"}, "k"))
+
+	// alice is updated last on the primary
+	writeValue(t, offline, []string{"dirs", "users"}, "alice", "offline")
+	time.Sleep(time.Millisecond)
+	writeValue(t, primary, []string{"dirs", "users"}, "alice", "primary-newest")
+
+	_, err = primary.MergeFrom(offline, nil, boltdb.KeepNewest)
+	require.NoError(t, err)
+	assert.Equal(t, "primary-newest", readValue(t, primary, []string{"dirs", "users"}, "alice"))
+}
+
+func TestMergeFromCallback(t *testing.T) {
+	primary, offline := seedMerge(t, &boltdb.Config{})
+
+	var conflicts []string
+	_, err := primary.MergeFrom(offline, []string{"dirs"}, func(c *boltdb.Conflict) ([]byte, error) {
+		conflicts = append(conflicts, c.Key)
+		return append(append(c.Ours, '+'), c.Theirs...), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"carol"}, conflicts)
+	assert.Equal(t, "primary+offline", readValue(t, primary, []string{"dirs", "users"}, "carol"))
+
+	// failed merges are rolled back
+	writeValue(t, offline, []string{"dirs", "users"}, "dave", "offline")
+	failure := errors.New("unresolved")
+	_, err = primary.MergeFrom(offline, []string{"dirs"}, func(c *boltdb.Conflict) ([]byte, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+	require.NoError(t, primary.View(func(r boltdb.Reader) error {
+		assert.False(t, r.KeyExists([]string{"dirs", "users"}, "dave"))
+		return nil
+	}))
+}
@@ -0,0 +1,97 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const keymetaBucket = "keymeta"
+
+// KeyMetadata describes when, and by whom, a key was created and last updated.
+type KeyMetadata struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// recordMetadata updates the metadata of key in path after it has been written by the session.
+func (s *Session) recordMetadata(path []string, key []byte) error {
+	if !s.store.config.TrackMetadata {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	md := KeyMetadata{CreatedAt: now, CreatedBy: s.principal}
+	if v := s.getShadow(keymetaBucket, path, key); v != nil {
+		if err := json.Unmarshal(v, &md); err != nil {
+			return err
+		}
+	}
+	md.UpdatedAt = now
+	md.UpdatedBy = s.principal
+
+	buf, err := json.Marshal(&md)
+	if err != nil {
+		return err
+	}
+
+	return s.putShadow(keymetaBucket, path, key, buf)
+}
+
+// deleteMetadata removes the metadata of key in path after it has been deleted by the session.
+func (s *Session) deleteMetadata(path []string, key []byte) error {
+	if !s.store.config.TrackMetadata {
+		return nil
+	}
+	return s.deleteShadow(keymetaBucket, path, key)
+}
+
+// deleteBucketMetadata removes the metadata of every key below the bucket at path.
+func (s *Session) deleteBucketMetadata(path []string) error {
+	if !s.store.config.TrackMetadata {
+		return nil
+	}
+	return s.deleteShadowBucket(keymetaBucket, path)
+}
+
+// Metadata returns the metadata of key in bucket path.
+// Metadata is only tracked when enabled in the store configuration;
+// ErrKeyNotFound is returned for keys without metadata.
+func (s *Session) Metadata(path []string, key string) (*KeyMetadata, error) {
+	s.store.logger.Trace("Session::Metadata", "path", path, "key", key)
+
+	var result *KeyMetadata
+
+	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		v := s.getShadow(keymetaBucket, path, []byte(key))
+		if v == nil {
+			return ErrKeyNotFound
+		}
+
+		var md KeyMetadata
+		if err := json.Unmarshal(v, &md); err != nil {
+			return err
+		}
+		result = &md
+
+		return nil
+	}
+
+	err := s.intercept(newOp("Metadata", path, key), func() error { return s.view(read) })
+
+	return result, wrapError("Metadata", path, key, err)
+}
+
+// Principal returns the identity of the writer the session was started for,
+// empty unless the session was started with WriteSessionAs.
+func (s *Session) Principal() string {
+	return s.principal
+}
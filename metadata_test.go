@@ -0,0 +1,101 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAs(t *testing.T, s *boltdb.Store, principal string, path []string, key string) {
+	session, closer, err := s.WriteSessionAs(principal)
+	require.NoError(t, err)
+	require.NoError(t, session.Write(path, key, []byte(key)))
+	closer()
+}
+
+func readMetadata(t *testing.T, s *boltdb.Store, path []string, key string) (*boltdb.KeyMetadata, error) {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+	return session.Metadata(path, key)
+}
+
+func TestMetadata(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{TrackMetadata: true})
+
+	path := []string{"objects", "users"}
+
+	before := time.Now()
+	writeAs(t, s, "alice", path, "k1")
+
+	created, err := readMetadata(t, s, path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", created.CreatedBy)
+	assert.Equal(t, "alice", created.UpdatedBy)
+	assert.False(t, created.CreatedAt.Before(before))
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+
+	writeAs(t, s, "bob", path, "k1")
+
+	updated, err := readMetadata(t, s, path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", updated.CreatedBy)
+	assert.Equal(t, "bob", updated.UpdatedBy)
+	assert.True(t, updated.CreatedAt.Equal(created.CreatedAt))
+	assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+
+	deleteKey(t, s, path, "k1")
+
+	_, err = readMetadata(t, s, path, "k1")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+
+	write(t, s, path, "k2")
+	md, err := readMetadata(t, s, path, "k2")
+	require.NoError(t, err)
+	assert.Empty(t, md.CreatedBy)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket([]string{"objects"}))
+	closer()
+
+	_, err = readMetadata(t, s, path, "k2")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
+
+func TestMetadataRollback(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{TrackMetadata: true})
+
+	path := []string{"objects"}
+	writeAs(t, s, "alice", path, "k1")
+
+	session, closer, err := s.WriteSessionAs("bob")
+	require.NoError(t, err)
+
+	rollbackTo, err := session.Savepoint()
+	require.NoError(t, err)
+	require.NoError(t, session.Write(path, "k1", []byte("v2")))
+	require.NoError(t, session.Write(path, "k2", []byte("v2")))
+	rollbackTo()
+	closer()
+
+	md, err := readMetadata(t, s, path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", md.UpdatedBy)
+
+	_, err = readMetadata(t, s, path, "k2")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
+
+func TestMetadataDisabled(t *testing.T) {
+	s := newTestStore(t)
+
+	writeAs(t, s, "alice", []string{"objects"}, "k1")
+
+	_, err := readMetadata(t, s, []string{"objects"}, "k1")
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
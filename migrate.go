@@ -0,0 +1,249 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	schemaBucket     = []byte("schema")
+	schemaVersionKey = []byte("version")
+)
+
+// Migration upgrades the store content to schema Version, and optionally downgrades it back.
+type Migration struct {
+	Version uint64               // schema version after the migration ran, greater than zero
+	Name    string               // description, used in logs and errors
+	Up      func(*Session) error // applies the migration in a write session
+	Down    func(*Session) error // reverts the migration in a write session, nil when it cannot be reverted
+}
+
+// MigrationStep is a migration run by DryRunMigrateTo.
+type MigrationStep struct {
+	Version uint64 // version of the migration
+	Name    string // name of the migration
+	Down    bool   // the migration is reverted
+	Buckets []Path // buckets the migration modified, in order of first modification
+}
+
+// RegisterMigration adds m to the migrations run by Migrate, ordered by version.
+// Migrations are registered before the store is opened, Open runs the pending ones.
+func (s *Store) RegisterMigration(m Migration) error {
+	if m.Version == 0 || m.Up == nil {
+		return errors.New("migration requires a version and an up function")
+	}
+
+	i := sort.Search(len(s.migrations), func(i int) bool { return s.migrations[i].Version >= m.Version })
+	if i < len(s.migrations) && s.migrations[i].Version == m.Version {
+		return errors.Errorf("migration %d already registered", m.Version)
+	}
+
+	s.migrations = append(s.migrations, Migration{})
+	copy(s.migrations[i+1:], s.migrations[i:])
+	s.migrations[i] = m
+
+	return nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the store, zero when none was.
+func (s *Store) SchemaVersion() (uint64, error) {
+	db := s.database()
+	if db == nil {
+		return 0, bolt.ErrDatabaseNotOpen
+	}
+
+	var version uint64
+	err := db.View(func(tx *bolt.Tx) error {
+		version = readSchemaVersion(tx)
+		return nil
+	})
+
+	return version, err
+}
+
+// Migrate runs the registered migrations newer than the schema version of the store, see MigrateTo.
+func (s *Store) Migrate(ctx context.Context) error {
+	if len(s.migrations) == 0 {
+		return nil
+	}
+	return s.MigrateTo(ctx, s.migrations[len(s.migrations)-1].Version)
+}
+
+// MigrateTo brings the store to schema version, zero or the version of a registered migration.
+// Newer migrations are reverted with their down function, newest first, and older pending ones
+// applied. Migrations run in a single write session: either all of them are applied or, when
+// one of them fails, none is. Returns an error when the store schema is newer than the last
+// registered migration or a migration to revert has no down function.
+func (s *Store) MigrateTo(ctx context.Context, version uint64) error {
+	_, err := s.migrateTo(ctx, version, false)
+	return err
+}
+
+// DryRunMigrateTo runs the migrations MigrateTo would run and rolls them back, reporting the
+// migrations in the order they ran along with the buckets each of them modified.
+func (s *Store) DryRunMigrateTo(ctx context.Context, version uint64) ([]MigrationStep, error) {
+	return s.migrateTo(ctx, version, true)
+}
+
+var errDryRun = errors.New("dry run")
+
+func (s *Store) migrateTo(ctx context.Context, target uint64, dryRun bool) ([]MigrationStep, error) {
+	var steps []MigrationStep
+
+	err := s.updateContext(ctx, func(session *Session) error {
+		session.recording = dryRun
+
+		plan, err := s.migrationPlan(readSchemaVersion(session.tx), target)
+		if err != nil {
+			return err
+		}
+
+		for _, step := range plan {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			m := s.migrations[step.index]
+			fn, version := m.Up, m.Version
+			if step.down {
+				fn, version = m.Down, s.previousVersion(step.index)
+			}
+
+			s.logger.Info("migrate::boltdb", "version", m.Version, "name", m.Name, "down", step.down, "dryRun", dryRun)
+
+			mark := len(session.events)
+			if err := fn(session); err != nil {
+				return errors.Wrapf(err, "migration %d %s", m.Version, m.Name)
+			}
+			if session.err != nil {
+				return errors.Wrapf(session.err, "migration %d %s", m.Version, m.Name)
+			}
+			if err := writeSchemaVersion(session.tx, version); err != nil {
+				return err
+			}
+			session.dirty = true
+
+			steps = append(steps, MigrationStep{
+				Version: m.Version,
+				Name:    m.Name,
+				Down:    step.down,
+				Buckets: eventBuckets(session.events[mark:]),
+			})
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !(dryRun && err == errDryRun) {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// plannedMigration is the index of a migration to run, and its direction.
+type plannedMigration struct {
+	index int
+	down  bool
+}
+
+// migrationPlan returns the migrations bringing the store from schema version current to target.
+func (s *Store) migrationPlan(current, target uint64) ([]plannedMigration, error) {
+	var last uint64
+	if n := len(s.migrations); n > 0 {
+		last = s.migrations[n-1].Version
+	}
+	if current > last {
+		return nil, errors.Errorf("store schema version %d is newer than the last migration %d", current, last)
+	}
+	if target != 0 && !s.hasMigration(target) {
+		return nil, errors.Errorf("migration %d not registered", target)
+	}
+
+	var plan []plannedMigration
+
+	if target >= current {
+		for i, m := range s.migrations {
+			if m.Version > current && m.Version <= target {
+				plan = append(plan, plannedMigration{index: i})
+			}
+		}
+		return plan, nil
+	}
+
+	for i := len(s.migrations) - 1; i >= 0; i-- {
+		m := s.migrations[i]
+		if m.Version <= target || m.Version > current {
+			continue
+		}
+		if m.Down == nil {
+			return nil, errors.Errorf("migration %d %s has no down function", m.Version, m.Name)
+		}
+		plan = append(plan, plannedMigration{index: i, down: true})
+	}
+
+	return plan, nil
+}
+
+func (s *Store) hasMigration(version uint64) bool {
+	i := sort.Search(len(s.migrations), func(i int) bool { return s.migrations[i].Version >= version })
+	return i < len(s.migrations) && s.migrations[i].Version == version
+}
+
+// previousVersion returns the schema version preceding the migration at index i.
+func (s *Store) previousVersion(i int) uint64 {
+	if i == 0 {
+		return 0
+	}
+	return s.migrations[i-1].Version
+}
+
+// eventBuckets returns the distinct bucket paths of events, in order of first appearance.
+func eventBuckets(events []Event) []Path {
+	var (
+		buckets []Path
+		seen    = map[string]bool{}
+	)
+	for _, event := range events {
+		key := string(keys.JoinStrings(event.Path...))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		buckets = append(buckets, Path(event.Path))
+	}
+	return buckets
+}
+
+func readSchemaVersion(tx *bolt.Tx) uint64 {
+	b := metaChild(tx, schemaBucket)
+	if b == nil {
+		return 0
+	}
+
+	buf := b.Get(schemaVersionKey)
+	if len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+func writeSchemaVersion(tx *bolt.Tx, version uint64) error {
+	b, err := createMetaChild(tx, schemaBucket)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, version)
+
+	return b.Put(schemaVersionKey, buf)
+}
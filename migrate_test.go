@@ -0,0 +1,185 @@
+package boltdb_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMigration(version uint64, key string) boltdb.Migration {
+	return boltdb.Migration{
+		Version: version,
+		Name:    "write " + key,
+		Up: func(s *boltdb.Session) error {
+			return s.Write([]string{"schema"}, key, []byte(key))
+		},
+	}
+}
+
+func TestMigrateOnOpen(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db")}
+
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.RegisterMigration(writeMigration(2, "b")))
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.Error(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.Open())
+
+	version, err := store.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	assert.Equal(t, []string{"a", "b"}, listKeys(t, store, []string{"schema"}))
+
+	deleteKey(t, store, []string{"schema"}, "a")
+	store.Close()
+
+	// reopening only runs the migrations added since
+	store = boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.RegisterMigration(writeMigration(2, "b")))
+	require.NoError(t, store.RegisterMigration(writeMigration(3, "c")))
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	version, err = store.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	assert.Equal(t, []string{"b", "c"}, listKeys(t, store, []string{"schema"}))
+}
+
+func TestMigrateAtomic(t *testing.T) {
+	store := newTestStore(t)
+
+	errFailed := errors.New("failed")
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.RegisterMigration(boltdb.Migration{
+		Version: 2,
+		Up:      func(*boltdb.Session) error { return errFailed },
+	}))
+
+	assert.ErrorIs(t, store.Migrate(context.Background()), errFailed)
+
+	version, err := store.SchemaVersion()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+	assert.False(t, session.BucketExists([]string{"schema"}))
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db")}
+
+	store := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.RegisterMigration(writeMigration(2, "b")))
+	require.NoError(t, store.Open())
+	store.Close()
+
+	older := boltdb.NewStore(cfg, &logger)
+	require.NoError(t, older.RegisterMigration(writeMigration(1, "a")))
+	assert.Error(t, older.Open())
+	older.Close()
+}
+
+func TestMigrateCanceled(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, store.Migrate(ctx), context.Canceled)
+}
+
+func reversibleMigration(version uint64, key string) boltdb.Migration {
+	m := writeMigration(version, key)
+	m.Down = func(s *boltdb.Session) error {
+		return s.DeleteKey([]string{"schema"}, key)
+	}
+	return m
+}
+
+func TestMigrateTo(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.RegisterMigration(reversibleMigration(2, "b")))
+	require.NoError(t, store.RegisterMigration(reversibleMigration(3, "c")))
+
+	require.NoError(t, store.MigrateTo(ctx, 2))
+	assert.Equal(t, []string{"a", "b"}, listKeys(t, store, []string{"schema"}))
+
+	require.NoError(t, store.Migrate(ctx))
+	assert.Equal(t, []string{"a", "b", "c"}, listKeys(t, store, []string{"schema"}))
+
+	require.NoError(t, store.MigrateTo(ctx, 1))
+	assert.Equal(t, []string{"a"}, listKeys(t, store, []string{"schema"}))
+
+	version, err := store.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	assert.Error(t, store.MigrateTo(ctx, 0)) // migration 1 has no down function
+	assert.Error(t, store.MigrateTo(ctx, 5)) // migration 5 is not registered
+}
+
+func TestDryRunMigrateTo(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.RegisterMigration(writeMigration(1, "a")))
+	require.NoError(t, store.RegisterMigration(boltdb.Migration{
+		Version: 2,
+		Name:    "split",
+		Up: func(s *boltdb.Session) error {
+			if err := s.Write([]string{"users", "active"}, "alice", []byte("1")); err != nil {
+				return err
+			}
+			if err := s.Write([]string{"schema"}, "b", []byte("b")); err != nil {
+				return err
+			}
+			return s.Write([]string{"users", "active"}, "bob", []byte("1"))
+		},
+		Down: func(s *boltdb.Session) error {
+			return s.DeleteBucket([]string{"users"})
+		},
+	}))
+
+	steps, err := store.DryRunMigrateTo(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.MigrationStep{
+		{Version: 1, Name: "write a", Buckets: []boltdb.Path{{"schema"}}},
+		{Version: 2, Name: "split", Buckets: []boltdb.Path{{"users", "active"}, {"schema"}}},
+	}, steps)
+
+	version, err := store.SchemaVersion()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	assert.False(t, session.BucketExists([]string{"schema"}))
+	closer()
+
+	require.NoError(t, store.Migrate(ctx))
+
+	steps, err = store.DryRunMigrateTo(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.MigrationStep{
+		{Version: 2, Name: "split", Down: true, Buckets: []boltdb.Path{{"users"}}},
+	}, steps)
+	assert.Equal(t, []string{"alice", "bob"}, listKeys(t, store, []string{"users", "active"}))
+}
@@ -0,0 +1,90 @@
+package boltdb
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Increment adds delta to the counter stored in key in bucket path and returns the new total.
+// Counters are stored as base 10 integers; a missing key is initialized to zero.
+// Returns ErrNotNumeric when the current value is not a counter, and ErrOverflow
+// when the new total does not fit in an int64.
+func (s *Session) Increment(path []string, key string, delta int64) (int64, error) {
+	s.store.logger.Trace("Session::Increment", "path", path, "key", key, "delta", delta)
+
+	var total int64
+
+	err := s.modify("Increment", path, []byte(key), 0, func(current []byte) ([]byte, error) {
+		var n int64
+		if current != nil {
+			var err error
+			if n, err = strconv.ParseInt(string(current), 10, 64); err != nil {
+				return nil, errors.Wrapf(ErrNotNumeric, "%q", current)
+			}
+		}
+
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, ErrOverflow
+		}
+
+		total = n + delta
+
+		return []byte(strconv.FormatInt(total, 10)), nil
+	})
+	if err != nil {
+		return 0, wrapError("Increment", path, key, err)
+	}
+
+	return total, nil
+}
+
+// Append appends data to the value of key in bucket path, creating the key when absent.
+func (s *Session) Append(path []string, key string, data []byte) error {
+	s.store.logger.Trace("Session::Append", "path", path, "key", key, "size", len(data))
+
+	err := s.modify("Append", path, []byte(key), len(data), func(current []byte) ([]byte, error) {
+		return append(current, data...), nil
+	})
+
+	return wrapError("Append", path, key, err)
+}
+
+// modify replaces the value of key in bucket path with the value returned by fn, given the
+// current value or nil when the key does not exist, in a single mutating operation op
+// charging size bytes against the rate limits.
+func (s *Session) modify(name string, path []string, key []byte, size int, fn func(current []byte) ([]byte, error)) error {
+	write := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		current := s.currentValue(path, key)
+		if isRef(current) {
+			var err error
+			if current, err = s.resolveRef(current); err != nil {
+				return err
+			}
+		} else if current != nil {
+			current = append([]byte{}, current...)
+		}
+
+		value, err := fn(current)
+		if err != nil {
+			return err
+		}
+
+		if err := checkKeyValue(key, value); err != nil {
+			return err
+		}
+
+		return s.put(path, key, value)
+	}
+
+	op := newOp(name, path, string(key))
+	op.size = size
+
+	return s.intercept(op, func() error { return s.update(write) })
+}
@@ -0,0 +1,67 @@
+package boltdb_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrement(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"counters"}
+
+	increment := func(key string, delta int64) (int64, error) {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return session.Increment(path, key, delta)
+	}
+
+	total, err := increment("hits", 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+
+	total, err = increment("hits", -7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-2), total)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	value, err := session.Read(path, "hits")
+	closer()
+	require.NoError(t, err)
+	assert.Equal(t, "-2", string(value))
+
+	_, err = increment("hits", math.MinInt64)
+	assert.True(t, errors.Is(err, boltdb.ErrOverflow))
+
+	writeValue(t, s, path, "name", "not a number")
+	_, err = increment("name", 1)
+	assert.True(t, errors.Is(err, boltdb.ErrNotNumeric))
+}
+
+func TestAppend(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"logs"}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Append(path, "log", []byte("a")))
+	require.NoError(t, session.Append(path, "log", []byte("bc")))
+	require.NoError(t, session.Append(path, "log", nil))
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(path, "log")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(value))
+}
@@ -0,0 +1,103 @@
+package boltdb
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// MoveKey renames oldKey to newKey in bucket path.
+// Returns ErrKeyNotFound when oldKey does not exist, and ErrKeyExists when
+// newKey already exists and overwrite is false.
+func (s *Session) MoveKey(path []string, oldKey, newKey string, overwrite bool) error {
+	s.store.logger.Trace("Session::MoveKey", "path", path, "old", oldKey, "new", newKey)
+
+	move := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		return s.moveKey(path, []byte(oldKey), path, []byte(newKey), MoveOptions{Overwrite: overwrite})
+	}
+
+	err := s.intercept(newOp("MoveKey", path, oldKey), func() error { return s.update(move) })
+
+	return wrapError("MoveKey", path, oldKey, err)
+}
+
+// MoveOptions controls how a key is moved.
+type MoveOptions struct {
+	// Overwrite replaces an existing destination key instead of failing with ErrKeyExists.
+	Overwrite bool
+	// Transform, when set, returns the value written to the destination given the source value.
+	// An error returned by Transform aborts the move.
+	Transform func(value []byte) ([]byte, error)
+}
+
+// MoveKeyAcross moves key from bucket srcPath to bucket dstPath, creating dstPath when needed.
+// Returns ErrKeyNotFound when key does not exist in srcPath, and ErrKeyExists when
+// it already exists in dstPath unless opts.Overwrite is set.
+func (s *Session) MoveKeyAcross(srcPath, dstPath []string, key string, opts MoveOptions) error {
+	s.store.logger.Trace("Session::MoveKeyAcross", "src", srcPath, "dst", dstPath, "key", key)
+
+	move := func(tx *bolt.Tx) error {
+		if err := Path(srcPath).Validate(); err != nil {
+			return err
+		}
+		if err := Path(dstPath).Validate(); err != nil {
+			return err
+		}
+		return s.moveKey(srcPath, []byte(key), dstPath, []byte(key), opts)
+	}
+
+	op := newOp("MoveKeyAcross", srcPath, key)
+	op.Target = dstPath
+
+	err := s.intercept(op, func() error { return s.update(move) })
+
+	return wrapError("MoveKeyAcross", srcPath, key, err)
+}
+
+// moveKey moves srcKey in srcPath to dstKey in dstPath. The paths must have been validated.
+func (s *Session) moveKey(srcPath []string, srcKey []byte, dstPath []string, dstKey []byte, opts MoveOptions) error {
+	value := s.currentValue(srcPath, srcKey)
+	if value == nil {
+		return ErrKeyNotFound
+	}
+	if isRef(value) {
+		var err error
+		if value, err = s.resolveRef(value); err != nil {
+			return err
+		}
+	} else {
+		// bolt values are only valid until the transaction is modified
+		value = append([]byte{}, value...)
+	}
+
+	same := len(srcPath) == len(dstPath) && hasPathPrefix(srcPath, dstPath) && string(srcKey) == string(dstKey)
+
+	if !same && !opts.Overwrite && s.currentValue(dstPath, dstKey) != nil {
+		return &StoreError{Path: dstPath, Key: string(dstKey), Err: ErrKeyExists}
+	}
+
+	if opts.Transform != nil {
+		var err error
+		if value, err = opts.Transform(value); err != nil {
+			return err
+		}
+	}
+
+	if same {
+		if opts.Transform == nil {
+			return nil
+		}
+		return s.put(dstPath, dstKey, value)
+	}
+
+	if err := checkKeyValue(dstKey, value); err != nil {
+		return err
+	}
+
+	if err := s.put(dstPath, dstKey, value); err != nil {
+		return err
+	}
+
+	return s.delete(srcPath, srcKey)
+}
@@ -0,0 +1,138 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveKey(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"objects"}
+	write(t, s, path, "k1")
+	write(t, s, path, "k2")
+
+	move := func(oldKey, newKey string, overwrite bool) error {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return session.MoveKey(path, oldKey, newKey, overwrite)
+	}
+
+	require.NoError(t, move("k1", "k3", false))
+	assert.Equal(t, []string{"k2", "k3"}, listKeys(t, s, path))
+
+	err := move("k2", "k3", false)
+	assert.True(t, errors.Is(err, boltdb.ErrKeyExists))
+	assert.Equal(t, []string{"k2", "k3"}, listKeys(t, s, path))
+
+	require.NoError(t, move("k2", "k3", true))
+	assert.Equal(t, []string{"k3"}, listKeys(t, s, path))
+
+	require.NoError(t, move("k3", "k3", false))
+	assert.Equal(t, []string{"k3"}, listKeys(t, s, path))
+
+	err = move("missing", "k4", false)
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(path, "k3")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", string(value))
+}
+
+func TestMoveKeyAcross(t *testing.T) {
+	s := newTestStore(t)
+
+	staged := []string{"objects", "staged"}
+	active := []string{"objects", "active"}
+	write(t, s, staged, "k1")
+	write(t, s, staged, "k2")
+	write(t, s, active, "k2")
+
+	move := func(key string, opts boltdb.MoveOptions) error {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		return session.MoveKeyAcross(staged, active, key, opts)
+	}
+
+	require.NoError(t, move("k1", boltdb.MoveOptions{
+		Transform: func(value []byte) ([]byte, error) {
+			return append(value, "-active"...), nil
+		},
+	}))
+
+	err := move("k2", boltdb.MoveOptions{})
+	assert.True(t, errors.Is(err, boltdb.ErrKeyExists))
+
+	errTransform := errors.New("rejected")
+	err = move("k2", boltdb.MoveOptions{
+		Overwrite: true,
+		Transform: func([]byte) ([]byte, error) { return nil, errTransform },
+	})
+	assert.True(t, errors.Is(err, errTransform))
+
+	assert.Equal(t, []string{"k2"}, listKeys(t, s, staged))
+	assert.Equal(t, []string{"k1", "k2"}, listKeys(t, s, active))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.Read(active, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1-active", string(value))
+}
+
+func TestMoveKeyRefs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blobs")
+	blobs, err := boltdb.NewDirBlobs(dir)
+	require.NoError(t, err)
+
+	s := newTestStoreWithConfig(t, &boltdb.Config{Dedup: true, ChunkSize: 8})
+	s.SetBlobProvider(blobs, 1000)
+
+	src := []string{"src"}
+	dst := []string{"dst"}
+	deduped := strings.Repeat("deduplicated ", 8)
+	external := strings.Repeat("external ", 200)
+
+	writeValue(t, s, src, "dedup", deduped)
+	writeValue(t, s, src, "shared", deduped)
+	writeValue(t, s, src, "blob", external)
+	writeStream(t, s, src, "stream", streamed)
+
+	move := func(key string, opts boltdb.MoveOptions) {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		defer closer()
+		require.NoError(t, session.MoveKeyAcross(src, dst, key, opts))
+	}
+
+	upper := boltdb.MoveOptions{Transform: func(value []byte) ([]byte, error) { return bytes.ToUpper(value), nil }}
+
+	move("dedup", upper)
+	move("blob", upper)
+	move("stream", boltdb.MoveOptions{})
+
+	assert.Equal(t, strings.ToUpper(deduped), readValue(t, s, dst, "dedup"))
+	assert.Equal(t, strings.ToUpper(external), readValue(t, s, dst, "blob"))
+	assert.Equal(t, streamed, readValue(t, s, dst, "stream"))
+	// the deduplicated value of the source is still referenced
+	assert.Equal(t, deduped, readValue(t, s, src, "shared"))
+
+	move("shared", boltdb.MoveOptions{})
+	assert.Equal(t, deduped, readValue(t, s, dst, "shared"))
+	assert.Empty(t, listKeys(t, s, src))
+}
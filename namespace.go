@@ -0,0 +1,707 @@
+package boltdb
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// namespaceRoot is the root bucket holding the buckets of every namespace.
+const namespaceRoot = "tenants"
+
+// NamespaceStore is a StoreAPI confined to the buckets of a single tenant, see Namespace.
+type NamespaceStore struct {
+	store StoreAPI
+	root  Path
+}
+
+var (
+	_ StoreAPI = (*NamespaceStore)(nil)
+	_ Writer   = (*nsWriter)(nil)
+)
+
+// Namespace returns a view of store confined to the buckets of tenant tenantID, stored below the
+// bucket path ["tenants", tenantID]. Sessions of the namespace take paths relative to the tenant
+// root and return paths relative to it, so no operation can reach the buckets of other tenants.
+// Index queries and audit logs only return entries of the namespace, and RebuildIndex, which
+// rebuilds the index of every tenant, is rejected with ErrInvalidPath.
+func Namespace(store StoreAPI, tenantID string) (*NamespaceStore, error) {
+	root := Path{namespaceRoot, tenantID}
+	if err := root.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid tenant id %q", tenantID)
+	}
+	return &NamespaceStore{store: store, root: root}, nil
+}
+
+// Scope returns a view of store confined to the buckets below prefix, such as those of a model version,
+// with the guarantees of the views returned by Namespace.
+func Scope(store StoreAPI, prefix Path) (*NamespaceStore, error) {
+	if err := prefix.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid scope")
+	}
+	return &NamespaceStore{store: store, root: append(Path{}, prefix...)}, nil
+}
+
+// WithPrefix returns the session confined to the buckets below prefix, taking and returning paths relative
+// to prefix, see Scope. Writes through the returned Writer fail when the session is a read session.
+// Operations fail with ErrInvalidPath when prefix is not a valid path.
+func (s *Session) WithPrefix(prefix []string) Writer {
+	root := append(Path{}, prefix...)
+	return &nsWriter{nsReader: nsReader{r: s, root: root}, w: s}
+}
+
+// Root returns the bucket path of the namespace in the underlying store.
+func (n *NamespaceStore) Root() Path {
+	return append(Path{}, n.root...)
+}
+
+// View runs fn in a read session of the namespace.
+func (n *NamespaceStore) View(fn func(Reader) error) error {
+	return n.store.View(func(r Reader) error {
+		return fn(&nsReader{r: r, root: n.root})
+	})
+}
+
+// Update runs fn in a write session of the namespace.
+func (n *NamespaceStore) Update(fn func(Writer) error) error {
+	return n.store.Update(func(w Writer) error {
+		return fn(&nsWriter{nsReader: nsReader{r: w, root: n.root}, w: w})
+	})
+}
+
+// Revision returns the revision of the underlying store.
+func (n *NamespaceStore) Revision() uint64 {
+	return n.store.Revision()
+}
+
+// WatchFrom returns the events of the namespace below prefix, with paths relative to the namespace.
+func (n *NamespaceStore) WatchFrom(rev uint64, prefix Path) (<-chan Event, func()) {
+	in, stopIn := n.store.WatchFrom(rev, n.abs(prefix))
+
+	out := make(chan Event)
+	done := make(chan struct{})
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			stopIn()
+		})
+	}
+
+	go func() {
+		defer close(out)
+		for event := range in {
+			event.Path = n.rel(event.Path)
+			select {
+			case out <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return out, stop
+}
+
+func (n *NamespaceStore) abs(path []string) []string {
+	return append(append([]string{}, n.root...), path...)
+}
+
+func (n *NamespaceStore) rel(path []string) []string {
+	return append([]string{}, path[len(n.root):]...)
+}
+
+// nsReader is a Reader confined to a namespace.
+type nsReader struct {
+	r    Reader
+	root Path
+}
+
+// abs returns the path of the bucket at the relative path in the underlying store.
+func (n *nsReader) abs(path []string) ([]string, error) {
+	if err := Path(path).Validate(); err != nil {
+		return nil, err
+	}
+	return append(append([]string{}, n.root...), path...), nil
+}
+
+// rel returns the path relative to the namespace of path, which must be below the namespace root.
+func (n *nsReader) rel(path []string) []string {
+	return append([]string{}, path[len(n.root):]...)
+}
+
+func (n *nsReader) contains(path []string) bool {
+	return len(path) > len(n.root) && hasPathPrefix(path, n.root)
+}
+
+// err strips the namespace root from the path of the StoreError carried by err.
+func (n *nsReader) err(err error) error {
+	var se *StoreError
+	if errors.As(err, &se) && hasPathPrefix(se.Path, n.root) {
+		se.Path = n.rel(se.Path)
+	}
+	return err
+}
+
+func (n *nsReader) Revision() uint64 {
+	return n.r.Revision()
+}
+
+func (n *nsReader) Read(path []string, key string) ([]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.r.Read(p, key)
+	return v, n.err(err)
+}
+
+func (n *nsReader) ReadB(path []string, key []byte) ([]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.r.ReadB(p, key)
+	return v, n.err(err)
+}
+
+func (n *nsReader) ReadUint64Key(path []string, key uint64) ([]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.r.ReadUint64Key(p, key)
+	return v, n.err(err)
+}
+
+func (n *nsReader) ReadStream(path []string, key string) (io.ReadCloser, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.ReadStream(p, key)
+	return r, n.err(err)
+}
+
+func (n *nsReader) ReadWithETag(path []string, key string) ([]byte, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	v, etag, err := n.r.ReadWithETag(p, key)
+	return v, etag, n.err(err)
+}
+
+func (n *nsReader) ReadField(path []string, key, pointer string) ([]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.r.ReadField(p, key, pointer)
+	return v, n.err(err)
+}
+
+func (n *nsReader) ReadAt(path []string, key string, rev uint64) ([]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.r.ReadAt(p, key, rev)
+	return v, n.err(err)
+}
+
+func (n *nsReader) History(path []string, key string, limit int) ([]Version, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := n.r.History(p, key, limit)
+	return versions, n.err(err)
+}
+
+func (n *nsReader) Metadata(path []string, key string) (*KeyMetadata, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	md, err := n.r.Metadata(p, key)
+	return md, n.err(err)
+}
+
+func (n *nsReader) KeyExists(path []string, key string) bool {
+	p, err := n.abs(path)
+	return err == nil && n.r.KeyExists(p, key)
+}
+
+func (n *nsReader) KeyExistsB(path []string, key []byte) bool {
+	p, err := n.abs(path)
+	return err == nil && n.r.KeyExistsB(p, key)
+}
+
+func (n *nsReader) PrefixExists(path []string, prefix string) (bool, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return false, err
+	}
+	ok, err := n.r.PrefixExists(p, prefix)
+	return ok, n.err(err)
+}
+
+func (n *nsReader) BucketExists(path []string) bool {
+	p, err := n.abs(path)
+	return err == nil && n.r.BucketExists(p)
+}
+
+func (n *nsReader) CurrentSeq(path []string) (uint64, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := n.r.CurrentSeq(p)
+	return seq, n.err(err)
+}
+
+// BucketInfo reports the depth of path relative to the namespace root.
+func (n *nsReader) BucketInfo(path []string) (*BucketInfo, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := n.r.BucketInfo(p)
+	if err == nil {
+		info.Depth = len(path)
+	}
+	return info, n.err(err)
+}
+
+func (n *nsReader) Sample(path []string, size int) ([]string, [][]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, values, err := n.r.Sample(p, size)
+	return keys, values, n.err(err)
+}
+
+func (n *nsReader) KeyHistogram(path []string, prefixDepth int) (*KeyHistogram, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	histogram, err := n.r.KeyHistogram(p, prefixDepth)
+	return histogram, n.err(err)
+}
+
+func (n *nsReader) ValueSizeStats(path []string) (*ValueSizeStats, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := n.r.ValueSizeStats(p)
+	return stats, n.err(err)
+}
+
+func (n *nsReader) List(path []string, pageToken string) ([]string, [][]byte, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	keys, values, next, err := n.r.List(p, pageToken)
+	return keys, values, next, n.err(err)
+}
+
+func (n *nsReader) ListEntries(path []string, pageToken string) ([]Entry, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next, err := n.r.ListEntries(p, pageToken)
+	return entries, next, n.err(err)
+}
+
+func (n *nsReader) ListKeys(path []string, pageToken string) ([]string, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	keys, next, err := n.r.ListKeys(p, pageToken)
+	return keys, next, n.err(err)
+}
+
+// Paginate walks the keys of path through the List and ListKeys of the namespace.
+func (n *nsReader) Paginate(path []string, opts PaginateOptions) *Paginator {
+	return newPaginator(n, path, opts)
+}
+
+// ListBuckets lists the buckets of the namespace root when path is empty.
+func (n *nsReader) ListBuckets(path []string, pageToken string) ([]string, string, error) {
+	p := []string(n.root)
+	if len(path) > 0 {
+		var err error
+		if p, err = n.abs(path); err != nil {
+			return nil, "", err
+		}
+	}
+	buckets, next, err := n.r.ListBuckets(p, pageToken)
+	return buckets, next, n.err(err)
+}
+
+// Tree returns the tree of the namespace root when path is empty, its root node unnamed.
+func (n *nsReader) Tree(path []string, depth int) (*Node, error) {
+	p := []string(n.root)
+	if len(path) > 0 {
+		var err error
+		if p, err = n.abs(path); err != nil {
+			return nil, err
+		}
+	}
+	node, err := n.r.Tree(p, depth)
+	if err == nil && len(path) == 0 {
+		node.Name = ""
+	}
+	return node, n.err(err)
+}
+
+func (n *nsReader) ReadScan(path []string, prefix string) ([]string, [][]byte, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, values, err := n.r.ReadScan(p, prefix)
+	return keys, values, n.err(err)
+}
+
+func (n *nsReader) ScanB(path []string, start []byte, fn func(key, value []byte) bool) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.r.ScanB(p, start, fn))
+}
+
+func (n *nsReader) ScanMatch(path []string, pattern string, kind MatchKind, pageToken string) ([]string, [][]byte, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	keys, values, next, err := n.r.ScanMatch(p, pattern, kind, pageToken)
+	return keys, values, next, n.err(err)
+}
+
+// QueryIndex returns the entries of the namespace matching q. Pages hold fewer entries than
+// those of the underlying store, since entries of other namespaces are skipped.
+func (n *nsReader) QueryIndex(name string, q IndexQuery, pageToken string) ([]IndexEntry, string, error) {
+	entries, next, err := n.r.QueryIndex(name, q, pageToken)
+	if err != nil {
+		return nil, "", n.err(err)
+	}
+	return n.indexEntries(entries), next, nil
+}
+
+func (n *nsReader) SearchTokens(path []string, query, pageToken string) ([]IndexEntry, string, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next, err := n.r.SearchTokens(p, query, pageToken)
+	if err != nil {
+		return nil, "", n.err(err)
+	}
+	return n.indexEntries(entries), next, nil
+}
+
+func (n *nsReader) indexEntries(entries []IndexEntry) []IndexEntry {
+	result := []IndexEntry{}
+	for _, e := range entries {
+		if n.contains(e.Path) {
+			result = append(result, IndexEntry{Path: n.rel(e.Path), Key: e.Key})
+		}
+	}
+	return result
+}
+
+// AuditLog returns the audit records of the namespace matching q.
+func (n *nsReader) AuditLog(q AuditQuery, pageToken string) ([]AuditRecord, string, error) {
+	q.Path = append(append([]string{}, n.root...), q.Path...)
+
+	records, next, err := n.r.AuditLog(q, pageToken)
+	if err != nil {
+		return nil, "", n.err(err)
+	}
+	for i := range records {
+		if n.contains(records[i].Path) {
+			records[i].Path = n.rel(records[i].Path)
+		}
+	}
+	return records, next, nil
+}
+
+// nsWriter is a Writer confined to a namespace.
+type nsWriter struct {
+	nsReader
+	w Writer
+}
+
+func (n *nsWriter) Principal() string {
+	return n.w.Principal()
+}
+
+func (n *nsWriter) Savepoint() (func(), error) {
+	return n.w.Savepoint()
+}
+
+func (n *nsWriter) OnCommit(fn func()) {
+	n.w.OnCommit(fn)
+}
+
+func (n *nsWriter) OnRollback(fn func()) {
+	n.w.OnRollback(fn)
+}
+
+func (n *nsWriter) Write(path []string, key string, value []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.Write(p, key, value))
+}
+
+func (n *nsWriter) WriteB(path []string, key, value []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.WriteB(p, key, value))
+}
+
+func (n *nsWriter) WriteUint64Key(path []string, key uint64, value []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.WriteUint64Key(p, key, value))
+}
+
+func (n *nsWriter) WriteMany(path []string, values map[string][]byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.WriteMany(p, values))
+}
+
+func (n *nsWriter) WriteIfMatch(path []string, key string, value []byte, etag string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.WriteIfMatch(p, key, value, etag))
+}
+
+func (n *nsWriter) WriteIfNoneMatch(path []string, key string, value []byte, etag string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.WriteIfNoneMatch(p, key, value, etag))
+}
+
+func (n *nsWriter) DeleteKey(path []string, key string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.DeleteKey(p, key))
+}
+
+func (n *nsWriter) DeleteKeyB(path []string, key []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.DeleteKeyB(p, key))
+}
+
+func (n *nsWriter) DeleteUint64Key(path []string, key uint64) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.DeleteUint64Key(p, key))
+}
+
+func (n *nsWriter) DeleteMany(path []string, keys []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.DeleteMany(p, keys))
+}
+
+func (n *nsWriter) MoveKey(path []string, oldKey, newKey string, overwrite bool) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.MoveKey(p, oldKey, newKey, overwrite))
+}
+
+func (n *nsWriter) MoveKeyAcross(srcPath, dstPath []string, key string, opts MoveOptions) error {
+	src, err := n.abs(srcPath)
+	if err != nil {
+		return err
+	}
+	dst, err := n.abs(dstPath)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.MoveKeyAcross(src, dst, key, opts))
+}
+
+func (n *nsWriter) Increment(path []string, key string, delta int64) (int64, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := n.w.Increment(p, key, delta)
+	return v, n.err(err)
+}
+
+func (n *nsWriter) WriteStream(path []string, key string) (io.WriteCloser, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := n.w.WriteStream(p, key)
+	if err != nil {
+		return nil, n.err(err)
+	}
+	return &nsStreamWriter{w: w, ns: &n.nsReader}, nil
+}
+
+// nsStreamWriter strips the namespace root from the errors of a stream writer.
+type nsStreamWriter struct {
+	w  io.WriteCloser
+	ns *nsReader
+}
+
+func (n *nsStreamWriter) Write(p []byte) (int, error) {
+	c, err := n.w.Write(p)
+	return c, n.ns.err(err)
+}
+
+func (n *nsStreamWriter) Close() error {
+	return n.ns.err(n.w.Close())
+}
+
+func (n *nsWriter) Append(path []string, key string, data []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.Append(p, key, data))
+}
+
+func (n *nsWriter) Merge(path []string, key string, partial []byte) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.Merge(p, key, partial))
+}
+
+func (n *nsWriter) PatchJSON(path []string, key string, patch []byte, mode PatchMode) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.PatchJSON(p, key, patch, mode))
+}
+
+func (n *nsWriter) NextSeq(path []string) (uint64, error) {
+	p, err := n.abs(path)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := n.w.NextSeq(p)
+	return seq, n.err(err)
+}
+
+func (n *nsWriter) SetSeq(path []string, v uint64) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.SetSeq(p, v))
+}
+
+func (n *nsWriter) ResetSeq(path []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.ResetSeq(p))
+}
+
+func (n *nsWriter) CreateBucket(path []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.CreateBucket(p))
+}
+
+func (n *nsWriter) DeleteBucket(path []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.DeleteBucket(p))
+}
+
+func (n *nsWriter) TruncateBucket(path []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.TruncateBucket(p))
+}
+
+func (n *nsWriter) TruncateBucketRecursive(path []string) error {
+	p, err := n.abs(path)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.TruncateBucketRecursive(p))
+}
+
+func (n *nsWriter) CopyBucket(src, dst []string) error {
+	s, err := n.abs(src)
+	if err != nil {
+		return err
+	}
+	d, err := n.abs(dst)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.CopyBucket(s, d))
+}
+
+func (n *nsWriter) MoveBucket(src, dst []string) error {
+	s, err := n.abs(src)
+	if err != nil {
+		return err
+	}
+	d, err := n.abs(dst)
+	if err != nil {
+		return err
+	}
+	return n.err(n.w.MoveBucket(s, d))
+}
+
+// RebuildIndex is rejected, since indexes span every namespace.
+func (n *nsWriter) RebuildIndex(name string) error {
+	return &StoreError{Op: "RebuildIndex", Key: name, Err: errors.Wrap(ErrInvalidPath, "indexes span every namespace")}
+}
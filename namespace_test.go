@@ -0,0 +1,157 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespace(t *testing.T) {
+	store := newTestStore(t)
+
+	acme, err := boltdb.Namespace(store, "acme")
+	require.NoError(t, err)
+	globex, err := boltdb.Namespace(store, "globex")
+	require.NoError(t, err)
+
+	require.NoError(t, acme.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users"}, "alice", []byte("acme"))
+	}))
+	require.NoError(t, globex.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users"}, "alice", []byte("globex"))
+	}))
+
+	assert.Equal(t, "acme", readValue(t, store, []string{"tenants", "acme", "users"}, "alice"))
+
+	require.NoError(t, acme.View(func(r boltdb.Reader) error {
+		v, err := r.Read([]string{"users"}, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("acme"), v)
+
+		buckets, _, err := r.ListBuckets(nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users"}, buckets)
+
+		_, err = r.Read([]string{"users"}, "bob")
+		var se *boltdb.StoreError
+		require.ErrorAs(t, err, &se)
+		assert.Equal(t, []string{"users"}, se.Path)
+		return nil
+	}))
+
+	require.NoError(t, globex.View(func(r boltdb.Reader) error {
+		v, err := r.Read([]string{"users"}, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("globex"), v)
+		return nil
+	}))
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	store := newTestStore(t)
+
+	_, err := boltdb.Namespace(store, "")
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+
+	acme, err := boltdb.Namespace(store, "acme")
+	require.NoError(t, err)
+
+	err = acme.Update(func(w boltdb.Writer) error {
+		return w.Write(nil, "k", []byte("v"))
+	})
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+
+	err = acme.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"__meta"}, "k", []byte("v"))
+	})
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+
+	err = acme.Update(func(w boltdb.Writer) error {
+		return w.RebuildIndex("by_name")
+	})
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+
+	// deleting the whole namespace does not touch other tenants
+	writeValue(t, store, []string{"tenants", "globex", "users"}, "alice", "globex")
+	require.NoError(t, acme.Update(func(w boltdb.Writer) error {
+		return w.DeleteBucket([]string{"users"})
+	}))
+	assert.Equal(t, "globex", readValue(t, store, []string{"tenants", "globex", "users"}, "alice"))
+}
+
+func TestNamespaceWatch(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	acme, err := boltdb.Namespace(store, "acme")
+	require.NoError(t, err)
+
+	events, stop := acme.WatchFrom(store.Revision(), nil)
+	defer stop()
+
+	writeValue(t, store, []string{"tenants", "globex", "users"}, "alice", "globex")
+	require.NoError(t, acme.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users"}, "alice", []byte("acme"))
+	}))
+
+	select {
+	case event := <-events:
+		assert.Equal(t, []string{"users"}, event.Path)
+		assert.Equal(t, []byte("alice"), event.Key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestSessionWithPrefix(t *testing.T) {
+	store := newTestStore(t)
+
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+
+	scoped := session.WithPrefix([]string{"models", "v2"})
+	require.NoError(t, scoped.Write([]string{"objects"}, "doc", []byte("v2")))
+	require.NoError(t, session.Write([]string{"models", "v1", "objects"}, "doc", []byte("v1")))
+
+	v, err := scoped.Read([]string{"objects"}, "doc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), v)
+
+	buckets, _, err := scoped.ListBuckets(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"objects"}, buckets)
+
+	closer()
+
+	assert.Equal(t, "v2", readValue(t, store, []string{"models", "v2", "objects"}, "doc"))
+
+	reader, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, err = reader.WithPrefix([]string{"models", "v2"}).Read([]string{"objects"}, "missing")
+	var se *boltdb.StoreError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, []string{"objects"}, se.Path)
+
+	_, err = reader.WithPrefix([]string{"models", ""}).Read([]string{"objects"}, "doc")
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+}
+
+func TestScope(t *testing.T) {
+	store := newTestStore(t)
+
+	v1, err := boltdb.Scope(store, boltdb.Path{"models", "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, boltdb.Path{"models", "v1"}, v1.Root())
+
+	require.NoError(t, v1.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"objects"}, "doc", []byte("v1"))
+	}))
+	assert.Equal(t, "v1", readValue(t, store, []string{"models", "v1", "objects"}, "doc"))
+
+	_, err = boltdb.Scope(store, boltdb.Path{})
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+}
@@ -0,0 +1,129 @@
+package boltdb
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	pageTokenVersion byte = 2
+	pageTokenMACSize      = 16
+)
+
+// pageTokenInclusive is the version of the tokens holding the first key of the next page, issued
+// before tokens held the last key of their page. They are still accepted.
+const pageTokenInclusive byte = 1
+
+// tokenCodec encodes cursor positions into opaque page tokens. Tokens hold the last key of their
+// page, the next page starting after it, so keys inserted or deleted around the page boundary
+// between two pages are neither repeated nor skipped.
+// When a secret is configured the tokens are signed with a truncated HMAC-SHA256,
+// so tampered tokens are rejected instead of silently seeking to an arbitrary key.
+type tokenCodec struct {
+	secret []byte
+}
+
+func newTokenCodec(secret string) tokenCodec {
+	if secret == "" {
+		return tokenCodec{}
+	}
+	return tokenCodec{secret: []byte(secret)}
+}
+
+// encode returns the token of the page following key, the last key of the current page.
+func (c tokenCodec) encode(key []byte) string {
+	payload := make([]byte, 0, 1+len(key)+pageTokenMACSize)
+	payload = append(payload, pageTokenVersion)
+	payload = append(payload, key...)
+
+	if c.secret != nil {
+		payload = append(payload, c.mac(payload)...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decode returns the cursor key of the given page token, nil for the empty token, and whether
+// the page starts after the key, rather than at the key for the tokens of pageTokenInclusive.
+func (c tokenCodec) decode(token string) ([]byte, bool, error) {
+	if token == "" {
+		return nil, false, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false, errors.Wrapf(ErrInvalidPageToken, "token [%s]", token)
+	}
+
+	if c.secret != nil {
+		if len(payload) < 1+pageTokenMACSize {
+			return nil, false, errors.Wrapf(ErrInvalidPageToken, "token [%s]", token)
+		}
+		sig := payload[len(payload)-pageTokenMACSize:]
+		payload = payload[:len(payload)-pageTokenMACSize]
+		if !hmac.Equal(sig, c.mac(payload)) {
+			return nil, false, errors.Wrapf(ErrInvalidPageToken, "token [%s] signature mismatch", token)
+		}
+	}
+
+	if len(payload) < 2 || (payload[0] != pageTokenVersion && payload[0] != pageTokenInclusive) {
+		return nil, false, errors.Wrapf(ErrInvalidPageToken, "token [%s]", token)
+	}
+
+	return payload[1:], payload[0] == pageTokenVersion, nil
+}
+
+func (c tokenCodec) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	_, _ = h.Write(payload)
+	return h.Sum(nil)[:pageTokenMACSize]
+}
+
+// seekPage positions cursor at the first entry of the page starting at start, or after start,
+// as decoded by tokenCodec.decode. A nil start is the first entry of the cursor.
+func seekPage(cursor *bolt.Cursor, start []byte, after bool) ([]byte, []byte) {
+	if start == nil {
+		return cursor.First()
+	}
+
+	k, v := cursor.Seek(start)
+	if after && k != nil && bytes.Equal(k, start) {
+		k, v = cursor.Next()
+	}
+
+	return k, v
+}
+
+// page walks a single page of the cursor, starting after the position encoded in pageToken,
+// and calls fn for every entry. Only entries for which fn returns true count towards the page size.
+// It returns the token of the following page, or an empty token when the cursor has been exhausted.
+func (s *Session) page(cursor *bolt.Cursor, pageToken string, fn func(k, v []byte) bool) (string, error) {
+	start, after, err := s.store.tokens.decode(pageToken)
+	if err != nil {
+		return "", err
+	}
+
+	var last []byte
+
+	k, v := seekPage(cursor, start, after)
+	for i := int32(0); i < pageSize && k != nil; k, v = cursor.Next() {
+		if err := s.checkDeadline(); err != nil {
+			return "", err
+		}
+		if fn(k, v) {
+			i++
+		}
+		last = k
+	}
+
+	if k == nil {
+		return "", nil
+	}
+
+	return s.store.tokens.encode(last), nil
+}
@@ -0,0 +1,158 @@
+package boltdb_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageTokens(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{PageTokenSecret: "secret"})
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	for i := 0; i < 150; i++ {
+		require.NoError(t, session.Write([]string{"paging"}, fmt.Sprintf("key-%03d", i), []byte("value")))
+	}
+
+	keys, next, err := session.ListKeys([]string{"paging"}, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 100)
+	assert.NotEmpty(t, next)
+	assert.NotContains(t, next, "key-100")
+
+	keys, values, last, err := session.List([]string{"paging"}, next)
+	require.NoError(t, err)
+	assert.Len(t, keys, 50)
+	assert.Len(t, values, 50)
+	assert.Equal(t, "key-100", keys[0])
+	assert.Empty(t, last)
+
+	_, _, err = session.ListKeys([]string{"paging"}, next+"x")
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPageToken))
+
+	_, _, _, err = session.List([]string{"paging"}, "key-100")
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPageToken))
+}
+
+func writeKeys(t *testing.T, s *boltdb.Store, path []string, n int) {
+	t.Helper()
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < n; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%03d", i), []byte("value")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func TestPageTokensExclusive(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paging"}
+	writeKeys(t, s, path, 150)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	first, next, err := session.ListKeys(path, "")
+	require.NoError(t, err)
+	closer()
+	require.Equal(t, "key-099", first[len(first)-1])
+
+	// keys inserted after the last key of the page, before the first key of the next page,
+	// and the first key of the next page deleted, between the two pages
+	writeValue(t, s, path, "key-099a", "inserted")
+	writeValue(t, s, path, "key-050a", "inserted")
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey(path, "key-100"))
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	second, last, err := session.ListKeys(path, next)
+	require.NoError(t, err)
+	assert.Empty(t, last)
+	require.Len(t, second, 50)
+	assert.Equal(t, "key-099a", second[0])
+	assert.Equal(t, "key-101", second[1])
+
+	// no key is repeated across the pages
+	seen := map[string]bool{}
+	for _, k := range append(first, second...) {
+		assert.False(t, seen[k], "key %s repeated", k)
+		seen[k] = true
+	}
+}
+
+func TestPageTokensFullPages(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paging"}
+	writeKeys(t, s, path, 200)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, next, err := session.ListKeys(path, "")
+	require.NoError(t, err)
+	require.Len(t, keys, 100)
+
+	keys, next, err = session.ListKeys(path, next)
+	require.NoError(t, err)
+	assert.Len(t, keys, 100)
+	assert.Equal(t, "key-100", keys[0])
+	assert.Empty(t, next)
+}
+
+func TestPageTokensScanMatch(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paging"}
+	writeKeys(t, s, path, 150)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	_, _, next, err := session.ScanMatch(path, "key-*", boltdb.Glob, "")
+	require.NoError(t, err)
+	closer()
+
+	writeValue(t, s, path, "key-099a", "inserted")
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	keys, _, last, err := session.ScanMatch(path, "key-*", boltdb.Glob, next)
+	require.NoError(t, err)
+	assert.Empty(t, last)
+	require.Len(t, keys, 51)
+	assert.Equal(t, "key-099a", keys[0])
+}
+
+func TestPageTokensInclusive(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paging"}
+	writeKeys(t, s, path, 150)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	// tokens issued before tokens were exclusive hold the first key of the next page
+	token := base64.RawURLEncoding.EncodeToString(append([]byte{1}, "key-100"...))
+
+	keys, _, err := session.ListKeys(path, token)
+	require.NoError(t, err)
+	require.Len(t, keys, 50)
+	assert.Equal(t, "key-100", keys[0])
+}
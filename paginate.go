@@ -0,0 +1,117 @@
+package boltdb
+
+// PaginateOptions configures a Paginator, see Session.Paginate.
+type PaginateOptions struct {
+	// PageSize is the number of keys of the pages, 100 when zero.
+	PageSize int
+	// KeysOnly lists keys only, Paginator.Values returning nil.
+	KeysOnly bool
+	// PageToken is a token returned by List or ListKeys the listing starts from, the first key when empty.
+	PageToken string
+}
+
+// Paginator walks the keys of a bucket page by page, managing the page tokens of List and ListKeys:
+//
+//	p := session.Paginate(path, boltdb.PaginateOptions{PageSize: 50})
+//	for p.Next() {
+//		for i, key := range p.Keys() {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+//
+// Pages are read from the session of the paginator, they are only valid while the session is open.
+type Paginator struct {
+	fetch func(token string) ([]string, [][]byte, string, error)
+	size  int
+	token string
+	done  bool
+
+	pendingKeys   []string
+	pendingValues [][]byte
+
+	keys   []string
+	values [][]byte
+	err    error
+}
+
+// Paginate returns a Paginator over the keys of bucket path, nested buckets being skipped.
+// Errors of List and ListKeys, such as ErrPathNotFound, are returned by Paginator.Err.
+func (s *Session) Paginate(path []string, opts PaginateOptions) *Paginator {
+	s.store.logger.Trace("Session::Paginate", "path", path, "pageSize", opts.PageSize, "keysOnly", opts.KeysOnly)
+
+	return newPaginator(s, path, opts)
+}
+
+func newPaginator(r Reader, path []string, opts PaginateOptions) *Paginator {
+	p := &Paginator{size: opts.PageSize, token: opts.PageToken}
+	if p.size <= 0 {
+		p.size = int(pageSize)
+	}
+
+	p.fetch = func(token string) ([]string, [][]byte, string, error) {
+		return r.List(path, token)
+	}
+	if opts.KeysOnly {
+		p.fetch = func(token string) ([]string, [][]byte, string, error) {
+			keys, next, err := r.ListKeys(path, token)
+			return keys, nil, next, err
+		}
+	}
+
+	return p
+}
+
+// Next advances the paginator to the next page, returning false once the keys have been exhausted
+// or listing failed, see Paginator.Err. The last page may hold fewer keys than the page size.
+func (p *Paginator) Next() bool {
+	p.keys, p.values = nil, nil
+	if p.err != nil {
+		return false
+	}
+
+	for len(p.pendingKeys) < p.size && !p.done {
+		keys, values, next, err := p.fetch(p.token)
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		p.pendingKeys = append(p.pendingKeys, keys...)
+		p.pendingValues = append(p.pendingValues, values...)
+		p.token = next
+		p.done = next == ""
+	}
+
+	n := len(p.pendingKeys)
+	if n == 0 {
+		return false
+	}
+	if n > p.size {
+		n = p.size
+	}
+
+	p.keys, p.pendingKeys = p.pendingKeys[:n:n], p.pendingKeys[n:]
+	if p.pendingValues != nil {
+		p.values, p.pendingValues = p.pendingValues[:n:n], p.pendingValues[n:]
+	}
+
+	return true
+}
+
+// Keys returns the keys of the current page, in byte order.
+func (p *Paginator) Keys() []string {
+	return p.keys
+}
+
+// Values returns the values of the keys of the current page, nil when listing keys only.
+func (p *Paginator) Values() [][]byte {
+	return p.values
+}
+
+// Err returns the error listing failed with, nil once the keys have been exhausted.
+func (p *Paginator) Err() error {
+	return p.err
+}
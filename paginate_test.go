@@ -0,0 +1,107 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paginate"}
+	writeKeys(t, s, path, 250)
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		var (
+			keys  []string
+			sizes []int
+		)
+
+		p := r.Paginate(path, boltdb.PaginateOptions{PageSize: 60})
+		for p.Next() {
+			assert.Len(t, p.Values(), len(p.Keys()))
+			assert.Equal(t, []byte("value"), p.Values()[0])
+			keys = append(keys, p.Keys()...)
+			sizes = append(sizes, len(p.Keys()))
+		}
+		require.NoError(t, p.Err())
+
+		assert.Equal(t, []int{60, 60, 60, 60, 10}, sizes)
+		require.Len(t, keys, 250)
+		for i, k := range keys {
+			assert.Equal(t, fmt.Sprintf("key-%03d", i), k)
+		}
+		assert.False(t, p.Next())
+
+		return nil
+	}))
+}
+
+func TestPaginateKeysOnly(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"paginate"}
+	writeKeys(t, s, path, 150)
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		_, token, err := r.ListKeys(path, "")
+		require.NoError(t, err)
+
+		p := r.Paginate(path, boltdb.PaginateOptions{KeysOnly: true, PageToken: token})
+		require.True(t, p.Next())
+		assert.Nil(t, p.Values())
+		assert.Len(t, p.Keys(), 50)
+		assert.Equal(t, "key-100", p.Keys()[0])
+		assert.False(t, p.Next())
+		assert.NoError(t, p.Err())
+
+		return nil
+	}))
+}
+
+func TestPaginateErrors(t *testing.T) {
+	s := newTestStore(t)
+	writeKeys(t, s, []string{"paginate"}, 1)
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		p := r.Paginate([]string{"missing"}, boltdb.PaginateOptions{})
+		assert.False(t, p.Next())
+		assert.ErrorIs(t, p.Err(), boltdb.ErrPathNotFound)
+		assert.False(t, p.Next())
+
+		p = r.Paginate([]string{"paginate"}, boltdb.PaginateOptions{PageToken: "garbage"})
+		assert.False(t, p.Next())
+		assert.ErrorIs(t, p.Err(), boltdb.ErrInvalidPageToken)
+
+		return nil
+	}))
+}
+
+func TestPaginateNamespace(t *testing.T) {
+	s := newTestStore(t)
+	writeKeys(t, s, []string{"tenants", "acme", "users"}, 120)
+
+	acme, err := boltdb.Namespace(s, "acme")
+	require.NoError(t, err)
+
+	require.NoError(t, acme.View(func(r boltdb.Reader) error {
+		var n int
+		p := r.Paginate([]string{"users"}, boltdb.PaginateOptions{PageSize: 50, KeysOnly: true})
+		for p.Next() {
+			n += len(p.Keys())
+		}
+		require.NoError(t, p.Err())
+		assert.Equal(t, 120, n)
+
+		p = r.Paginate([]string{"groups"}, boltdb.PaginateOptions{})
+		assert.False(t, p.Next())
+
+		var se *boltdb.StoreError
+		require.ErrorAs(t, p.Err(), &se)
+		assert.Equal(t, []string{"groups"}, se.Path)
+
+		return nil
+	}))
+}
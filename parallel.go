@@ -0,0 +1,154 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ParallelScan calls fn for every key-value pair of bucket path whose key starts with prefix, splitting
+// the keys into workers ranges of about the same number of keys, each scanned by its own goroutine in its
+// own read session. fn is called concurrently, keys and values are only valid during the call.
+// Nested buckets are skipped.
+//
+// The scan stops at the first error returned by fn or by a range, or once ctx is done, returning the error.
+// Ranges are read in distinct transactions, so writes committed while the ranges start may be seen by
+// some ranges only.
+func (s *Store) ParallelScan(ctx context.Context, path []string, prefix string, workers int, fn func(key, value []byte) error) error {
+	s.logger.Trace("Store::ParallelScan", "path", path, "prefix", prefix, "workers", workers)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	starts, err := s.scanRanges(path, []byte(prefix), workers)
+	if err != nil || len(starts) == 0 {
+		return wrapError("ParallelScan", path, prefix, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			first = err
+			cancel()
+		})
+	}
+
+	for i := range starts {
+		var end []byte
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		wg.Add(1)
+		go func(start, end []byte) {
+			defer wg.Done()
+
+			if err := s.scanRange(ctx, path, []byte(prefix), start, end, fn); err != nil {
+				fail(err)
+			}
+		}(starts[i], end)
+	}
+
+	wg.Wait()
+
+	if first == nil {
+		first = ctx.Err()
+	}
+
+	return first
+}
+
+// scanRanges returns the first keys of the n ranges of about the same number of keys starting with prefix
+// in bucket path, fewer when there are fewer keys.
+func (s *Store) scanRanges(path []string, prefix []byte, n int) ([][]byte, error) {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	var starts [][]byte
+
+	err = session.view(func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := session.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		c := b.Cursor()
+
+		var count int
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			count++
+		}
+		if count < n {
+			n = count
+		}
+
+		var i int
+		for k, _ := c.Seek(prefix); k != nil && len(starts) < n; k, _ = c.Next() {
+			if i == len(starts)*count/n {
+				starts = append(starts, append([]byte{}, k...))
+			}
+			i++
+		}
+
+		return nil
+	})
+
+	return starts, err
+}
+
+// scanRange calls fn for the key-value pairs of the range of keys starting with prefix from start,
+// up to end excluded, or to the last key starting with prefix when end is nil.
+func (s *Store) scanRange(ctx context.Context, path []string, prefix, start, end []byte, fn func(key, value []byte) error) error {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	var (
+		failed error
+		steps  int
+	)
+	err = session.ScanB(path, start, func(key, value []byte) bool {
+		if !bytes.HasPrefix(key, prefix) || (end != nil && bytes.Compare(key, end) >= 0) {
+			return false
+		}
+
+		steps++
+		if steps%deadlineCheckInterval == 0 {
+			if failed = ctx.Err(); failed != nil {
+				return false
+			}
+		}
+
+		if err := fn(key, value); err != nil {
+			failed = errors.Wrapf(err, "key %q", key)
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return failed
+}
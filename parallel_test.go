@@ -0,0 +1,88 @@
+package boltdb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelScan(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 1000; i++ {
+			if err := w.Write([]string{"a"}, fmt.Sprintf("k%04d", i), []byte(fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+		return w.Write([]string{"a"}, "other", []byte("other"))
+	}))
+
+	for _, workers := range []int{0, 1, 3, 8, 2000} {
+		var (
+			mu   sync.Mutex
+			seen = map[string]string{}
+		)
+		err := s.ParallelScan(context.Background(), []string{"a"}, "k", workers, func(key, value []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			seen[string(key)] = string(value)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, seen, 1000, "%d workers", workers)
+		assert.Equal(t, "999", seen["k0999"])
+	}
+}
+
+func TestParallelScanEmpty(t *testing.T) {
+	s := newTestStore(t)
+	write(t, s, []string{"a"}, "k1")
+
+	err := s.ParallelScan(context.Background(), []string{"a"}, "missing", 4, func(key, value []byte) error {
+		t.Fatal("no key expected")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = s.ParallelScan(context.Background(), []string{"missing"}, "", 4, func(key, value []byte) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+}
+
+func TestParallelScanError(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 100; i++ {
+			if err := w.Write([]string{"a"}, fmt.Sprintf("k%04d", i), []byte("v")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	errStop := errors.New("stop")
+	err := s.ParallelScan(context.Background(), []string{"a"}, "", 4, func(key, value []byte) error {
+		if string(key) == "k0050" {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.ParallelScan(ctx, []string{"a"}, "", 4, func(key, value []byte) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
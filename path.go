@@ -0,0 +1,74 @@
+package boltdb
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MaxPathDepth         = 32  // maximum number of segments in a bucket path
+	MaxPathSegmentLength = 255 // maximum length of a single bucket path segment, in bytes
+)
+
+// Path is a bucket path, from the root bucket down to the nested bucket.
+// Since its underlying type is []string, a Path can be passed to every Session method
+// taking a bucket path.
+type Path []string
+
+// NewPath returns the path made of segments, or ErrInvalidPath when the path is not valid.
+func NewPath(segments ...string) (Path, error) {
+	p := Path(segments)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that the path has at least one and no more than MaxPathDepth segments,
+// and that each segment is non-empty, at most MaxPathSegmentLength long and free of NUL bytes.
+// The root bucket "__meta" is reserved for store metadata.
+func (p Path) Validate() error {
+	if len(p) == 0 {
+		return errors.Wrap(ErrInvalidPath, "empty path")
+	}
+	if p[0] == metaRoot {
+		return errors.Wrapf(ErrInvalidPath, "path [%s] uses reserved root bucket %q", p, metaRoot)
+	}
+	if len(p) > MaxPathDepth {
+		return errors.Wrapf(ErrInvalidPath, "path [%s] exceeds max depth %d", p, MaxPathDepth)
+	}
+
+	for i, segment := range p {
+		switch {
+		case segment == "":
+			return errors.Wrapf(ErrInvalidPath, "path [%s] segment %d is empty", p, i)
+		case len(segment) > MaxPathSegmentLength:
+			return errors.Wrapf(ErrInvalidPath, "path [%s] segment %d exceeds max length %d", p, i, MaxPathSegmentLength)
+		case strings.IndexByte(segment, 0) >= 0:
+			return errors.Wrapf(ErrInvalidPath, "path [%s] segment %d contains NUL byte", p, i)
+		}
+	}
+
+	return nil
+}
+
+// String returns the path segments joined by "/".
+func (p Path) String() string {
+	return pathStr(p)
+}
+
+// Append returns a new path with segments added to the tail of p.
+func (p Path) Append(segments ...string) Path {
+	result := make(Path, 0, len(p)+len(segments))
+	result = append(result, p...)
+	return append(result, segments...)
+}
+
+// Parent returns the path of the parent bucket, nil for a root level bucket.
+func (p Path) Parent() Path {
+	if len(p) <= 1 {
+		return nil
+	}
+	return append(Path{}, p[:len(p)-1]...)
+}
@@ -0,0 +1,87 @@
+package boltdb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPath(t *testing.T) {
+	p, err := boltdb.NewPath("a", "b", "c")
+	require.NoError(t, err)
+	assert.Equal(t, "a/b/c", p.String())
+	assert.Equal(t, boltdb.Path{"a", "b"}, p.Parent())
+	assert.Equal(t, boltdb.Path{"a", "b", "c", "d"}, p.Append("d"))
+	assert.Equal(t, boltdb.Path{"a", "b", "c"}, p)
+	assert.Nil(t, boltdb.Path{"a"}.Parent())
+
+	invalid := [][]string{
+		{},
+		{"a", ""},
+		{"a\x00b"},
+		{strings.Repeat("x", boltdb.MaxPathSegmentLength+1)},
+		make([]string, boltdb.MaxPathDepth+1),
+	}
+	for _, segments := range invalid {
+		_, err := boltdb.NewPath(segments...)
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath), "%q", segments)
+	}
+}
+
+func TestPathSessionArgument(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	p := boltdb.Path{"typed", "path"}
+	require.NoError(t, session.Write(p, "key", []byte("value")))
+
+	buf, err := session.Read([]string{"typed", "path"}, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(buf))
+	assert.True(t, session.BucketExists(p.Parent()))
+}
+
+func TestInvalidPathArguments(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	for _, path := range [][]string{nil, {}, {"a", ""}} {
+		_, err = session.Read(path, "key")
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		err = session.Write(path, "key", []byte("value"))
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		err = session.DeleteKey(path, "key")
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		err = session.DeleteBucket(path)
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		_, _, err = session.ListKeys(path, "")
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		_, err = session.NextSeq(path)
+		assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+
+		assert.False(t, session.BucketExists(path))
+		assert.False(t, session.KeyExists(path, "key"))
+	}
+
+	// the root level remains listable
+	_, _, err = session.ListBuckets([]string{}, "")
+	assert.NoError(t, err)
+
+	// deleting a missing root bucket is not an error
+	assert.NoError(t, session.DeleteBucket([]string{"missing"}))
+}
@@ -0,0 +1,55 @@
+package boltdb
+
+import "sync"
+
+// prefixMap associates values with bucket path prefixes, resolving paths to the value of
+// their longest registered prefix.
+type prefixMap struct {
+	mu      sync.RWMutex
+	entries []prefixEntry
+}
+
+type prefixEntry struct {
+	prefix []string
+	value  interface{}
+}
+
+// set associates value with prefix, replacing the value previously associated with it.
+// A nil value removes the association.
+func (m *prefixMap) set(prefix []string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, e := range m.entries {
+		if len(e.prefix) == len(prefix) && hasPathPrefix(e.prefix, prefix) {
+			if value == nil {
+				m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			} else {
+				m.entries[i].value = value
+			}
+			return
+		}
+	}
+
+	if value != nil {
+		m.entries = append(m.entries, prefixEntry{prefix: append([]string{}, prefix...), value: value})
+	}
+}
+
+// lookup returns the value associated with the longest prefix of path.
+func (m *prefixMap) lookup(path []string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		match interface{}
+		depth = -1
+	)
+	for _, e := range m.entries {
+		if len(e.prefix) > depth && hasPathPrefix(path, e.prefix) {
+			match, depth = e.value, len(e.prefix)
+		}
+	}
+
+	return match, depth >= 0
+}
@@ -0,0 +1,276 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// Property tests run against a single store per test, every check using a bucket of its own,
+// but for the model test which opens a store per test case.
+
+var (
+	genKey   = rapid.StringN(1, 12, -1)
+	genValue = rapid.SliceOfN(rapid.Byte(), 0, 64)
+)
+
+// genPath draws a bucket path of up to 3 segments under root.
+func genPath(t *rapid.T, root string) []string {
+	segments := rapid.SliceOfN(rapid.StringMatching(`[a-z]{1,4}`), 0, 2).Draw(t, "path").([]string)
+	return append([]string{root}, segments...)
+}
+
+// genEntries draws up to max distinct keys along with their values.
+func genEntries(t *rapid.T, max int) map[string][]byte {
+	keys := rapid.SliceOfNDistinct(genKey, 0, max, nil).Draw(t, "keys").([]string)
+
+	entries := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		entries[k] = genValue.Draw(t, "value").([]byte)
+	}
+	return entries
+}
+
+// checkRoot returns a root bucket name unique to every check of a test.
+func checkRoot() func() string {
+	var n int
+	return func() string {
+		n++
+		return fmt.Sprintf("check%06d", n)
+	}
+}
+
+func sortedKeys(entries map[string][]byte) []string {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// listAll returns the keys and values of every page of bucket path.
+func listAll(t require.TestingT, s *boltdb.Store, path []string) ([]string, [][]byte, int) {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	var (
+		keys   []string
+		values [][]byte
+		pages  int
+		token  string
+	)
+	for {
+		k, v, next, err := session.List(path, token)
+		require.NoError(t, err)
+
+		pages++
+		keys = append(keys, k...)
+		for _, value := range v {
+			values = append(values, append([]byte{}, value...))
+		}
+
+		if next == "" {
+			return keys, values, pages
+		}
+		require.NotEqual(t, token, next, "page token repeated")
+		token = next
+	}
+}
+
+func TestPropertyWriteRead(t *testing.T) {
+	s := newTestStore(t)
+	root := checkRoot()
+
+	rapid.Check(t, func(t *rapid.T) {
+		path := genPath(t, root())
+		entries := genEntries(t, 20)
+
+		require.NoError(t, s.Update(func(w boltdb.Writer) error {
+			for k, v := range entries {
+				if err := w.Write(path, k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		require.NoError(t, s.View(func(r boltdb.Reader) error {
+			for k, v := range entries {
+				got, err := r.Read(path, k)
+				require.NoError(t, err)
+				assert.True(t, bytes.Equal(v, got), "key %q: got %q, want %q", k, got, v)
+			}
+			return nil
+		}))
+	})
+}
+
+func TestPropertyListPagination(t *testing.T) {
+	s := newTestStore(t)
+	root := checkRoot()
+
+	rapid.Check(t, func(t *rapid.T) {
+		path := genPath(t, root())
+		entries := genEntries(t, 350)
+
+		require.NoError(t, s.Update(func(w boltdb.Writer) error {
+			if err := w.CreateBucket(path); err != nil {
+				return err
+			}
+			for k, v := range entries {
+				if err := w.Write(path, k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		keys, values, pages := listAll(t, s, path)
+
+		// the union of the pages is the set of keys written, in byte order, without duplicates
+		assert.Equal(t, sortedKeys(entries), append([]string{}, keys...))
+		assert.True(t, sort.StringsAreSorted(keys))
+		for i, k := range keys {
+			assert.True(t, bytes.Equal(entries[k], values[i]), "key %q", k)
+		}
+
+		// no more pages than needed, along with an empty last page when the keys fill the pages
+		want := (len(entries) + 99) / 100
+		if want == 0 {
+			want = 1
+		}
+		assert.LessOrEqual(t, pages, want+1)
+	})
+}
+
+func TestPropertyDelete(t *testing.T) {
+	s := newTestStore(t)
+	root := checkRoot()
+
+	rapid.Check(t, func(t *rapid.T) {
+		path := genPath(t, root())
+		entries := genEntries(t, 20)
+
+		deleted := map[string]bool{}
+		for k := range entries {
+			deleted[k] = rapid.Bool().Draw(t, "delete "+k).(bool)
+		}
+
+		require.NoError(t, s.Update(func(w boltdb.Writer) error {
+			for k, v := range entries {
+				if err := w.Write(path, k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+
+		require.NoError(t, s.Update(func(w boltdb.Writer) error {
+			for k, del := range deleted {
+				if del {
+					if err := w.DeleteKey(path, k); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}))
+
+		session, closer, err := s.ReadSession()
+		require.NoError(t, err)
+		defer closer()
+
+		for k, del := range deleted {
+			_, err := session.Read(path, k)
+			if del {
+				assert.ErrorIs(t, err, boltdb.ErrKeyNotFound, "key %q", k)
+				assert.False(t, session.KeyExists(path, k), "key %q", k)
+			} else {
+				assert.NoError(t, err, "key %q", k)
+				assert.True(t, session.KeyExists(path, k), "key %q", k)
+			}
+		}
+	})
+}
+
+// storeMachine checks random sequences of writes, deletes and reads against a map.
+// Every test case runs against a store of its own.
+type storeMachine struct {
+	store *boltdb.Store
+	path  []string
+	model map[string][]byte
+}
+
+func (m *storeMachine) Init(t *rapid.T) {
+	logger := zerolog.Nop()
+
+	store, err := boltdb.NewMemoryStore(&logger)
+	require.NoError(t, err)
+
+	m.store = store
+	m.path = genPath(t, "model")
+	m.model = map[string][]byte{}
+}
+
+func (m *storeMachine) Cleanup() {
+	m.store.Close()
+}
+
+func (m *storeMachine) Write(t *rapid.T) {
+	k := genKey.Draw(t, "key").(string)
+	v := genValue.Draw(t, "value").([]byte)
+
+	require.NoError(t, m.store.Update(func(w boltdb.Writer) error {
+		return w.Write(m.path, k, v)
+	}))
+	m.model[k] = v
+}
+
+func (m *storeMachine) Delete(t *rapid.T) {
+	if len(m.model) == 0 {
+		t.Skip("no key")
+	}
+	k := rapid.SampledFrom(sortedKeys(m.model)).Draw(t, "key").(string)
+
+	require.NoError(t, m.store.Update(func(w boltdb.Writer) error {
+		return w.DeleteKey(m.path, k)
+	}))
+	delete(m.model, k)
+}
+
+func (m *storeMachine) Read(t *rapid.T) {
+	k := genKey.Draw(t, "key").(string)
+
+	require.NoError(t, m.store.View(func(r boltdb.Reader) error {
+		got, err := r.Read(m.path, k)
+		if want, ok := m.model[k]; ok {
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(want, got), "key %q", k)
+		} else {
+			assert.Error(t, err, "key %q", k)
+		}
+		return nil
+	}))
+}
+
+func (m *storeMachine) Check(t *rapid.T) {
+	if len(m.model) == 0 {
+		return
+	}
+
+	keys, _, _ := listAll(t, m.store, m.path)
+	assert.Equal(t, sortedKeys(m.model), keys)
+}
+
+func TestPropertyModel(t *testing.T) {
+	rapid.Check(t, rapid.Run(&storeMachine{}))
+}
@@ -0,0 +1,85 @@
+package boltdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Publisher publishes the changes committed to a store to an event bus, e.g. NATS, so downstream services react
+// to them without polling the store, see Store.StartPublisher.
+type Publisher interface {
+	// Publish publishes events, the events of committed revisions in commit order. Events are published again
+	// when Publish returned an error, so subscribers must tolerate duplicates.
+	Publish(ctx context.Context, events []Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, events []Event) error
+
+// Publish calls f.
+func (f PublisherFunc) Publish(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// PublisherConfig configures the publisher started by Store.StartPublisher.
+type PublisherConfig struct {
+	Publisher     Publisher       // publisher the events are passed to
+	Prefix        Path            // bucket path prefix of the events published, every event when empty
+	Ops           []EventOp       // operations published, EventPut and EventDelete when empty
+	FromRevision  uint64          // revision already published, events of later revisions are published
+	BatchSize     int             // changelog events read per batch, defaultReplicationBatchSize when zero
+	RetryInterval time.Duration   // time between attempts to publish a failed batch, defaultReplicationRetry when zero
+	OnError       func(err error) // called when publishing a batch failed, optional
+}
+
+// StartPublisher starts publishing the events committed after cfg.FromRevision to cfg.Publisher, those of
+// cfg.Ops below cfg.Prefix, or deleting or recursively truncating a bucket holding cfg.Prefix, at least once
+// and in commit order. Publishers tail the changelog like replicators, see Store.StartReplication, so the
+// changelog must be enabled, and the returned replicator reports the revision published, to resume from once
+// the store is reopened. Batches holding no matching event are not published.
+// The publisher is stopped by Replicator.Stop and when the store is closed.
+func (s *Store) StartPublisher(cfg PublisherConfig) (*Replicator, error) {
+	if !s.config.EnableChangelog {
+		return nil, errors.Wrap(ErrChangelogDisabled, "publisher requires the changelog")
+	}
+	if cfg.Publisher == nil {
+		return nil, errors.New("publisher requires a publisher")
+	}
+
+	ops := cfg.Ops
+	if len(ops) == 0 {
+		ops = []EventOp{EventPut, EventDelete}
+	}
+
+	target := ReplicationTargetFunc(func(ctx context.Context, batch *ReplicationBatch) error {
+		var events []Event
+		for _, event := range batch.Events {
+			if eventUnder(event, cfg.Prefix) && publishedOp(ops, event.Op) {
+				events = append(events, event)
+			}
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return cfg.Publisher.Publish(ctx, events)
+	})
+
+	return s.StartReplication(ReplicationConfig{
+		Target:        target,
+		FromRevision:  cfg.FromRevision,
+		BatchSize:     cfg.BatchSize,
+		RetryInterval: cfg.RetryInterval,
+		OnError:       cfg.OnError,
+	})
+}
+
+func publishedOp(ops []EventOp, op EventOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
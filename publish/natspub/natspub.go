@@ -0,0 +1,109 @@
+// Package natspub provides a boltdb.Publisher publishing the changes committed to a store to NATS.
+//
+// Every event is published as a message holding the JSON encoded event, on the subject
+// <Config.Subject>.<op>.<path segments>, e.g. boltdb.put.tenants.acme.users, so subscribers
+// filter the changes with subject wildcards, e.g. boltdb.*.tenants.acme.>. Connections are
+// flushed once a batch is published, so events are published at least once once Publish returned.
+package natspub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+// DefaultSubject is the subject prefix of the messages when Config.Subject is empty.
+const DefaultSubject = "boltdb"
+
+// RevisionHeader is the header of the messages holding the revision of their event.
+const RevisionHeader = "Boltdb-Revision"
+
+// Conn is the subset of the NATS API used by the publisher, implemented by *nats.Conn.
+type Conn interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushWithContext(ctx context.Context) error
+}
+
+// Config configures a Publisher.
+type Config struct {
+	Subject string // subject prefix of the messages, DefaultSubject when empty
+}
+
+// Publisher publishes events as NATS messages.
+type Publisher struct {
+	conn Conn
+	cfg  Config
+}
+
+var _ boltdb.Publisher = (*Publisher)(nil)
+
+// New returns a publisher publishing events through conn.
+func New(conn Conn, cfg Config) (*Publisher, error) {
+	if conn == nil {
+		return nil, errors.New("nats publisher requires a connection")
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultSubject
+	}
+	if strings.ContainsAny(cfg.Subject, "*> \t\r\n") || strings.HasPrefix(cfg.Subject, ".") || strings.HasSuffix(cfg.Subject, ".") {
+		return nil, errors.Errorf("invalid nats subject '%s'", cfg.Subject)
+	}
+	return &Publisher{conn: conn, cfg: cfg}, nil
+}
+
+// Publish publishes a message per event, then flushes the connection.
+func (p *Publisher) Publish(ctx context.Context, events []boltdb.Event) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode event of revision %d", event.Revision)
+		}
+
+		msg := nats.NewMsg(p.Subject(event))
+		msg.Header.Set(RevisionHeader, strconv.FormatUint(event.Revision, 10))
+		msg.Data = data
+
+		if err := p.conn.PublishMsg(msg); err != nil {
+			return errors.Wrapf(err, "failed to publish event of revision %d", event.Revision)
+		}
+	}
+
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to flush nats connection")
+	}
+	return nil
+}
+
+// Subject returns the subject event is published on. Characters NATS reserves in subject tokens,
+// '.', '*', '>', '%' and whitespace, are escaped in path segments as %XX.
+func (p *Publisher) Subject(event boltdb.Event) string {
+	tokens := []string{p.cfg.Subject, event.Op.String()}
+	for _, segment := range event.Path {
+		tokens = append(tokens, escape(segment))
+	}
+	return strings.Join(tokens, ".")
+}
+
+func escape(token string) string {
+	if token == "" {
+		return "%"
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		switch c {
+		case '.', '*', '>', '%', ' ', '\t', '\r', '\n':
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
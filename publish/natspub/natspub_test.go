@@ -0,0 +1,105 @@
+package natspub_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/publish/natspub"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn records the messages published, which are only delivered once flushed.
+type fakeConn struct {
+	mu        sync.Mutex
+	pending   []*nats.Msg
+	delivered []*nats.Msg
+	failFlush int
+}
+
+func (c *fakeConn) PublishMsg(msg *nats.Msg) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, msg)
+	return nil
+}
+
+func (c *fakeConn) FlushWithContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failFlush > 0 {
+		c.failFlush--
+		c.pending = nil
+		return errors.New("flush timeout")
+	}
+	c.delivered = append(c.delivered, c.pending...)
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) messages() []*nats.Msg {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*nats.Msg(nil), c.delivered...)
+}
+
+func TestPublish(t *testing.T) {
+	logger := zerolog.Nop()
+	store := boltdb.NewStore(&boltdb.Config{DBPath: filepath.Join(t.TempDir(), "test.db"), EnableChangelog: true}, &logger)
+	require.NoError(t, store.Open())
+	t.Cleanup(store.Close)
+
+	conn := &fakeConn{failFlush: 1}
+	publisher, err := natspub.New(conn, natspub.Config{Subject: "changes"})
+	require.NoError(t, err)
+
+	r, err := store.StartPublisher(boltdb.PublisherConfig{Publisher: publisher, RetryInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants", "acme.io"}, "alice", []byte("v"))
+	}))
+	require.NoError(t, store.Update(func(w boltdb.Writer) error {
+		return w.DeleteKey([]string{"tenants", "acme.io"}, "alice")
+	}))
+
+	require.Eventually(t, func() bool { return r.Stats().Shipped == store.Revision() }, 5*time.Second, 5*time.Millisecond)
+
+	msgs := conn.messages()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "changes.put.tenants.acme%2Eio", msgs[0].Subject)
+	assert.Equal(t, "changes.delete.tenants.acme%2Eio", msgs[1].Subject)
+
+	var event boltdb.Event
+	require.NoError(t, json.Unmarshal(msgs[0].Data, &event))
+	assert.Equal(t, []byte("alice"), event.Key)
+	assert.Equal(t, []byte("v"), event.Value)
+	assert.Equal(t, boltdb.EventPut, event.Op)
+	assert.Equal(t, strconv.FormatUint(event.Revision, 10), msgs[0].Header.Get(natspub.RevisionHeader))
+}
+
+func TestSubject(t *testing.T) {
+	publisher, err := natspub.New(&fakeConn{}, natspub.Config{})
+	require.NoError(t, err)
+
+	subject := publisher.Subject(boltdb.Event{Op: boltdb.EventDelete, Path: []string{"a b", "*", ">", "100%", ""}})
+	assert.Equal(t, "boltdb.delete.a%20b.%2A.%3E.100%25.%", subject)
+
+	_, err = natspub.New(&fakeConn{}, natspub.Config{Subject: "changes.>"})
+	assert.Error(t, err)
+	_, err = natspub.New(nil, natspub.Config{})
+	assert.Error(t, err)
+}
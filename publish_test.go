@@ -0,0 +1,107 @@
+package boltdb_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisher(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	var (
+		mu       sync.Mutex
+		events   []boltdb.Event
+		failures = 1
+	)
+	publisher := boltdb.PublisherFunc(func(ctx context.Context, batch []boltdb.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failures > 0 {
+			failures--
+			return errors.New("bus unavailable")
+		}
+		events = append(events, batch...)
+		return nil
+	})
+
+	r, err := store.StartPublisher(boltdb.PublisherConfig{
+		Publisher:     publisher,
+		Prefix:        boltdb.Path{"tenants", "a"},
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.CreateBucket([]string{"tenants", "a", "users"}) }))
+	write(t, store, []string{"tenants", "a", "users"}, "alice")
+	write(t, store, []string{"tenants", "b", "users"}, "bob")
+	deleteKey(t, store, []string{"tenants", "a", "users"}, "alice")
+
+	require.Eventually(t, func() bool { return r.Stats().Shipped == store.Revision() }, 5*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, boltdb.EventPut, events[0].Op)
+	assert.Equal(t, []byte("alice"), events[0].Key)
+	assert.Equal(t, boltdb.EventDelete, events[1].Op)
+	assert.Equal(t, uint64(1), r.Stats().Errors)
+}
+
+func TestPublisherBucketOps(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	var (
+		mu     sync.Mutex
+		events []boltdb.Event
+	)
+	publisher := boltdb.PublisherFunc(func(ctx context.Context, batch []boltdb.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, batch...)
+		return nil
+	})
+
+	r, err := store.StartPublisher(boltdb.PublisherConfig{
+		Publisher:     publisher,
+		Prefix:        boltdb.Path{"tenants", "a", "users"},
+		Ops:           []boltdb.EventOp{boltdb.EventDeleteBucket, boltdb.EventTruncateBucket},
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	write(t, store, []string{"tenants", "a", "users"}, "alice")
+	write(t, store, []string{"tenants", "b", "users"}, "bob")
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.TruncateBucket([]string{"tenants", "a"}) }))
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.TruncateBucketRecursive([]string{"tenants"}) }))
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.DeleteBucket([]string{"tenants"}) }))
+
+	require.Eventually(t, func() bool { return r.Stats().Shipped == store.Revision() }, 5*time.Second, 5*time.Millisecond)
+
+	// deleting or recursively truncating a bucket holding the prefix deletes its keys
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, boltdb.EventTruncateBucket, events[0].Op)
+	assert.Equal(t, []string{"tenants"}, events[0].Path)
+	assert.Equal(t, boltdb.EventDeleteBucket, events[1].Op)
+}
+
+func TestPublisherRequiresChangelog(t *testing.T) {
+	store := newTestStore(t)
+
+	_, err := store.StartPublisher(boltdb.PublisherConfig{
+		Publisher: boltdb.PublisherFunc(func(ctx context.Context, events []boltdb.Event) error { return nil }),
+	})
+	assert.ErrorIs(t, err, boltdb.ErrChangelogDisabled)
+}
@@ -0,0 +1,167 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PurgeOptions configures Store.PurgeSubtree.
+type PurgeOptions struct {
+	// KeepChangelog keeps the changelog events of the purged keys, holding their values, so replicas and
+	// watchers resuming from an earlier revision still receive them.
+	KeepChangelog bool
+	// Compact compacts the database once purged, see Store.Compact, so the free pages still holding the
+	// purged values are dropped from the database file.
+	Compact bool
+}
+
+// PurgeReport reports the data deleted by Store.PurgeSubtree.
+type PurgeReport struct {
+	Keys            int           // keys deleted from the subtree
+	Versions        int           // versions deleted from the key history, see Config.VersionedPaths
+	ChangelogEvents int           // changelog events deleted
+	Compaction      *CompactStats // compaction of the database, nil unless PurgeOptions.Compact is set
+}
+
+// PurgeSubtree deletes bucket path, including its nested buckets, along with the data the store maintains
+// about its keys: index entries, metadata, checksums, key history, references to streamed, external and
+// deduplicated values and, unless opts.KeepChangelog is set, changelog events, in a single write session.
+// The session verifies that nothing is left of the subtree before committing, so deleting the data of a
+// tenant is a single operation. The audit log, which records the keys modified but not their values, is
+// kept, as removing its records would break its hash chain.
+func (s *Store) PurgeSubtree(ctx context.Context, path []string, opts PurgeOptions) (PurgeReport, error) {
+	s.logger.Trace("Store::PurgeSubtree", "path", path)
+
+	var report PurgeReport
+
+	if len(path) == 0 {
+		return report, wrapError("PurgeSubtree", path, "", ErrInvalidPath)
+	}
+	if err := Path(path).Validate(); err != nil {
+		return report, wrapError("PurgeSubtree", path, "", err)
+	}
+
+	err := s.updateContext(ctx, func(session *Session) error {
+		if err := session.view(func(tx *bolt.Tx) error {
+			report.Keys = countKeys(session, path)
+			report.Versions = countKeys(session, shadowPath(historyBucket, path))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if err := session.DeleteBucket(path); err != nil {
+			return err
+		}
+
+		return session.update(func(tx *bolt.Tx) error {
+			// deleted buckets record the last versions of their keys
+			if err := session.deleteShadowBucket(historyBucket, path); err != nil {
+				return err
+			}
+
+			if !opts.KeepChangelog {
+				n, err := purgeChangelog(tx, path)
+				if err != nil {
+					return err
+				}
+				report.ChangelogEvents = n
+			}
+
+			return session.verifyPurged(path, !opts.KeepChangelog)
+		})
+	})
+	if err != nil {
+		return report, wrapError("PurgeSubtree", path, "", err)
+	}
+
+	if opts.Compact {
+		stats, err := s.Compact(ctx)
+		if err != nil {
+			return report, errors.Wrap(err, "purged, failed to compact")
+		}
+		report.Compaction = &stats
+	}
+
+	return report, nil
+}
+
+// countKeys returns the number of keys below the bucket at path, zero when missing.
+func countKeys(s *Session, path []string) int {
+	b, err := s.setBucket(path)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	_ = walkBucket(b, path, func([]string, []byte, []byte) error {
+		n++
+		return nil
+	})
+	return n
+}
+
+// purgeChangelog deletes the changelog events of the keys and buckets below path.
+func purgeChangelog(tx *bolt.Tx, path []string) (int, error) {
+	b := metaChild(tx, changelogBucket)
+	if b == nil {
+		return 0, nil
+	}
+
+	var purged [][]byte
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var event Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			return 0, err
+		}
+		if hasPathPrefix(event.Path, path) {
+			purged = append(purged, append([]byte{}, k...))
+		}
+	}
+
+	for _, k := range purged {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(purged), nil
+}
+
+// verifyPurged fails when the bucket at path, or the data maintained about its keys, is left in the session,
+// along with its changelog events when changelog is set.
+func (s *Session) verifyPurged(path []string, changelog bool) error {
+	if _, err := s.setBucket(path); err == nil {
+		return errors.New("purge left the bucket")
+	}
+
+	for _, root := range []string{keymetaBucket, checksumBucket, historyBucket} {
+		if _, err := s.setBucket(shadowPath(root, path)); err == nil {
+			return errors.Errorf("purge left %s entries", root)
+		}
+	}
+
+	if !changelog {
+		return nil
+	}
+
+	if b := metaChild(s.tx, changelogBucket); b != nil {
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var event Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			if hasPathPrefix(event.Path, path) {
+				return errors.New("purge left changelog events")
+			}
+		}
+	}
+
+	return nil
+}
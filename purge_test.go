@@ -0,0 +1,102 @@
+package boltdb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeSubtree(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		EnableChangelog: true,
+		TrackMetadata:   true,
+		Checksums:       true,
+		VersionedPaths:  [][]string{{"tenants"}},
+	})
+	require.NoError(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-email",
+		Path:   []string{"tenants"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/email")},
+	}))
+
+	for _, tenant := range []string{"a", "b"} {
+		path := []string{"tenants", tenant, "users"}
+		for i := 0; i < 3; i++ {
+			writeValue(t, s, path, fmt.Sprintf("u%d", i), fmt.Sprintf(`{"email":"%s%d@example.com"}`, tenant, i))
+		}
+		writeValue(t, s, path, "u0", fmt.Sprintf(`{"email":"%s0@example.org"}`, tenant))
+	}
+
+	report, err := s.PurgeSubtree(context.Background(), []string{"tenants", "a"}, boltdb.PurgeOptions{Compact: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Keys)
+	assert.Equal(t, 4, report.Versions)
+	assert.Equal(t, 4, report.ChangelogEvents)
+	require.NotNil(t, report.Compaction)
+	assert.Greater(t, report.Compaction.SizeBefore, int64(0))
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		assert.False(t, r.BucketExists([]string{"tenants", "a"}))
+
+		entries, _, err := r.QueryIndex("by-email", boltdb.IndexQuery{}, "")
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+
+		// tenant b is left alone
+		v, err := r.Read([]string{"tenants", "b", "users"}, "u1")
+		require.NoError(t, err)
+		assert.Equal(t, `{"email":"b1@example.com"}`, string(v))
+
+		history, err := r.History([]string{"tenants", "b", "users"}, "u0", 0)
+		require.NoError(t, err)
+		assert.NotEmpty(t, history)
+		return nil
+	}))
+
+	// the changelog only holds the events of tenant b, and the delete of tenant a
+	events, stop := s.WatchFrom(0, nil)
+	defer stop()
+	for i := 0; i < 4; i++ {
+		event := <-events
+		assert.NotEqual(t, "a", event.Path[1])
+	}
+	event := <-events
+	assert.Equal(t, boltdb.EventDeleteBucket, event.Op)
+	assert.Equal(t, []string{"tenants", "a"}, event.Path)
+
+	// the store is usable once compacted
+	writeValue(t, s, []string{"tenants", "c"}, "k", "v")
+	assert.Equal(t, "v", readValue(t, s, []string{"tenants", "c"}, "k"))
+}
+
+func TestPurgeSubtreeInvalidPath(t *testing.T) {
+	s := newTestStore(t)
+
+	_, err := s.PurgeSubtree(context.Background(), nil, boltdb.PurgeOptions{})
+	assert.ErrorIs(t, err, boltdb.ErrInvalidPath)
+}
+
+func TestCompact(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"bulk"}
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 2000; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%04d", i), make([]byte, 1024)); err != nil {
+				return err
+			}
+		}
+		return w.Write([]string{"kept"}, "k", []byte("v"))
+	}))
+	require.NoError(t, s.Update(func(w boltdb.Writer) error { return w.DeleteBucket(path) }))
+
+	rev := s.Revision()
+	stats, err := s.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, stats.SizeAfter, stats.SizeBefore/2)
+	assert.Equal(t, rev, s.Revision())
+	assert.Equal(t, "v", readValue(t, s, []string{"kept"}, "k"))
+}
@@ -0,0 +1,255 @@
+package boltdb
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RateLimit bounds the rate of the session operations on the buckets under a path prefix, so the import
+// of a tenant cannot starve the other tenants sharing the store. Operations exceeding the limit fail with
+// ErrRateLimited, or wait for the limit to admit them when Wait is set.
+type RateLimit struct {
+	// Prefix of the limited bucket paths, AnySegment matching any segment, each matched path having its own
+	// limit, e.g. {"tenants", "*"} limits every tenant separately. An empty prefix limits the whole store,
+	// including the operations on indexes.
+	Prefix []string `json:"prefix"`
+	// OpsPerSecond is the rate of operations, zero for no limit. Operations writing several keys, such as
+	// WriteMany, count once per key.
+	OpsPerSecond float64 `json:"ops_per_second"`
+	// Burst is the number of operations admitted at once, OpsPerSecond rounded up when zero.
+	Burst int `json:"burst"`
+	// BytesPerSecond is the rate of the bytes of the values written, zero for no limit. A single value
+	// larger than the rate is admitted, delaying the following writes.
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	// Wait makes operations exceeding the limit wait for it rather than failing, at most MaxWait when set,
+	// until the context of the session is done, see Store.WriteSessionContext, and failing with
+	// ErrDeadlineExceeded when the limit admits them past the deadline of the operation, see
+	// Config.RequestTimeout. Operations waiting in a write session hold the writer of the store,
+	// see Store.WaitRateLimit.
+	Wait    bool          `json:"wait"`
+	MaxWait time.Duration `json:"max_wait"`
+}
+
+func (l *RateLimit) match(path []string) bool {
+	if len(path) < len(l.Prefix) {
+		return false
+	}
+	for i, segment := range l.Prefix {
+		if segment != AnySegment && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitRateLimit waits until the rate limits of bucket path admit an operation, without counting it, or fails
+// with the error of ctx once it is done. Bulk writers wait before starting every write session, rather than
+// holding the writer of the store while their operations wait for the limits.
+func (s *Store) WaitRateLimit(ctx context.Context, path []string) error {
+	if s.limiter == nil {
+		return nil
+	}
+
+	for {
+		delay := s.limiter.delay(path, 1, 1, false)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// rateLimit counts the keys and bytes written by op against the rate limits of its path, failing with
+// ErrRateLimited when they do not admit it, unless they wait for it. Waits end with the context of the
+// session, and fail with ErrDeadlineExceeded rather than outlasting the deadline of the operation.
+func (s *Session) rateLimit(op *Op) error {
+	limiter := s.store.limiter
+	if limiter == nil {
+		return nil
+	}
+
+	keys, size := op.keys, op.size
+	if keys == 0 {
+		keys = 1
+	}
+	if size == 0 {
+		size = len(op.Value)
+	}
+
+	var waited time.Duration
+	for {
+		delay := limiter.delay(op.Path, keys, size, true)
+		if delay == 0 {
+			return nil
+		}
+
+		wait, maxWait := limiter.waitFor(op.Path)
+		if !wait || (maxWait > 0 && waited+delay > maxWait) {
+			return errors.Wrapf(ErrRateLimited, "retry after %s", delay.Round(time.Millisecond))
+		}
+		if !s.deadline.IsZero() && time.Now().Add(delay).After(s.deadline) {
+			return errors.Wrapf(ErrDeadlineExceeded, "rate limited, retry after %s", delay.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return s.ctx.Err()
+		}
+		waited += delay
+	}
+}
+
+// rateLimiter holds a pair of token buckets for every path matched by the rate limits of the store.
+type rateLimiter struct {
+	limits []RateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*rateBuckets
+}
+
+type rateBuckets struct {
+	ops   tokenBucket
+	bytes tokenBucket
+}
+
+func newRateLimiter(limits []RateLimit) *rateLimiter {
+	return &rateLimiter{limits: limits, buckets: map[string]*rateBuckets{}}
+}
+
+// delay returns the time before the limits matching path admit an operation writing n bytes to keys keys,
+// zero when they admit it now, in which case it is counted when take is set.
+func (r *rateLimiter) delay(path []string, keys, n int, take bool) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	ops, size := float64(keys), float64(n)
+
+	var (
+		delay   time.Duration
+		matched []*rateBuckets
+	)
+	for i := range r.limits {
+		l := &r.limits[i]
+		if !l.match(path) {
+			continue
+		}
+
+		b := r.bucketsOf(i, path, now)
+		if d := b.ops.delay(ops, now); d > delay {
+			delay = d
+		}
+		// reads and deletes are not delayed by the bytes written
+		if d := b.bytes.delay(size, now); size > 0 && d > delay {
+			delay = d
+		}
+		matched = append(matched, b)
+	}
+
+	if delay == 0 && take {
+		for _, b := range matched {
+			b.ops.take(ops)
+			b.bytes.take(size)
+		}
+	}
+
+	return delay
+}
+
+// waitFor reports whether operations on path wait for their limits, and for how long at most.
+// Operations matching several waiting limits wait at most for the shortest MaxWait.
+func (r *rateLimiter) waitFor(path []string) (bool, time.Duration) {
+	var maxWait time.Duration
+	for i := range r.limits {
+		l := &r.limits[i]
+		if !l.match(path) {
+			continue
+		}
+		if !l.Wait {
+			return false, 0
+		}
+		if l.MaxWait > 0 && (maxWait == 0 || l.MaxWait < maxWait) {
+			maxWait = l.MaxWait
+		}
+	}
+	return true, maxWait
+}
+
+func (r *rateLimiter) bucketsOf(i int, path []string, now time.Time) *rateBuckets {
+	l := &r.limits[i]
+
+	var sb strings.Builder
+	sb.WriteString(strconv.Itoa(i))
+	for _, segment := range path[:len(l.Prefix)] {
+		sb.WriteString("\x00" + segment)
+	}
+	key := sb.String()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		burst := float64(l.Burst)
+		if burst <= 0 {
+			burst = math.Max(1, math.Ceil(l.OpsPerSecond))
+		}
+		b = &rateBuckets{
+			ops:   newTokenBucket(l.OpsPerSecond, burst, now),
+			bytes: newTokenBucket(l.BytesPerSecond, math.Max(1, l.BytesPerSecond), now),
+		}
+		r.buckets[key] = b
+	}
+
+	return b
+}
+
+// tokenBucket refills rate tokens per second up to burst. A zero rate never limits.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) tokenBucket {
+	return tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// delay refills the bucket and returns the time before n tokens are available, at most burst tokens being
+// required, so n larger than burst is admitted once the bucket is full.
+func (b *tokenBucket) delay(n float64, now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	need := math.Min(n, b.burst)
+	if b.tokens >= need {
+		return 0
+	}
+
+	return time.Duration(math.Ceil((need - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// take removes n tokens, possibly leaving the bucket in debt.
+func (b *tokenBucket) take(n float64) {
+	if b.rate > 0 {
+		b.tokens -= n
+	}
+}
@@ -0,0 +1,131 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeErr(s *boltdb.Store, path []string, key string, value []byte) error {
+	return s.Update(func(w boltdb.Writer) error {
+		return w.Write(path, key, value)
+	})
+}
+
+func TestRateLimitPerPrefix(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{Prefix: []string{"tenants", boltdb.AnySegment}, OpsPerSecond: 0.1, Burst: 2},
+	}})
+
+	a := []string{"tenants", "a", "objects"}
+	require.NoError(t, writeErr(s, a, "k1", []byte("v")))
+	require.NoError(t, writeErr(s, a, "k2", []byte("v")))
+
+	err := writeErr(s, a, "k3", []byte("v"))
+	assert.ErrorIs(t, err, boltdb.ErrRateLimited)
+	assert.Contains(t, err.Error(), "retry after")
+
+	// every tenant has its own limit, and paths outside the prefix are not limited
+	assert.NoError(t, writeErr(s, []string{"tenants", "b"}, "k1", []byte("v")))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, writeErr(s, []string{"other"}, "k", []byte("v")))
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{OpsPerSecond: 20, Burst: 1, Wait: true},
+	}})
+	path := []string{"a"}
+	writeValue(t, s, path, "k", "v")
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "v", readValue(t, s, path, "k"))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRateLimitMaxWait(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{OpsPerSecond: 1, Wait: true, MaxWait: 10 * time.Millisecond},
+	}})
+
+	require.NoError(t, writeErr(s, []string{"a"}, "k1", []byte("v")))
+	assert.ErrorIs(t, writeErr(s, []string{"a"}, "k2", []byte("v")), boltdb.ErrRateLimited)
+}
+
+func TestRateLimitBytes(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{Prefix: []string{"imports"}, BytesPerSecond: 1000},
+	}})
+	path := []string{"imports"}
+
+	// a value larger than the rate is admitted, delaying the following writes
+	require.NoError(t, writeErr(s, path, "k1", bytes.Repeat([]byte("x"), 1500)))
+	assert.ErrorIs(t, writeErr(s, path, "k2", []byte("v")), boltdb.ErrRateLimited)
+
+	// reads are not limited by the bytes written
+	assert.Len(t, readValue(t, s, path, "k1"), 1500)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.WaitRateLimit(ctx, path), context.DeadlineExceeded)
+
+	require.NoError(t, s.WaitRateLimit(context.Background(), path))
+	assert.NoError(t, writeErr(s, path, "k2", []byte("v")))
+}
+
+func TestRateLimitBulkWrites(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{Prefix: []string{"many"}, OpsPerSecond: 0.1, Burst: 3},
+		{Prefix: []string{"appends"}, BytesPerSecond: 1000},
+	}})
+
+	// every key written by WriteMany is counted
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.WriteMany([]string{"many"}, map[string][]byte{"k1": []byte("v"), "k2": []byte("v"), "k3": []byte("v")})
+	}))
+	assert.ErrorIs(t, writeErr(s, []string{"many"}, "k4", []byte("v")), boltdb.ErrRateLimited)
+
+	// as are the bytes appended
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.Append([]string{"appends"}, "k1", bytes.Repeat([]byte("x"), 1500))
+	}))
+	assert.ErrorIs(t, writeErr(s, []string{"appends"}, "k2", []byte("v")), boltdb.ErrRateLimited)
+}
+
+func TestRateLimitWaitContext(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{RateLimits: []boltdb.RateLimit{
+		{OpsPerSecond: 0.1, Wait: true},
+	}})
+	path := []string{"a"}
+	require.NoError(t, writeErr(s, path, "k1", []byte("v")))
+
+	// waits end with the context of the session
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := s.UpdateContext(ctx, func(w boltdb.Writer) error {
+		return w.Write(path, "k2", []byte("v"))
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimitWaitDeadline(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		RequestTimeout: 20 * time.Millisecond,
+		RateLimits:     []boltdb.RateLimit{{OpsPerSecond: 0.1, Wait: true}},
+	})
+	path := []string{"a"}
+	require.NoError(t, writeErr(s, path, "k1", []byte("v")))
+
+	// waits do not outlast the deadline of the operation
+	start := time.Now()
+	assert.ErrorIs(t, writeErr(s, path, "k2", []byte("v")), boltdb.ErrDeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
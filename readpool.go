@@ -0,0 +1,237 @@
+package boltdb
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultReadPoolMaxAge is the age above which pooled read sessions are closed, when ReadPoolConfig.MaxAge is zero.
+const defaultReadPoolMaxAge = 100 * time.Millisecond
+
+// ReadPoolConfig configures the pool returned by Store.NewReadPool.
+type ReadPoolConfig struct {
+	Size   int           // idle read sessions kept, GOMAXPROCS when zero
+	MaxAge time.Duration // age above which read sessions are closed, defaultReadPoolMaxAge when zero
+}
+
+// ReadPoolStats reports the read sessions of a ReadPool.
+type ReadPoolStats struct {
+	Idle    int    // read sessions idle in the pool
+	Started uint64 // read sessions started
+	Reused  uint64 // reads run in a read session started by an earlier read
+	Retired uint64 // read sessions closed for their age, or because a write session committed since they started
+}
+
+// ReadPool runs short reads in read sessions reused from one read to the next, saving the cost of starting
+// and closing a read transaction per read, see Store.NewReadPool.
+type ReadPool struct {
+	started, reused, retired uint64 // accessed atomically, first to be 64-bit aligned on 32-bit platforms
+
+	store  *Store
+	maxAge time.Duration
+	idle   chan *pooledSession
+
+	mu     sync.RWMutex // held while returning sessions to the pool, write-locked by Close
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type pooledSession struct {
+	session *Session
+	closer  func()
+	started time.Time
+}
+
+// NewReadPool returns a pool of up to cfg.Size idle read sessions, reused by reads until cfg.MaxAge elapsed
+// since they started, or a write session committed. Reads of the pool thus see the last committed revision,
+// as reads of new read sessions do.
+//
+// Idle read sessions hold their read transaction open, keeping bolt from reusing the pages freed by the
+// writes committed since they started, and making writes growing the memory map of the database wait
+// for them to be closed, within cfg.MaxAge, see Config.InitialMmapSize. The pool is closed by ReadPool.Close
+// and when the store is closed.
+func (s *Store) NewReadPool(cfg ReadPoolConfig) *ReadPool {
+	if cfg.Size <= 0 {
+		cfg.Size = runtime.GOMAXPROCS(0)
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultReadPoolMaxAge
+	}
+
+	p := &ReadPool{
+		store:  s,
+		maxAge: cfg.MaxAge,
+		idle:   make(chan *pooledSession, cfg.Size),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go p.run()
+
+	s.addStopper(p.Close)
+
+	return p
+}
+
+// View runs fn in a pooled read session. The session is only valid during the call, as are the values read.
+// Once the pool has been closed, fn runs in a new read session.
+func (p *ReadPool) View(fn func(Reader) error) error {
+	ps, err := p.get()
+	if err != nil {
+		return err
+	}
+	if ps == nil {
+		return p.store.View(fn)
+	}
+
+	err = fn(ps.session)
+	p.put(ps)
+
+	return err
+}
+
+// Read returns a copy of the value of key in bucket path, read in a pooled read session.
+func (p *ReadPool) Read(path []string, key string) ([]byte, error) {
+	var value []byte
+
+	err := p.View(func(r Reader) error {
+		v, err := r.Read(path, key)
+		value = append([]byte{}, v...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Close closes the idle read sessions of the pool, and the sessions returned to it from then on.
+func (p *ReadPool) Close() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+
+		close(p.stop)
+		<-p.done
+
+		for {
+			select {
+			case ps := <-p.idle:
+				ps.closer()
+			default:
+				return
+			}
+		}
+	})
+}
+
+// Stats returns the read sessions of the pool.
+func (p *ReadPool) Stats() ReadPoolStats {
+	return ReadPoolStats{
+		Idle:    len(p.idle),
+		Started: atomic.LoadUint64(&p.started),
+		Reused:  atomic.LoadUint64(&p.reused),
+		Retired: atomic.LoadUint64(&p.retired),
+	}
+}
+
+// get returns an idle read session which is still fresh, or a new one, nil once the pool has been closed.
+func (p *ReadPool) get() (*pooledSession, error) {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+
+	if closed {
+		return nil, nil
+	}
+
+	for {
+		var ps *pooledSession
+		select {
+		case ps = <-p.idle:
+		default:
+		}
+		if ps == nil {
+			break
+		}
+
+		if p.fresh(ps) {
+			atomic.AddUint64(&p.reused, 1)
+			return ps, nil
+		}
+		p.retire(ps)
+	}
+
+	session, closer, err := p.store.ReadSession()
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddUint64(&p.started, 1)
+
+	return &pooledSession{session: session, closer: closer, started: time.Now()}, nil
+}
+
+// put returns ps to the pool, unless it is full, closed, or ps is no longer fresh.
+func (p *ReadPool) put(ps *pooledSession) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		ps.closer()
+		return
+	}
+	if !p.fresh(ps) {
+		p.retire(ps)
+		return
+	}
+
+	select {
+	case p.idle <- ps:
+	default:
+		ps.closer()
+	}
+}
+
+func (p *ReadPool) fresh(ps *pooledSession) bool {
+	return time.Since(ps.started) < p.maxAge && atomic.LoadUint64(&p.store.revision) == ps.session.revision
+}
+
+func (p *ReadPool) retire(ps *pooledSession) {
+	atomic.AddUint64(&p.retired, 1)
+	ps.closer()
+}
+
+// run closes the idle read sessions once they are no longer fresh.
+func (p *ReadPool) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.maxAge / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.sweep()
+		}
+	}
+}
+
+func (p *ReadPool) sweep() {
+	for n := len(p.idle); n > 0; n-- {
+		select {
+		case ps := <-p.idle:
+			p.put(ps)
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package boltdb_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPool(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{InitialMmapSize: 16 << 20})
+	path := []string{"acl"}
+	write(t, s, path, "alice")
+
+	pool := s.NewReadPool(boltdb.ReadPoolConfig{Size: 2, MaxAge: time.Hour})
+
+	for i := 0; i < 10; i++ {
+		v, err := pool.Read(path, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("alice"), v)
+	}
+	stats := pool.Stats()
+	assert.Equal(t, uint64(1), stats.Started)
+	assert.Equal(t, uint64(9), stats.Reused)
+	assert.Equal(t, 1, stats.Idle)
+
+	// reads see the writes committed since the pooled session started
+	writeValue(t, s, path, "alice", "v2")
+	v, err := pool.Read(path, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), v)
+	assert.Equal(t, uint64(1), pool.Stats().Retired)
+
+	_, err = pool.Read(path, "bob")
+	assert.ErrorIs(t, err, boltdb.ErrKeyNotFound)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, pool.View(func(r boltdb.Reader) error {
+					_, err := r.Read(path, "alice")
+					return err
+				}))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, pool.Stats().Idle, 2)
+
+	pool.Close()
+	assert.Zero(t, pool.Stats().Idle)
+
+	v, err = pool.Read(path, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), v)
+}
+
+func TestReadPoolMaxAge(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"acl"}
+	write(t, s, path, "alice")
+
+	pool := s.NewReadPool(boltdb.ReadPoolConfig{MaxAge: 10 * time.Millisecond})
+
+	_, err := pool.Read(path, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Stats().Idle)
+
+	// idle sessions are closed once too old, so writes growing the memory map do not wait for them
+	require.Eventually(t, func() bool {
+		return pool.Stats().Idle == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, uint64(1), pool.Stats().Retired)
+
+	writeValue(t, s, []string{"filler"}, "k", string(make([]byte, 1<<20)))
+}
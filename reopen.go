@@ -0,0 +1,165 @@
+package boltdb
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ReopenPolicy controls how a store reopens its database, see Config.AutoReopen.
+type ReopenPolicy struct {
+	// ProbeInterval is the delay between two health probes of the database, zero disables the probes.
+	// A probe fails when the database file has been removed or replaced, or a read transaction fails,
+	// in which case the database is closed and reopened.
+	ProbeInterval time.Duration `json:"probe_interval"`
+	// MinInterval is the minimum delay between two reopen attempts. Sessions started in between
+	// fail with bolt.ErrDatabaseNotOpen.
+	MinInterval time.Duration `json:"min_interval"`
+}
+
+// probeTask is the name of the janitor task probing the database.
+const probeTask = "probe"
+
+// ReopenStats reports the reopen attempts of a store, see Config.AutoReopen.
+type ReopenStats struct {
+	Attempts      uint64 // reopen attempts
+	Reopens       uint64 // successful reopen attempts
+	ProbeFailures uint64 // failed health probes
+}
+
+// reopener tracks the reopen attempts and health probes of a store.
+// Its counters come first, to be 64-bit aligned on 32-bit platforms, see Store.
+type reopener struct {
+	attempts      uint64 // accessed atomically
+	reopens       uint64 // accessed atomically
+	probeFailures uint64 // accessed atomically
+
+	mu   sync.Mutex
+	last time.Time // time of the last reopen attempt
+}
+
+// ReopenStats returns the reopen attempts and failed health probes since the store was created.
+func (s *Store) ReopenStats() ReopenStats {
+	return ReopenStats{
+		Attempts:      atomic.LoadUint64(&s.reopen.attempts),
+		Reopens:       atomic.LoadUint64(&s.reopen.reopens),
+		ProbeFailures: atomic.LoadUint64(&s.reopen.probeFailures),
+	}
+}
+
+// reopenDB reopens the database of a store which failed to open, or whose database has been closed
+// by a failed health probe. It fails with bolt.ErrDatabaseNotOpen without Config.AutoReopen,
+// once the store has been closed, or while the previous attempt is more recent than ReopenPolicy.MinInterval.
+func (s *Store) reopenDB() error {
+	policy := s.config.AutoReopen
+	if policy == nil {
+		return bolt.ErrDatabaseNotOpen
+	}
+
+	s.reopen.mu.Lock()
+	if !s.reopen.last.IsZero() && time.Since(s.reopen.last) < policy.MinInterval {
+		s.reopen.mu.Unlock()
+		return bolt.ErrDatabaseNotOpen
+	}
+	s.reopen.last = time.Now()
+	s.reopen.mu.Unlock()
+
+	s.dbMu.Lock()
+	if !s.opened {
+		s.dbMu.Unlock()
+		return bolt.ErrDatabaseNotOpen
+	}
+	if s.db != nil {
+		s.dbMu.Unlock()
+		return nil
+	}
+
+	atomic.AddUint64(&s.reopen.attempts, 1)
+	err := s.openLocked()
+	s.dbMu.Unlock()
+
+	if err != nil {
+		s.logger.Warn("reopen::boltdb", "DBPath", s.config.DBPath, "error", err)
+		return errors.Wrapf(bolt.ErrDatabaseNotOpen, "reopen failed: %v", err)
+	}
+
+	atomic.AddUint64(&s.reopen.reopens, 1)
+	s.logger.Info("reopen::boltdb", "DBPath", s.config.DBPath)
+
+	return s.migrateOnOpen()
+}
+
+// startProbes starts the health probes of the database, until the store is closed.
+func (s *Store) startProbes() {
+	interval := s.config.AutoReopen.ProbeInterval
+	if interval <= 0 {
+		return
+	}
+
+	// probe failures are counted by ReopenStats rather than as failures of the task
+	_, _ = s.Schedule(JanitorTask{
+		Name:     probeTask,
+		Interval: interval,
+		Run: func(context.Context) error {
+			s.runProbe()
+			return nil
+		},
+	})
+}
+
+// runProbe probes the database, closing it when the probe fails, and reopens it when it is not open.
+func (s *Store) runProbe() {
+	if db := s.database(); db != nil {
+		err := s.probe(db)
+		if err == nil {
+			return
+		}
+
+		atomic.AddUint64(&s.reopen.probeFailures, 1)
+		s.logger.Warn("probe::boltdb", "DBPath", s.config.DBPath, "error", err)
+		s.closeDB(db)
+	}
+
+	_ = s.reopenDB()
+}
+
+// probe checks the database file is still the one opened and can be read.
+func (s *Store) probe(db *bolt.DB) error {
+	info, err := os.Stat(db.Path())
+	if err != nil {
+		return errors.Wrap(err, "failed to stat database file")
+	}
+
+	s.dbMu.RLock()
+	opened := s.dbFile
+	s.dbMu.RUnlock()
+
+	if opened != nil && !os.SameFile(opened, info) {
+		return errors.New("database file replaced")
+	}
+
+	return db.View(func(tx *bolt.Tx) error {
+		_ = readRevision(tx)
+		return nil
+	})
+}
+
+// closeDB closes db, unless it has already been closed or replaced.
+func (s *Store) closeDB(db *bolt.DB) {
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+
+	if s.db != db {
+		return
+	}
+
+	if err := db.Close(); err != nil {
+		s.logger.Warn("close::boltdb", "error", err)
+	}
+	s.db = nil
+}
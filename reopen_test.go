@@ -0,0 +1,90 @@
+package boltdb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestReopenAfterFailedOpen(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{
+		DBPath:     filepath.Join(blocker, "reopen.db"),
+		AutoReopen: &boltdb.ReopenPolicy{},
+	}, nil)
+	t.Cleanup(s.Close)
+
+	require.Error(t, s.Open())
+
+	_, _, err := s.ReadSession()
+	assert.ErrorIs(t, err, bolt.ErrDatabaseNotOpen)
+
+	require.NoError(t, os.Remove(blocker))
+
+	write(t, s, []string{"reopen"}, "k1")
+	assert.Equal(t, "k1", readValue(t, s, []string{"reopen"}, "k1"))
+
+	stats := s.ReopenStats()
+	assert.Equal(t, uint64(2), stats.Attempts)
+	assert.Equal(t, uint64(1), stats.Reopens)
+}
+
+func TestReopenMinInterval(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{
+		DBPath:     filepath.Join(blocker, "reopen.db"),
+		AutoReopen: &boltdb.ReopenPolicy{MinInterval: time.Hour},
+	}, nil)
+	t.Cleanup(s.Close)
+
+	require.Error(t, s.Open())
+
+	_, _, err := s.ReadSession()
+	assert.ErrorIs(t, err, bolt.ErrDatabaseNotOpen)
+
+	require.NoError(t, os.Remove(blocker))
+
+	_, _, err = s.ReadSession()
+	assert.ErrorIs(t, err, bolt.ErrDatabaseNotOpen)
+	assert.Equal(t, uint64(1), s.ReopenStats().Attempts)
+}
+
+func TestReopenProbe(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		AutoReopen: &boltdb.ReopenPolicy{ProbeInterval: 5 * time.Millisecond},
+	})
+
+	write(t, s, []string{"reopen"}, "k1")
+
+	require.NoError(t, os.Remove(s.DBPath()))
+
+	require.Eventually(t, func() bool {
+		return s.ReopenStats().Reopens == 1
+	}, 5*time.Second, time.Millisecond)
+
+	assert.GreaterOrEqual(t, s.ReopenStats().ProbeFailures, uint64(1))
+	assert.FileExists(t, s.DBPath())
+
+	write(t, s, []string{"reopen"}, "k2")
+	assert.Equal(t, "k2", readValue(t, s, []string{"reopen"}, "k2"))
+}
+
+func TestNoReopenAfterClose(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{AutoReopen: &boltdb.ReopenPolicy{}})
+
+	s.Close()
+
+	_, _, err := s.ReadSession()
+	assert.ErrorIs(t, err, bolt.ErrDatabaseNotOpen)
+	assert.Zero(t, s.ReopenStats().Attempts)
+}
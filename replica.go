@@ -0,0 +1,175 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// replicatedKey holds, in the metadata bucket, the revision of the leader the last applied batch ended at.
+var replicatedKey = []byte("replicated")
+
+// ReplicatedRevision returns the revision of the leader store up to which replication batches have been
+// applied to the store, the revision to resume replication from, see ReplicationConfig.FromRevision.
+func (s *Store) ReplicatedRevision() (uint64, error) {
+	db := s.database()
+	if db == nil {
+		return 0, bolt.ErrDatabaseNotOpen
+	}
+
+	var rev uint64
+	err := db.View(func(tx *bolt.Tx) error {
+		rev = readReplicated(tx)
+		return nil
+	})
+
+	return rev, err
+}
+
+// ApplyReplicationStream applies the replication batches read from r, as written by StreamTarget,
+// until r is exhausted or ctx is done. See ApplyReplicationBatch.
+func (s *Store) ApplyReplicationStream(ctx context.Context, r io.Reader) error {
+	s.logger.Trace("Store::ApplyReplicationStream")
+
+	dec := json.NewDecoder(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var batch ReplicationBatch
+		if err := dec.Decode(&batch); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to read replication batch")
+		}
+
+		if err := s.ApplyReplicationBatch(ctx, &batch); err != nil {
+			return err
+		}
+	}
+}
+
+// ApplyReplicationBatch applies the events of batch in a single write transaction, recording the leader
+// revision the batch ends at. Events of revisions already applied are skipped, so batches shipped
+// again after a failure are applied once. Applies to stores configured as Replica, which reject
+// write sessions otherwise, as well as to regular stores.
+func (s *Store) ApplyReplicationBatch(ctx context.Context, batch *ReplicationBatch) error {
+	s.logger.Trace("Store::ApplyReplicationBatch", "revision", batch.Revision, "events", len(batch.Events))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	session, err := s.beginTx(context.Background(), true)
+	if err != nil {
+		return errors.Wrap(err, "failed to start write transaction")
+	}
+
+	applied := readReplicated(session.tx)
+	if batch.Revision <= applied {
+		session.rollback()
+		return nil
+	}
+
+	for i := range batch.Events {
+		event := &batch.Events[i]
+		if event.Revision <= applied {
+			continue
+		}
+		if err := session.update(func(tx *bolt.Tx) error { return session.applyEvent(event) }); err != nil {
+			session.rollback()
+			return wrapError("ApplyReplicationBatch", event.Path, string(event.Key), err)
+		}
+	}
+
+	if err := writeReplicated(session.tx, batch.Revision); err != nil {
+		session.rollback()
+		return err
+	}
+
+	return session.commit()
+}
+
+// applyEvent performs the mutation described by event.
+func (s *Session) applyEvent(event *Event) error {
+	path, key := event.Path, event.Key
+	if err := Path(path).Validate(); err != nil {
+		return err
+	}
+
+	switch event.Op {
+	case EventPut:
+		// events hold the values themselves, never references to streamed, external or deduplicated values
+		return s.put(path, key, event.Value)
+
+	case EventDelete:
+		return s.delete(path, key)
+
+	case EventCreateBucket:
+		if _, err := s.setBucketIfNotExist(path); err != nil {
+			return err
+		}
+		s.emit(EventCreateBucket, path, nil, nil)
+		return nil
+
+	case EventDeleteBucket:
+		return s.deleteBucket(path)
+
+	case EventSetSequence:
+		if len(event.Value) != 8 {
+			return errors.Errorf("invalid sequence event of revision %d", event.Revision)
+		}
+		b, err := s.setBucketIfNotExist(path)
+		if err != nil {
+			return err
+		}
+		seq := binary.BigEndian.Uint64(event.Value)
+		if err := b.SetSequence(seq); err != nil {
+			return err
+		}
+		s.emitSequence(path, seq)
+		return nil
+
+	case EventTruncateBucket:
+		return s.truncate(path, len(event.Value) == 1 && event.Value[0] == 1)
+	}
+
+	return errors.Errorf("unknown event op %d of revision %d", event.Op, event.Revision)
+}
+
+// ReplicaTarget returns a target applying the shipped batches to follower, a store in the same process,
+// see ApplyReplicationBatch.
+func ReplicaTarget(follower *Store) ReplicationTarget {
+	return ReplicationTargetFunc(follower.ApplyReplicationBatch)
+}
+
+func readReplicated(tx *bolt.Tx) uint64 {
+	b := tx.Bucket(metaBucket)
+	if b == nil {
+		return 0
+	}
+
+	buf := b.Get(replicatedKey)
+	if len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+func writeReplicated(tx *bolt.Tx, revision uint64) error {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, revision)
+
+	return b.Put(replicatedKey, buf)
+}
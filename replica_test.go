@@ -0,0 +1,132 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyReplicationStream(t *testing.T) {
+	leader := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	follower := newTestStoreWithConfig(t, &boltdb.Config{Replica: true})
+
+	writeValue(t, leader, []string{"a"}, "k1", "v1")
+	writeValue(t, leader, []string{"a", "b"}, "k2", "v2")
+	deleteKey(t, leader, []string{"a"}, "k1")
+
+	session, closer, err := leader.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.SetSeq([]string{"a"}, 42))
+	require.NoError(t, session.CreateBucket([]string{"c"}))
+	closer()
+
+	var stream bytes.Buffer
+	r, err := leader.StartReplication(boltdb.ReplicationConfig{Target: boltdb.StreamTarget(&stream), BatchSize: 2})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return r.Stats().Lag == 0 }, 5*time.Second, 5*time.Millisecond)
+	r.Stop()
+
+	recorded := append([]byte{}, stream.Bytes()...)
+	require.NoError(t, follower.ApplyReplicationStream(context.Background(), &stream))
+
+	// applying the stream again is a no-op
+	require.NoError(t, follower.ApplyReplicationStream(context.Background(), bytes.NewReader(recorded)))
+
+	rev, err := follower.ReplicatedRevision()
+	require.NoError(t, err)
+	assert.Equal(t, leader.Revision(), rev)
+
+	assert.Equal(t, "v2", readValue(t, follower, []string{"a", "b"}, "k2"))
+
+	require.NoError(t, follower.View(func(r boltdb.Reader) error {
+		assert.False(t, r.KeyExists([]string{"a"}, "k1"))
+		assert.True(t, r.BucketExists([]string{"c"}))
+		return nil
+	}))
+}
+
+func TestReplicaTarget(t *testing.T) {
+	leader := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	follower := newTestStoreWithConfig(t, &boltdb.Config{Replica: true})
+
+	writeValue(t, leader, []string{"a"}, "k1", "v1")
+
+	r, err := leader.StartReplication(boltdb.ReplicationConfig{Target: boltdb.ReplicaTarget(follower)})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	writeValue(t, leader, []string{"a"}, "k2", "v2")
+
+	require.Eventually(t, func() bool {
+		rev, err := follower.ReplicatedRevision()
+		return err == nil && rev == leader.Revision()
+	}, 5*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, "v1", readValue(t, follower, []string{"a"}, "k1"))
+	assert.Equal(t, "v2", readValue(t, follower, []string{"a"}, "k2"))
+}
+
+func TestReplicaReadOnly(t *testing.T) {
+	follower := newTestStoreWithConfig(t, &boltdb.Config{Replica: true})
+
+	_, _, err := follower.WriteSession()
+	assert.ErrorIs(t, err, boltdb.ErrReadOnly)
+
+	err = follower.Update(func(w boltdb.Writer) error { return nil })
+	assert.ErrorIs(t, err, boltdb.ErrReadOnly)
+}
+
+func TestReplicaBinaryKeys(t *testing.T) {
+	leader := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	follower := newTestStoreWithConfig(t, &boltdb.Config{Replica: true})
+
+	require.NoError(t, leader.Update(func(w boltdb.Writer) error {
+		if err := w.WriteUint64Key([]string{"ids"}, 255, []byte("v255")); err != nil {
+			return err
+		}
+		return w.WriteB([]string{"ids"}, []byte{0xff, 0xfe}, []byte("raw"))
+	}))
+
+	var stream bytes.Buffer
+	r, err := leader.StartReplication(boltdb.ReplicationConfig{Target: boltdb.StreamTarget(&stream)})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return r.Stats().Lag == 0 }, 5*time.Second, 5*time.Millisecond)
+	r.Stop()
+
+	require.NoError(t, follower.ApplyReplicationStream(context.Background(), &stream))
+
+	session, closer, err := follower.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	value, err := session.ReadUint64Key([]string{"ids"}, 255)
+	require.NoError(t, err)
+	assert.Equal(t, "v255", string(value))
+
+	value, err = session.ReadB([]string{"ids"}, []byte{0xff, 0xfe})
+	require.NoError(t, err)
+	assert.Equal(t, "raw", string(value))
+}
+
+func TestReplicaStream(t *testing.T) {
+	leader := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true, ChunkSize: 8})
+	follower := newTestStoreWithConfig(t, &boltdb.Config{Replica: true})
+
+	writeStream(t, leader, []string{"blobs"}, "b1", streamed)
+
+	r, err := leader.StartReplication(boltdb.ReplicationConfig{Target: boltdb.ReplicaTarget(follower)})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.Eventually(t, func() bool {
+		rev, err := follower.ReplicatedRevision()
+		return err == nil && rev == leader.Revision()
+	}, 5*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, streamed, readValue(t, follower, []string{"blobs"}, "b1"))
+}
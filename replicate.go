@@ -0,0 +1,252 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultReplicationBatchSize = 1024            // events shipped per batch, unless a single revision holds more
+	defaultReplicationRetry     = 5 * time.Second // time between attempts to ship a batch the target rejected
+)
+
+// ReplicationBatch holds the changelog events of consecutive committed revisions, shipped
+// to a ReplicationTarget in a single call. Batches never split the events of a revision.
+type ReplicationBatch struct {
+	Revision uint64  `json:"revision"` // revision of the last event of the batch
+	Events   []Event `json:"events"`
+}
+
+// ReplicationTarget receives the batches shipped by a replicator, e.g. a remote endpoint or a follower store.
+type ReplicationTarget interface {
+	// Ship delivers batch. Batches are shipped in revision order; a batch is shipped again
+	// when Ship returned an error, so targets must tolerate duplicates.
+	Ship(ctx context.Context, batch *ReplicationBatch) error
+}
+
+// ReplicationTargetFunc adapts a function to a ReplicationTarget.
+type ReplicationTargetFunc func(ctx context.Context, batch *ReplicationBatch) error
+
+// Ship calls f.
+func (f ReplicationTargetFunc) Ship(ctx context.Context, batch *ReplicationBatch) error {
+	return f(ctx, batch)
+}
+
+// StreamTarget returns a target writing batches to w as JSON lines, e.g. to a network connection
+// or a file read by Store.ApplyReplicationStream.
+func StreamTarget(w io.Writer) ReplicationTarget {
+	enc := json.NewEncoder(w)
+	return ReplicationTargetFunc(func(ctx context.Context, batch *ReplicationBatch) error {
+		return enc.Encode(batch)
+	})
+}
+
+// ReplicationConfig configures the replicator started by Store.StartReplication.
+type ReplicationConfig struct {
+	Target        ReplicationTarget // target batches are shipped to
+	FromRevision  uint64            // revision the target already holds, events of later revisions are shipped
+	BatchSize     int               // events shipped per batch, defaultReplicationBatchSize when zero
+	RetryInterval time.Duration     // time between attempts to ship a rejected batch, defaultReplicationRetry when zero
+	OnError       func(err error)   // called when shipping a batch failed, optional
+}
+
+// ReplicationStats reports the progress of a replicator.
+type ReplicationStats struct {
+	Revision    uint64    // revision of the store
+	Shipped     uint64    // revision of the last event shipped
+	Lag         uint64    // revisions committed but not shipped yet
+	Batches     uint64    // batches shipped
+	Events      uint64    // events shipped
+	Errors      uint64    // failed attempts to ship a batch
+	LastShipped time.Time // time the last batch was shipped, zero before the first batch
+	LastError   error     // error of the last failed attempt, nil once a batch has been shipped
+}
+
+// Replicator ships the committed changes of a store to a ReplicationTarget, see Store.StartReplication.
+type Replicator struct {
+	store *Store
+	cfg   ReplicationConfig
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	mu    sync.Mutex
+	stats ReplicationStats
+}
+
+// StartReplication starts shipping the changes committed after cfg.FromRevision to cfg.Target,
+// tailing the changelog like a write-ahead log shipper, for warm standbys.
+// Historical changes are shipped from the changelog first, then new changes as they are committed.
+// The changelog must be enabled, and changelog entries must be retained until they have been shipped.
+// The replicator is stopped by Replicator.Stop and when the store is closed.
+func (s *Store) StartReplication(cfg ReplicationConfig) (*Replicator, error) {
+	if !s.config.EnableChangelog {
+		return nil, errors.New("replication requires the changelog to be enabled")
+	}
+	if cfg.Target == nil {
+		return nil, errors.New("replication requires a target")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultReplicationBatchSize
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultReplicationRetry
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Replicator{
+		store:  s,
+		cfg:    cfg,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		stats:  ReplicationStats{Shipped: cfg.FromRevision},
+	}
+
+	go r.run(ctx)
+
+	s.addStopper(r.Stop)
+
+	return r, nil
+}
+
+// Stop stops the replicator, waiting for a batch being shipped.
+func (r *Replicator) Stop() {
+	r.once.Do(func() {
+		r.cancel()
+		<-r.done
+	})
+}
+
+// Stats returns the progress of the replicator.
+func (r *Replicator) Stats() ReplicationStats {
+	r.mu.Lock()
+	stats := r.stats
+	r.mu.Unlock()
+
+	stats.Revision = r.store.Revision()
+	if stats.Revision > stats.Shipped {
+		stats.Lag = stats.Revision - stats.Shipped
+	}
+
+	return stats
+}
+
+func (r *Replicator) run(ctx context.Context) {
+	defer close(r.done)
+
+	rev := r.cfg.FromRevision
+
+	for {
+		// subscribe before reading, so no commit in between goes unnoticed
+		w := r.store.watchers.add()
+
+		var err error
+		if rev, err = r.ship(ctx, rev); err == nil {
+			// wait for the next commit, or for the watcher to overflow
+			select {
+			case <-ctx.Done():
+			case <-w.ch:
+			}
+		}
+		r.store.watchers.remove(w)
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			r.failed(err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.cfg.RetryInterval):
+			}
+		}
+	}
+}
+
+// ship ships the changelog batches following rev until the changelog is exhausted,
+// returning the revision of the last event shipped.
+func (r *Replicator) ship(ctx context.Context, rev uint64) (uint64, error) {
+	for {
+		batch, err := r.store.readReplicationBatch(rev, r.cfg.BatchSize)
+		if err != nil || batch == nil {
+			return rev, err
+		}
+
+		if err := r.cfg.Target.Ship(ctx, batch); err != nil {
+			return rev, errors.Wrapf(err, "failed to ship revision %d", batch.Revision)
+		}
+		rev = batch.Revision
+
+		r.mu.Lock()
+		r.stats.Shipped = rev
+		r.stats.Batches++
+		r.stats.Events += uint64(len(batch.Events))
+		r.stats.LastShipped = time.Now()
+		r.stats.LastError = nil
+		r.mu.Unlock()
+	}
+}
+
+func (r *Replicator) failed(err error) {
+	r.store.logger.Error("replication::boltdb", "error", err)
+
+	r.mu.Lock()
+	r.stats.Errors++
+	r.stats.LastError = err
+	r.mu.Unlock()
+
+	if r.cfg.OnError != nil {
+		r.cfg.OnError(err)
+	}
+}
+
+// readReplicationBatch returns the changelog events of the revisions following rev, stopping at the first
+// revision boundary after size events. It returns nil when there are no events following rev.
+func (s *Store) readReplicationBatch(rev uint64, size int) (*ReplicationBatch, error) {
+	db := s.database()
+	if db == nil {
+		return nil, bolt.ErrDatabaseNotOpen
+	}
+
+	var batch *ReplicationBatch
+
+	err := db.View(func(tx *bolt.Tx) error {
+		b := metaChild(tx, changelogBucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Seek(changelogKey(rev+1, 0)); k != nil; k, v = c.Next() {
+			revision := binary.BigEndian.Uint64(k)
+			if batch != nil && revision != batch.Revision && len(batch.Events) >= size {
+				break
+			}
+
+			var event Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+
+			if batch == nil {
+				batch = &ReplicationBatch{}
+			}
+			batch.Revision = revision
+			batch.Events = append(batch.Events, event)
+		}
+
+		return nil
+	})
+
+	return batch, err
+}
@@ -0,0 +1,168 @@
+package boltdb_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectTarget is a ReplicationTarget recording the shipped batches.
+type collectTarget struct {
+	mu      sync.Mutex
+	batches []*boltdb.ReplicationBatch
+	fail    int
+}
+
+func (c *collectTarget) Ship(ctx context.Context, batch *boltdb.ReplicationBatch) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail > 0 {
+		c.fail--
+		return errors.New("target unavailable")
+	}
+	c.batches = append(c.batches, batch)
+	return nil
+}
+
+func (c *collectTarget) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := []string{}
+	for _, b := range c.batches {
+		for _, e := range b.Events {
+			if e.Op == boltdb.EventPut {
+				keys = append(keys, string(e.Key))
+			}
+		}
+	}
+	return keys
+}
+
+func TestReplication(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	write(t, store, []string{"a"}, "k1")
+	write(t, store, []string{"a"}, "k2")
+
+	target := &collectTarget{}
+	r, err := store.StartReplication(boltdb.ReplicationConfig{Target: target, BatchSize: 1})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	write(t, store, []string{"a"}, "k3")
+
+	require.Eventually(t, func() bool { return len(target.keys()) == 3 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"k1", "k2", "k3"}, target.keys())
+
+	stats := r.Stats()
+	assert.Equal(t, store.Revision(), stats.Shipped)
+	assert.Zero(t, stats.Lag)
+	assert.Equal(t, uint64(3), stats.Batches)
+	assert.False(t, stats.LastShipped.IsZero())
+}
+
+func TestReplicationResume(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	write(t, store, []string{"a"}, "k1")
+	from := store.Revision()
+	write(t, store, []string{"a"}, "k2")
+
+	target := &collectTarget{}
+	r, err := store.StartReplication(boltdb.ReplicationConfig{Target: target, FromRevision: from})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.Eventually(t, func() bool { return len(target.keys()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"k2"}, target.keys())
+}
+
+func TestReplicationRetry(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	write(t, store, []string{"a"}, "k1")
+
+	var (
+		mu     sync.Mutex
+		errs   int
+		target = &collectTarget{fail: 2}
+	)
+	r, err := store.StartReplication(boltdb.ReplicationConfig{
+		Target:        target,
+		RetryInterval: time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs++
+		},
+	})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.Eventually(t, func() bool { return len(target.keys()) == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, errs)
+	assert.Equal(t, uint64(2), r.Stats().Errors)
+	assert.NoError(t, r.Stats().LastError)
+}
+
+func TestReplicationBatchKeepsRevisions(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+	for _, k := range []string{"k1", "k2", "k3"} {
+		require.NoError(t, session.Write([]string{"a"}, k, []byte("v")))
+	}
+	closer()
+
+	target := &collectTarget{}
+	r, err := store.StartReplication(boltdb.ReplicationConfig{Target: target, BatchSize: 1})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.Eventually(t, func() bool { return len(target.keys()) == 3 }, 5*time.Second, 5*time.Millisecond)
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	require.Len(t, target.batches, 1)
+	assert.Equal(t, store.Revision(), target.batches[0].Revision)
+}
+
+func TestStreamTarget(t *testing.T) {
+	var buf bytes.Buffer
+	target := boltdb.StreamTarget(&buf)
+
+	batch := &boltdb.ReplicationBatch{Revision: 2, Events: []boltdb.Event{{Revision: 2, Op: boltdb.EventPut, Path: []string{"a"}, Key: []byte("k")}}}
+	require.NoError(t, target.Ship(context.Background(), batch))
+	require.NoError(t, target.Ship(context.Background(), batch))
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var got boltdb.ReplicationBatch
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &got))
+		assert.Equal(t, *batch, got)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestReplicationRequiresChangelog(t *testing.T) {
+	store := newTestStore(t)
+
+	_, err := store.StartReplication(boltdb.ReplicationConfig{Target: &collectTarget{}})
+	assert.Error(t, err)
+}
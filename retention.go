@@ -0,0 +1,328 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultRetentionInterval is the delay between two enforcements of the retention policies,
+// used when Config.RetentionInterval is zero.
+const DefaultRetentionInterval = time.Minute
+
+// retentionTask is the name of the janitor task enforcing the retention policies.
+const retentionTask = "retention"
+
+// retentionBatchSize is the number of keys deleted per write session by Store.EnforceRetention,
+// so large deletions do not hold the writer of the store.
+const retentionBatchSize = 1000
+
+// RetentionPolicy bounds the keys kept in buckets, e.g. the entries of decision logs, deleting the keys beyond
+// its limits every Config.RetentionInterval, see Store.EnforceRetention.
+type RetentionPolicy struct {
+	// Path of the buckets, AnySegment matching any segment, each matched bucket being bounded separately,
+	// e.g. {"tenants", "*", "logs"}. Nested buckets are not bounded by the policy.
+	Path []string `json:"path"`
+	// MaxAge, when set, deletes the keys last updated longer ago, according to their metadata, which
+	// requires Config.TrackMetadata. Keys without metadata, written before it was set, are kept.
+	MaxAge time.Duration `json:"max_age"`
+	// MaxCount, when set, is the number of keys kept, the last ones in key order, e.g. keys made of
+	// timestamps or sequences, the first keys being deleted.
+	MaxCount int `json:"max_count"`
+}
+
+func (p *RetentionPolicy) validate(cfg *Config) error {
+	if len(p.Path) == 0 {
+		return errors.New("retention policy requires a path")
+	}
+	if p.MaxAge <= 0 && p.MaxCount <= 0 {
+		return errors.Errorf("retention policy %v requires a max age or a max count", p.Path)
+	}
+	if p.MaxAge > 0 && !cfg.TrackMetadata {
+		return errors.Errorf("retention policy %v max age requires metadata tracking", p.Path)
+	}
+	return nil
+}
+
+// RetentionReport reports the keys deleted by Store.EnforceRetention, or which would be deleted by a dry run.
+type RetentionReport struct {
+	DryRun  bool
+	Deleted int               // keys deleted, or which would be deleted
+	Buckets []BucketRetention // buckets holding keys beyond their policy
+}
+
+// BucketRetention reports the keys deleted from a bucket by its retention policy.
+type BucketRetention struct {
+	Path    []string
+	Kept    int      // keys kept
+	Expired int      // keys deleted for being older than the max age of the policy
+	Excess  int      // keys deleted for being beyond the max count of the policy
+	Keys    []string // keys which would be deleted, only reported by dry runs
+}
+
+// retentionVictim is a key deleted by a retention policy.
+type retentionVictim struct {
+	key     string
+	expired bool
+}
+
+// validateRetention fails when one of the retention policies of the configuration is invalid.
+func (s *Store) validateRetention() error {
+	for i := range s.config.Retention {
+		if err := s.config.Retention[i].validate(s.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startRetention enforces the retention policies every Config.RetentionInterval, until the store is closed.
+func (s *Store) startRetention() {
+	interval := s.config.RetentionInterval
+	if interval <= 0 {
+		interval = DefaultRetentionInterval
+	}
+
+	_, _ = s.Schedule(JanitorTask{
+		Name:     retentionTask,
+		Interval: interval,
+		Jitter:   interval / 10,
+		Run: func(ctx context.Context) error {
+			_, err := s.EnforceRetention(ctx, false)
+			return err
+		},
+	})
+}
+
+// EnforceRetention deletes the keys beyond the retention policies of Config.Retention, as the janitor does
+// every Config.RetentionInterval, or only reports them when dryRun is set. Keys are deleted in write sessions
+// of background priority, see WritePriorityBackground, of retentionBatchSize keys each, so keys written
+// meanwhile are not deleted unless they already exceed the policy once their batch is deleted.
+func (s *Store) EnforceRetention(ctx context.Context, dryRun bool) (RetentionReport, error) {
+	s.logger.Trace("Store::EnforceRetention", "dryRun", dryRun)
+
+	report := RetentionReport{DryRun: dryRun}
+
+	for i := range s.config.Retention {
+		policy := &s.config.Retention[i]
+
+		var paths [][]string
+		if err := s.retentionView(func(session *Session, tx *bolt.Tx) error {
+			paths = matchingBuckets(tx, policy.Path)
+			return nil
+		}); err != nil {
+			return report, err
+		}
+
+		for _, path := range paths {
+			result, err := s.enforceBucketRetention(ctx, policy, path, dryRun)
+			if err != nil {
+				return report, wrapError("EnforceRetention", path, "", err)
+			}
+			if result.Expired+result.Excess == 0 {
+				continue
+			}
+			report.Deleted += result.Expired + result.Excess
+			report.Buckets = append(report.Buckets, result)
+		}
+	}
+
+	if report.Deleted > 0 && !dryRun {
+		s.logger.Info("retention::boltdb", "deleted", report.Deleted, "buckets", len(report.Buckets))
+	}
+
+	return report, nil
+}
+
+// enforceBucketRetention deletes the keys of bucket path beyond policy.
+func (s *Store) enforceBucketRetention(ctx context.Context, policy *RetentionPolicy, path []string, dryRun bool) (BucketRetention, error) {
+	result := BucketRetention{Path: path}
+
+	var victims []retentionVictim
+	if err := s.retentionView(func(session *Session, tx *bolt.Tx) error {
+		var err error
+		victims, result.Kept, err = session.retentionVictims(policy, path, time.Now().Add(-policy.MaxAge))
+		return err
+	}); err != nil {
+		return result, err
+	}
+
+	if dryRun {
+		for _, v := range victims {
+			result.Keys = append(result.Keys, v.key)
+			if v.expired {
+				result.Expired++
+			} else {
+				result.Excess++
+			}
+		}
+		return result, nil
+	}
+
+	ctx = WithWritePriority(ctx, WritePriorityBackground)
+
+	for len(victims) > 0 {
+		batch := victims
+		if len(batch) > retentionBatchSize {
+			batch = batch[:retentionBatchSize]
+		}
+		victims = victims[len(batch):]
+
+		cutoff := time.Now().Add(-policy.MaxAge)
+
+		var expired, excess int
+		err := s.updateContext(ctx, func(session *Session) error {
+			keys := make([]string, 0, len(batch))
+			for _, v := range batch {
+				if v.expired {
+					// keys updated since they were found expired are kept
+					md, err := session.keyMetadata(path, []byte(v.key))
+					if err != nil {
+						return err
+					}
+					if md == nil || !md.UpdatedAt.Before(cutoff) {
+						continue
+					}
+					expired++
+				} else {
+					excess++
+				}
+				keys = append(keys, v.key)
+			}
+			return session.DeleteMany(path, keys)
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.Expired += expired
+		result.Excess += excess
+		result.Kept += len(batch) - expired - excess
+	}
+
+	return result, nil
+}
+
+// retentionVictims returns the keys of bucket path beyond policy, keys last updated before cutoff being
+// expired, along with the number of keys kept.
+func (s *Session) retentionVictims(policy *RetentionPolicy, path []string, cutoff time.Time) ([]retentionVictim, int, error) {
+	b, err := s.setBucket(path)
+	if err != nil {
+		// deleted meanwhile
+		return nil, 0, nil
+	}
+
+	expired := func(k []byte) (bool, error) {
+		if policy.MaxAge <= 0 {
+			return false, nil
+		}
+		md, err := s.keyMetadata(path, k)
+		if err != nil || md == nil {
+			return false, err
+		}
+		return md.UpdatedAt.Before(cutoff), nil
+	}
+
+	// count the keys which have not expired, the first ones beyond policy.MaxCount being excess
+	unexpired := 0
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		old, err := expired(k)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !old {
+			unexpired++
+		}
+	}
+
+	excess := 0
+	if policy.MaxCount > 0 && unexpired > policy.MaxCount {
+		excess = unexpired - policy.MaxCount
+	}
+
+	kept := unexpired - excess
+
+	var victims []retentionVictim
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		old, err := expired(k)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch {
+		case old:
+			victims = append(victims, retentionVictim{key: string(k), expired: true})
+		case excess > 0:
+			victims = append(victims, retentionVictim{key: string(k)})
+			excess--
+		}
+	}
+
+	return victims, kept, nil
+}
+
+// retentionView runs fn in a read session of the store.
+func (s *Store) retentionView(fn func(*Session, *bolt.Tx) error) error {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	return session.view(func(tx *bolt.Tx) error { return fn(session, tx) })
+}
+
+// matchingBuckets returns the paths of the buckets matching pattern, AnySegment matching any segment,
+// the metadata bucket excluded.
+func matchingBuckets(tx *bolt.Tx, pattern []string) [][]string {
+	var paths [][]string
+
+	var match func(b *bolt.Bucket, path []string)
+	match = func(b *bolt.Bucket, path []string) {
+		if len(path) == len(pattern) {
+			paths = append(paths, path)
+			return
+		}
+
+		segment := pattern[len(path)]
+		if segment != AnySegment {
+			if child := b.Bucket([]byte(segment)); child != nil {
+				match(child, append(append([]string{}, path...), segment))
+			}
+			return
+		}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v == nil {
+				match(b.Bucket(k), append(append([]string{}, path...), string(k)))
+			}
+		}
+	}
+
+	if pattern[0] != AnySegment {
+		if b := tx.Bucket([]byte(pattern[0])); b != nil {
+			match(b, []string{pattern[0]})
+		}
+		return paths
+	}
+
+	c := tx.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if bytes.Equal(k, metaBucket) {
+			continue
+		}
+		match(tx.Bucket(k), []string{string(k)})
+	}
+
+	return paths
+}
@@ -0,0 +1,109 @@
+package boltdb_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionMaxCount(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		Retention:         []boltdb.RetentionPolicy{{Path: []string{"tenants", boltdb.AnySegment, "logs"}, MaxCount: 10}},
+		RetentionInterval: time.Hour,
+	})
+
+	for _, tenant := range []string{"a", "b"} {
+		writeKeys(t, s, []string{"tenants", tenant, "logs"}, 15)
+	}
+	writeKeys(t, s, []string{"tenants", "a", "logs", "archive"}, 15)
+
+	report, err := s.EnforceRetention(context.Background(), true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 10, report.Deleted)
+	require.Len(t, report.Buckets, 2)
+	assert.Equal(t, []string{"tenants", "a", "logs"}, report.Buckets[0].Path)
+	assert.Equal(t, 10, report.Buckets[0].Kept)
+	assert.Equal(t, 5, report.Buckets[0].Excess)
+	assert.Equal(t, []string{"key-000", "key-001", "key-002", "key-003", "key-004"}, report.Buckets[0].Keys)
+
+	// dry runs leave the keys
+	assert.Len(t, listKeys(t, s, []string{"tenants", "a", "logs"}), 15)
+
+	report, err = s.EnforceRetention(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 10, report.Deleted)
+	assert.Nil(t, report.Buckets[1].Keys)
+
+	for _, tenant := range []string{"a", "b"} {
+		keys := listKeys(t, s, []string{"tenants", tenant, "logs"})
+		require.Len(t, keys, 10)
+		assert.Equal(t, "key-005", keys[0])
+	}
+
+	// nested buckets are not bounded
+	assert.Len(t, listKeys(t, s, []string{"tenants", "a", "logs", "archive"}), 15)
+
+	report, err = s.EnforceRetention(context.Background(), false)
+	require.NoError(t, err)
+	assert.Zero(t, report.Deleted)
+	assert.Empty(t, report.Buckets)
+}
+
+func TestRetentionMaxAge(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		TrackMetadata:     true,
+		Retention:         []boltdb.RetentionPolicy{{Path: []string{"logs"}, MaxAge: 50 * time.Millisecond, MaxCount: 2}},
+		RetentionInterval: time.Hour,
+	})
+
+	path := []string{"logs"}
+	write(t, s, path, "a-old")
+	write(t, s, path, "b-old")
+	time.Sleep(100 * time.Millisecond)
+	write(t, s, path, "c-new")
+	write(t, s, path, "d-new")
+	write(t, s, path, "e-new")
+
+	report, err := s.EnforceRetention(context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, report.Buckets, 1)
+	assert.Equal(t, 2, report.Buckets[0].Expired)
+	assert.Equal(t, 1, report.Buckets[0].Excess)
+	assert.Equal(t, 2, report.Buckets[0].Kept)
+
+	assert.Equal(t, []string{"d-new", "e-new"}, listKeys(t, s, path))
+}
+
+func TestRetentionJanitor(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		Retention:         []boltdb.RetentionPolicy{{Path: []string{"logs"}, MaxCount: 3}},
+		RetentionInterval: 5 * time.Millisecond,
+	})
+
+	writeKeys(t, s, []string{"logs"}, 20)
+
+	assert.Eventually(t, func() bool {
+		return len(listKeys(t, s, []string{"logs"})) == 3
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestRetentionInvalid(t *testing.T) {
+	for _, policy := range []boltdb.RetentionPolicy{
+		{MaxCount: 1},
+		{Path: []string{"logs"}},
+		{Path: []string{"logs"}, MaxAge: time.Hour},
+	} {
+		s := boltdb.NewStoreWithLogger(&boltdb.Config{
+			DBPath:    filepath.Join(t.TempDir(), "test.db"),
+			Retention: []boltdb.RetentionPolicy{policy},
+		}, nil)
+		assert.Error(t, s.Open())
+		s.Close()
+	}
+}
@@ -0,0 +1,109 @@
+package boltdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// RetryPolicy controls how UpdateWithRetry retries transient failures.
+type RetryPolicy struct {
+	MaxAttempts    int           // total number of attempts, including the first one
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound of the delay between retries
+	Multiplier     float64       // growth factor applied to the delay after each retry
+}
+
+// DefaultRetryPolicy returns the retry policy used when a zero RetryPolicy is passed in.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+}
+
+// UpdateWithRetry runs fn inside a write session, waiting for the writer until ctx is done, and commits the result.
+// When the attempt fails with a transient error (write queue full or database not open)
+// the whole closure is re-executed in a new write session, backing off between attempts.
+// Any other error returned by fn, or recorded by the session, rolls back the
+// transaction and is returned as-is.
+func (s *Store) UpdateWithRetry(ctx context.Context, fn func(*Session) error, policy RetryPolicy) error {
+	policy = policy.withDefaults()
+
+	backoff := policy.InitialBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		err = s.updateContext(ctx, fn)
+		if err == nil || !IsTransient(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		s.logger.Debug("UpdateWithRetry", "error", err, "attempt", attempt, "backoff", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrap(ctx.Err(), err.Error())
+		case <-timer.C:
+		}
+
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// IsTransient reports whether err is a transient store error worth retrying.
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrWriteQueueFull) || errors.Is(err, bolt.ErrDatabaseNotOpen)
+}
+
+// update runs fn in a write session and commits it when neither fn nor the session failed.
+func (s *Store) update(fn func(*Session) error) error {
+	return s.updateContext(context.Background(), fn)
+}
+
+// updateContext is update, the write session waiting for the writer until ctx is done.
+func (s *Store) updateContext(ctx context.Context, fn func(*Session) error) error {
+	session, err := s.beginContext(ctx, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to start write transaction")
+	}
+
+	if err := fn(session); err != nil {
+		session.rollback()
+		return err
+	}
+
+	return session.commit()
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = def.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = def.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = def.Multiplier
+	}
+
+	return p
+}
@@ -0,0 +1,132 @@
+package boltdb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = boltdb.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	s := newTestStore(t)
+
+	attempts := 0
+	err := s.UpdateWithRetry(context.Background(), func(session *boltdb.Session) error {
+		attempts++
+		if err := session.Write([]string{"retry"}, "key", []byte("value")); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return boltdb.ErrWriteQueueFull
+		}
+		return nil
+	}, testRetryPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	buf, err := session.Read([]string{"retry"}, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(buf))
+}
+
+func TestUpdateWithRetryExhausted(t *testing.T) {
+	s := newTestStore(t)
+
+	attempts := 0
+	err := s.UpdateWithRetry(context.Background(), func(session *boltdb.Session) error {
+		attempts++
+		return boltdb.ErrWriteQueueFull
+	}, testRetryPolicy)
+	assert.True(t, errors.Is(err, boltdb.ErrWriteQueueFull))
+	assert.Equal(t, testRetryPolicy.MaxAttempts, attempts)
+}
+
+func TestUpdateWithRetryPermanentError(t *testing.T) {
+	s := newTestStore(t)
+
+	errPermanent := errors.New("permanent")
+
+	attempts := 0
+	err := s.UpdateWithRetry(context.Background(), func(session *boltdb.Session) error {
+		attempts++
+		if err := session.Write([]string{"retry"}, "key", []byte("value")); err != nil {
+			return err
+		}
+		return errPermanent
+	}, testRetryPolicy)
+	assert.True(t, errors.Is(err, errPermanent))
+	assert.Equal(t, 1, attempts)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	assert.False(t, session.BucketExists([]string{"retry"}))
+}
+
+func TestUpdateWithRetryCanceled(t *testing.T) {
+	s := newTestStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.UpdateWithRetry(ctx, func(session *boltdb.Session) error {
+		return nil
+	}, testRetryPolicy)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestUpdateWithRetryWaitCanceled(t *testing.T) {
+	s := newTestStore(t)
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	defer closer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// the writer held above is never released, the wait ends with ctx
+	err = s.UpdateWithRetry(ctx, func(session *boltdb.Session) error {
+		return nil
+	}, testRetryPolicy)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestUpdateWithRetryQueueFull(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{MaxWriteQueue: 1})
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, s.Update(func(w boltdb.Writer) error { return nil }))
+	}()
+	waitForQueue(t, s, 1)
+
+	// the queue is full until the writer is released
+	time.AfterFunc(5*time.Millisecond, closer)
+
+	attempts := 0
+	err = s.UpdateWithRetry(context.Background(), func(session *boltdb.Session) error {
+		attempts++
+		return session.Write([]string{"retry"}, "key", []byte("value"))
+	}, boltdb.RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "value", readValue(t, s, []string{"retry"}, "key"))
+}
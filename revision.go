@@ -0,0 +1,126 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// The root bucket metaRoot holds the store metadata and is not accessible through the session API:
+// its keys hold the store revision and its nested buckets the data maintained by the store, e.g.
+// the changelog in __meta/changelog. Every other root bucket holds user data.
+const metaRoot = "__meta"
+
+var (
+	metaBucket  = []byte(metaRoot)
+	revisionKey = []byte("revision")
+)
+
+// Revision returns the store revision, a counter incremented by every committed
+// write session which modified the store.
+func (s *Store) Revision() uint64 {
+	return atomic.LoadUint64(&s.revision)
+}
+
+// Revision returns the store revision observed by the session.
+// For read sessions this is the revision of the snapshot the session reads from.
+// For write sessions this is the revision the session started from and, once the session
+// has been committed with modifications, the revision it committed.
+func (s *Session) Revision() uint64 {
+	return s.revision
+}
+
+// commit commits the session transaction, stamping the next store revision into the
+// metadata bucket when the session modified the store. When the session recorded an
+// error the transaction is rolled back and the error is returned instead.
+func (s *Session) commit() error {
+	if s.err != nil {
+		s.rollback()
+		return s.err
+	}
+
+	revision := s.revision
+	if s.dirty {
+		revision++
+		if err := writeRevision(s.tx, revision); err != nil {
+			s.rollback()
+			return err
+		}
+		if err := s.appendChangelog(revision); err != nil {
+			s.rollback()
+			return err
+		}
+		if err := s.appendAudit(revision); err != nil {
+			s.rollback()
+			return err
+		}
+	}
+
+	if err := s.tx.Commit(); err != nil {
+		s.rolledBack()
+		return err
+	}
+
+	if s.dirty {
+		s.revision = revision
+		atomic.StoreUint64(&s.store.revision, revision)
+		s.store.invalidate(s.touched)
+		s.store.publish(s.events)
+		s.store.writeAudit(s.audit)
+	}
+
+	s.committed()
+
+	return nil
+}
+
+// rollback discards the session transaction.
+func (s *Session) rollback() {
+	_ = s.tx.Rollback()
+	s.rolledBack()
+}
+
+// metaChild returns the nested bucket name of the metadata bucket, nil when absent.
+func metaChild(tx *bolt.Tx, name []byte) *bolt.Bucket {
+	b := tx.Bucket(metaBucket)
+	if b == nil {
+		return nil
+	}
+	return b.Bucket(name)
+}
+
+// createMetaChild returns the nested bucket name of the metadata bucket, creating both when missing.
+func createMetaChild(tx *bolt.Tx, name []byte) (*bolt.Bucket, error) {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return nil, err
+	}
+	return b.CreateBucketIfNotExists(name)
+}
+
+func readRevision(tx *bolt.Tx) uint64 {
+	b := tx.Bucket(metaBucket)
+	if b == nil {
+		return 0
+	}
+
+	buf := b.Get(revisionKey)
+	if len(buf) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(buf)
+}
+
+func writeRevision(tx *bolt.Tx, revision uint64) error {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, revision)
+
+	return b.Put(revisionKey, buf)
+}
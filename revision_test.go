@@ -0,0 +1,110 @@
+package boltdb_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRevision(t *testing.T) {
+	s := newTestStore(t)
+	assert.Zero(t, s.Revision())
+
+	write := func(key string) *boltdb.Session {
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		require.NoError(t, session.Write([]string{"revision"}, key, []byte("value")))
+		closer()
+		return session
+	}
+
+	session := write("k1")
+	assert.Equal(t, uint64(1), session.Revision())
+	assert.Equal(t, uint64(1), s.Revision())
+
+	write("k2")
+	assert.Equal(t, uint64(2), s.Revision())
+
+	// read-only write sessions do not bump the revision
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	_, err = session.Read([]string{"revision"}, "k1")
+	require.NoError(t, err)
+	closer()
+	assert.Equal(t, uint64(2), s.Revision())
+
+	// rolled back write sessions do not bump the revision
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"revision"}, "k3", []byte("value")))
+	_, err = session.Read([]string{"revision"}, "missing")
+	require.Error(t, err)
+	closer()
+	assert.Equal(t, uint64(2), s.Revision())
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+	assert.Equal(t, uint64(2), session.Revision())
+
+	// the metadata bucket is neither listed nor accessible
+	buckets, _, err := session.ListBuckets([]string{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"revision"}, buckets)
+
+	_, err = session.Read([]string{"__meta"}, "revision")
+	assert.True(t, errors.Is(err, boltdb.ErrInvalidPath))
+}
+
+func TestRevisionReopen(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.UpdateWithRetry(context.Background(), func(session *boltdb.Session) error {
+		return session.Write([]string{"revision"}, "key", []byte("value"))
+	}, boltdb.RetryPolicy{}))
+	assert.Equal(t, uint64(1), s.Revision())
+
+	s.Close()
+	require.NoError(t, s.Open())
+	assert.Equal(t, uint64(1), s.Revision())
+}
+
+func TestUnderscoreBuckets(t *testing.T) {
+	cfg := &boltdb.Config{DBPath: filepath.Join(t.TempDir(), "underscore.db"), EnableChangelog: true}
+
+	// a database written before the store reserved its metadata bucket
+	db, err := bolt.Open(cfg.DBPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("__private"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("k1"), []byte("v1"))
+	}))
+	require.NoError(t, db.Close())
+
+	s := boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, s.Open())
+	t.Cleanup(s.Close)
+
+	assert.Equal(t, "v1", readValue(t, s, []string{"__private"}, "k1"))
+	writeValue(t, s, []string{"__private"}, "k2", "v2")
+	writeValue(t, s, []string{"__changelog"}, "k", "v")
+	assert.Equal(t, "v2", readValue(t, s, []string{"__private"}, "k2"))
+	assert.Equal(t, "v", readValue(t, s, []string{"__changelog"}, "k"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	buckets, _, err := session.ListBuckets([]string{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__changelog", "__private"}, buckets)
+}
@@ -0,0 +1,92 @@
+package boltdb
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Sample returns a pseudo-random sample of up to n keys of bucket path, along with their values, e.g. for
+// support tooling to inspect representative data without dumping the whole bucket. Every key of the bucket,
+// nested buckets excluded, is equally likely to be sampled, in a single walk of its keys. The sample is
+// returned in key order, the values being copies. Returns ErrPathNotFound when the bucket path does not exist.
+func (s *Session) Sample(path []string, n int) ([]string, [][]byte, error) {
+	s.store.logger.Trace("Session::Sample", "path", path, "n", n)
+
+	type sampled struct {
+		key   string
+		value []byte
+	}
+
+	var reservoir []sampled
+
+	sample := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		if n < 0 {
+			return errors.Errorf("invalid sample size %d", n)
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		// reservoir sampling, the i-th key replacing a sampled key with probability n/i
+		seen := 0
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
+			seen++
+
+			slot := len(reservoir)
+			if seen > n {
+				if slot = rand.Intn(seen); slot >= n {
+					continue
+				}
+			}
+
+			if err := s.verifyChecksum(path, k, v); err != nil {
+				return err
+			}
+			value, err := s.listedValue(v)
+			if err != nil {
+				return err
+			}
+
+			entry := sampled{key: string(k), value: append([]byte{}, value...)}
+			if slot == len(reservoir) {
+				reservoir = append(reservoir, entry)
+			} else {
+				reservoir[slot] = entry
+			}
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("Sample", path, ""), func() error { return s.view(sample) })
+
+	if err != nil {
+		s.store.logger.Trace("Sample", "error", err)
+		return nil, nil, wrapError("Sample", path, "", err)
+	}
+
+	sort.Slice(reservoir, func(i, j int) bool { return reservoir[i].key < reservoir[j].key })
+
+	keys := make([]string, len(reservoir))
+	values := make([][]byte, len(reservoir))
+	for i, entry := range reservoir {
+		keys[i], values[i] = entry.key, entry.value
+	}
+
+	return keys, values, nil
+}
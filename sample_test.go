@@ -0,0 +1,67 @@
+package boltdb_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"sample"}
+	writeKeys(t, s, path, 100)
+	write(t, s, []string{"sample", "nested"}, "k")
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		keys, values, err := r.Sample(path, 10)
+		require.NoError(t, err)
+		require.Len(t, keys, 10)
+		require.Len(t, values, 10)
+		assert.True(t, sort.StringsAreSorted(keys))
+
+		seen := map[string]bool{}
+		for i, key := range keys {
+			assert.False(t, seen[key])
+			seen[key] = true
+			assert.Equal(t, "value", string(values[i]))
+		}
+
+		// small buckets are returned whole, without their nested buckets
+		keys, _, err = r.Sample(path, 1000)
+		require.NoError(t, err)
+		assert.Len(t, keys, 100)
+
+		keys, _, err = r.Sample(path, 0)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		_, _, err = r.Sample([]string{"missing"}, 10)
+		assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+		return nil
+	}))
+}
+
+func TestSampleUniform(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"sample"}
+	writeKeys(t, s, path, 4)
+
+	counts := map[string]int{}
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		for i := 0; i < 400; i++ {
+			keys, _, err := r.Sample(path, 1)
+			require.NoError(t, err)
+			require.Len(t, keys, 1)
+			counts[keys[0]]++
+		}
+		return nil
+	}))
+
+	require.Len(t, counts, 4)
+	for key, n := range counts {
+		assert.Greater(t, n, 40, key)
+	}
+}
@@ -0,0 +1,241 @@
+package boltdb
+
+import (
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// undoFunc reverts a single mutation performed inside a write session.
+type undoFunc func() error
+
+// Savepoint marks the current state of a write session.
+// Calling the returned rollbackTo function undoes every mutation performed in
+// the session after the savepoint was taken, while keeping the earlier ones.
+// The savepoint stays valid after a rollback, so it can be rolled back to again.
+//
+// Savepoints are implemented with an in-memory undo journal which is only
+// maintained once the first savepoint of the session has been taken.
+func (s *Session) Savepoint() (rollbackTo func(), err error) {
+	s.store.logger.Trace("Session::Savepoint")
+
+	if s.upgradable {
+		return nil, s.upgrade()
+	}
+	if s.tx == nil || !s.tx.Writable() {
+		return nil, wrapError("Savepoint", nil, "", bolt.ErrTxNotWritable)
+	}
+
+	s.journaling = true
+
+	mark := len(s.journal)
+	events := len(s.events)
+	onCommit, onRollback := len(s.onCommit), len(s.onRollback)
+	sessionErr := s.err
+
+	rollbackTo = func() {
+		for len(s.journal) > mark {
+			last := len(s.journal) - 1
+			undo := s.journal[last]
+			s.journal = s.journal[:last]
+
+			if err := undo(); err != nil {
+				s.store.logger.Error("Savepoint::rollback", "error", err)
+				s.err = errors.Wrap(err, "savepoint rollback")
+				return
+			}
+		}
+		s.events = s.events[:events]
+		s.err = sessionErr
+
+		if len(s.onCommit) > onCommit {
+			s.onCommit = s.onCommit[:onCommit]
+		}
+		if len(s.onRollback) > onRollback {
+			callbacks := append([]func(){}, s.onRollback[onRollback:]...)
+			s.onRollback = s.onRollback[:onRollback]
+			runCallbacks(callbacks)
+		}
+	}
+
+	return rollbackTo, nil
+}
+
+// journalKey records how to restore key in path to its current state.
+func (s *Session) journalKey(path []string, key []byte) {
+	if !s.journaling {
+		return
+	}
+
+	if undo := s.undoMissingPath(path); undo != nil {
+		s.journal = append(s.journal, undo)
+		return
+	}
+
+	b, err := s.setBucket(path)
+	if err != nil {
+		return
+	}
+
+	prev := b.Get(key)
+	if prev != nil {
+		prev = append([]byte{}, prev...)
+	}
+
+	s.journal = append(s.journal, func() error {
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			return b.Delete(key)
+		}
+		return b.Put(key, prev)
+	})
+}
+
+// journalSequence records how to restore the sequence of the bucket at path.
+func (s *Session) journalSequence(path []string) {
+	if !s.journaling {
+		return
+	}
+
+	if undo := s.undoMissingPath(path); undo != nil {
+		s.journal = append(s.journal, undo)
+		return
+	}
+
+	b, err := s.setBucket(path)
+	if err != nil {
+		return
+	}
+
+	seq := b.Sequence()
+
+	s.journal = append(s.journal, func() error {
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+		return b.SetSequence(seq)
+	})
+}
+
+// journalCreate records how to remove the buckets of path which do not exist yet.
+func (s *Session) journalCreate(path []string) {
+	if !s.journaling {
+		return
+	}
+
+	if undo := s.undoMissingPath(path); undo != nil {
+		s.journal = append(s.journal, undo)
+	}
+}
+
+// journalBucket records a snapshot of the bucket at path, including nested buckets,
+// so it can be recreated after it has been deleted or modified in bulk.
+func (s *Session) journalBucket(path []string) {
+	if !s.journaling {
+		return
+	}
+
+	b, err := s.setBucket(path)
+	if err != nil {
+		return
+	}
+
+	snap := snapshotBucket(b)
+
+	s.journal = append(s.journal, func() error {
+		if err := s.deleteBucketPath(path); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		b, err := s.setBucketIfNotExist(path)
+		if err != nil {
+			return err
+		}
+		return snap.restore(b)
+	})
+}
+
+// undoMissingPath returns an undo function deleting the first bucket of path
+// which does not exist yet, or nil when the whole path exists.
+func (s *Session) undoMissingPath(path []string) undoFunc {
+	for i := range path {
+		if _, err := s.setBucket(path[:i+1]); err != nil {
+			created := path[:i+1]
+			return func() error {
+				return s.deleteBucketPath(created)
+			}
+		}
+	}
+	return nil
+}
+
+// bucketSnapshot is an in-memory deep copy of a bucket.
+type bucketSnapshot struct {
+	sequence uint64
+	keys     [][]byte
+	values   [][]byte
+	names    [][]byte
+	buckets  []*bucketSnapshot
+}
+
+func snapshotBucket(b *bolt.Bucket) *bucketSnapshot {
+	snap := &bucketSnapshot{sequence: b.Sequence()}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			snap.names = append(snap.names, append([]byte{}, k...))
+			snap.buckets = append(snap.buckets, snapshotBucket(b.Bucket(k)))
+			continue
+		}
+		snap.keys = append(snap.keys, append([]byte{}, k...))
+		snap.values = append(snap.values, append([]byte{}, v...))
+	}
+
+	return snap
+}
+
+func (snap *bucketSnapshot) restore(b *bolt.Bucket) error {
+	for i, k := range snap.keys {
+		if err := b.Put(k, snap.values[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, name := range snap.names {
+		child, err := b.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+		if err := snap.buckets[i].restore(child); err != nil {
+			return err
+		}
+	}
+
+	return b.SetSequence(snap.sequence)
+}
+
+// deleteBucketPath deletes the bucket at the tail of path,
+// invalidating the buckets resolved by the session.
+func (s *Session) deleteBucketPath(path []string) error {
+	s.bucketEpoch++
+
+	if len(path) == 1 {
+		return s.tx.DeleteBucket([]byte(path[0]))
+	}
+
+	b := s.tx.Bucket([]byte(path[0]))
+	for _, p := range path[1 : len(path)-1] {
+		if b == nil {
+			break
+		}
+		b = b.Bucket([]byte(p))
+	}
+	if b == nil {
+		return bolt.ErrBucketNotFound
+	}
+
+	return b.DeleteBucket([]byte(path[len(path)-1]))
+}
@@ -0,0 +1,59 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavepointRollback(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	require.NoError(t, session.Write([]string{"sp", "a"}, "k1", []byte("v1")))
+	require.NoError(t, session.Write([]string{"sp", "b"}, "k1", []byte("v1")))
+
+	rollbackTo, err := session.Savepoint()
+	require.NoError(t, err)
+
+	require.NoError(t, session.Write([]string{"sp", "a"}, "k1", []byte("changed")))
+	require.NoError(t, session.Write([]string{"sp", "a"}, "k2", []byte("v2")))
+	require.NoError(t, session.DeleteBucket([]string{"sp", "b"}))
+	require.NoError(t, session.Write([]string{"sp", "c", "d"}, "k1", []byte("v1")))
+	_, err = session.NextSeq([]string{"sp", "a"})
+	require.NoError(t, err)
+
+	rollbackTo()
+	closer()
+
+	session, closer, err = s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	buf, err := session.Read([]string{"sp", "a"}, "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(buf))
+
+	buf, err = session.Read([]string{"sp", "b"}, "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(buf))
+
+	_, err = session.Read([]string{"sp", "a"}, "k2")
+	assert.Error(t, err)
+
+	assert.False(t, session.BucketExists([]string{"sp", "c"}))
+}
+
+func TestSavepointReadSession(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	_, err = session.Savepoint()
+	assert.Error(t, err)
+}
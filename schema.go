@@ -0,0 +1,209 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// AnySegment matches any segment of the paths of a BucketSchema.
+const AnySegment = "*"
+
+// ValueType is the type of the values of the buckets declared by a BucketSchema.
+type ValueType int
+
+const (
+	AnyValue     ValueType = iota // values of any content
+	JSONValue                     // valid JSON documents
+	StringValue                   // valid UTF-8 strings
+	IntegerValue                  // decimal integers, as written by Session.Increment
+)
+
+// BucketSchema declares the buckets matching a path, along with the constraints on their values.
+type BucketSchema struct {
+	// Path of the declared buckets, AnySegment matching any segment, e.g. {"tenants", "*", "users"}.
+	Path []string
+	// Nested declares the buckets nested below Path, with the same constraints.
+	Nested bool
+
+	Type         ValueType    // type of the values
+	MaxValueSize int          // maximum size in bytes of the values, zero for no limit
+	Validate     ValidateFunc // checks the values decoded by the codec of the application, optional
+
+	// Indexes are registered along with the schema, see Store.RegisterIndex. Their Path defaults to Path,
+	// which must have no AnySegment then.
+	Indexes []Index
+}
+
+// Schema describes the buckets of a store, see Store.RegisterSchema.
+type Schema struct {
+	Buckets []BucketSchema
+	// Strict fails with ErrUndeclaredPath the writes to the keys of undeclared buckets, and the creation of
+	// buckets which are neither declared nor parents of declared buckets.
+	Strict bool
+}
+
+// RegisterSchema declares the buckets of the store and the constraints on their values, checked by write
+// sessions along with the validators, see Store.RegisterValidator. Writes of values violating the
+// constraints of their bucket, declared by the first BucketSchema matching it, fail with a ValidationError.
+// The schema is registered once, along with its indexes.
+func (s *Store) RegisterSchema(schema Schema) error {
+	buckets := make([]BucketSchema, len(schema.Buckets))
+	for i, b := range schema.Buckets {
+		if err := Path(b.Path).Validate(); err != nil {
+			return errors.Wrapf(err, "bucket schema %d", i)
+		}
+		b.Path = append([]string{}, b.Path...)
+		buckets[i] = b
+	}
+
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+
+	if s.schema != nil {
+		return errors.New("schema already registered")
+	}
+
+	// indexes are all checked before any is registered, so a schema is either registered whole or not at all
+	var indexes []Index
+	for _, b := range buckets {
+		for _, idx := range b.Indexes {
+			if idx.Path == nil {
+				if b.wildcard() {
+					return errors.Errorf("index %s requires a path, bucket schema [%s] has wildcards", idx.Name, Path(b.Path))
+				}
+				idx.Path = b.Path
+			}
+			if err := idx.check(); err != nil {
+				return err
+			}
+			indexes = append(indexes, idx)
+		}
+	}
+	if err := s.indexes.add(indexes...); err != nil {
+		return err
+	}
+
+	s.schema = &Schema{Buckets: buckets, Strict: schema.Strict}
+
+	return nil
+}
+
+// bucketSchema returns the first declaration matching bucket path, nil when there is none, and whether
+// undeclared paths are rejected.
+func (s *Store) bucketSchema(path []string) (*BucketSchema, bool) {
+	s.schemaMu.RLock()
+	defer s.schemaMu.RUnlock()
+
+	if s.schema == nil {
+		return nil, false
+	}
+
+	for i := range s.schema.Buckets {
+		if b := &s.schema.Buckets[i]; b.matches(path) {
+			return b, s.schema.Strict
+		}
+	}
+
+	return nil, s.schema.Strict
+}
+
+// checkBucketDeclared fails with ErrUndeclaredPath when the schema is strict and bucket path is neither
+// declared nor the parent of a declared bucket.
+func (s *Store) checkBucketDeclared(path []string) error {
+	if len(path) == 0 || path[0] == metaRoot {
+		return nil
+	}
+
+	s.schemaMu.RLock()
+	defer s.schemaMu.RUnlock()
+
+	if s.schema == nil || !s.schema.Strict {
+		return nil
+	}
+
+	for i := range s.schema.Buckets {
+		if b := &s.schema.Buckets[i]; b.matches(path) || b.parentOf(path) {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(ErrUndeclaredPath, "path [%s]", Path(path))
+}
+
+// checkSchema checks value, about to be written to key, against the declaration of bucket path.
+func (s *Session) checkSchema(path []string, key, value []byte) error {
+	b, strict := s.store.bucketSchema(path)
+	if b == nil {
+		if strict {
+			return errors.Wrapf(ErrUndeclaredPath, "path [%s]", Path(path))
+		}
+		return nil
+	}
+
+	if err := b.check(path, key, value); err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	return nil
+}
+
+func (b *BucketSchema) check(path []string, key, value []byte) error {
+	if b.MaxValueSize > 0 && len(value) > b.MaxValueSize {
+		return errors.Errorf("value size %d exceeds %d bytes", len(value), b.MaxValueSize)
+	}
+
+	switch b.Type {
+	case JSONValue:
+		if !json.Valid(value) {
+			return errors.New("value is not valid JSON")
+		}
+	case StringValue:
+		if !utf8.Valid(value) {
+			return errors.New("value is not valid UTF-8")
+		}
+	case IntegerValue:
+		if _, err := strconv.ParseInt(string(value), 10, 64); err != nil {
+			return errors.New("value is not a decimal integer")
+		}
+	}
+
+	if b.Validate != nil {
+		return b.Validate(path, key, value)
+	}
+
+	return nil
+}
+
+// matches reports whether path is a bucket declared by b.
+func (b *BucketSchema) matches(path []string) bool {
+	if len(path) < len(b.Path) || (len(path) > len(b.Path) && !b.Nested) {
+		return false
+	}
+	return b.matchSegments(path[:len(b.Path)])
+}
+
+// parentOf reports whether path is the parent of a bucket declared by b.
+func (b *BucketSchema) parentOf(path []string) bool {
+	return len(path) < len(b.Path) && b.matchSegments(path)
+}
+
+func (b *BucketSchema) matchSegments(path []string) bool {
+	for i, segment := range path {
+		if b.Path[i] != AnySegment && b.Path[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BucketSchema) wildcard() bool {
+	for _, segment := range b.Path {
+		if segment == AnySegment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{
+			{Path: []string{"tenants", "*", "users"}, Type: boltdb.JSONValue, MaxValueSize: 32},
+			{Path: []string{"counters"}, Type: boltdb.IntegerValue},
+			{Path: []string{"blobs"}, Nested: true},
+		},
+	}))
+
+	update := func(fn func(w boltdb.Writer) error) error { return s.Update(fn) }
+
+	assert.NoError(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants", "acme", "users"}, "alice", []byte(`{"name":"alice"}`))
+	}))
+	assert.ErrorIs(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants", "acme", "users"}, "bob", []byte(`{"name":`))
+	}), boltdb.ErrValidation)
+	assert.ErrorIs(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants", "acme", "users"}, "carol", []byte(`{"name":"carol with a long name"}`))
+	}), boltdb.ErrValidation)
+
+	assert.NoError(t, update(func(w boltdb.Writer) error {
+		_, err := w.Increment([]string{"counters"}, "hits", 1)
+		return err
+	}))
+	assert.ErrorIs(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"counters"}, "hits", []byte("many"))
+	}), boltdb.ErrValidation)
+
+	assert.NoError(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"blobs", "images", "2024"}, "logo", []byte{0xff, 0xfe})
+	}))
+
+	// undeclared paths are not constrained without strict mode
+	assert.NoError(t, update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenant"}, "alice", []byte("junk"))
+	}))
+
+	assert.Error(t, s.RegisterSchema(boltdb.Schema{}), "schema registered twice")
+}
+
+func TestSchemaStrict(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.RegisterSchema(boltdb.Schema{
+		Strict:  true,
+		Buckets: []boltdb.BucketSchema{{Path: []string{"tenants", "*", "users"}}},
+	}))
+
+	assert.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants", "acme", "users"}, "alice", []byte("v"))
+	}))
+
+	for _, path := range [][]string{{"tenant", "acme", "users"}, {"tenants", "acme", "user"}, {"tenants", "acme", "users", "nested"}} {
+		err := s.Update(func(w boltdb.Writer) error {
+			return w.Write(path, "alice", []byte("v"))
+		})
+		assert.ErrorIs(t, err, boltdb.ErrUndeclaredPath, "path %q", path)
+
+		var se *boltdb.StoreError
+		require.True(t, errors.As(err, &se))
+	}
+
+	// parents of declared buckets can be created, but not written to
+	assert.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.CreateBucket([]string{"tenants", "globex"})
+	}))
+	assert.ErrorIs(t, s.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"tenants"}, "acme", []byte("v"))
+	}), boltdb.ErrUndeclaredPath)
+	assert.ErrorIs(t, s.Update(func(w boltdb.Writer) error {
+		return w.CreateBucket([]string{"tenant"})
+	}), boltdb.ErrUndeclaredPath)
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		assert.False(t, r.BucketExists([]string{"tenant"}))
+		return nil
+	}))
+}
+
+func TestSchemaIndexes(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{{
+			Path:    []string{"users"},
+			Type:    boltdb.JSONValue,
+			Indexes: []boltdb.Index{{Name: "users-by-email", Fields: []boltdb.IndexField{boltdb.StringField("/email")}}},
+		}},
+	}))
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users"}, "alice", []byte(`{"email":"alice@acme.com"}`))
+	}))
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		entries, _, err := r.QueryIndex("users-by-email", boltdb.IndexQuery{Equal: [][]byte{[]byte("alice@acme.com")}}, "")
+		require.NoError(t, err)
+		assert.Equal(t, []boltdb.IndexEntry{{Path: []string{"users"}, Key: "alice"}}, entries)
+		return nil
+	}))
+
+	err := boltdb.NewStoreWithLogger(&boltdb.Config{}, nil).RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{{
+			Path:    []string{"tenants", "*"},
+			Indexes: []boltdb.Index{{Name: "tenants", Fields: []boltdb.IndexField{boltdb.StringField("/name")}}},
+		}},
+	})
+	assert.Error(t, err)
+}
+
+func TestSchemaIndexesAtomic(t *testing.T) {
+	s := newTestStore(t)
+
+	byEmail := boltdb.Index{Name: "users-by-email", Fields: []boltdb.IndexField{boltdb.StringField("/email")}}
+
+	// a schema with an invalid index registers none of its indexes
+	err := s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{
+			{Path: []string{"users"}, Indexes: []boltdb.Index{byEmail}},
+			{Path: []string{"groups"}, Indexes: []boltdb.Index{{Name: "groups-by-name"}}},
+		},
+	})
+	require.Error(t, err)
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		_, _, err := r.QueryIndex("users-by-email", boltdb.IndexQuery{}, "")
+		assert.ErrorIs(t, err, boltdb.ErrIndexNotFound)
+		return nil
+	}))
+
+	// nor does a schema declaring an index twice
+	assert.Error(t, s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{
+			{Path: []string{"users"}, Indexes: []boltdb.Index{byEmail}},
+			{Path: []string{"admins"}, Indexes: []boltdb.Index{byEmail}},
+		},
+	}))
+
+	require.NoError(t, s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{{Path: []string{"users"}, Indexes: []boltdb.Index{byEmail}}},
+	}))
+}
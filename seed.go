@@ -0,0 +1,134 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// SeedMode controls how Store.Seed treats keys which already exist.
+type SeedMode int
+
+const (
+	SeedSkipExisting SeedMode = iota // keep the current value of existing keys
+	SeedOverwrite                    // replace the value of existing keys
+)
+
+// SeedEntry is a key-value pair of a seed file.
+// Value is stored in its compact JSON encoding, unless Text is set, which is stored verbatim.
+type SeedEntry struct {
+	Path  []string    `json:"path" yaml:"path"`
+	Key   string      `json:"key" yaml:"key"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	Text  *string     `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// seedFuncs are the functions available to seed file templates.
+var seedFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"now": func() string { return time.Now().UTC().Format(time.RFC3339) },
+}
+
+// Seed loads the seed files of fsys matching glob into the store, in a single write session.
+//
+// Seed files hold a list of entries, see SeedEntry, in JSON (.json) or YAML (.yaml, .yml).
+// Files are rendered as text/template templates before they are parsed, with the functions
+// env, which returns the value of an environment variable, and now, which returns the current
+// UTC time in RFC 3339 format. Files are loaded in lexical order, a key seeded by several files
+// holds the value of the last one when mode is SeedOverwrite and of the first one otherwise.
+// Loading the same seed files again in SeedSkipExisting mode leaves the store unchanged.
+func (s *Store) Seed(ctx context.Context, fsys fs.FS, glob string, mode SeedMode) error {
+	s.logger.Trace("Store::Seed", "glob", glob, "mode", int(mode))
+
+	names, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return errors.Wrapf(err, "seed glob %q", glob)
+	}
+
+	var entries []SeedEntry
+	for _, name := range names {
+		fileEntries, err := readSeedFile(fsys, name)
+		if err != nil {
+			return errors.Wrapf(err, "seed file %s", name)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return s.updateContext(ctx, func(session *Session) error {
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			value, err := entry.value()
+			if err != nil {
+				return wrapError("Seed", entry.Path, entry.Key, err)
+			}
+
+			if mode == SeedSkipExisting && Path(entry.Path).Validate() == nil &&
+				session.currentValue(entry.Path, []byte(entry.Key)) != nil {
+				continue
+			}
+
+			if err := session.Write(entry.Path, entry.Key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func readSeedFile(fsys fs.FS, name string) ([]SeedEntry, error) {
+	buf, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(seedFuncs).Parse(string(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, nil); err != nil {
+		return nil, err
+	}
+
+	var entries []SeedEntry
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		dec := json.NewDecoder(&rendered)
+		dec.UseNumber()
+		err = dec.Decode(&entries)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(rendered.Bytes(), &entries)
+	default:
+		err = errors.Errorf("unsupported seed file extension %q", path.Ext(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// value returns the value stored for the entry.
+func (e *SeedEntry) value() ([]byte, error) {
+	if e.Text != nil {
+		return []byte(*e.Text), nil
+	}
+	if e.Value == nil {
+		return nil, errors.New("seed entry has neither value nor text")
+	}
+	return json.Marshal(e.Value)
+}
@@ -0,0 +1,85 @@
+package boltdb_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var seedFS = fstest.MapFS{
+	"seed/01-policies.yaml": {Data: []byte(`
+- path: [policies]
+  key: default
+  value:
+    effect: allow
+    rules: [read, write]
+- path: [system, users]
+  key: admin
+  text: '{{ env "SEED_ADMIN" }}'
+`)},
+	"seed/02-objects.json": {Data: []byte(`[
+  {"path": ["system", "objects"], "key": "root", "value": {"id": 1}},
+  {"path": ["policies"], "key": "default", "text": "deny"}
+]`)},
+	"seed/readme.txt": {Data: []byte("not a seed file")},
+}
+
+func TestSeed(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	os.Setenv("SEED_ADMIN", "root@example.com")
+	defer os.Unsetenv("SEED_ADMIN")
+
+	require.NoError(t, store.Seed(ctx, seedFS, "seed/*.[jy]*", boltdb.SeedSkipExisting))
+
+	assert.Equal(t, `{"effect":"allow","rules":["read","write"]}`, readValue(t, store, []string{"policies"}, "default"))
+	assert.Equal(t, "root@example.com", readValue(t, store, []string{"system", "users"}, "admin"))
+	assert.Equal(t, `{"id":1}`, readValue(t, store, []string{"system", "objects"}, "root"))
+
+	// seeding again keeps the store unchanged
+	rev := store.Revision()
+	writeValue(t, store, []string{"system", "users"}, "admin", "changed")
+	require.NoError(t, store.Seed(ctx, seedFS, "seed/*.[jy]*", boltdb.SeedSkipExisting))
+	assert.Equal(t, "changed", readValue(t, store, []string{"system", "users"}, "admin"))
+	assert.Equal(t, rev+1, store.Revision())
+
+	require.NoError(t, store.Seed(ctx, seedFS, "seed/*.[jy]*", boltdb.SeedOverwrite))
+	assert.Equal(t, "deny", readValue(t, store, []string{"policies"}, "default"))
+	assert.Equal(t, "root@example.com", readValue(t, store, []string{"system", "users"}, "admin"))
+}
+
+func TestSeedInvalid(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"a.yaml":   {Data: []byte("- path: [a]\n  key: k\n  text: v\n")},
+		"b.yaml":   {Data: []byte("- path: [b]\n  key: k\n")},
+		"c.toml":   {Data: []byte("")},
+		"d.json":   {Data: []byte("{{ .Missing")},
+		"e.yaml":   {Data: []byte("- path: [__meta]\n  key: k\n  text: v\n")},
+		"ok.yaml":  {Data: []byte("- path: [ok]\n  key: k\n  value: 1\n")},
+		"bad.json": {Data: []byte("{")},
+	}
+
+	for _, name := range []string{"b.yaml", "c.toml", "d.json", "e.yaml", "bad.json"} {
+		assert.Error(t, store.Seed(ctx, fsys, name, boltdb.SeedOverwrite), name)
+	}
+	assert.Error(t, store.Seed(ctx, fsys, "[", boltdb.SeedOverwrite))
+
+	// a failing file leaves the store unchanged
+	assert.Error(t, store.Seed(ctx, fsys, "[ab].yaml", boltdb.SeedOverwrite))
+	session, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	assert.False(t, session.BucketExists([]string{"a"}))
+	closer()
+
+	require.NoError(t, store.Seed(ctx, fsys, "ok.yaml", boltdb.SeedOverwrite))
+	assert.Equal(t, "1", readValue(t, store, []string{"ok"}, "k"))
+}
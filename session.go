@@ -2,53 +2,127 @@ package boltdb
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"strings"
+	"time"
 
+	"github.com/aserto-dev/boltdb/keys"
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 )
 
 type Session struct {
-	store *Store   // store pointer
-	tx    *bolt.Tx // session transaction
-	err   error    // session error
+	store *Store          // store pointer
+	ctx   context.Context // context the session was started with, see Store.WriteSessionContext
+	tx    *bolt.Tx        // session transaction
+	err   error           // session error
+
+	revision  uint64 // store revision observed by the session
+	dirty     bool   // session modified the store
+	events    []Event
+	recording bool          // events are collected regardless of the changelog, see Store.DryRunMigrateTo
+	audit     []AuditRecord // audit records of the events, written to the audit writer on commit
+	principal string        // writer identity, see Store.WriteSessionAs
+	snapshot  bool          // reads bypass the read cache, see Store.SnapshotSession
+
+	writer     bool    // the session holds the writer of the store, see writeQueue
+	upgradable bool    // mutations fail, recording the upgrade, see Store.ReadModifyWrite
+	upgraded   bool    // a mutation was attempted by the upgradable session
+	touched    []touch // keys and buckets modified, evicted from the read cache on commit
+
+	bucketEpoch     uint64                  // incremented whenever buckets are deleted, invalidating resolved buckets
+	bucketMemo      map[string]*bolt.Bucket // buckets resolved by the session, by path
+	bucketMemoEpoch uint64                  // bucket epoch the memoized buckets were resolved in
+
+	journaling bool       // undo journal is maintained, see Savepoint
+	journal    []undoFunc // undo journal
+
+	onCommit   []func() // callbacks run once the session committed, see OnCommit
+	onRollback []func() // callbacks run once the session rolled back, see OnRollback
+
+	open      bool        // the session is counted open by Store.SessionStats
+	started   time.Time   // time the session started, when long sessions are watched
+	longTimer *time.Timer // fires once the session exceeded Config.LongSessionThreshold
+
+	blobsPinned bool // blobs are pinned until the session ends, see pinBlobs
+
+	deadline time.Time // deadline of the running operation, see Config.RequestTimeout
+	steps    int       // cursor steps taken by the running operation, see checkDeadline
 }
 
 // Read value from key in bucket path.
 func (s *Session) Read(path []string, key string) ([]byte, error) {
-	s.store.logger.Trace().Interface("path", path).Str("key", key).Msg("Session::Read")
+	return s.ReadB(path, []byte(key))
+}
+
+// ReadB reads the value of a binary key in bucket path.
+func (s *Session) ReadB(path []string, key []byte) ([]byte, error) {
+	s.store.logger.Trace("Session::Read", "path", path, "key", string(key))
 
 	var result []byte
 
 	read := func(tx *bolt.Tx) error {
-		b, err := s.setBucket(path)
-		if err != nil {
-			return errors.Wrapf(ErrPathNotFound, "path [%s]", path)
+		if err := Path(path).Validate(); err != nil {
+			return err
 		}
 
-		result = b.Get([]byte(key))
-		if result == nil {
-			return errors.Wrapf(ErrKeyNotFound, "key [%s]", key)
+		if v, ok := s.cacheGet(path, key); ok {
+			result = v
+			return nil
 		}
 
-		return nil
+		var err error
+		if s.flight() {
+			result, err = s.store.flights.do(s.flightKey(path, key), func() ([]byte, error) {
+				v, err := s.readKey(path, key)
+				return append([]byte{}, v...), err
+			})
+		} else {
+			result, err = s.readKey(path, key)
+		}
+
+		return err
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(read)
-	} else {
-		err = read(s.tx)
-		s.err = err
+	op := newOp("Read", path, string(key))
+	err := s.intercept(op, func() error {
+		err := s.view(read)
+		op.Value = result
+		return err
+	})
+
+	return op.Value, wrapError("Read", path, string(key), err)
+}
+
+// readKey reads the value of key in bucket path from the session transaction, adding it to the read cache.
+func (s *Session) readKey(path []string, key []byte) ([]byte, error) {
+	b, err := s.setBucket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := b.Get(key)
+	if value == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	if err := s.verifyChecksum(path, key, value); err != nil {
+		return nil, err
 	}
 
-	return result, err
+	if value, err = s.resolveRef(value); err != nil {
+		return nil, err
+	}
+
+	s.cacheAdd(path, key, value)
+
+	return value, nil
 }
 
-// List returns paged collection of key and value arrays
+// List returns paged collection of key and value arrays.
+// Returns ErrPathNotFound when the bucket path does not exist.
 func (s *Session) List(path []string, pageToken string) ([]string, [][]byte, string, error) {
-	s.store.logger.Trace().Interface("path", path).Str("pageToken", pageToken).Msg("Session::List")
+	s.store.logger.Trace("Session::List", "path", path, "pageToken", pageToken)
 
 	var (
 		keys      = make([]string, 0)
@@ -57,92 +131,166 @@ func (s *Session) List(path []string, pageToken string) ([]string, [][]byte, str
 	)
 
 	list := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
 		b, err := s.setBucket(path)
 		if err != nil {
 			return err
 		}
 
-		cursor := b.Cursor()
-
-		var k, v []byte
-		for i := int32(0); i < pageSize; i++ {
-			if i == 0 {
-				if pageToken == "" {
-					k, v = cursor.First()
-				} else {
-					k, v = cursor.Seek([]byte(pageToken))
-				}
-			} else {
-				k, v = cursor.Next()
+		var corrupt error
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			if v == nil {
+				return false // nested bucket
 			}
-			if k == nil {
-				break
+			if corrupt == nil {
+				corrupt = s.verifyChecksum(path, k, v)
+			}
+			value, err := s.listedValue(v)
+			if corrupt == nil {
+				corrupt = err
 			}
-
 			keys = append(keys, string(k))
-			values = append(values, v)
+			values = append(values, value)
+			return true
+		})
+		if err == nil {
+			err = corrupt
 		}
 
-		k, _ = cursor.Next()
-		if k != nil {
-			nextToken = string(k)
-		}
+		return err
+	}
 
-		return nil
+	err := s.intercept(newOp("List", path, ""), func() error { return s.view(list) })
+
+	if err != nil {
+		s.store.logger.Trace("List", "error", err)
+		return []string{}, [][]byte{}, "", wrapError("List", path, "", err)
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(list)
-	} else {
-		err = list(s.tx)
-		s.err = err
+	return keys, values, nextToken, nil
+}
+
+// EntryKind identifies the type of an entry returned by ListEntries.
+type EntryKind int
+
+const (
+	EntryKey    EntryKind = iota // key-value pair
+	EntryBucket                  // nested bucket
+)
+
+// Entry is a single item of a bucket, either a key-value pair or a nested bucket.
+type Entry struct {
+	Kind  EntryKind
+	Key   string
+	Value []byte // nil for nested buckets
+}
+
+// ListEntries returns a paged collection of the keys and nested buckets in bucket path,
+// in key order, with each entry typed as either EntryKey or EntryBucket.
+func (s *Session) ListEntries(path []string, pageToken string) ([]Entry, string, error) {
+	s.store.logger.Trace("Session::ListEntries", "path", path, "pageToken", pageToken)
+
+	var (
+		entries   = make([]Entry, 0)
+		nextToken string
+	)
+
+	list := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		var corrupt error
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			entry := Entry{Kind: EntryKey, Key: string(k), Value: v}
+			if v == nil {
+				entry.Kind = EntryBucket
+			} else if corrupt == nil {
+				corrupt = s.verifyChecksum(path, k, v)
+				if corrupt == nil {
+					entry.Value, corrupt = s.listedValue(v)
+				}
+			}
+			entries = append(entries, entry)
+			return true
+		})
+		if err == nil {
+			err = corrupt
+		}
+
+		return err
 	}
 
+	err := s.intercept(newOp("ListEntries", path, ""), func() error { return s.view(list) })
+
 	if err != nil {
-		s.store.logger.Trace().Err(err).Msg("List")
-		return []string{}, [][]byte{}, "", nil
+		s.store.logger.Trace("ListEntries", "error", err)
+		return []Entry{}, "", wrapError("ListEntries", path, "", err)
 	}
 
-	return keys, values, nextToken, nil
+	return entries, nextToken, nil
 }
 
 // Key exists checks if a key exists at given bucket path.
 func (s *Session) KeyExists(path []string, key string) bool {
-	s.store.logger.Trace().Interface("path", path).Str("key", key).Msg("Session::KeyExists")
+	return s.KeyExistsB(path, []byte(key))
+}
+
+// KeyExistsB checks if a binary key exists at given bucket path.
+func (s *Session) KeyExistsB(path []string, key []byte) bool {
+	s.store.logger.Trace("Session::KeyExists", "path", path, "key", string(key))
 
 	exists := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		if s.bloomAbsent(path, key) {
+			return ErrKeyNotFound
+		}
+
+		if _, ok := s.cacheGet(path, key); ok {
+			return nil
+		}
+
 		b, err := s.setBucket(path)
 		if err != nil {
-			return errors.Wrapf(err, "KeyExist path [%s]", path)
+			return err
 		}
 
-		buf := b.Get([]byte(key))
+		buf := b.Get(key)
 		if buf == nil {
-			return errors.Wrapf(ErrKeyNotFound, "key [%s]", key)
+			return ErrKeyNotFound
+		}
+
+		if !isRef(buf) && s.verifyChecksum(path, key, buf) == nil {
+			s.cacheAdd(path, key, buf)
 		}
 
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(exists)
-	} else {
-		err = exists(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("KeyExists", path, string(key)), func() error { return s.view(exists) })
 
 	if err != nil && !(errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrPathNotFound)) {
-		s.store.logger.Debug().Str("err", err.Error()).Msg("KeyExists")
+		s.store.logger.Debug("KeyExists", "err", err.Error())
 	}
 
 	return err == nil
 }
 
-// List keys returns paged collection of keys
+// List keys returns paged collection of keys.
+// Returns ErrPathNotFound when the bucket path does not exist.
 func (s *Session) ListKeys(path []string, pageToken string) ([]string, string, error) {
-	s.store.logger.Trace().Interface("path", path).Str("pageToken", pageToken).Msg("Session::ListKeys")
+	s.store.logger.Trace("Session::ListKeys", "path", path, "pageToken", pageToken)
 
 	var (
 		keys      = make([]string, 0)
@@ -150,50 +298,31 @@ func (s *Session) ListKeys(path []string, pageToken string) ([]string, string, e
 	)
 
 	list := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
 		b, err := s.setBucket(path)
 		if err != nil {
 			return err
 		}
 
-		cursor := b.Cursor()
-
-		var k []byte
-		for i := int32(0); i < pageSize; i++ {
-			if i == 0 {
-				if pageToken == "" {
-					k, _ = cursor.First()
-				} else {
-					k, _ = cursor.Seek([]byte(pageToken))
-				}
-			} else {
-				k, _ = cursor.Next()
-			}
-			if k == nil {
-				break
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			if v == nil {
+				return false // nested bucket
 			}
-
 			keys = append(keys, string(k))
-		}
-
-		k, _ = cursor.Next()
-		if k != nil {
-			nextToken = string(k)
-		}
+			return true
+		})
 
-		return nil
+		return err
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(list)
-	} else {
-		err = list(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("ListKeys", path, ""), func() error { return s.view(list) })
 
 	if err != nil {
-		s.store.logger.Trace().Err(err).Msg("ListKeys")
-		return []string{}, "", nil
+		s.store.logger.Trace("ListKeys", "error", err)
+		return []string{}, "", wrapError("ListKeys", path, "", err)
 	}
 
 	return keys, nextToken, nil
@@ -201,14 +330,22 @@ func (s *Session) ListKeys(path []string, pageToken string) ([]string, string, e
 
 // PrefixExists scans keys for prefix match
 func (s *Session) PrefixExists(path []string, prefix string) (bool, error) {
-	s.store.logger.Trace().Interface("path", path).Str("prefix", prefix).Msg("Session::PrefixExists")
+	s.store.logger.Trace("Session::PrefixExists", "path", path, "prefix", prefix)
 
 	var exists bool
 
 	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
 		b, err := s.setBucket(path)
 		if err != nil {
-			return errors.Wrapf(ErrPathNotFound, "path [%s]", path)
+			return err
+		}
+
+		if s.bloomPrefixAbsent(path, []byte(prefix)) {
+			return nil
 		}
 
 		c := b.Cursor()
@@ -218,6 +355,9 @@ func (s *Session) PrefixExists(path []string, prefix string) (bool, error) {
 			if k == nil {
 				break
 			}
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
 			if strings.HasPrefix(string(k), prefix) {
 				exists = true
 				break
@@ -227,17 +367,11 @@ func (s *Session) PrefixExists(path []string, prefix string) (bool, error) {
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(read)
-	} else {
-		err = read(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("PrefixExists", path, prefix), func() error { return s.view(read) })
 
 	if err != nil {
-		s.store.logger.Trace().Err(s.err).Msg("PrefixExists")
-		return false, err
+		s.store.logger.Trace("PrefixExists", "error", s.err)
+		return false, wrapError("PrefixExists", path, prefix, err)
 	}
 
 	return exists, nil
@@ -245,7 +379,7 @@ func (s *Session) PrefixExists(path []string, prefix string) (bool, error) {
 
 // ReadScan returns list of key-value pairs which match the scan prefix filter.
 func (s *Session) ReadScan(path []string, prefix string) ([]string, [][]byte, error) {
-	s.store.logger.Trace().Interface("path", path).Str("prefix", prefix).Msg("Session::ReadScan")
+	s.store.logger.Trace("Session::ReadScan", "path", path, "prefix", prefix)
 
 	var (
 		keys   = make([]string, 0)
@@ -253,9 +387,13 @@ func (s *Session) ReadScan(path []string, prefix string) ([]string, [][]byte, er
 	)
 
 	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
 		b, err := s.setBucket(path)
 		if err != nil {
-			return errors.Wrapf(ErrPathNotFound, "path [%s]", path)
+			return err
 		}
 
 		c := b.Cursor()
@@ -265,8 +403,17 @@ func (s *Session) ReadScan(path []string, prefix string) ([]string, [][]byte, er
 			if k == nil {
 				break
 			}
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
 
-			fmt.Printf("key=%s, value=%s\n", k, v)
+			if err := s.verifyChecksum(path, k, v); err != nil {
+				return err
+			}
+			v, err := s.listedValue(v)
+			if err != nil {
+				return err
+			}
 			keys = append(keys, string(k))
 			values = append(values, v)
 		}
@@ -274,17 +421,11 @@ func (s *Session) ReadScan(path []string, prefix string) ([]string, [][]byte, er
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(read)
-	} else {
-		err = read(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("ReadScan", path, prefix), func() error { return s.view(read) })
 
 	if err != nil {
-		s.store.logger.Trace().Err(s.err).Msg("ReadScan")
-		return []string{}, [][]byte{}, err
+		s.store.logger.Trace("ReadScan", "error", s.err)
+		return []string{}, [][]byte{}, wrapError("ReadScan", path, prefix, err)
 	}
 
 	return keys, values, nil
@@ -292,112 +433,384 @@ func (s *Session) ReadScan(path []string, prefix string) ([]string, [][]byte, er
 
 // Generate next ID for bucket
 func (s *Session) NextSeq(path []string) (uint64, error) {
-	s.store.logger.Trace().Interface("path", path).Msg("Session::NextID")
+	s.store.logger.Trace("Session::NextID", "path", path)
 
 	var id uint64
 
 	genID := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		s.journalSequence(path)
+
 		b, err := s.setBucketIfNotExist(path)
 		if err != nil {
-			return errors.Wrapf(err, "bucket [%s]", path)
+			return err
 		}
 
 		id, err = b.NextSequence()
+		if err != nil {
+			return err
+		}
 
-		return err
+		s.emitSequence(path, id)
+
+		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.Update(genID)
-	} else {
-		err = genID(s.tx)
-		s.err = err
+	err := s.intercept(newOp("NextSeq", path, ""), func() error { return s.update(genID) })
+
+	return id, wrapError("NextSeq", path, "", err)
+}
+
+// CurrentSeq returns the current sequence value of bucket path without incrementing it.
+func (s *Session) CurrentSeq(path []string) (uint64, error) {
+	s.store.logger.Trace("Session::CurrentSeq", "path", path)
+
+	var seq uint64
+
+	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		seq = b.Sequence()
+
+		return nil
 	}
 
-	return id, err
+	err := s.intercept(newOp("CurrentSeq", path, ""), func() error { return s.view(read) })
+
+	return seq, wrapError("CurrentSeq", path, "", err)
 }
 
-// Write value for key in bucket path.
-func (s *Session) Write(path []string, key string, value []byte) error {
-	s.store.logger.Trace().Interface("path", path).Str("key", key).Msg("Session::Write")
+// SetSeq sets the sequence value of bucket path, creating the bucket path when needed.
+// The following NextSeq call returns v+1.
+func (s *Session) SetSeq(path []string, v uint64) error {
+	s.store.logger.Trace("Session::SetSeq", "path", path, "seq", v)
+
+	set := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		s.journalSequence(path)
 
-	write := func(tx *bolt.Tx) error {
 		b, err := s.setBucketIfNotExist(path)
 		if err != nil {
-			return errors.Wrapf(err, "bucket [%s]", path)
+			return err
 		}
 
-		if err := b.Put([]byte(key), value); err != nil {
-			return errors.Wrap(err, "createHandler")
+		if err := b.SetSequence(v); err != nil {
+			return err
 		}
 
+		s.emitSequence(path, v)
+
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.Update(write)
-	} else {
-		err = write(s.tx)
-		s.err = err
+	err := s.intercept(newOp("SetSeq", path, ""), func() error { return s.update(set) })
+
+	return wrapError("SetSeq", path, "", err)
+}
+
+// ResetSeq resets the sequence value of bucket path to zero.
+func (s *Session) ResetSeq(path []string) error {
+	return s.SetSeq(path, 0)
+}
+
+// Write value for key in bucket path.
+func (s *Session) Write(path []string, key string, value []byte) error {
+	return s.WriteB(path, []byte(key), value)
+}
+
+// WriteB writes value for a binary key in bucket path.
+func (s *Session) WriteB(path []string, key, value []byte) error {
+	s.store.logger.Trace("Session::Write", "path", path, "key", string(key))
+
+	op := newOp("Write", path, string(key))
+	op.Value = value
+
+	write := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		return s.put(path, key, op.Value)
 	}
 
-	return err
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("Write", path, string(key), err)
+}
+
+// put writes value for key in bucket path within the session transaction,
+// maintaining the key history and metadata. The path must have been validated.
+// Values encoded like references to streamed, external or deduplicated values are rejected,
+// so callers cannot forge references to the values of other keys.
+func (s *Session) put(path []string, key, value []byte) error {
+	if err := checkValue(value); err != nil {
+		return err
+	}
+	return s.putStored(path, key, value)
+}
+
+// putStored is put, writing value as stored, which may refer to a streamed, external or
+// deduplicated value. Only values read from the store, or encoded by the store, are written
+// with putStored.
+func (s *Session) putStored(path []string, key, value []byte) error {
+	s.journalKey(path, key)
+
+	b, err := s.setBucketIfNotExist(path)
+	if err != nil {
+		return err
+	}
+
+	return s.putBucket(b, path, key, value)
+}
+
+// putBucket is putStored, writing to b, the already resolved bucket at path.
+// The caller must have journaled key.
+func (s *Session) putBucket(b *bolt.Bucket, path []string, key, value []byte) error {
+	indexes := s.store.indexes.matching(path)
+
+	content, err := s.contentOf(path, indexes, value)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validate(path, key, content); err != nil {
+		return err
+	}
+
+	s.touchKey(path, key)
+	s.bloomAdd(path, key)
+
+	var old []byte
+	prev := b.Get(key)
+	if prev != nil && len(indexes) > 0 {
+		if old, err = s.resolveRef(prev); err != nil {
+			return err
+		}
+		old = append([]byte{}, old...)
+	}
+	replaced := refOf(prev)
+
+	stored, err := s.externalize(path, value)
+	if err != nil {
+		return err
+	}
+	if stored, err = s.dedupe(path, stored); err != nil {
+		return err
+	}
+
+	if err := b.Put(key, stored); err != nil {
+		return err
+	}
+
+	if err := s.swapRefs(replaced, stored); err != nil {
+		return err
+	}
+
+	if err := s.updateIndexes(indexes, path, key, old, content); err != nil {
+		return err
+	}
+
+	if err := s.recordVersion(path, key, content); err != nil {
+		return err
+	}
+
+	if err := s.recordMetadata(path, key); err != nil {
+		return err
+	}
+
+	if err := s.recordChecksum(path, key, stored); err != nil {
+		return err
+	}
+
+	s.emit(EventPut, path, key, content)
+
+	return nil
+}
+
+// contentOf returns the value held by value, written to bucket path matching indexes. Validators, the schema,
+// indexes, the key history and events see the value referred to by a reference, never the reference itself,
+// so references are only resolved, reading the whole value, when one of them inspects the value.
+func (s *Session) contentOf(path []string, indexes []*Index, value []byte) ([]byte, error) {
+	if !isRef(value) {
+		return value, nil
+	}
+	if schema, _ := s.store.bucketSchema(path); schema == nil && s.store.validator(path) == nil &&
+		len(indexes) == 0 && !s.store.versioned(path) && !s.collecting() {
+		return value, nil
+	}
+	return s.resolveRef(value)
 }
 
 // Delete key, deletes key at given path when present.
 // The call does not return an error when key does not exist.
 func (s *Session) DeleteKey(path []string, key string) error {
-	s.store.logger.Trace().Interface("path", path).Str("key", key).Msg("Session::DeleteKey")
+	return s.DeleteKeyB(path, []byte(key))
+}
+
+// DeleteKeyB deletes a binary key at given path when present.
+func (s *Session) DeleteKeyB(path []string, key []byte) error {
+	s.store.logger.Trace("Session::DeleteKey", "path", path, "key", string(key))
 
 	del := func(tx *bolt.Tx) error {
-		b, err := s.setBucketIfNotExist(path)
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+		return s.delete(path, key)
+	}
+
+	err := s.intercept(newOp("DeleteKey", path, string(key)), func() error { return s.update(del) })
+
+	return wrapError("DeleteKey", path, string(key), err)
+}
+
+// delete removes key from bucket path within the session transaction,
+// maintaining the key history and metadata. The path must have been validated.
+func (s *Session) delete(path []string, key []byte) error {
+	s.journalKey(path, key)
+	s.touchKey(path, key)
+
+	b, err := s.setBucketIfNotExist(path)
+	if err != nil {
+		return nil
+	}
+
+	old := b.Get(key)
+	existed := old != nil
+	replaced := refOf(old)
+
+	indexes := s.store.indexes.matching(path)
+	if existed && len(indexes) > 0 {
+		old = append([]byte{}, old...)
+	}
+
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+
+	if existed {
+		if err := s.updateIndexes(indexes, path, key, old, nil); err != nil {
+			return err
+		}
+		if err := s.recordVersion(path, key, nil); err != nil {
+			return err
+		}
+		if err := s.deleteMetadata(path, key); err != nil {
+			return err
+		}
+		if err := s.deleteChecksum(path, key); err != nil {
+			return err
+		}
+		if err := s.swapRefs(replaced, nil); err != nil {
+			return err
+		}
+	}
+
+	s.emit(EventDelete, path, key, nil)
+
+	return nil
+}
+
+// ScanB walks the keys of bucket path in byte order, starting at the first key
+// greater than or equal to start (the first key when start is nil),
+// and calls fn for every key-value pair until fn returns false.
+// Nested buckets are skipped. Keys and values are only valid during the callback.
+func (s *Session) ScanB(path []string, start []byte, fn func(key, value []byte) bool) error {
+	s.store.logger.Trace("Session::ScanB", "path", path, "start", string(start))
+
+	scan := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
 		if err != nil {
-			return nil
+			return err
+		}
+
+		c := b.Cursor()
+
+		var k, v []byte
+		if start == nil {
+			k, v = c.First()
+		} else {
+			k, v = c.Seek(start)
 		}
 
-		if err := b.Delete([]byte(key)); err != nil {
-			return errors.Wrapf(err, "delete path:[%s] key:[%s]", path, key)
+		for ; k != nil; k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil {
+				continue // nested bucket
+			}
+			if err := s.verifyChecksum(path, k, v); err != nil {
+				return errors.Wrapf(err, "key %q", k)
+			}
+			value, err := s.listedValue(v)
+			if err != nil {
+				return errors.Wrapf(err, "key %q", k)
+			}
+			if !fn(k, value) {
+				break
+			}
 		}
 
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.Update(del)
-	} else {
-		err = del(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("ScanB", path, string(start)), func() error { return s.view(scan) })
 
-	return err
+	return wrapError("ScanB", path, string(start), err)
+}
+
+// WriteUint64Key writes value for a key encoded with keys.Uint64, so keys sort numerically.
+func (s *Session) WriteUint64Key(path []string, key uint64, value []byte) error {
+	return s.WriteB(path, keys.Uint64(key), value)
+}
+
+// ReadUint64Key reads the value of a key encoded with keys.Uint64.
+func (s *Session) ReadUint64Key(path []string, key uint64) ([]byte, error) {
+	return s.ReadB(path, keys.Uint64(key))
+}
+
+// DeleteUint64Key deletes a key encoded with keys.Uint64.
+func (s *Session) DeleteUint64Key(path []string, key uint64) error {
+	return s.DeleteKeyB(path, keys.Uint64(key))
 }
 
 // BucketExists checks if a bucket path exists.
 func (s *Session) BucketExists(path []string) bool {
-	s.store.logger.Trace().Interface("path", path).Msg("PathExists")
+	s.store.logger.Trace("PathExists", "path", path)
 
 	exists := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
 		_, err := s.setBucket(path)
 		return err
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.View(exists)
-	} else {
-		err = exists(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("BucketExists", path, ""), func() error { return s.view(exists) })
 
 	if errors.Is(err, ErrPathNotFound) {
 		return false
 	}
 
 	if err != nil {
-		s.store.logger.Debug().Interface("err", err).Msg("PathExists err")
+		s.store.logger.Debug("PathExists err", "err", err)
 	}
 
 	return err == nil
@@ -405,65 +818,90 @@ func (s *Session) BucketExists(path []string) bool {
 
 // Create bucket path.
 func (s *Session) CreateBucket(path []string) error {
-	s.store.logger.Trace().Interface("path", path).Msg("Session::CreateBucket")
+	s.store.logger.Trace("Session::CreateBucket", "path", path)
 
 	create := func(tx *bolt.Tx) error {
-		b, err := s.setBucketIfNotExist(path)
-		if err != nil {
-			return errors.Wrapf(err, "bucket [%s]", path)
+		if err := Path(path).Validate(); err != nil {
+			return err
 		}
-		if b == nil {
-			return errors.Wrapf(err, "bucket [%s]", path)
+
+		s.journalCreate(path)
+
+		if _, err := s.setBucketIfNotExist(path); err != nil {
+			return err
 		}
+
+		s.emit(EventCreateBucket, path, nil, nil)
+
 		return nil
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.Update(create)
-	} else {
-		err = create(s.tx)
-		s.err = err
-	}
+	err := s.intercept(newOp("CreateBucket", path, ""), func() error { return s.update(create) })
 
-	return err
+	return wrapError("CreateBucket", path, "", err)
 }
 
 // Delete bucket at the tail of the given bucket path.
 // The call does not return an error when the bucket does not exist.
 func (s *Session) DeleteBucket(path []string) error {
-	s.store.logger.Trace().Interface("path", path).Msg("Session::DeleteBucket")
+	s.store.logger.Trace("Session::DeleteBucket", "path", path)
 
 	del := func(tx *bolt.Tx) error {
-		if len(path) == 1 {
-			return tx.DeleteBucket([]byte(path[0]))
+		if err := Path(path).Validate(); err != nil {
+			return err
 		}
+		return s.deleteBucket(path)
+	}
 
-		b, err := s.setBucket(path[:len(path)-1])
-		if err != nil {
-			return nil
+	err := s.intercept(newOp("DeleteBucket", path, ""), func() error { return s.update(del) })
+
+	return wrapError("DeleteBucket", path, "", err)
+}
+
+// deleteBucket deletes the bucket at the tail of path within the session transaction,
+// maintaining the key history and metadata. The path must have been validated.
+func (s *Session) deleteBucket(path []string) error {
+	s.journalBucket(path)
+	s.touchBucket(path)
+
+	if err := s.recordBucketVersions(path); err != nil {
+		return err
+	}
+
+	if err := s.deleteBucketMetadata(path); err != nil {
+		return err
+	}
+
+	if err := s.deleteBucketChecksums(path); err != nil {
+		return err
+	}
+
+	if b, err := s.setBucket(path); err == nil {
+		if err := s.unindexBucket(b, path, true); err != nil {
+			return err
 		}
-		err = b.DeleteBucket([]byte(path[len(path)-1]))
-		if err != nil && errors.Is(err, bolt.ErrBucketNotFound) {
-			return nil
+		if err := s.releaseBucketRefs(b, path, true); err != nil {
+			return err
 		}
-		return err
 	}
 
-	var err error
-	if s.tx == nil {
-		err = s.store.db.Update(del)
-	} else {
-		err = del(s.tx)
-		s.err = err
+	err := s.deleteBucketPath(path)
+	if err != nil && errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	return err
+	s.emit(EventDeleteBucket, path, nil, nil)
+
+	return nil
 }
 
 // List buckets, returns a paged collection of buckets.
+// The root buckets are listed when path is empty, the buckets reserved by the store excluded.
 func (s *Session) ListBuckets(path []string, pageToken string) ([]string, string, error) {
-	s.store.logger.Trace().Interface("path", path).Str("pageToken", pageToken).Msg("Session::ListBuckets")
+	s.store.logger.Trace("Session::ListBuckets", "path", path, "pageToken", pageToken)
 
 	var (
 		buckets   = make([]string, 0)
@@ -471,14 +909,20 @@ func (s *Session) ListBuckets(path []string, pageToken string) ([]string, string
 	)
 
 	list := func(tx *bolt.Tx) error {
-
 		if len(path) == 0 {
-			_ = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
-				buckets = append(buckets, string(name))
-				return nil
+			var err error
+			nextToken, err = s.page(tx.Cursor(), pageToken, func(k, v []byte) bool {
+				if bytes.Equal(k, metaBucket) {
+					return false // metadata bucket
+				}
+				buckets = append(buckets, string(k))
+				return true
 			})
-			nextToken = ""
-			return nil
+			return err
+		}
+
+		if err := Path(path).Validate(); err != nil {
+			return err
 		}
 
 		b, err := s.setBucket(path)
@@ -486,51 +930,65 @@ func (s *Session) ListBuckets(path []string, pageToken string) ([]string, string
 			return err
 		}
 
-		cursor := b.Cursor()
-
-		var k []byte
-		for i := int32(0); i < pageSize; i++ {
-			if i == 0 {
-				if pageToken == "" {
-					k, _ = cursor.First()
-				} else {
-					k, _ = cursor.Seek([]byte(pageToken))
-				}
-			} else {
-				k, _ = cursor.Next()
+		nextToken, err = s.page(b.Cursor(), pageToken, func(k, v []byte) bool {
+			if v != nil {
+				return false // key
 			}
-			if k == nil {
-				break
-			}
-
 			buckets = append(buckets, string(k))
-		}
+			return true
+		})
 
-		k, _ = cursor.Next()
-		if k != nil {
-			nextToken = string(k)
-		}
+		return err
+	}
 
-		return nil
+	err := s.intercept(newOp("ListBuckets", path, ""), func() error { return s.view(list) })
+
+	if err != nil {
+		s.store.logger.Trace("ListBuckets", "error", err)
+		return []string{}, "", wrapError("ListBuckets", path, "", err)
 	}
 
-	var err error
+	return buckets, nextToken, nil
+}
+
+// view runs a read operation against the session transaction,
+// or inside a new read-only transaction when the session has none.
+func (s *Session) view(fn func(tx *bolt.Tx) error) error {
 	if s.tx == nil {
-		err = s.store.db.View(list)
-	} else {
-		err = list(s.tx)
-		s.err = err
+		return s.store.database().View(fn)
 	}
 
-	if err != nil {
-		s.store.logger.Trace().Err(err).Msg("ListBuckets")
-		return []string{}, "", err
+	err := fn(s.tx)
+	s.err = err
+
+	return err
+}
+
+// update runs a mutating operation against the session transaction,
+// or inside a new read-write transaction when the session has none.
+func (s *Session) update(fn func(tx *bolt.Tx) error) error {
+	if s.tx == nil {
+		return s.store.database().Update(fn)
+	}
+	if s.upgradable {
+		return s.upgrade()
 	}
 
-	return buckets, nextToken, nil
+	err := fn(s.tx)
+	s.err = err
+	if err == nil {
+		s.dirty = true
+	}
+
+	return err
 }
 
 func (s *Session) setBucket(path []string) (*bolt.Bucket, error) {
+	memo := bucketMemoKey(path)
+	if b := s.memoizedBucket(memo); b != nil {
+		return b, nil
+	}
+
 	var b *bolt.Bucket
 
 	for index, p := range path {
@@ -540,17 +998,29 @@ func (s *Session) setBucket(path []string) (*bolt.Bucket, error) {
 			b = b.Bucket([]byte(p))
 		}
 		if b == nil {
-			return nil, errors.Wrapf(ErrPathNotFound, "path [%s]", pathStr(path))
+			return nil, &StoreError{Path: path, Err: ErrPathNotFound}
 		}
 	}
 
 	if b == nil {
-		return nil, errors.Wrapf(ErrPathNotFound, "path [%s]", pathStr(path))
+		return nil, &StoreError{Path: path, Err: ErrPathNotFound}
 	}
+
+	s.memoizeBucket(memo, b)
+
 	return b, nil
 }
 
 func (s *Session) setBucketIfNotExist(path []string) (*bolt.Bucket, error) {
+	memo := bucketMemoKey(path)
+	if b := s.memoizedBucket(memo); b != nil {
+		return b, nil
+	}
+
+	if err := s.store.checkBucketDeclared(path); err != nil {
+		return nil, &StoreError{Path: path, Err: err}
+	}
+
 	var (
 		b   *bolt.Bucket
 		err error
@@ -562,16 +1032,40 @@ func (s *Session) setBucketIfNotExist(path []string) (*bolt.Bucket, error) {
 			b, err = b.CreateBucketIfNotExists([]byte(p))
 		}
 		if err != nil {
-			return nil, errors.Wrapf(err, "bucket [%s]", p)
+			return nil, &StoreError{Path: path[:index+1], Err: err}
 		}
 	}
 
 	if b == nil {
-		return nil, errors.Wrapf(ErrPathNotFound, "path [%s]", pathStr(path))
+		return nil, &StoreError{Path: path, Err: ErrPathNotFound}
 	}
+
+	s.memoizeBucket(memo, b)
+
 	return b, nil
 }
 
+// memoizedBucket returns the bucket resolved earlier in the session for the memo key of its path,
+// nil when it has not been resolved since buckets were last deleted.
+func (s *Session) memoizedBucket(memo string) *bolt.Bucket {
+	if s.bucketMemoEpoch != s.bucketEpoch {
+		s.bucketMemo, s.bucketMemoEpoch = nil, s.bucketEpoch
+		return nil
+	}
+	return s.bucketMemo[memo]
+}
+
+func (s *Session) memoizeBucket(memo string, b *bolt.Bucket) {
+	if s.bucketMemo == nil {
+		s.bucketMemo = map[string]*bolt.Bucket{}
+	}
+	s.bucketMemo[memo] = b
+}
+
+func bucketMemoKey(path []string) string {
+	return string(keys.JoinStrings(path...))
+}
+
 func pathStr(path []string) string {
 	return strings.Join(path, "/")
 }
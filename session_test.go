@@ -1,15 +1,28 @@
 package boltdb_test
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// listFixtures are the buckets written by TestListBucketsRoots and TestListBucketsSubLevel.
+var listFixtures = boltdbtest.Fixtures{
+	"l1a/l2a/l3a": {"k1a": []byte("v1a"), "k2a": []byte("v2a"), "k3a": []byte("v3a")},
+	"l1a/l2a/l3b": {"k1b": []byte("v1b")},
+	"l1a/l2b/l3a": {"k2a": []byte("v2a")},
+	"l1a/l2c/l3a": {"k3a": []byte("v3a")},
+	"l1b/l2b/l3b": {"k1b": []byte("v1b")},
+}
+
 func TestListBucketsEmpty(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
 
 	session, closer, err := s.ReadSession()
 	require.NoError(t, err)
@@ -24,8 +37,7 @@ func TestListBucketsEmpty(t *testing.T) {
 }
 
 func TestListBucketsRoots(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
 
 	session, closer, err := s.WriteSession()
 	require.NoError(t, err)
@@ -57,11 +69,51 @@ func TestListBucketsRoots(t *testing.T) {
 	closer()
 }
 
+func TestListBucketsRootsPaginated(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		for i := 0; i < 250; i++ {
+			if err := w.Write([]string{fmt.Sprintf("tenant-%03d", i)}, "k", []byte("v")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	var (
+		buckets []string
+		pages   int
+		token   string
+	)
+	for {
+		page, next, err := session.ListBuckets([]string{}, token)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 100)
+
+		pages++
+		buckets = append(buckets, page...)
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	assert.Equal(t, 3, pages)
+	require.Len(t, buckets, 250)
+	for i, b := range buckets {
+		assert.Equal(t, fmt.Sprintf("tenant-%03d", i), b)
+	}
+}
+
 func TestListBucketsSubLevel(t *testing.T) {
 	var err error
 
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
 
 	session, closer, err := s.WriteSession()
 	require.NoError(t, err)
@@ -109,8 +161,8 @@ func TestListBucketsSubLevel(t *testing.T) {
 }
 
 func TestListKeys(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.ReadSession()
 	require.NoError(t, err)
@@ -125,8 +177,8 @@ func TestListKeys(t *testing.T) {
 }
 
 func TestListValues(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.ReadSession()
 	require.NoError(t, err)
@@ -142,8 +194,8 @@ func TestListValues(t *testing.T) {
 }
 
 func TestBucketExists(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.ReadSession()
 	require.NoError(t, err)
@@ -162,8 +214,8 @@ func TestBucketExists(t *testing.T) {
 }
 
 func TestKeyExists(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.ReadSession()
 	require.NoError(t, err)
@@ -182,8 +234,8 @@ func TestKeyExists(t *testing.T) {
 }
 
 func TestDeleteValue(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.WriteSession()
 	require.NoError(t, err)
@@ -200,8 +252,8 @@ func TestDeleteValue(t *testing.T) {
 }
 
 func TestDeleteBucket(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
+	boltdbtest.Seed(t, s, listFixtures)
 
 	session, closer, err := s.WriteSession()
 	require.NoError(t, err)
@@ -218,8 +270,7 @@ func TestDeleteBucket(t *testing.T) {
 }
 
 func TestReadSession(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
 
 	{
 		session, closer, err := s.WriteSession()
@@ -245,8 +296,7 @@ func TestReadSession(t *testing.T) {
 }
 
 func TestWriteSessions(t *testing.T) {
-	s := setupStore(t)
-	t.Cleanup(s.Close)
+	s := newTestStore(t)
 
 	session, closer, err := s.WriteSession()
 	require.NoError(t, err)
@@ -261,3 +311,177 @@ func TestWriteSessions(t *testing.T) {
 	str := string(buf)
 	assert.Equal(t, str, "hello")
 }
+
+func TestListEntriesNestedBuckets(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	require.NoError(t, session.Write([]string{"entries"}, "a", []byte("va")))
+	require.NoError(t, session.Write([]string{"entries", "b"}, "x", []byte("vx")))
+	require.NoError(t, session.Write([]string{"entries"}, "c", []byte("vc")))
+
+	keys, next, err := session.ListKeys([]string{"entries"}, "")
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Equal(t, []string{"a", "c"}, keys)
+
+	keys, values, _, err := session.List([]string{"entries"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, keys)
+	assert.Equal(t, [][]byte{[]byte("va"), []byte("vc")}, values)
+
+	buckets, _, err := session.ListBuckets([]string{"entries"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, buckets)
+
+	entries, next, err := session.ListEntries([]string{"entries"}, "")
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	require.Len(t, entries, 3)
+	assert.Equal(t, boltdb.Entry{Kind: boltdb.EntryKey, Key: "a", Value: []byte("va")}, entries[0])
+	assert.Equal(t, boltdb.Entry{Kind: boltdb.EntryBucket, Key: "b"}, entries[1])
+	assert.Equal(t, boltdb.EntryKey, entries[2].Kind)
+}
+
+func TestListPathNotFound(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	_, _, _, err = session.List([]string{"missing"}, "")
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+
+	_, _, err = session.ListKeys([]string{"missing"}, "")
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+}
+
+func TestBinaryKeys(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	path := []string{"binary"}
+	keys := [][]byte{
+		{0x00, 0x00, 0x00, 0x01, 0xff, 0xfe},
+		{0x00, 0x00, 0x00, 0x02, 0x80},
+		{0x00, 0x00, 0x01, 0x00},
+	}
+	for i, key := range keys {
+		require.NoError(t, session.WriteB(path, key, []byte{byte(i)}))
+	}
+
+	buf, err := session.ReadB(path, keys[1])
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, buf)
+	assert.True(t, session.KeyExistsB(path, keys[0]))
+
+	var seen [][]byte
+	err = session.ScanB(path, []byte{0x00, 0x00, 0x00, 0x02}, func(key, value []byte) bool {
+		seen = append(seen, append([]byte{}, key...))
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, keys[1:], seen)
+
+	require.NoError(t, session.DeleteKeyB(path, keys[0]))
+	assert.False(t, session.KeyExistsB(path, keys[0]))
+}
+
+func TestUint64Keys(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	path := []string{"numeric"}
+	for _, id := range []uint64{256, 2, 10, 1} {
+		require.NoError(t, session.WriteUint64Key(path, id, []byte(fmt.Sprint(id))))
+	}
+
+	var order []uint64
+	err = session.ScanB(path, nil, func(key, _ []byte) bool {
+		id, err := keys.ParseUint64(key)
+		require.NoError(t, err)
+		order = append(order, id)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 10, 256}, order)
+
+	buf, err := session.ReadUint64Key(path, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", string(buf))
+
+	require.NoError(t, session.DeleteUint64Key(path, 10))
+	_, err = session.ReadUint64Key(path, 10)
+	assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound))
+}
+
+func TestSequence(t *testing.T) {
+	s := newTestStore(t)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	path := []string{"sequence"}
+
+	_, err = session.CurrentSeq(path)
+	assert.True(t, errors.Is(err, boltdb.ErrPathNotFound))
+
+	id, err := session.NextSeq(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), id)
+
+	require.NoError(t, session.SetSeq(path, 41))
+	seq, err := session.CurrentSeq(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(41), seq)
+
+	id, err = session.NextSeq(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), id)
+
+	require.NoError(t, session.ResetSeq(path))
+	seq, err = session.CurrentSeq(path)
+	require.NoError(t, err)
+	assert.Zero(t, seq)
+}
+
+func TestBucketMemoization(t *testing.T) {
+	s := newTestStore(t)
+
+	path := []string{"a", "b", "c", "d"}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, session.Write(path, fmt.Sprintf("k%03d", i), []byte("v")))
+	}
+
+	// deleting buckets invalidates buckets resolved earlier in the session
+	require.NoError(t, session.DeleteBucket([]string{"a", "b"}))
+	assert.False(t, session.BucketExists(path))
+	require.NoError(t, session.Write(path, "k1", []byte("v1")))
+
+	rollbackTo, err := session.Savepoint()
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"x", "y"}, "k1", []byte("v1")))
+	rollbackTo()
+	assert.False(t, session.BucketExists([]string{"x"}))
+	require.NoError(t, session.Write([]string{"x", "y"}, "k2", []byte("v2")))
+
+	closer()
+
+	assert.Equal(t, []string{"k1"}, listKeys(t, s, path))
+	assert.Equal(t, []string{"k2"}, listKeys(t, s, []string{"x", "y"}))
+}
@@ -0,0 +1,66 @@
+package boltdb
+
+import (
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Shadow buckets keep per-key data maintained by the store next to the user data.
+// A shadow bucket mirrors the bucket path of the data it describes below a bucket of the metadata
+// bucket, e.g. the metadata of key k in a/b is stored as key k in __meta/keymeta/a/b.
+
+// shadowPath returns the path of the shadow bucket mirroring path below the root bucket of the metadata bucket.
+func shadowPath(root string, path []string) []string {
+	return append([]string{metaRoot, root}, path...)
+}
+
+// putShadow writes value for key in the shadow bucket mirroring path.
+func (s *Session) putShadow(root string, path []string, key, value []byte) error {
+	spath := shadowPath(root, path)
+
+	s.journalKey(spath, key)
+
+	b, err := s.setBucketIfNotExist(spath)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(key, value)
+}
+
+// getShadow returns the value of key in the shadow bucket mirroring path, nil when absent.
+func (s *Session) getShadow(root string, path []string, key []byte) []byte {
+	b, err := s.setBucket(shadowPath(root, path))
+	if err != nil {
+		return nil
+	}
+	return b.Get(key)
+}
+
+// deleteShadow deletes key from the shadow bucket mirroring path.
+func (s *Session) deleteShadow(root string, path []string, key []byte) error {
+	spath := shadowPath(root, path)
+
+	b, err := s.setBucket(spath)
+	if err != nil {
+		return nil
+	}
+
+	s.journalKey(spath, key)
+
+	return b.Delete(key)
+}
+
+// deleteShadowBucket deletes the shadow bucket mirroring path, including nested buckets.
+func (s *Session) deleteShadowBucket(root string, path []string) error {
+	spath := shadowPath(root, path)
+
+	s.journalBucket(spath)
+
+	err := s.deleteBucketPath(spath)
+	if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+// +build go1.21
+
+package boltdb
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLevelTrace is the slog level of trace records, below slog.LevelDebug.
+const slogLevelTrace = slog.LevelDebug - 4
+
+var slogLevels = map[LogLevel]slog.Level{
+	LogTrace: slogLevelTrace,
+	LogDebug: slog.LevelDebug,
+	LogInfo:  slog.LevelInfo,
+	LogWarn:  slog.LevelWarn,
+	LogError: slog.LevelError,
+}
+
+// SlogLogger adapts a log/slog logger to a Logger. Trace records are logged at slog.LevelDebug-4.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(context.Background(), slogLevels[level])
+}
+
+func (l slogLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	l.logger.Log(context.Background(), slogLevels[level], msg, keyvals...)
+}
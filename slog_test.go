@@ -0,0 +1,38 @@
+//go:build go1.21
+// +build go1.21
+
+package boltdb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := boltdb.SlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 4})))
+
+	store := newTestStoreWithLogger(t, &boltdb.Config{}, logger)
+	writeValue(t, store, []string{"a"}, "k", "v")
+
+	found := false
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &record))
+		if record["msg"] == "Session::Write" {
+			found = true
+			assert.Equal(t, "DEBUG-4", record["level"])
+			assert.Equal(t, "store", record["component"])
+			assert.Equal(t, "k", record["key"])
+		}
+	}
+	assert.True(t, found)
+
+	assert.False(t, boltdb.SlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))).Enabled(boltdb.LogDebug))
+}
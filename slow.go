@@ -0,0 +1,65 @@
+package boltdb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SlowStats reports the operations and sessions which exceeded Config.SlowOpThreshold and
+// Config.LongSessionThreshold.
+type SlowStats struct {
+	SlowOps      uint64 // session operations slower than SlowOpThreshold
+	LongSessions uint64 // sessions kept open longer than LongSessionThreshold
+}
+
+// SlowStats returns the counts of slow operations and long sessions since the store was created.
+func (s *Store) SlowStats() SlowStats {
+	return SlowStats{
+		SlowOps:      atomic.LoadUint64(&s.slowOps),
+		LongSessions: atomic.LoadUint64(&s.longSessions),
+	}
+}
+
+// observeOp logs a warning when op, started at start, exceeded the slow operation threshold.
+func (s *Session) observeOp(op *Op, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < s.store.config.SlowOpThreshold {
+		return
+	}
+
+	atomic.AddUint64(&s.store.slowOps, 1)
+	s.store.logger.Warn("slow operation", "op", op.Name, "path", op.Path, "key", op.Key, "duration", elapsed)
+}
+
+// watchDuration starts the timer warning about the session once it has been open longer than
+// the long session threshold. Long read sessions keep the pages they read from being reused,
+// long write sessions block every other writer.
+func (s *Session) watchDuration() {
+	threshold := s.store.config.LongSessionThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	s.started = time.Now()
+	writable := s.tx.Writable()
+
+	s.longTimer = time.AfterFunc(threshold, func() {
+		atomic.AddUint64(&s.store.longSessions, 1)
+		s.store.logger.Warn("long session", "writable", writable, "duration", time.Since(s.started))
+	})
+}
+
+// finish stops the long session timer once the session has been committed or rolled back,
+// logging the duration of sessions which exceeded the threshold.
+func (s *Session) finish() {
+	s.countClosed()
+
+	if s.longTimer == nil {
+		return
+	}
+
+	if !s.longTimer.Stop() {
+		s.store.logger.Warn("long session closed", "writable", s.tx.Writable(), "duration", time.Since(s.started))
+	}
+	s.longTimer = nil
+}
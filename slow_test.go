@@ -0,0 +1,59 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowOps(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogWarn}
+	store := newTestStoreWithLogger(t, &boltdb.Config{SlowOpThreshold: 5 * time.Millisecond}, logger)
+
+	store.Use(func(next boltdb.OpHandler) boltdb.OpHandler {
+		return func(op *boltdb.Op) error {
+			if op.Key == "slow" {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return next(op)
+		}
+	})
+
+	writeValue(t, store, []string{"a"}, "fast", "v")
+	writeValue(t, store, []string{"a"}, "slow", "v")
+
+	assert.Equal(t, uint64(1), store.SlowStats().SlowOps)
+	assert.Equal(t, []string{"slow operation"}, logger.messages())
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	assert.Equal(t, "Write", logger.records[0]["op"])
+	assert.Equal(t, "slow", logger.records[0]["key"])
+	assert.GreaterOrEqual(t, logger.records[0]["duration"], 10*time.Millisecond)
+}
+
+func TestLongSessions(t *testing.T) {
+	logger := &recordLogger{level: boltdb.LogWarn}
+	store := newTestStoreWithLogger(t, &boltdb.Config{LongSessionThreshold: 10 * time.Millisecond}, logger)
+
+	_, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	closer()
+	assert.Zero(t, store.SlowStats().LongSessions)
+
+	_, closer, err = store.ReadSession()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return store.SlowStats().LongSessions == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{"long session"}, logger.messages())
+
+	closer()
+	assert.Equal(t, []string{"long session", "long session closed"}, logger.messages())
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	assert.Equal(t, false, logger.records[1]["writable"])
+}
@@ -0,0 +1,173 @@
+package boltdb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSnapshotTTL is the time a snapshot remains open without being used, when Store.Snapshot
+// is called with a zero ttl.
+const DefaultSnapshotTTL = time.Minute
+
+// Snapshot pins a read transaction, so paginated listings walking many pages, possibly over several
+// requests, see the keys of a single revision while writes continue, see Store.Snapshot.
+// Snapshots are safe for concurrent use.
+type Snapshot struct {
+	store *Store
+	id    string
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	session *Session
+	closer  func()
+	timer   *time.Timer
+}
+
+// Snapshot opens a snapshot of the current revision, closed by Snapshot.Close, once unused for ttl,
+// or when the store is closed. Listings of the snapshot fail with ErrSnapshotExpired once it has been closed.
+//
+// The snapshot holds a read transaction open, which keeps bolt from reusing the pages freed by the
+// writes committed since, so the database file grows while snapshots are open. Writes growing the
+// memory map of the database wait for the snapshot to close, see Config.InitialMmapSize.
+func (s *Store) Snapshot(ttl time.Duration) (*Snapshot, error) {
+	if ttl <= 0 {
+		ttl = DefaultSnapshotTTL
+	}
+
+	session, closer, err := s.SnapshotSession()
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		closer()
+		return nil, errors.Wrap(err, "failed to generate snapshot id")
+	}
+
+	snap := &Snapshot{store: s, id: hex.EncodeToString(id), ttl: ttl, session: session, closer: closer}
+	snap.timer = time.AfterFunc(ttl, snap.Close)
+
+	s.snapshotsMu.Lock()
+	if s.snapshots == nil {
+		s.snapshots = map[string]*Snapshot{}
+		s.addStopper(s.closeSnapshots)
+	}
+	s.snapshots[snap.id] = snap
+	s.snapshotsMu.Unlock()
+
+	s.logger.Trace("Store::Snapshot", "id", snap.id, "revision", session.revision)
+
+	return snap, nil
+}
+
+// LookupSnapshot returns the open snapshot of id, so the pages of a listing are read from the same
+// snapshot across requests. It fails with ErrSnapshotExpired when the snapshot has been closed.
+func (s *Store) LookupSnapshot(id string) (*Snapshot, error) {
+	s.snapshotsMu.Lock()
+	snap, ok := s.snapshots[id]
+	s.snapshotsMu.Unlock()
+
+	if !ok {
+		return nil, errors.Wrapf(ErrSnapshotExpired, "snapshot %s", id)
+	}
+
+	return snap, nil
+}
+
+func (s *Store) closeSnapshots() {
+	s.snapshotsMu.Lock()
+	snaps := make([]*Snapshot, 0, len(s.snapshots))
+	for _, snap := range s.snapshots {
+		snaps = append(snaps, snap)
+	}
+	s.snapshots = nil
+	s.snapshotsMu.Unlock()
+
+	for _, snap := range snaps {
+		snap.Close()
+	}
+}
+
+// ID returns the identifier of the snapshot, see Store.LookupSnapshot.
+func (p *Snapshot) ID() string {
+	return p.id
+}
+
+// Revision returns the revision of the store the snapshot holds.
+func (p *Snapshot) Revision() uint64 {
+	return p.session.revision
+}
+
+// Close closes the read transaction of the snapshot.
+func (p *Snapshot) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closer == nil {
+		return
+	}
+
+	p.timer.Stop()
+	p.closer()
+	p.closer = nil
+
+	p.store.snapshotsMu.Lock()
+	delete(p.store.snapshots, p.id)
+	p.store.snapshotsMu.Unlock()
+}
+
+// View runs fn against the read session of the snapshot, postponing its expiry.
+// Sessions are not safe for concurrent use, so calls are serialized.
+func (p *Snapshot) View(fn func(Reader) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closer == nil {
+		return errors.Wrapf(ErrSnapshotExpired, "snapshot %s", p.id)
+	}
+	p.timer.Reset(p.ttl)
+
+	return fn(p.session)
+}
+
+// List returns a page of the keys and values of bucket path in the snapshot, see Session.List.
+// Values are copied, as they outlive the call.
+func (p *Snapshot) List(path []string, pageToken string) ([]string, [][]byte, string, error) {
+	var (
+		keys   []string
+		values [][]byte
+		next   string
+	)
+
+	err := p.View(func(r Reader) error {
+		var err error
+		keys, values, next, err = r.List(path, pageToken)
+		for i, v := range values {
+			values[i] = append([]byte{}, v...)
+		}
+		return err
+	})
+
+	return keys, values, next, err
+}
+
+// ListKeys returns a page of the keys of bucket path in the snapshot, see Session.ListKeys.
+func (p *Snapshot) ListKeys(path []string, pageToken string) ([]string, string, error) {
+	var (
+		keys []string
+		next string
+	)
+
+	err := p.View(func(r Reader) error {
+		var err error
+		keys, next, err = r.ListKeys(path, pageToken)
+		return err
+	})
+
+	return keys, next, err
+}
@@ -0,0 +1,111 @@
+package boltdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{InitialMmapSize: 16 << 20})
+	path := []string{"paging"}
+	writeKeys(t, s, path, 150)
+
+	snap, err := s.Snapshot(time.Minute)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	keys, next, err := snap.ListKeys(path, "")
+	require.NoError(t, err)
+	require.Len(t, keys, 100)
+
+	// writes committed after the snapshot are not seen by its pages
+	writeValue(t, s, path, "key-149a", "inserted")
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey(path, "key-120"))
+	closer()
+
+	resumed, err := s.LookupSnapshot(snap.ID())
+	require.NoError(t, err)
+	assert.Equal(t, snap.Revision(), resumed.Revision())
+
+	keys, values, next, err := resumed.List(path, next)
+	require.NoError(t, err)
+	assert.Empty(t, next)
+	require.Len(t, keys, 50)
+	assert.Contains(t, keys, "key-120")
+	assert.NotContains(t, keys, "key-149a")
+	assert.Equal(t, "value", string(values[0]))
+
+	assert.Less(t, snap.Revision(), s.Revision())
+}
+
+func TestSnapshotExpired(t *testing.T) {
+	s := newTestStore(t)
+	write(t, s, []string{"a"}, "k")
+
+	snap, err := s.Snapshot(20 * time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.LookupSnapshot(snap.ID())
+		return err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	_, _, err = snap.ListKeys([]string{"a"}, "")
+	assert.ErrorIs(t, err, boltdb.ErrSnapshotExpired)
+
+	closed, err := s.Snapshot(0)
+	require.NoError(t, err)
+	closed.Close()
+	closed.Close()
+
+	_, err = s.LookupSnapshot(closed.ID())
+	assert.ErrorIs(t, err, boltdb.ErrSnapshotExpired)
+}
+
+func TestSnapshotStoreClosed(t *testing.T) {
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: t.TempDir() + "/snapshot.db"}, nil)
+	require.NoError(t, s.Open())
+
+	snap, err := s.Snapshot(time.Hour)
+	require.NoError(t, err)
+
+	// the store does not wait for the snapshot to expire
+	s.Close()
+
+	err = snap.View(func(r boltdb.Reader) error { return nil })
+	assert.ErrorIs(t, err, boltdb.ErrSnapshotExpired)
+}
+
+func TestSnapshotSession(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{CacheSize: 10, InitialMmapSize: 16 << 20})
+
+	path := []string{"objects"}
+	write(t, s, path, "k1")
+
+	fresh, closeFresh, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closeFresh()
+
+	pinned, closePinned, err := s.SnapshotSession()
+	require.NoError(t, err)
+	defer closePinned()
+
+	writeValue(t, s, path, "k1", "v2")
+	assert.Equal(t, "v2", readValue(t, s, path, "k1"))
+
+	// neither session is served the value cached since
+	value, err := fresh.Read(path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", string(value))
+
+	value, err = pinned.Read(path, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", string(value))
+	assert.Equal(t, fresh.Revision(), pinned.Revision())
+}
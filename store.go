@@ -1,39 +1,181 @@
 package boltdb
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 
+	"github.com/aserto-dev/boltdb/keys"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	bolt "go.etcd.io/bbolt"
 )
 
 type Store struct {
-	logger *zerolog.Logger
+	// accessed atomically, first to be 64-bit aligned on 32-bit platforms
+	revision      uint64 // last committed revision
+	slowOps       uint64 // operations slower than Config.SlowOpThreshold
+	longSessions  uint64 // sessions open longer than Config.LongSessionThreshold
+	readSessions  int64  // read sessions open
+	writeSessions int64  // write sessions open
+
+	// reopen attempts and health probes, see Config.AutoReopen,
+	// following the counters above so that its own are 64-bit aligned
+	reopen reopener
+
+	logger *fieldLogger
 	config *Config
-	db     *bolt.DB
+	tokens tokenCodec
+
+	dbMu   sync.RWMutex
+	db     *bolt.DB    // open database, nil until opened and once closed, see database
+	dbFile os.FileInfo // database file opened, compared with the file found by health probes
+	opened bool        // Open was called since the store was created or closed, see Config.AutoReopen
+	disk   diskGuard   // disk guardrails, see Config.MaxDBSize and Config.MinFreeSpace
+	writer *writeQueue // queue of the write sessions, see Store.WriteSessionContext
+
+	watchers   watcherSet              // live change subscriptions
+	merges     prefixMap               // merge operators by path prefix, see RegisterMerge
+	validators prefixMap               // value validators by path prefix, see RegisterValidator
+	indexes    indexSet                // secondary indexes, see RegisterIndex
+	cache      *lruCache               // read cache, nil when disabled
+	limiter    *rateLimiter            // rate limits of the session operations, see Config.RateLimits
+	flights    *flightGroup            // concurrent reads of the same keys, see Config.SingleFlightReads
+	blooms     map[string]*bloomFilter // bloom filters by bucket path, see Config.BloomFilters
+	tempDir    string                  // directory removed on close, see NewMemoryStore
+
+	migrations []Migration // schema migrations ordered by version, see RegisterMigration
+
+	schemaMu sync.RWMutex
+	schema   *Schema // declared buckets, see RegisterSchema
+
+	interceptorsMu sync.RWMutex
+	interceptors   []Interceptor // session operation interceptors, see Use
+	policy         AccessPolicy  // checks the operations of sessions with a principal, see SetAccessPolicy
+
+	auditMu   sync.Mutex
+	auditSink AuditSink // receives audit records, see SetAuditSink
+
+	blobMu        sync.RWMutex
+	blobs         BlobProvider   // stores the values larger than blobThreshold, see SetBlobProvider
+	blobThreshold int            // size above which values are stored by blobs
+	blobGC        sync.RWMutex   // pinned by the sessions uploading blobs, locked to delete blobs
+	blobDeletes   sync.WaitGroup // blob deletions in progress, waited for by Close
+	retired       sync.WaitGroup // databases replaced by Compact, closing once their read sessions end
+
+	snapshotsMu sync.Mutex
+	snapshots   map[string]*Snapshot // open snapshots by id, see Store.Snapshot
+
+	stoppersMu sync.Mutex
+	stoppers   []func() // stop background work when the store is closed, e.g. backup schedules
+
+	janitor janitor // background maintenance tasks, see Store.Schedule
 }
 
+// NewStore returns a store configured by cfg, logging to logger. See NewStoreWithLogger.
 func NewStore(cfg *Config, logger *zerolog.Logger) *Store {
-	newLogger := logger.With().Str("component", "store").Logger()
+	return NewStoreWithLogger(cfg, ZerologLogger(logger))
+}
+
+// NewStoreWithLogger returns a store configured by cfg, logging to logger, or not logging when logger is nil.
+func NewStoreWithLogger(cfg *Config, logger Logger) *Store {
+	store := &Store{
+		config:  cfg,
+		logger:  newFieldLogger(logger, "component", "store").withPolicy(cfg.LogSampling, cfg.RedactPaths),
+		tokens:  newTokenCodec(cfg.PageTokenSecret),
+		writer:  newWriteQueue(),
+		flights: &flightGroup{},
+	}
+
+	if cfg.CacheSize > 0 {
+		store.cache = newLRUCache(cfg.CacheSize)
+	}
 
-	return &Store{
-		config: cfg,
-		logger: &newLogger,
-		db:     nil,
+	if len(cfg.RateLimits) > 0 {
+		store.limiter = newRateLimiter(cfg.RateLimits)
 	}
+
+	if len(cfg.BloomFilters) > 0 {
+		store.blooms = map[string]*bloomFilter{}
+		for _, f := range cfg.BloomFilters {
+			store.blooms[string(keys.JoinStrings(f.Path...))] = newBloomFilter(f)
+		}
+	}
+
+	return store
 }
 
-// Open store.
+// Open store. With Config.AutoReopen set, a store which failed to open is reopened
+// by the following sessions and health probes.
 func (s *Store) Open() error {
-	s.logger.Info().Str("DBPath", s.config.DBPath).Msg("open::boltdb")
-	var err error
+	s.logger.Info("open::boltdb", "DBPath", s.config.DBPath)
 
 	if s.config.DBPath == "" {
 		return errors.New("store path not set")
 	}
 
+	if err := s.registerTokenIndexes(); err != nil {
+		return err
+	}
+
+	if err := s.validateRetention(); err != nil {
+		return err
+	}
+
+	if s.config.AuditFile != "" {
+		if err := s.openAuditFile(); err != nil {
+			return err
+		}
+	}
+
+	s.dbMu.Lock()
+	s.opened = true
+	s.dbMu.Unlock()
+
+	if s.config.AutoReopen != nil {
+		s.startProbes()
+	}
+
+	if err := s.openDB(); err != nil {
+		return err
+	}
+
+	if s.diskGuarded() {
+		_ = s.checkDisk()
+		s.startDiskMonitor()
+	}
+
+	if s.config.CompactInterval > 0 && !s.config.ReadOnly {
+		s.startCompaction()
+	}
+
+	if len(s.config.Retention) > 0 && !s.readOnly() {
+		s.startRetention()
+	}
+
+	if err := s.migrateOnOpen(); err != nil {
+		return err
+	}
+
+	return s.createRootBuckets()
+}
+
+// openDB opens the database file, unless it is already open.
+func (s *Store) openDB() error {
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+
+	return s.openLocked()
+}
+
+// openLocked is openDB, called with dbMu locked.
+func (s *Store) openLocked() error {
+	if s.db != nil {
+		return nil
+	}
+
 	dbDir := filepath.Dir(s.config.DBPath)
 	exists, err := filePathExists(dbDir)
 	if err != nil {
@@ -45,64 +187,268 @@ func (s *Store) Open() error {
 		}
 	}
 
-	db, err := bolt.Open(s.config.DBPath, 0600, &bolt.Options{Timeout: s.config.RequestTimeout})
+	options := &bolt.Options{
+		Timeout:         s.config.RequestTimeout,
+		InitialMmapSize: s.config.InitialMmapSize,
+		ReadOnly:        s.config.ReadOnly,
+	}
+	db, err := bolt.Open(s.config.DBPath, 0600, options)
+	if errors.Is(err, bolt.ErrTimeout) {
+		db, err = s.lockFailed(options)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to open directory '%s'", s.config.DBPath)
 	}
 
+	if err := s.loadBloomFilters(db); err != nil {
+		db.Close()
+		return errors.Wrap(err, "failed to load bloom filters")
+	}
+
+	if err := db.View(func(tx *bolt.Tx) error {
+		atomic.StoreUint64(&s.revision, readRevision(tx))
+		return nil
+	}); err != nil {
+		db.Close()
+		return err
+	}
+
 	s.db = db
+	s.dbFile, _ = os.Stat(s.config.DBPath)
+	if !s.config.ReadOnly {
+		s.recordLockHolder()
+	}
+
+	return nil
+}
+
+// readOnly reports whether the store rejects write sessions, being a replica or opened read-only.
+func (s *Store) readOnly() bool {
+	return s.config.Replica || s.config.ReadOnly
+}
+
+// migrateOnOpen applies the registered migrations once the database has been opened.
+func (s *Store) migrateOnOpen() error {
+	// replicas receive the migrated data from their leader
+	if len(s.migrations) > 0 && !s.readOnly() {
+		if err := s.Migrate(context.Background()); err != nil {
+			return errors.Wrap(err, "failed to migrate store")
+		}
+	}
 
 	return nil
 }
 
+// createRootBuckets creates the buckets of Config.RootBuckets missing once the database has been opened,
+// in a single write session, which is not started when none is missing.
+func (s *Store) createRootBuckets() error {
+	if len(s.config.RootBuckets) == 0 || s.readOnly() {
+		return nil
+	}
+
+	var missing [][]string
+	err := s.View(func(r Reader) error {
+		for _, path := range s.config.RootBuckets {
+			if err := Path(path).Validate(); err != nil {
+				return wrapError("Open", path, "", err)
+			}
+			if !r.BucketExists(path) {
+				missing = append(missing, path)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	s.logger.Info("open::boltdb", "rootBuckets", missing)
+
+	err = s.Update(func(w Writer) error {
+		for _, path := range missing {
+			if err := w.CreateBucket(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return errors.Wrap(err, "failed to create root buckets")
+}
+
 // Close store
 func (s *Store) Close() {
+	s.stopBackground()
+	s.blobDeletes.Wait()
+	s.retired.Wait()
+
+	s.dbMu.Lock()
+	s.opened = false
 	if s.db != nil {
 		s.db.Close()
 		s.db = nil
+		if !s.config.ReadOnly {
+			s.clearLockHolder()
+		}
+	}
+	s.dbMu.Unlock()
+
+	if s.tempDir != "" {
+		if err := os.RemoveAll(s.tempDir); err != nil {
+			s.logger.Warn("close::boltdb", "error", err, "dir", s.tempDir)
+		}
 	}
 }
 
-// Start new read session.
+// DBPath returns the path of the database file.
+func (s *Store) DBPath() string {
+	return s.config.DBPath
+}
+
+// ReadSession starts a new read session, reading the store at the revision the session started at. The read
+// cache, see Config.CacheSize, only serves the session until a write session commits, so long-lived sessions
+// read from the database rather than the cache once stale. Use SnapshotSession to never use the cache.
 func (s *Store) ReadSession() (*Session, func(), error) {
-	tx, err := s.db.Begin(false)
+	session, err := s.begin(false)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to start read transaction")
 	}
 
-	session := Session{
-		store: s,
-		tx:    tx,
+	closer := func() {
+		session.rollback()
 	}
 
-	closer := func() {
-		_ = session.tx.Rollback()
+	return session, closer, nil
+}
+
+// SnapshotSession starts a new read session pinned to the current revision: every read of the session
+// sees the store as of that revision, and none of the writes committed later, bypassing the read cache.
+// Sessions hold a read transaction open until closed, see Store.Snapshot.
+func (s *Store) SnapshotSession() (*Session, func(), error) {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session.snapshot = true
+
+	return session, closer, nil
+}
+
+// ReadSessionAs starts a new read session on behalf of principal, whose operations are checked by
+// the access policy, see Store.SetAccessPolicy.
+func (s *Store) ReadSessionAs(principal string) (*Session, func(), error) {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &session, closer, nil
+	session.principal = principal
+
+	return session, closer, nil
 }
 
 // Start new write session
 func (s *Store) WriteSession() (*Session, func(), error) {
-	tx, err := s.db.Begin(true)
+	session, err := s.begin(true)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to start write transaction")
 	}
 
-	session := Session{
-		store: s,
-		tx:    tx,
+	closer := func() {
+		if err := session.commit(); err != nil {
+			s.logger.Trace("WriteSession::commit", "error", err)
+		}
 	}
 
-	closer := func() {
-		if session.err != nil {
-			_ = session.tx.Rollback()
-			return
+	return session, closer, nil
+}
+
+// WriteSessionAs starts a new write session on behalf of principal, recorded as the writer of the keys
+// modified in the session, whose operations are checked by the access policy, see Store.SetAccessPolicy.
+func (s *Store) WriteSessionAs(principal string) (*Session, func(), error) {
+	session, closer, err := s.WriteSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session.principal = principal
+
+	return session, closer, nil
+}
+
+// begin starts a new transaction and returns the session wrapping it.
+// Write transactions of replicas, of read-only stores, and of stores made read-only by their disk guardrails,
+// fail with ErrReadOnly.
+func (s *Store) begin(writable bool) (*Session, error) {
+	return s.beginContext(context.Background(), writable)
+}
+
+// beginContext is begin, write transactions waiting for the writer until ctx is done.
+func (s *Store) beginContext(ctx context.Context, writable bool) (*Session, error) {
+	if writable && s.readOnly() {
+		return nil, ErrReadOnly
+	}
+	if writable {
+		if err := s.diskReadOnly(); err != nil {
+			return nil, err
+		}
+	}
+	return s.beginTx(ctx, writable)
+}
+
+// beginTx is beginContext, without rejecting the write transactions of replicas.
+func (s *Store) beginTx(ctx context.Context, writable bool) (*Session, error) {
+	if writable {
+		if err := s.writer.acquire(ctx, s.config.MaxWriteQueue); err != nil {
+			return nil, err
+		}
+	}
+
+	session, err := s.beginLocked(ctx, writable)
+	if err != nil && writable {
+		s.writer.release()
+	}
+
+	return session, err
+}
+
+// beginLocked is beginTx, once write transactions acquired the writer.
+func (s *Store) beginLocked(ctx context.Context, writable bool) (*Session, error) {
+	db := s.database()
+	if db == nil {
+		if err := s.reopenDB(); err != nil {
+			return nil, err
 		}
-		_ = session.tx.Commit()
+		if db = s.database(); db == nil {
+			return nil, bolt.ErrDatabaseNotOpen
+		}
+	}
+
+	tx, err := db.Begin(writable)
+	if err != nil {
+		return nil, err
+	}
+
+	session := Session{
+		store:    s,
+		ctx:      ctx,
+		tx:       tx,
+		revision: readRevision(tx),
+		writer:   writable,
 	}
+	session.countOpen()
+	session.watchDuration()
+
+	return &session, nil
+}
+
+// database returns the open database, nil when the store is not open.
+func (s *Store) database() *bolt.DB {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
 
-	return &session, closer, nil
+	return s.db
 }
 
 // filePathExists, internal helper function to detect if the file path exists
@@ -1,41 +1,113 @@
 package boltdb_test
 
 import (
-	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/aserto-dev/boltdb"
-	"github.com/rs/zerolog"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-var (
-	cfg *boltdb.Config
-)
+// newTestStore opens a store backed by a private database file that is removed when the test ends.
+func newTestStore(t *testing.T) *boltdb.Store {
+	return boltdbtest.NewTestStore(t)
+}
 
-func TestMain(m *testing.M) {
-	tmpDir, err := ioutil.TempDir("", "tests")
-	if err != nil {
-		return
-	}
+// newTestStoreWithConfig is newTestStore using cfg, with the database path set by the helper.
+func newTestStoreWithConfig(t *testing.T, cfg *boltdb.Config) *boltdb.Store {
+	return boltdbtest.NewTestStoreWithConfig(t, cfg)
+}
+
+// write commits key with its own name as value in a new write session.
+func write(t *testing.T, s *boltdb.Store, path []string, key string) {
+	t.Helper()
 
-	cfg = &boltdb.Config{}
-	cfg.DBPath = filepath.Join(tmpDir, "test.db")
+	writeValue(t, s, path, key, key)
+}
+
+// writeValue commits key with value in a new write session.
+func writeValue(t *testing.T, s *boltdb.Store, path []string, key, value string) {
+	t.Helper()
 
-	os.Exit(m.Run())
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.Write(path, key, []byte(value)))
+	closer()
 }
 
-func setupStore(t *testing.T) *boltdb.Store {
-	logger := zerolog.New(io.Discard)
+// deleteKey deletes key in a new write session.
+func deleteKey(t *testing.T, s *boltdb.Store, path []string, key string) {
+	t.Helper()
 
-	store := boltdb.NewStore(cfg, &logger)
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteKey(path, key))
+	closer()
+}
 
-	if err := store.Open(); err != nil {
-		t.Logf("Open %v", err)
-		t.FailNow()
+func TestRootBuckets(t *testing.T) {
+	cfg := &boltdb.Config{
+		DBPath:      filepath.Join(t.TempDir(), "test.db"),
+		RootBuckets: [][]string{{"tenants"}, {"config", "features"}},
 	}
 
-	return store
+	store := boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, store.Open())
+
+	require.NoError(t, store.View(func(r boltdb.Reader) error {
+		assert.True(t, r.BucketExists([]string{"tenants"}))
+		assert.True(t, r.BucketExists([]string{"config", "features"}))
+
+		keys, _, err := r.ListKeys([]string{"config", "features"}, "")
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		return nil
+	}))
+	rev := store.Revision()
+	store.Close()
+
+	// existing buckets are not created again
+	store = boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, store.Open())
+	assert.Equal(t, rev, store.Revision())
+	store.Close()
+
+	cfg.RootBuckets = append(cfg.RootBuckets, []string{"__meta"})
+	store = boltdb.NewStoreWithLogger(cfg, nil)
+	t.Cleanup(store.Close)
+	assert.ErrorIs(t, store.Open(), boltdb.ErrInvalidPath)
+}
+
+func TestReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "readonly.db")
+
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dbPath}, nil)
+	require.NoError(t, s.Open())
+	write(t, s, []string{"a"}, "k1")
+	s.Close()
+
+	// read-only stores share the lock of the database
+	cfg := &boltdb.Config{DBPath: dbPath, ReadOnly: true, RootBuckets: [][]string{{"b"}}}
+	r1 := boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, r1.Open())
+	t.Cleanup(r1.Close)
+	r2 := boltdb.NewStoreWithLogger(cfg, nil)
+	require.NoError(t, r2.Open())
+	t.Cleanup(r2.Close)
+
+	assert.Equal(t, "k1", readValue(t, r2, []string{"a"}, "k1"))
+	assert.ErrorIs(t, r1.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"a"}, "k2", []byte("v"))
+	}), boltdb.ErrReadOnly)
+
+	require.NoError(t, r1.View(func(r boltdb.Reader) error {
+		assert.False(t, r.BucketExists([]string{"b"}))
+		return nil
+	}))
+
+	_, err := boltdb.ReadLockInfo(dbPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
 }
@@ -0,0 +1,516 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Streamed values are split into chunks stored in the chunk bucket, keyed by the generation
+// of the stream followed by the chunk index, and reference counted by generation, so copies
+// of a streamed key share its chunks. The key itself holds the manifest of the stream.
+
+const chunkBucket = "chunks"
+
+// DefaultChunkSize is the size of the chunks of streamed values, used when Config.ChunkSize is zero.
+const DefaultChunkSize = 1 << 20
+
+// streamMagic starts the manifests of streamed values.
+var streamMagic = []byte{0, 0xff, 'b', 'd', 'b', 's', 't', 'r'}
+
+// manifestSize is the length of a manifest: magic, generation, size, chunks and CRC-32C checksum.
+const manifestSize = 8 + 8 + 8 + 4 + 4
+
+// streamManifest describes a streamed value.
+type streamManifest struct {
+	gen    uint64 // generation, unique to the stream
+	size   uint64 // value length
+	chunks uint32 // number of chunks
+	crc    uint32 // CRC-32C checksum of the value
+}
+
+func (m *streamManifest) encode() []byte {
+	buf := make([]byte, manifestSize)
+	copy(buf, streamMagic)
+	binary.BigEndian.PutUint64(buf[8:], m.gen)
+	binary.BigEndian.PutUint64(buf[16:], m.size)
+	binary.BigEndian.PutUint32(buf[24:], m.chunks)
+	binary.BigEndian.PutUint32(buf[28:], m.crc)
+	return buf
+}
+
+// parseManifest returns the manifest held by value, nil when value is not a streamed value.
+func parseManifest(value []byte) *streamManifest {
+	if len(value) != manifestSize || !bytes.HasPrefix(value, streamMagic) {
+		return nil
+	}
+
+	return &streamManifest{
+		gen:    binary.BigEndian.Uint64(value[8:]),
+		size:   binary.BigEndian.Uint64(value[16:]),
+		chunks: binary.BigEndian.Uint32(value[24:]),
+		crc:    binary.BigEndian.Uint32(value[28:]),
+	}
+}
+
+// refKey returns the key of the reference count of generation gen.
+func refKey(gen uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, gen)
+	return key
+}
+
+// chunkKey returns the key of chunk i of generation gen.
+func chunkKey(gen uint64, i uint32) []byte {
+	key := make([]byte, 12)
+	binary.BigEndian.PutUint64(key, gen)
+	binary.BigEndian.PutUint32(key[8:], i)
+	return key
+}
+
+// ReadStream returns a reader of the value of key in bucket path, streaming the chunks of values
+// written with WriteStream one at a time, and the blobs of external values. The reader reads
+// from the session transaction, it must not be used once the session has been closed.
+func (s *Session) ReadStream(path []string, key string) (io.ReadCloser, error) {
+	s.store.logger.Trace("Session::ReadStream", "path", path, "key", key)
+
+	var r io.ReadCloser
+
+	read := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		value := b.Get([]byte(key))
+		if value == nil {
+			return ErrKeyNotFound
+		}
+
+		if err := s.verifyChecksum(path, []byte(key), value); err != nil {
+			return err
+		}
+
+		if m := parseManifest(value); m != nil {
+			if err := s.verifyStream(m); err != nil {
+				return err
+			}
+			r = &chunkReader{session: s, manifest: m, hash: crc32.New(castagnoli)}
+			return nil
+		}
+		if ref := parseBlobRef(value); ref != nil {
+			r, err = s.openBlob(ref)
+			return err
+		}
+		if value, err = s.deduped(value); err != nil {
+			return err
+		}
+
+		r = io.NopCloser(bytes.NewReader(value))
+		return nil
+	}
+
+	err := s.intercept(newOp("ReadStream", path, key), func() error { return s.view(read) })
+	if err != nil {
+		return nil, wrapError("ReadStream", path, key, err)
+	}
+
+	return r, nil
+}
+
+// WriteStream returns a writer of the value of key in bucket path, stored in chunks of Config.ChunkSize
+// bytes. The value is written once the writer is closed, and is read with Read or ReadStream.
+// Bolt keeps the pages written in memory until the session commits, so streaming bounds the memory
+// used by the caller rather than by the store.
+//
+// Interceptors see the write once the writer is closed, without a value. Validators, the schema,
+// indexes, the key history and events, the changelog and watchers included, see the whole value,
+// read back once the writer is closed, so streaming to such paths holds the value in memory at close.
+func (s *Session) WriteStream(path []string, key string) (io.WriteCloser, error) {
+	s.store.logger.Trace("Session::WriteStream", "path", path, "key", key)
+
+	if err := Path(path).Validate(); err != nil {
+		return nil, wrapError("WriteStream", path, key, err)
+	}
+	// the chunks are written before the value, so principals are checked before writing them
+	if err := s.authorize(newOp("WriteStream", path, key)); err != nil {
+		return nil, wrapError("WriteStream", path, key, err)
+	}
+
+	size := s.store.config.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	w := &chunkWriter{
+		session: s,
+		path:    path,
+		key:     []byte(key),
+		size:    size,
+		hash:    crc32.New(castagnoli),
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b, err := createMetaChild(tx, []byte(chunkBucket))
+		if err != nil {
+			return err
+		}
+		w.gen, err = b.NextSequence()
+		return err
+	})
+	if err != nil {
+		return nil, wrapError("WriteStream", path, key, err)
+	}
+
+	return w, nil
+}
+
+// chunkWriter writes a streamed value chunk by chunk.
+type chunkWriter struct {
+	session *Session
+	path    []string
+	key     []byte
+	size    int
+	gen     uint64
+
+	buf    []byte
+	chunks uint32
+	total  uint64
+	hash   hash.Hash32
+	closed bool
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("stream closed")
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.size)
+		}
+
+		m := w.size - len(w.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+
+		if len(w.buf) == w.size {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// flush writes the buffered chunk. Bolt keeps the chunk until the session commits,
+// so the next chunk is buffered in a new slice.
+func (w *chunkWriter) flush() error {
+	chunk := w.buf
+	w.buf = nil
+
+	err := w.session.update(func(tx *bolt.Tx) error {
+		return w.session.putShadow(chunkBucket, nil, chunkKey(w.gen, w.chunks), chunk)
+	})
+	if err != nil {
+		return wrapError("WriteStream", w.path, string(w.key), err)
+	}
+
+	_, _ = w.hash.Write(chunk)
+	w.chunks++
+	w.total += uint64(len(chunk))
+
+	return nil
+}
+
+// Close writes the remaining chunk and the manifest of the value.
+func (w *chunkWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	m := streamManifest{gen: w.gen, size: w.total, chunks: w.chunks, crc: w.hash.Sum32()}
+
+	s := w.session
+	write := func(tx *bolt.Tx) error {
+		return s.putStored(w.path, w.key, m.encode())
+	}
+
+	op := newOp("WriteStream", w.path, string(w.key))
+	op.size = int(w.total)
+
+	err := s.intercept(op, func() error { return s.update(write) })
+
+	return wrapError("WriteStream", w.path, string(w.key), err)
+}
+
+// chunkReader reads a streamed value chunk by chunk, verifying its checksum once read.
+type chunkReader struct {
+	session  *Session
+	manifest *streamManifest
+	next     uint32 // index of the next chunk
+	chunk    []byte // unread part of the current chunk
+	read     uint64
+	hash     hash.Hash32
+	closed   bool
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, errors.New("stream closed")
+	}
+
+	for len(r.chunk) == 0 {
+		if r.next == r.manifest.chunks {
+			return 0, r.verify()
+		}
+
+		chunk := r.session.getShadow(chunkBucket, nil, chunkKey(r.manifest.gen, r.next))
+		if chunk == nil {
+			return 0, errors.Wrapf(ErrValueCorrupt, "chunk %d of %d missing", r.next, r.manifest.chunks)
+		}
+		_, _ = r.hash.Write(chunk)
+		r.chunk = chunk
+		r.next++
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	r.read += uint64(n)
+
+	return n, nil
+}
+
+func (r *chunkReader) verify() error {
+	if r.read != r.manifest.size {
+		return errors.Wrapf(ErrValueCorrupt, "read %d bytes, expected %d", r.read, r.manifest.size)
+	}
+	if sum := r.hash.Sum32(); sum != r.manifest.crc {
+		return errors.Wrapf(ErrValueCorrupt, "checksum %08x, expected %08x", sum, r.manifest.crc)
+	}
+	return io.EOF
+}
+
+func (r *chunkReader) Close() error {
+	r.chunk = nil
+	r.closed = true
+	return nil
+}
+
+// verifyStream fails with ErrValueCorrupt unless the chunks of m match its size and checksum, so readers
+// never return the bytes of a stream before it has been verified. Chunks are read from the memory mapped
+// database, so verifying them holds no copy of the value.
+func (s *Session) verifyStream(m *streamManifest) error {
+	hash := crc32.New(castagnoli)
+
+	var size uint64
+	for i := uint32(0); i < m.chunks; i++ {
+		chunk := s.getShadow(chunkBucket, nil, chunkKey(m.gen, i))
+		if chunk == nil {
+			return errors.Wrapf(ErrValueCorrupt, "chunk %d of %d missing", i, m.chunks)
+		}
+		_, _ = hash.Write(chunk)
+		size += uint64(len(chunk))
+	}
+
+	if size != m.size {
+		return errors.Wrapf(ErrValueCorrupt, "stream of %d bytes, expected %d", size, m.size)
+	}
+	if sum := hash.Sum32(); sum != m.crc {
+		return errors.Wrapf(ErrValueCorrupt, "checksum %08x, expected %08x", sum, m.crc)
+	}
+	return nil
+}
+
+// readStreamed returns the value of a streamed value described by m, read at once.
+func (s *Session) readStreamed(m *streamManifest) ([]byte, error) {
+	r := &chunkReader{session: s, manifest: m, hash: crc32.New(castagnoli)}
+
+	buf := bytes.NewBuffer(make([]byte, 0, m.size))
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// checkValue fails with ErrReservedValue when value, written by a caller, is encoded like a reference
+// to a streamed value, an external blob or a deduplicated value.
+func checkValue(value []byte) error {
+	if isRef(value) {
+		return errors.Wrap(ErrReservedValue, "value starts with the magic of a reference")
+	}
+	return nil
+}
+
+// isRef reports whether value refers to a streamed value, an external blob or a deduplicated value.
+func isRef(value []byte) bool {
+	return parseManifest(value) != nil || parseBlobRef(value) != nil || parseDedupRef(value) != nil
+}
+
+// resolveRef returns a copy of the value referred to by value, a streamed value, an external blob
+// or a deduplicated value, value itself otherwise.
+func (s *Session) resolveRef(value []byte) ([]byte, error) {
+	if m := parseManifest(value); m != nil {
+		return s.readStreamed(m)
+	}
+	if ref := parseBlobRef(value); ref != nil {
+		return s.readBlob(ref)
+	}
+	if parseDedupRef(value) != nil {
+		v, err := s.deduped(value)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, v...), nil
+	}
+	return value, nil
+}
+
+// listedValue returns the value held by v, as returned by lists and scans: the values referred to by
+// streamed values and external blobs are read like Read reads them, while deduplicated values are
+// only valid during the session transaction, like the other values of bolt.
+func (s *Session) listedValue(v []byte) ([]byte, error) {
+	if parseDedupRef(v) != nil {
+		return s.deduped(v)
+	}
+	return s.resolveRef(v)
+}
+
+// refOf returns a copy of value when it refers to a streamed value, an external blob
+// or a deduplicated value, nil otherwise.
+func refOf(value []byte) []byte {
+	if !isRef(value) {
+		return nil
+	}
+	return append([]byte{}, value...)
+}
+
+// swapRefs maintains the reference counts of the streamed values, external blobs and deduplicated values referenced
+// by the values replaced by a write, replaced being the value replaced, see refOf, and value
+// the value written, nil for deletes.
+func (s *Session) swapRefs(replaced, value []byte) error {
+	if m := parseManifest(value); m != nil {
+		if err := s.addStreamRef(m.gen, 1); err != nil {
+			return err
+		}
+	}
+	if r := parseBlobRef(value); r != nil {
+		if err := s.addBlobRef(r, 1); err != nil {
+			return err
+		}
+	}
+	if hash := parseDedupRef(value); hash != nil {
+		if err := s.addDedupRef(hash, 1); err != nil {
+			return err
+		}
+	}
+	return s.releaseRefs(replaced)
+}
+
+// releaseRefs drops the reference held by value to a streamed value, an external blob or a deduplicated value.
+func (s *Session) releaseRefs(value []byte) error {
+	if m := parseManifest(value); m != nil {
+		return s.releaseStream(m)
+	}
+	if r := parseBlobRef(value); r != nil {
+		return s.addBlobRef(r, -1)
+	}
+	if hash := parseDedupRef(value); hash != nil {
+		return s.addDedupRef(hash, -1)
+	}
+	return nil
+}
+
+// addStreamRef adds delta to the reference count of generation gen.
+func (s *Session) addStreamRef(gen uint64, delta int64) error {
+	var count int64
+	if v := s.getShadow(chunkBucket, nil, refKey(gen)); len(v) == 8 {
+		count = int64(binary.BigEndian.Uint64(v))
+	}
+	count += delta
+
+	if count <= 0 {
+		return s.deleteShadow(chunkBucket, nil, refKey(gen))
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(count))
+
+	return s.putShadow(chunkBucket, nil, refKey(gen), buf)
+}
+
+// releaseStream drops a reference to the chunks of m, deleting them once no longer referenced.
+func (s *Session) releaseStream(m *streamManifest) error {
+	if err := s.addStreamRef(m.gen, -1); err != nil {
+		return err
+	}
+	if s.getShadow(chunkBucket, nil, refKey(m.gen)) != nil {
+		return nil
+	}
+
+	for i := uint32(0); i < m.chunks; i++ {
+		if err := s.deleteShadow(chunkBucket, nil, chunkKey(m.gen, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseBucketRefs releases the streamed values, external blobs and deduplicated values referenced by bucket b at path
+// about to be deleted, including the values of nested buckets when recursive is set.
+func (s *Session) releaseBucketRefs(b *bolt.Bucket, path []string, recursive bool) error {
+	if metaChild(s.tx, []byte(chunkBucket)) == nil && metaChild(s.tx, []byte(blobRefBucket)) == nil &&
+		metaChild(s.tx, []byte(dedupBucket)) == nil {
+		return nil
+	}
+
+	var refs [][]byte
+	collect := func(_ []string, _, v []byte) error {
+		if ref := refOf(v); ref != nil {
+			refs = append(refs, ref)
+		}
+		return nil
+	}
+
+	if recursive {
+		_ = walkBucket(b, path, collect)
+	} else {
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if v != nil {
+				_ = collect(path, k, v)
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if err := s.releaseRefs(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
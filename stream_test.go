@@ -0,0 +1,266 @@
+package boltdb_test
+
+import (
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+const streamed = "the quick brown fox jumps over the lazy dog"
+
+// writeStream commits value for key, written with WriteStream in pieces of 5 bytes.
+func writeStream(t *testing.T, s *boltdb.Store, path []string, key, value string) {
+	t.Helper()
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	w, err := session.WriteStream(path, key)
+	require.NoError(t, err)
+	for r := strings.NewReader(value); r.Len() > 0; {
+		_, err := io.CopyN(w, r, 5)
+		if err != io.EOF {
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, w.Close())
+	closer()
+}
+
+// countChunks returns the number of keys of the chunk bucket of the closed store at dbPath.
+func countChunks(t *testing.T, dbPath string) int {
+	t.Helper()
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var n int
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket([]byte("__meta")); meta != nil {
+			if b := meta.Bucket([]byte("chunks")); b != nil {
+				n = b.Stats().KeyN
+			}
+		}
+		return nil
+	}))
+	return n
+}
+
+func TestStream(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 8, Checksums: true})
+
+	writeStream(t, s, []string{"blobs"}, "b1", streamed)
+	writeValue(t, s, []string{"blobs"}, "b2", "plain")
+
+	assert.Equal(t, streamed, readValue(t, s, []string{"blobs"}, "b1"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	for key, want := range map[string]string{"b1": streamed, "b2": "plain"} {
+		r, err := session.ReadStream([]string{"blobs"}, key)
+		require.NoError(t, err)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+		require.NoError(t, r.Close())
+	}
+
+	_, err = session.ReadStream([]string{"blobs"}, "missing")
+	assert.ErrorIs(t, err, boltdb.ErrKeyNotFound)
+}
+
+func TestStreamEmpty(t *testing.T) {
+	s := newTestStore(t)
+
+	writeStream(t, s, []string{"blobs"}, "empty", "")
+
+	assert.Equal(t, "", readValue(t, s, []string{"blobs"}, "empty"))
+}
+
+func TestStreamChunksReleased(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stream.db")
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dbPath, ChunkSize: 8}, nil)
+	require.NoError(t, s.Open())
+
+	writeStream(t, s, []string{"blobs"}, "b1", streamed)
+	writeStream(t, s, []string{"blobs"}, "b2", streamed)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	// copies share the chunks of the streamed values
+	require.NoError(t, session.CopyBucket([]string{"blobs"}, []string{"copy"}))
+	require.NoError(t, session.DeleteKey([]string{"blobs"}, "b1"))
+	require.NoError(t, session.Write([]string{"blobs"}, "b2", []byte("plain")))
+	closer()
+
+	assert.Equal(t, streamed, readValue(t, s, []string{"copy"}, "b1"))
+	assert.Equal(t, streamed, readValue(t, s, []string{"copy"}, "b2"))
+	assert.Equal(t, "plain", readValue(t, s, []string{"blobs"}, "b2"))
+
+	s.Close()
+	// 2 streams of 6 chunks, along with their reference counts
+	assert.Equal(t, 14, countChunks(t, dbPath))
+
+	require.NoError(t, s.Open())
+	session, closer, err = s.WriteSession()
+	require.NoError(t, err)
+	require.NoError(t, session.DeleteBucket([]string{"copy"}))
+	closer()
+	s.Close()
+
+	assert.Equal(t, 0, countChunks(t, dbPath))
+}
+
+func TestStreamRollback(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 8})
+
+	writeStream(t, s, []string{"blobs"}, "b1", streamed)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	rollbackTo, err := session.Savepoint()
+	require.NoError(t, err)
+
+	w, err := session.WriteStream([]string{"blobs"}, "b1")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("replaced value"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	rollbackTo()
+	closer()
+
+	assert.Equal(t, streamed, readValue(t, s, []string{"blobs"}, "b1"))
+}
+
+func TestStreamForgedManifest(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stream.db")
+	s := boltdb.NewStoreWithLogger(&boltdb.Config{DBPath: dbPath, ChunkSize: 8}, nil)
+	require.NoError(t, s.Open())
+	t.Cleanup(s.Close)
+
+	writeStream(t, s, []string{"victim"}, "secret", streamed)
+
+	// manifest of generation 1, the stream of the victim, with a guessed checksum
+	forged := make([]byte, 32)
+	copy(forged, "\x00\xffbdbstr")
+	binary.BigEndian.PutUint64(forged[8:], 1)
+	binary.BigEndian.PutUint64(forged[16:], uint64(len(streamed)))
+	binary.BigEndian.PutUint32(forged[24:], uint32((len(streamed)+7)/8))
+
+	assert.ErrorIs(t, s.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"attacker"}, "k", forged)
+	}), boltdb.ErrReservedValue)
+	assert.ErrorIs(t, s.Update(func(w boltdb.Writer) error {
+		return w.WriteMany([]string{"attacker"}, map[string][]byte{"k": forged})
+	}), boltdb.ErrReservedValue)
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	h, err := session.Bucket([]string{"victim"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, h.Write("k", forged), boltdb.ErrReservedValue)
+	closer()
+
+	// manifests forged before references were rejected fail before returning any byte
+	s.Close()
+	db, err := bolt.Open(dbPath, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("attacker"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("k"), forged)
+	}))
+	require.NoError(t, db.Close())
+	require.NoError(t, s.Open())
+
+	rs, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, err = rs.ReadStream([]string{"attacker"}, "k")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+	_, err = rs.Read([]string{"attacker"}, "k")
+	assert.ErrorIs(t, err, boltdb.ErrValueCorrupt)
+}
+
+func TestStreamValidated(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 4})
+	s.RegisterValidator([]string{"objects"}, boltdb.ValidJSON)
+	require.NoError(t, s.RegisterSchema(boltdb.Schema{
+		Buckets: []boltdb.BucketSchema{{Path: []string{"typed"}, Type: boltdb.JSONValue}},
+	}))
+
+	for _, path := range [][]string{{"objects"}, {"typed"}} {
+		writeStream(t, s, path, "valid", `{"x":1}`)
+		assert.Equal(t, `{"x":1}`, readValue(t, s, path, "valid"))
+
+		session, closer, err := s.WriteSession()
+		require.NoError(t, err)
+		w, err := session.WriteStream(path, "invalid")
+		require.NoError(t, err)
+		_, err = io.WriteString(w, `{"x":`)
+		require.NoError(t, err)
+		assert.ErrorIs(t, w.Close(), boltdb.ErrValidation)
+		closer()
+	}
+}
+
+func TestStreamIndexed(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 4})
+	require.NoError(t, s.RegisterIndex(boltdb.Index{
+		Name:   "by-type",
+		Path:   []string{"objects"},
+		Fields: []boltdb.IndexField{boltdb.StringField("/type")},
+	}))
+
+	writeStream(t, s, []string{"objects"}, "o1", `{"type":"user"}`)
+	assert.Equal(t, []string{"objects:o1"}, queryIndex(t, s, "by-type", boltdb.IndexQuery{Equal: [][]byte{[]byte("user")}}))
+
+	writeStream(t, s, []string{"objects"}, "o1", `{"type":"group"}`)
+	assert.Empty(t, queryIndex(t, s, "by-type", boltdb.IndexQuery{Equal: [][]byte{[]byte("user")}}))
+	assert.Equal(t, []string{"objects:o1"}, queryIndex(t, s, "by-type", boltdb.IndexQuery{Equal: [][]byte{[]byte("group")}}))
+
+	// rebuilding and unindexing read the streamed content as well
+	require.NoError(t, s.Update(func(w boltdb.Writer) error { return w.RebuildIndex("by-type") }))
+	assert.Equal(t, []string{"objects:o1"}, queryIndex(t, s, "by-type", boltdb.IndexQuery{}))
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error { return w.DeleteBucket([]string{"objects"}) }))
+	assert.Empty(t, queryIndex(t, s, "by-type", boltdb.IndexQuery{}))
+}
+
+func TestStreamVersioned(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 8, VersionedPaths: [][]string{{"objects"}}})
+
+	path := []string{"objects"}
+	writeStream(t, s, path, "k", streamed)                  // rev 1
+	writeStream(t, s, path, "k", strings.ToUpper(streamed)) // rev 2
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	buf, err := session.ReadAt(path, "k", 1)
+	require.NoError(t, err)
+	assert.Equal(t, streamed, string(buf))
+
+	history, err := session.History(path, "k", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{
+		{Revision: 2, Value: []byte(strings.ToUpper(streamed))},
+		{Revision: 1, Value: []byte(streamed)},
+	}, history)
+}
@@ -0,0 +1,169 @@
+package boltdb
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aserto-dev/boltdb/keys"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const tokenIndexPrefix = "__tokens:"
+
+// TokenIndex configures an inverted index over string fields of the JSON values below a bucket path.
+// Field values are split into lower case tokens of letters and digits.
+type TokenIndex struct {
+	Path   []string `json:"path"`   // bucket path prefix of the indexed keys, including nested buckets
+	Fields []string `json:"fields"` // JSON pointers of the indexed string fields
+	// Substring also indexes the suffixes of every token, so queries match within tokens
+	// instead of only at their start, at the cost of a larger index.
+	Substring bool `json:"substring"`
+}
+
+// registerTokenIndexes registers the configured token indexes which are not registered yet.
+func (s *Store) registerTokenIndexes() error {
+	for _, ti := range s.config.TokenIndexes {
+		ti := ti
+
+		name := tokenIndexPrefix + Path(ti.Path).String()
+		if _, ok := s.indexes.get(name); ok {
+			continue
+		}
+
+		err := s.RegisterIndex(Index{
+			Name:     name,
+			Path:     ti.Path,
+			tokenize: ti.tokenize,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ti *TokenIndex) tokenize(value []byte) [][]byte {
+	seen := map[string]struct{}{}
+
+	for _, pointer := range ti.Fields {
+		var field string
+		if !extractField(value, pointer, &field) {
+			continue
+		}
+
+		for _, token := range tokenize(field) {
+			seen[token] = struct{}{}
+			if !ti.Substring {
+				continue
+			}
+			for i := range token {
+				if i > 0 {
+					seen[token[i:]] = struct{}{}
+				}
+			}
+		}
+	}
+
+	tokens := make([][]byte, 0, len(seen))
+	for token := range seen {
+		tokens = append(tokens, []byte(token))
+	}
+	return tokens
+}
+
+// tokenize splits s into lower case tokens of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchTokens returns a paged collection of the keys below bucket path with an indexed field
+// containing a token starting with every token of query, using the token index covering path.
+// Returns ErrIndexNotFound when no token index covers path.
+func (s *Session) SearchTokens(path []string, query, pageToken string) ([]IndexEntry, string, error) {
+	s.store.logger.Trace("Session::SearchTokens", "path", path, "query", query)
+
+	var (
+		entries   = make([]IndexEntry, 0)
+		nextToken string
+	)
+
+	search := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		idx := s.store.indexes.tokenIndex(path)
+		if idx == nil {
+			return errors.Wrapf(ErrIndexNotFound, "no token index for %s", Path(path))
+		}
+
+		start, after, err := s.store.tokens.decode(pageToken)
+		if err != nil {
+			return err
+		}
+
+		tokens := tokenize(query)
+		if len(tokens) == 0 {
+			return nil
+		}
+
+		b, err := s.setBucket(idx.bucket())
+		if err != nil {
+			return nil
+		}
+
+		// keys matching the query, by the path and key segments of their entries
+		var matches map[string]IndexEntry
+		for _, token := range tokens {
+			found := map[string]IndexEntry{}
+
+			c := b.Cursor()
+			prefix := []byte(token)
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				if err := s.checkDeadline(); err != nil {
+					return err
+				}
+				entry, err := idx.decode(k)
+				if err != nil {
+					return err
+				}
+				if !hasPathPrefix(entry.Path, path) {
+					continue
+				}
+				id := string(keys.JoinStrings(append(append([]string{}, entry.Path...), entry.Key)...))
+				if _, ok := matches[id]; matches == nil || ok {
+					found[id] = entry
+				}
+			}
+
+			matches = found
+		}
+
+		ids := make([]string, 0, len(matches))
+		for id := range matches {
+			if start == nil || id > string(start) || (!after && id == string(start)) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		for i, id := range ids {
+			if int32(i) == pageSize {
+				nextToken = s.store.tokens.encode([]byte(ids[i-1]))
+				break
+			}
+			entries = append(entries, matches[id])
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("SearchTokens", path, query), func() error { return s.view(search) })
+
+	return entries, nextToken, wrapError("SearchTokens", path, query, err)
+}
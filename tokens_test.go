@@ -0,0 +1,90 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchTokens(t *testing.T, s *boltdb.Store, path []string, query string) []string {
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	result := []string{}
+
+	var token string
+	for {
+		entries, next, err := session.SearchTokens(path, query, token)
+		require.NoError(t, err)
+		for _, e := range entries {
+			result = append(result, boltdb.Path(e.Path).String()+":"+e.Key)
+		}
+		if next == "" {
+			return result
+		}
+		token = next
+	}
+}
+
+func TestSearchTokens(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		TokenIndexes: []boltdb.TokenIndex{{
+			Path:   []string{"objects"},
+			Fields: []string{"/display_name", "/email"},
+		}},
+	})
+
+	users := []string{"objects", "users"}
+	groups := []string{"objects", "groups"}
+
+	writeValue(t, s, users, "u1", `{"display_name":"Alice Smith","email":"alice@acme.com"}`)
+	writeValue(t, s, users, "u2", `{"display_name":"Bob Smithers","email":"bob@acme.com"}`)
+	writeValue(t, s, groups, "g1", `{"display_name":"Admins"}`)
+
+	assert.Equal(t, []string{"objects/users:u1", "objects/users:u2"}, searchTokens(t, s, users, "smi"))
+	assert.Equal(t, []string{"objects/users:u2"}, searchTokens(t, s, users, "smith bob"))
+	assert.Equal(t, []string{"objects/groups:g1"}, searchTokens(t, s, []string{"objects"}, "admin"))
+	assert.Equal(t, []string{"objects/users:u1", "objects/users:u2"}, searchTokens(t, s, users, "ACME.com"))
+	assert.Empty(t, searchTokens(t, s, users, "mith"))
+	assert.Empty(t, searchTokens(t, s, users, "  "))
+
+	writeValue(t, s, users, "u1", `{"display_name":"Carol Jones"}`)
+	assert.Equal(t, []string{"objects/users:u2"}, searchTokens(t, s, users, "smith"))
+
+	deleteKey(t, s, users, "u2")
+	assert.Empty(t, searchTokens(t, s, users, "smith"))
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+
+	_, _, err = session.SearchTokens([]string{"other"}, "smith", "")
+	assert.True(t, errors.Is(err, boltdb.ErrIndexNotFound))
+}
+
+func TestSearchTokensSubstring(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		TokenIndexes: []boltdb.TokenIndex{{
+			Path:      []string{"objects"},
+			Fields:    []string{"/display_name"},
+			Substring: true,
+		}},
+	})
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	for i := 0; i < 150; i++ {
+		require.NoError(t, session.Write([]string{"objects"}, fmt.Sprintf("u%03d", i), []byte(`{"display_name":"Bob Smithers"}`)))
+	}
+	closer()
+
+	result := searchTokens(t, s, []string{"objects"}, "mith")
+	require.Len(t, result, 150)
+	assert.Equal(t, "objects:u000", result[0])
+	assert.Equal(t, "objects:u149", result[149])
+}
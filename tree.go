@@ -0,0 +1,90 @@
+package boltdb
+
+import (
+	"bytes"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Node is a bucket of the tree returned by Session.Tree.
+type Node struct {
+	Name      string  // name of the bucket, empty for the root of the store
+	Keys      int     // number of keys of the bucket, nested buckets excluded
+	Children  []*Node // nested buckets, in byte order
+	Truncated bool    // the bucket has nested buckets below the depth limit, not listed in Children
+}
+
+// Tree returns the bucket path along with its nested buckets down to depth levels, all levels when depth
+// is zero or less, and the number of keys of every bucket. The root buckets of the store are listed
+// when path is empty, the buckets reserved by the store excluded.
+// Returns ErrPathNotFound when the bucket path does not exist.
+func (s *Session) Tree(path []string, depth int) (*Node, error) {
+	s.store.logger.Trace("Session::Tree", "path", path, "depth", depth)
+
+	if depth <= 0 {
+		depth = -1
+	}
+
+	var root *Node
+
+	tree := func(tx *bolt.Tx) error {
+		if len(path) == 0 {
+			root = &Node{}
+			return s.tree(root, tx, tx.Cursor(), depth)
+		}
+
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		root = &Node{Name: path[len(path)-1]}
+		return s.tree(root, b, b.Cursor(), depth)
+	}
+
+	err := s.intercept(newOp("Tree", path, ""), func() error { return s.view(tree) })
+
+	if err != nil {
+		s.store.logger.Trace("Tree", "error", err)
+		return nil, wrapError("Tree", path, "", err)
+	}
+
+	return root, nil
+}
+
+// tree counts the keys of the bucket of node and lists its nested buckets down to depth levels,
+// without limit when depth is negative.
+func (s *Session) tree(node *Node, parent bucketContainer, c *bolt.Cursor, depth int) error {
+	_, atRoot := parent.(*bolt.Tx)
+
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := s.checkDeadline(); err != nil {
+			return err
+		}
+
+		if v != nil {
+			node.Keys++
+			continue
+		}
+		if atRoot && bytes.Equal(k, metaBucket) {
+			continue
+		}
+		if depth == 0 {
+			node.Truncated = true
+			continue
+		}
+
+		b := parent.Bucket(k)
+		child := &Node{Name: string(k)}
+		if err := s.tree(child, b, b.Cursor(), depth-1); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, child)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nodeNames(nodes []*boltdb.Node) []string {
+	var out []string
+	for _, n := range nodes {
+		out = append(out, n.Name)
+	}
+	return out
+}
+
+func TestTree(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{TrackMetadata: true})
+	boltdbtest.Seed(t, s, listFixtures)
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	root, err := session.Tree(nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "", root.Name)
+	assert.Equal(t, []string{"l1a", "l1b"}, nodeNames(root.Children))
+
+	l1a := root.Children[0]
+	assert.Equal(t, []string{"l2a", "l2b", "l2c"}, nodeNames(l1a.Children))
+	assert.Equal(t, []string{"l3a", "l3b"}, nodeNames(l1a.Children[0].Children))
+	assert.Equal(t, 3, l1a.Children[0].Children[0].Keys)
+	assert.Equal(t, 1, l1a.Children[0].Children[1].Keys)
+	assert.False(t, l1a.Children[0].Children[0].Truncated)
+
+	node, err := session.Tree([]string{"l1a"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "l1a", node.Name)
+	assert.Equal(t, []string{"l2a", "l2b", "l2c"}, nodeNames(node.Children))
+	for _, child := range node.Children {
+		assert.Empty(t, child.Children)
+		assert.True(t, child.Truncated)
+		assert.Zero(t, child.Keys)
+	}
+
+	_, err = session.Tree([]string{"missing"}, 0)
+	assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+}
+
+func TestTreeNamespace(t *testing.T) {
+	s := newTestStore(t)
+
+	acme, err := boltdb.Namespace(s, "acme")
+	require.NoError(t, err)
+
+	require.NoError(t, acme.Update(func(w boltdb.Writer) error {
+		return w.Write([]string{"users", "admins"}, "alice", []byte("v"))
+	}))
+
+	require.NoError(t, acme.View(func(r boltdb.Reader) error {
+		root, err := r.Tree(nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "", root.Name)
+		require.Equal(t, []string{"users"}, nodeNames(root.Children))
+		assert.Equal(t, []string{"admins"}, nodeNames(root.Children[0].Children))
+		assert.Equal(t, 1, root.Children[0].Children[0].Keys)
+		return nil
+	}))
+}
@@ -0,0 +1,37 @@
+package boltdb
+
+import (
+	"github.com/pkg/errors"
+)
+
+// errUpgradeRequired fails the mutations of the read phase of Store.ReadModifyWrite.
+var errUpgradeRequired = errors.New("write session required")
+
+// ReadModifyWrite runs fn in a read session, so read-mostly operations do not hold the write lock of the
+// store. Once fn attempts a mutation, which fails in the read session, fn is run again, from the start,
+// in a write session committed when neither fn nor the session failed. fn must only depend on the store
+// content, as it may run twice, and may see a newer revision in the write session.
+func (s *Store) ReadModifyWrite(fn func(Writer) error) error {
+	session, closer, err := s.ReadSession()
+	if err != nil {
+		return err
+	}
+
+	session.upgradable = true
+	err = fn(session)
+	closer()
+
+	if !session.upgraded {
+		return err
+	}
+
+	s.logger.Trace("Store::ReadModifyWrite", "upgrade", true, "revision", session.revision)
+
+	return s.Update(fn)
+}
+
+// upgrade records the mutation attempted by the read phase of ReadModifyWrite, failing it.
+func (s *Session) upgrade() error {
+	s.upgraded = true
+	return errUpgradeRequired
+}
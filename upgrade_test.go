@@ -0,0 +1,65 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadModifyWrite(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"users"}
+	write(t, s, path, "alice")
+
+	var runs int
+	ensure := func(key string) func(w boltdb.Writer) error {
+		return func(w boltdb.Writer) error {
+			runs++
+			if w.KeyExists(path, key) {
+				return nil
+			}
+			return w.Write(path, key, []byte(key))
+		}
+	}
+
+	rev := s.Revision()
+	require.NoError(t, s.ReadModifyWrite(ensure("alice")))
+	assert.Equal(t, 1, runs, "no upgrade without mutation")
+	assert.Equal(t, rev, s.Revision())
+
+	runs = 0
+	require.NoError(t, s.ReadModifyWrite(ensure("bob")))
+	assert.Equal(t, 2, runs, "rerun in a write session")
+	assert.Equal(t, "bob", readValue(t, s, path, "bob"))
+}
+
+func TestReadModifyWriteErrors(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"users"}
+	failed := errors.New("failed")
+
+	// the mutation is rerun even when fn ignored its error in the read session
+	require.NoError(t, s.ReadModifyWrite(func(w boltdb.Writer) error {
+		_ = w.Write(path, "alice", []byte("v"))
+		return nil
+	}))
+	assert.Equal(t, "v", readValue(t, s, path, "alice"))
+
+	err := s.ReadModifyWrite(func(w boltdb.Writer) error {
+		if err := w.DeleteKey(path, "alice"); err != nil {
+			return err
+		}
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, "v", readValue(t, s, path, "alice"))
+
+	err = s.ReadModifyWrite(func(w boltdb.Writer) error {
+		_, err := w.Read(path, "bob")
+		return err
+	})
+	assert.ErrorIs(t, err, boltdb.ErrKeyNotFound)
+}
@@ -0,0 +1,96 @@
+package boltdb
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ValidateFunc checks the value of key in bucket path, returning an error when it is not valid.
+type ValidateFunc func(path []string, key, value []byte) error
+
+// ValidationError is returned by writes rejected by a validator, see Store.RegisterValidator.
+// It matches ErrValidation and unwraps to the error returned by the validator.
+type ValidationError struct {
+	Err error // validator error
+}
+
+func (e *ValidationError) Error() string {
+	return ErrValidation.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the validator error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// RegisterValidator sets the validator checking the values written to the keys of the buckets below prefix.
+// Paths use the validator registered for their longest prefix; a nil fn removes the registration.
+// Writes rejected by the validator fail with a ValidationError before the value is stored,
+// and Store.Check reports the stored values the validator rejects.
+func (s *Store) RegisterValidator(prefix []string, fn ValidateFunc) {
+	if fn == nil {
+		s.validators.set(prefix, nil)
+		return
+	}
+	s.validators.set(prefix, fn)
+}
+
+// validator returns the validator applying to bucket path, nil when there is none.
+func (s *Store) validator(path []string) ValidateFunc {
+	if v, ok := s.validators.lookup(path); ok {
+		return v.(ValidateFunc)
+	}
+	return nil
+}
+
+// validate runs the schema and the validator applying to path against value, about to be written to key.
+func (s *Session) validate(path []string, key, value []byte) error {
+	if err := s.checkSchema(path, key, value); err != nil {
+		return err
+	}
+
+	fn := s.store.validator(path)
+	if fn == nil {
+		return nil
+	}
+	if err := fn(path, key, value); err != nil {
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
+
+// AllOf returns a validator accepting values accepted by every one of fns, checked in order.
+func AllOf(fns ...ValidateFunc) ValidateFunc {
+	return func(path []string, key, value []byte) error {
+		for _, fn := range fns {
+			if err := fn(path, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MaxValueSize returns a validator rejecting values longer than n bytes.
+func MaxValueSize(n int) ValidateFunc {
+	return func(_ []string, _, value []byte) error {
+		if len(value) > n {
+			return errors.Errorf("value size %d exceeds %d bytes", len(value), n)
+		}
+		return nil
+	}
+}
+
+// ValidJSON is a validator rejecting values which are not valid JSON.
+func ValidJSON(_ []string, _, value []byte) error {
+	if !json.Valid(value) {
+		return errors.New("value is not valid JSON")
+	}
+	return nil
+}
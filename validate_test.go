@@ -0,0 +1,87 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/aserto-dev/boltdb/grpcerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestValidator(t *testing.T) {
+	store := newTestStore(t)
+	store.RegisterValidator([]string{"objects"}, boltdb.AllOf(boltdb.ValidJSON, boltdb.MaxValueSize(16)))
+	store.RegisterValidator([]string{"objects", "raw"}, boltdb.MaxValueSize(4))
+
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+
+	require.NoError(t, session.Write([]string{"objects"}, "a", []byte(`{"n":1}`)))
+	require.NoError(t, session.Write([]string{"objects", "raw"}, "b", []byte("raw")))
+	require.NoError(t, session.Write([]string{"other"}, "c", []byte("{")))
+	closer()
+
+	tests := []struct {
+		path  []string
+		value string
+	}{
+		{[]string{"objects"}, "{"},
+		{[]string{"objects", "nested"}, `{"long":"0123456789"}`},
+		{[]string{"objects", "raw"}, "{}{}{}"},
+	}
+	for _, tc := range tests {
+		session, closer, err := store.WriteSession()
+		require.NoError(t, err)
+
+		err = session.Write(tc.path, "k", []byte(tc.value))
+		assert.ErrorIs(t, err, boltdb.ErrValidation, tc.value)
+		assert.Equal(t, codes.InvalidArgument, grpcerr.Code(err))
+
+		var ve *boltdb.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		closer()
+
+		// the rejected write never reached the store
+		read, closer, err := store.ReadSession()
+		require.NoError(t, err)
+		assert.False(t, read.KeyExists(tc.path, "k"))
+		closer()
+	}
+
+	// removing the validator accepts the value
+	store.RegisterValidator([]string{"objects", "raw"}, nil)
+	writeValue(t, store, []string{"objects", "raw"}, "k", "{}")
+	assert.Equal(t, "{}", readValue(t, store, []string{"objects", "raw"}, "k"))
+}
+
+func TestValidatorCustomError(t *testing.T) {
+	store := newTestStore(t)
+
+	errReserved := errors.New("reserved key")
+	store.RegisterValidator([]string{"users"}, func(path []string, key, value []byte) error {
+		if string(key) == "root" {
+			return errReserved
+		}
+		return nil
+	})
+
+	session, closer, err := store.WriteSession()
+	require.NoError(t, err)
+
+	err = session.WriteMany([]string{"users"}, map[string][]byte{"alice": []byte("a"), "root": []byte("r")})
+	assert.ErrorIs(t, err, boltdb.ErrValidation)
+	assert.ErrorIs(t, err, errReserved)
+
+	// the pairs accepted by the validator are not written either, even once the session commits
+	require.NoError(t, session.Write([]string{"users"}, "bob", []byte("b")))
+	closer()
+
+	read, closer, err := store.ReadSession()
+	require.NoError(t, err)
+	defer closer()
+	assert.False(t, read.KeyExists([]string{"users"}, "alice"))
+	assert.True(t, read.KeyExists([]string{"users"}, "bob"))
+}
@@ -0,0 +1,118 @@
+package boltdb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ValueSizeStats reports the distribution of the sizes in bytes of the values of a bucket,
+// see Session.ValueSizeStats. Percentiles are nearest-rank percentiles, all sizes being zero for empty buckets.
+type ValueSizeStats struct {
+	Count int   // values of the bucket, those of nested buckets excluded
+	Total int64 // bytes of the values
+	Min   int
+	Max   int
+	Mean  float64
+	P50   int
+	P90   int
+	P99   int
+}
+
+// ValueSizeStats returns the distribution of the sizes of the values of bucket path, in a single walk of its
+// keys, e.g. for capacity dashboards or alerting on abnormal payload growth. Sizes are those of the values as
+// read: streamed and external values count as the size recorded by their reference, deduplicated values as
+// the size of the value shared.
+// Returns ErrPathNotFound when the bucket path does not exist.
+func (s *Session) ValueSizeStats(path []string) (*ValueSizeStats, error) {
+	s.store.logger.Trace("Session::ValueSizeStats", "path", path)
+
+	var stats *ValueSizeStats
+
+	measure := func(tx *bolt.Tx) error {
+		if err := Path(path).Validate(); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(path)
+		if err != nil {
+			return err
+		}
+
+		var sizes []int
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
+			size, err := s.valueSize(v)
+			if err != nil {
+				return errors.Wrapf(err, "key %q", k)
+			}
+			sizes = append(sizes, size)
+		}
+
+		stats = valueSizeStats(sizes)
+		return nil
+	}
+
+	err := s.intercept(newOp("ValueSizeStats", path, ""), func() error { return s.view(measure) })
+
+	if err != nil {
+		s.store.logger.Trace("ValueSizeStats", "error", err)
+		return nil, wrapError("ValueSizeStats", path, "", err)
+	}
+
+	return stats, nil
+}
+
+// valueSize returns the size of the value held by v, without reading streamed and external values.
+func (s *Session) valueSize(v []byte) (int, error) {
+	if m := parseManifest(v); m != nil {
+		return int(m.size), nil
+	}
+	if ref := parseBlobRef(v); ref != nil {
+		return int(ref.size), nil
+	}
+	value, err := s.deduped(v)
+	if err != nil {
+		return 0, err
+	}
+	return len(value), nil
+}
+
+// valueSizeStats returns the distribution of sizes, which it sorts.
+func valueSizeStats(sizes []int) *ValueSizeStats {
+	stats := &ValueSizeStats{Count: len(sizes)}
+	if len(sizes) == 0 {
+		return stats
+	}
+
+	sort.Ints(sizes)
+	for _, size := range sizes {
+		stats.Total += int64(size)
+	}
+
+	percentile := func(p float64) int {
+		rank := int(math.Ceil(p / 100 * float64(len(sizes))))
+		if rank < 1 {
+			rank = 1
+		}
+		return sizes[rank-1]
+	}
+
+	stats.Min = sizes[0]
+	stats.Max = sizes[len(sizes)-1]
+	stats.Mean = float64(stats.Total) / float64(len(sizes))
+	stats.P50 = percentile(50)
+	stats.P90 = percentile(90)
+	stats.P99 = percentile(99)
+
+	return stats
+}
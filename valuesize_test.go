@@ -0,0 +1,83 @@
+package boltdb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueSizeStats(t *testing.T) {
+	s := newTestStore(t)
+	path := []string{"sizes"}
+
+	require.NoError(t, s.Update(func(w boltdb.Writer) error {
+		// sizes 1 to 100
+		for i := 1; i <= 100; i++ {
+			if err := w.Write(path, fmt.Sprintf("key-%03d", i), make([]byte, i)); err != nil {
+				return err
+			}
+		}
+		if err := w.CreateBucket([]string{"sizes", "nested"}); err != nil {
+			return err
+		}
+		return w.CreateBucket([]string{"empty"})
+	}))
+
+	require.NoError(t, s.View(func(r boltdb.Reader) error {
+		stats, err := r.ValueSizeStats(path)
+		require.NoError(t, err)
+		assert.Equal(t, &boltdb.ValueSizeStats{
+			Count: 100,
+			Total: 5050,
+			Min:   1,
+			Max:   100,
+			Mean:  50.5,
+			P50:   50,
+			P90:   90,
+			P99:   99,
+		}, stats)
+
+		stats, err = r.ValueSizeStats([]string{"empty"})
+		require.NoError(t, err)
+		assert.Equal(t, &boltdb.ValueSizeStats{}, stats)
+
+		_, err = r.ValueSizeStats([]string{"missing"})
+		assert.ErrorIs(t, err, boltdb.ErrPathNotFound)
+		return nil
+	}))
+}
+
+func TestValueSizeStatsReferences(t *testing.T) {
+	long := strings.Repeat("x", 100)
+
+	s := newTestStoreWithConfig(t, &boltdb.Config{ChunkSize: 8, Dedup: true})
+	writeStream(t, s, []string{"sizes"}, "streamed", long)
+	writeValue(t, s, []string{"sizes"}, "deduped", strings.Repeat("y", 60))
+
+	blobs, _ := newBlobStore(t)
+	writeValue(t, blobs, []string{"sizes"}, "blob", long)
+	writeValue(t, blobs, []string{"sizes"}, "plain", "v")
+
+	for _, tc := range []struct {
+		store *boltdb.Store
+		min   int
+		max   int
+	}{
+		{s, 60, 100},
+		{blobs, 1, 100},
+	} {
+		require.NoError(t, tc.store.View(func(r boltdb.Reader) error {
+			stats, err := r.ValueSizeStats([]string{"sizes"})
+			require.NoError(t, err)
+			assert.Equal(t, 2, stats.Count)
+			assert.Equal(t, tc.min, stats.Min)
+			assert.Equal(t, tc.max, stats.Max)
+			assert.Equal(t, int64(tc.min+tc.max), stats.Total)
+			return nil
+		}))
+	}
+}
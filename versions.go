@@ -0,0 +1,254 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/aserto-dev/boltdb/keys"
+	bolt "go.etcd.io/bbolt"
+)
+
+const historyBucket = "history"
+
+const (
+	versionValue     byte = 0
+	versionTombstone byte = 1
+)
+
+// Version is a historical value of a key in a versioned bucket.
+type Version struct {
+	Revision uint64 // store revision which wrote the value
+	Value    []byte // value, nil when the key was deleted
+	Deleted  bool   // key was deleted at Revision
+}
+
+// versioned reports whether path is below one of the configured versioned paths.
+func (s *Store) versioned(path []string) bool {
+	for _, prefix := range s.config.VersionedPaths {
+		if hasPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordVersion appends the value written to key by the session to the key history,
+// when path is versioned. A nil value records a deletion.
+func (s *Session) recordVersion(path []string, key, value []byte) error {
+	if !s.store.versioned(path) {
+		return nil
+	}
+
+	hpath := shadowPath(historyBucket, path)
+	hkey := versionKey(key, s.revision+1)
+
+	s.journalKey(hpath, hkey)
+
+	b, err := s.setBucketIfNotExist(hpath)
+	if err != nil {
+		return err
+	}
+
+	entry := []byte{versionValue}
+	if value == nil {
+		entry[0] = versionTombstone
+	}
+	entry = append(entry, value...)
+
+	if err := b.Put(hkey, entry); err != nil {
+		return err
+	}
+
+	return s.pruneVersions(hpath, b, key)
+}
+
+// recordBucketVersions records the deletion of every key below the bucket at path.
+func (s *Session) recordBucketVersions(path []string) error {
+	b, err := s.setBucket(path)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		paths [][]string
+		names [][]byte
+	)
+	_ = walkBucket(b, path, func(p []string, k, _ []byte) error {
+		if s.store.versioned(p) {
+			paths = append(paths, p)
+			names = append(names, append([]byte{}, k...))
+		}
+		return nil
+	})
+
+	for i, key := range names {
+		if err := s.recordVersion(paths[i], key, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneVersions deletes the oldest versions of key exceeding the configured retention.
+func (s *Session) pruneVersions(hpath []string, b *bolt.Bucket, key []byte) error {
+	retain := s.store.config.VersionRetention
+	if retain <= 0 {
+		return nil
+	}
+
+	prefix := keys.Join(key)
+
+	var versions [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		versions = append(versions, append([]byte{}, k...))
+	}
+
+	for i := 0; i < len(versions)-retain; i++ {
+		s.journalKey(hpath, versions[i])
+		if err := b.Delete(versions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadAt returns the value key had at store revision rev in a versioned bucket path.
+// Returns ErrKeyNotFound when the key did not exist at rev, or its history has been pruned,
+// and ErrNotVersioned when path is not versioned.
+func (s *Session) ReadAt(path []string, key string, rev uint64) ([]byte, error) {
+	s.store.logger.Trace("Session::ReadAt", "path", path, "key", key, "rev", rev)
+
+	var result []byte
+
+	read := func(tx *bolt.Tx) error {
+		if err := s.checkVersioned(path); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(shadowPath(historyBucket, path))
+		if err != nil {
+			return ErrKeyNotFound
+		}
+
+		prefix := keys.Join([]byte(key))
+
+		k, v := lastVersion(b.Cursor(), []byte(key), rev)
+		if k == nil || !bytes.HasPrefix(k, prefix) || len(v) == 0 || v[0] == versionTombstone {
+			return ErrKeyNotFound
+		}
+
+		result = append([]byte{}, v[1:]...)
+
+		return nil
+	}
+
+	err := s.intercept(newOp("ReadAt", path, key), func() error { return s.view(read) })
+
+	return result, wrapError("ReadAt", path, key, err)
+}
+
+// History returns up to limit versions of key in a versioned bucket path, newest first.
+// A limit of zero or less returns all retained versions.
+func (s *Session) History(path []string, key string, limit int) ([]Version, error) {
+	s.store.logger.Trace("Session::History", "path", path, "key", key, "limit", limit)
+
+	versions := make([]Version, 0)
+
+	read := func(tx *bolt.Tx) error {
+		if err := s.checkVersioned(path); err != nil {
+			return err
+		}
+
+		b, err := s.setBucket(shadowPath(historyBucket, path))
+		if err != nil {
+			return nil
+		}
+
+		prefix := keys.Join([]byte(key))
+
+		c := b.Cursor()
+		k, v := lastVersion(c, []byte(key), math.MaxUint64)
+
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, v = c.Prev() {
+			if err := s.checkDeadline(); err != nil {
+				return err
+			}
+			if limit > 0 && len(versions) >= limit {
+				break
+			}
+			if len(k) != len(prefix)+8 || v == nil {
+				continue
+			}
+
+			version := Version{Revision: binary.BigEndian.Uint64(k[len(prefix):])}
+			if len(v) > 0 && v[0] == versionTombstone {
+				version.Deleted = true
+			} else if len(v) > 0 {
+				version.Value = append([]byte{}, v[1:]...)
+			}
+			versions = append(versions, version)
+		}
+
+		return nil
+	}
+
+	err := s.intercept(newOp("History", path, key), func() error { return s.view(read) })
+
+	return versions, wrapError("History", path, key, err)
+}
+
+func (s *Session) checkVersioned(path []string) error {
+	if err := Path(path).Validate(); err != nil {
+		return err
+	}
+	if !s.store.versioned(path) {
+		return ErrNotVersioned
+	}
+	return nil
+}
+
+// lastVersion positions c on the newest history entry of key with a revision less than
+// or equal to rev. The entry may belong to another key when key has no such version.
+func lastVersion(c *bolt.Cursor, key []byte, rev uint64) ([]byte, []byte) {
+	var seek []byte
+	if rev == math.MaxUint64 {
+		// first key past all versions of key
+		seek = keys.Join(key)
+		seek[len(seek)-1]++
+	} else {
+		seek = versionKey(key, rev+1)
+	}
+
+	if k, _ := c.Seek(seek); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// versionKey returns the history key of key at revision rev.
+func versionKey(key []byte, rev uint64) []byte {
+	return append(keys.Join(key), keys.Uint64(rev)...)
+}
+
+// walkBucket calls fn for every key-value pair below bucket b located at path, depth first.
+func walkBucket(b *bolt.Bucket, path []string, fn func(path []string, k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil {
+			if err := fn(path, k, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		child := append(append([]string{}, path...), string(k))
+		if err := walkBucket(b.Bucket(k), child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
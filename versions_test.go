@@ -0,0 +1,81 @@
+package boltdb_test
+
+import (
+	"testing"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedReadAt(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{VersionedPaths: [][]string{{"objects"}}})
+
+	path := []string{"objects", "users"}
+	write(t, s, path, "k1")                    // rev 1
+	writeValue(t, s, path, "k1", "v2")         // rev 2
+	write(t, s, path, "k2")                    // rev 3
+	deleteKey(t, s, path, "k1")                // rev 4
+	writeValue(t, s, path, "k1", "v5")         // rev 5
+	write(t, s, []string{"unversioned"}, "k1") // rev 6
+
+	session, closer, err := s.ReadSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	for rev, expected := range map[uint64]string{1: "k1", 2: "v2", 3: "v2", 5: "v5", 100: "v5"} {
+		buf, err := session.ReadAt(path, "k1", rev)
+		assert.NoError(t, err, "rev %d", rev)
+		assert.Equal(t, expected, string(buf), "rev %d", rev)
+	}
+
+	for _, rev := range []uint64{0, 4} {
+		_, err = session.ReadAt(path, "k1", rev)
+		assert.True(t, errors.Is(err, boltdb.ErrKeyNotFound), "rev %d", rev)
+	}
+
+	history, err := session.History(path, "k1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{
+		{Revision: 5, Value: []byte("v5")},
+		{Revision: 4, Deleted: true},
+		{Revision: 2, Value: []byte("v2")},
+		{Revision: 1, Value: []byte("k1")},
+	}, history)
+
+	history, err = session.History(path, "k2", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{{Revision: 3, Value: []byte("k2")}}, history)
+
+	_, err = session.History([]string{"unversioned"}, "k1", 0)
+	assert.True(t, errors.Is(err, boltdb.ErrNotVersioned))
+}
+
+func TestVersionRetention(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{
+		VersionedPaths:   [][]string{{"objects"}},
+		VersionRetention: 2,
+	})
+
+	path := []string{"objects"}
+	for _, value := range []string{"v1", "v2", "v3"} {
+		writeValue(t, s, path, "key", value)
+	}
+
+	session, closer, err := s.WriteSession()
+	require.NoError(t, err)
+	t.Cleanup(closer)
+
+	history, err := session.History(path, "key", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{
+		{Revision: 3, Value: []byte("v3")},
+		{Revision: 2, Value: []byte("v2")},
+	}, history)
+
+	require.NoError(t, session.DeleteBucket(path))
+	history, err = session.History(path, "key", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []boltdb.Version{{Revision: 4, Deleted: true}}, history)
+}
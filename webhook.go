@@ -0,0 +1,170 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultWebhookTimeout bounds every attempt to deliver a webhook when WebhookConfig.Timeout is zero.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// WebhookSignatureHeader holds the signature of the webhook body, sha256=<hex HMAC-SHA256 of the body>,
+	// when WebhookConfig.Secret is set.
+	WebhookSignatureHeader = "X-Boltdb-Signature"
+	// WebhookRevisionHeader holds the revision of the last change of the webhook body.
+	WebhookRevisionHeader = "X-Boltdb-Revision"
+)
+
+// WebhookConfig configures the webhook started by Store.StartWebhook.
+type WebhookConfig struct {
+	URL           string          // http or https endpoint the change summaries are posted to
+	Prefixes      []Path          // bucket path prefixes of the changes posted, every change when empty
+	Secret        []byte          // key signing the body in WebhookSignatureHeader, unsigned when empty
+	Client        *http.Client    // client posting the summaries, http.DefaultClient when nil
+	Timeout       time.Duration   // time allowed per attempt, DefaultWebhookTimeout when zero
+	FromRevision  uint64          // revision already posted, changes of later revisions are posted
+	BatchSize     int             // changelog events read per summary, defaultReplicationBatchSize when zero
+	RetryInterval time.Duration   // time between attempts to post a failed summary, defaultReplicationRetry when zero
+	OnError       func(err error) // called when posting a summary failed, optional
+}
+
+// WebhookPayload is the JSON body posted by webhooks, summarizing the changes committed up to Revision.
+type WebhookPayload struct {
+	Revision uint64          `json:"revision"`
+	Changes  []WebhookChange `json:"changes"`
+}
+
+// WebhookChange summarizes a committed change, values excluded.
+type WebhookChange struct {
+	Revision uint64   `json:"revision"`
+	Op       string   `json:"op"` // EventOp name, e.g. put or delete_bucket
+	Path     []string `json:"path"`
+	Key      string   `json:"key,omitempty"`
+}
+
+// StartWebhook starts posting the changes committed after cfg.FromRevision below cfg.Prefixes to cfg.URL,
+// including the deletion and recursive truncation of the buckets holding them, for integrations which cannot
+// consume a watch stream. Changes are read from the changelog in batches, each posted as a WebhookPayload,
+// at least once and in commit order: a summary is posted again after cfg.RetryInterval until the endpoint
+// answers with a 2xx status. Batches holding no matching change are not posted. Webhooks tail the changelog
+// like replicators, see Store.StartReplication, so the changelog must be enabled, and the returned replicator
+// reports the revision posted, to resume from once the store is reopened.
+// The webhook is stopped by Replicator.Stop and when the store is closed.
+func (s *Store) StartWebhook(cfg WebhookConfig) (*Replicator, error) {
+	if !s.config.EnableChangelog {
+		return nil, errors.Wrap(ErrChangelogDisabled, "webhook requires the changelog")
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid webhook url '%s'", cfg.URL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("invalid webhook url '%s'", cfg.URL)
+	}
+
+	for _, prefix := range cfg.Prefixes {
+		if err := prefix.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid webhook prefix %v", prefix)
+		}
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultWebhookTimeout
+	}
+
+	target := ReplicationTargetFunc(func(ctx context.Context, batch *ReplicationBatch) error {
+		payload := WebhookPayload{Revision: batch.Revision}
+		for _, event := range batch.Events {
+			if webhookMatches(cfg.Prefixes, event) {
+				payload.Changes = append(payload.Changes, WebhookChange{
+					Revision: event.Revision,
+					Op:       event.Op.String(),
+					Path:     event.Path,
+					Key:      string(event.Key),
+				})
+			}
+		}
+		if len(payload.Changes) == 0 {
+			return nil
+		}
+		return postWebhook(ctx, &cfg, &payload)
+	})
+
+	return s.StartReplication(ReplicationConfig{
+		Target:        target,
+		FromRevision:  cfg.FromRevision,
+		BatchSize:     cfg.BatchSize,
+		RetryInterval: cfg.RetryInterval,
+		OnError:       cfg.OnError,
+	})
+}
+
+func webhookMatches(prefixes []Path, event Event) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if eventUnder(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// postWebhook posts payload to the webhook url, failing unless the endpoint answered with a 2xx status.
+func postWebhook(ctx context.Context, cfg *WebhookConfig, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookRevisionHeader, strconv.FormatUint(payload.Revision, 10))
+	if len(cfg.Secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(cfg.Secret, body))
+	}
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to post webhook")
+	}
+	defer resp.Body.Close()
+	// drain the body, so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the signature of a webhook body, as sent in WebhookSignatureHeader.
+// Receivers verify webhooks by comparing it to the header with hmac.Equal.
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
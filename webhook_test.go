@@ -0,0 +1,103 @@
+package boltdb_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	secret := []byte("secret")
+
+	var (
+		mu       sync.Mutex
+		payloads []boltdb.WebhookPayload
+		failures = 1
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, boltdb.SignWebhook(secret, body), r.Header.Get(boltdb.WebhookSignatureHeader))
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var payload boltdb.WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	r, err := store.StartWebhook(boltdb.WebhookConfig{
+		URL:           server.URL,
+		Prefixes:      []boltdb.Path{{"tenants", "a"}, {"tenants", "c"}},
+		Secret:        secret,
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer r.Stop()
+
+	write(t, store, []string{"tenants", "a", "users"}, "alice")
+	write(t, store, []string{"tenants", "b", "users"}, "bob")
+	deleteKey(t, store, []string{"tenants", "a", "users"}, "alice")
+	write(t, store, []string{"tenants", "c"}, "carol")
+	// deleting a bucket holding a prefix deletes its keys
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.DeleteBucket([]string{"tenants", "b"}) }))
+	require.NoError(t, store.Update(func(w boltdb.Writer) error { return w.DeleteBucket([]string{"tenants"}) }))
+
+	require.Eventually(t, func() bool { return r.Stats().Shipped == store.Revision() }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, uint64(1), r.Stats().Errors)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var changes []boltdb.WebhookChange
+	for _, payload := range payloads {
+		changes = append(changes, payload.Changes...)
+	}
+
+	var (
+		puts, deletes []string
+		deleted       [][]string
+	)
+	for _, change := range changes {
+		assert.NotContains(t, change.Path, "b")
+		switch change.Op {
+		case "put":
+			puts = append(puts, change.Key)
+		case "delete":
+			deletes = append(deletes, change.Key)
+		case "delete_bucket":
+			deleted = append(deleted, change.Path)
+		}
+	}
+	assert.Equal(t, []string{"alice", "carol"}, puts)
+	assert.Equal(t, []string{"alice"}, deletes)
+	assert.Equal(t, [][]string{{"tenants"}}, deleted)
+}
+
+func TestWebhookConfig(t *testing.T) {
+	_, err := newTestStore(t).StartWebhook(boltdb.WebhookConfig{URL: "http://localhost"})
+	assert.ErrorIs(t, err, boltdb.ErrChangelogDisabled)
+
+	store := newTestStoreWithConfig(t, &boltdb.Config{EnableChangelog: true})
+	for _, u := range []string{"", "localhost:8080", "ftp://localhost", "http://"} {
+		_, err = store.StartWebhook(boltdb.WebhookConfig{URL: u})
+		assert.Error(t, err, u)
+	}
+}
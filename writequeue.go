@@ -0,0 +1,216 @@
+package boltdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WriteQueueStats reports the write sessions queued for the single writer of the store, see Store.WriteQueueStats.
+type WriteQueueStats struct {
+	Waiting   int           // write sessions currently waiting for the writer
+	Acquired  uint64        // write sessions started
+	Rejected  uint64        // write sessions rejected with ErrWriteQueueFull
+	Canceled  uint64        // write sessions whose context was done while waiting
+	TotalWait time.Duration // time spent waiting by the write sessions started
+	MaxWait   time.Duration // longest wait of a write session started
+}
+
+// WriteQueueStats returns the statistics of the write queue.
+func (s *Store) WriteQueueStats() WriteQueueStats {
+	return s.writer.stats()
+}
+
+// WriteSessionContext starts a new write session once the write sessions queued before it ended, or fails
+// with the error of ctx once it is done. Write sessions start in the order they were requested, by decreasing
+// priority, see WithWritePriority, and fail with ErrWriteQueueFull when Config.MaxWriteQueue sessions are
+// already waiting.
+func (s *Store) WriteSessionContext(ctx context.Context) (*Session, func(), error) {
+	session, err := s.beginContext(ctx, true)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to start write transaction")
+	}
+
+	closer := func() {
+		if err := session.commit(); err != nil {
+			s.logger.Trace("WriteSession::commit", "error", err)
+		}
+	}
+
+	return session, closer, nil
+}
+
+// UpdateContext runs fn in a write session started by WriteSessionContext, committed when neither fn
+// nor the session failed.
+func (s *Store) UpdateContext(ctx context.Context, fn func(Writer) error) error {
+	session, err := s.beginContext(ctx, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to start write transaction")
+	}
+
+	if err := fn(session); err != nil {
+		session.rollback()
+		return err
+	}
+
+	return session.commit()
+}
+
+// WritePriority hints the write queue at the urgency of a write session, see WithWritePriority.
+type WritePriority int
+
+const (
+	// WritePriorityBackground is the priority of long-running bulk writes, such as imports, which start
+	// once no write session of a higher priority is waiting.
+	WritePriorityBackground WritePriority = -1
+	// WritePriorityNormal is the priority of write sessions without a priority hint.
+	WritePriorityNormal WritePriority = 0
+	// WritePriorityInteractive is the priority of the mutations of API requests, which start ahead of
+	// the waiting write sessions of lower priorities.
+	WritePriorityInteractive WritePriority = 1
+)
+
+// writePriorities is the number of priority levels, from WritePriorityBackground to WritePriorityInteractive.
+const writePriorities = 3
+
+type writePriorityKey struct{}
+
+// WithWritePriority returns a copy of ctx hinting the write sessions started with it, by WriteSessionContext
+// and UpdateContext, at priority p. Waiting write sessions start by decreasing priority, then in the order they
+// were requested, so interactive mutations are not queued behind the batches of a bulk import. Write sessions
+// of a lower priority wait as long as higher priority sessions keep queueing.
+func WithWritePriority(ctx context.Context, p WritePriority) context.Context {
+	return context.WithValue(ctx, writePriorityKey{}, p)
+}
+
+// writePriority returns the priority hinted by ctx, WritePriorityNormal without a hint.
+func writePriority(ctx context.Context) WritePriority {
+	p, _ := ctx.Value(writePriorityKey{}).(WritePriority)
+	switch {
+	case p < WritePriorityBackground:
+		return WritePriorityBackground
+	case p > WritePriorityInteractive:
+		return WritePriorityInteractive
+	}
+	return p
+}
+
+// writeQueue hands the single writer of the store to the waiting write sessions of the highest priority,
+// in the order they asked for it.
+type writeQueue struct {
+	mu      sync.Mutex
+	held    bool                             // the writer is held by a write session
+	waiters [writePriorities][]chan struct{} // waiting write sessions by priority, closed once handed the writer
+	waiting int
+	stat    WriteQueueStats
+}
+
+func newWriteQueue() *writeQueue {
+	return &writeQueue{}
+}
+
+// acquire waits for the writer, failing with ErrWriteQueueFull when max sessions are already waiting,
+// unless max is zero, or with the error of ctx once it is done. The priority of the session is hinted by ctx.
+func (q *writeQueue) acquire(ctx context.Context, max int) error {
+	q.mu.Lock()
+	if !q.held {
+		q.held = true
+		q.acquiredLocked(0)
+		q.mu.Unlock()
+		return nil
+	}
+	if max > 0 && q.waiting >= max {
+		q.stat.Rejected++
+		q.mu.Unlock()
+		return errors.Wrapf(ErrWriteQueueFull, "%d write sessions waiting", max)
+	}
+
+	level := writePriority(ctx) - WritePriorityBackground
+	ready := make(chan struct{})
+	q.waiters[level] = append(q.waiters[level], ready)
+	q.waiting++
+	q.mu.Unlock()
+
+	start := time.Now()
+
+	select {
+	case <-ready:
+		q.acquired(time.Since(start))
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-ready:
+		// handed the writer while ctx was done
+		q.acquiredLocked(time.Since(start))
+		return nil
+	default:
+	}
+
+	waiters := q.waiters[level]
+	for i, w := range waiters {
+		if w == ready {
+			q.waiters[level] = append(waiters[:i:i], waiters[i+1:]...)
+			break
+		}
+	}
+	q.waiting--
+	q.stat.Canceled++
+
+	return ctx.Err()
+}
+
+func (q *writeQueue) acquired(wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.acquiredLocked(wait)
+}
+
+func (q *writeQueue) acquiredLocked(wait time.Duration) {
+	q.stat.Acquired++
+	q.stat.TotalWait += wait
+	if wait > q.stat.MaxWait {
+		q.stat.MaxWait = wait
+	}
+}
+
+// release hands the writer to the first waiting write session of the highest priority.
+func (q *writeQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for level := writePriorities - 1; level >= 0; level-- {
+		if waiters := q.waiters[level]; len(waiters) > 0 {
+			q.waiters[level] = waiters[1:]
+			q.waiting--
+			close(waiters[0])
+			return
+		}
+	}
+
+	q.held = false
+}
+
+func (q *writeQueue) stats() WriteQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := q.stat
+	stats.Waiting = q.waiting
+	return stats
+}
+
+// releaseWriter releases the writer held by the session, once its transaction ended.
+func (s *Session) releaseWriter() {
+	if s.writer {
+		s.writer = false
+		s.store.writer.release()
+	}
+}
@@ -0,0 +1,181 @@
+package boltdb_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/boltdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForQueue waits until n write sessions are waiting for the writer.
+func waitForQueue(t *testing.T, s *boltdb.Store, n int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return s.WriteQueueStats().Waiting == n
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestWriteQueueOrder(t *testing.T) {
+	s := newTestStore(t)
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.Update(func(w boltdb.Writer) error {
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				return nil
+			}))
+		}(i)
+		waitForQueue(t, s, i+1)
+	}
+
+	closer()
+	wg.Wait()
+
+	assert.Equal(t, []int{0, 1, 2}, order)
+
+	stats := s.WriteQueueStats()
+	assert.Zero(t, stats.Waiting)
+	assert.Equal(t, uint64(4), stats.Acquired)
+	assert.Greater(t, stats.MaxWait, time.Duration(0))
+	assert.GreaterOrEqual(t, stats.TotalWait, stats.MaxWait)
+}
+
+func TestWriteQueueFull(t *testing.T) {
+	s := newTestStoreWithConfig(t, &boltdb.Config{MaxWriteQueue: 1})
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- s.UpdateContext(context.Background(), func(w boltdb.Writer) error {
+			return w.Write([]string{"a"}, "k", []byte("v"))
+		})
+	}()
+	waitForQueue(t, s, 1)
+
+	_, _, err = s.WriteSession()
+	assert.ErrorIs(t, err, boltdb.ErrWriteQueueFull)
+	assert.Equal(t, uint64(1), s.WriteQueueStats().Rejected)
+
+	closer()
+	require.NoError(t, <-done)
+	assert.Equal(t, "v", readValue(t, s, []string{"a"}, "k"))
+}
+
+func TestWriteQueueContext(t *testing.T) {
+	s := newTestStore(t)
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err = s.WriteSessionContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, uint64(1), s.WriteQueueStats().Canceled)
+	assert.Zero(t, s.WriteQueueStats().Waiting)
+
+	closer()
+
+	session, closer, err := s.WriteSessionContext(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, session.Write([]string{"a"}, "k", []byte("v")))
+	closer()
+}
+
+func TestWriteQueuePriority(t *testing.T) {
+	s := newTestStore(t)
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		order []boltdb.WritePriority
+		wg    sync.WaitGroup
+	)
+	priorities := []boltdb.WritePriority{
+		boltdb.WritePriorityBackground,
+		boltdb.WritePriorityNormal,
+		boltdb.WritePriorityInteractive,
+		boltdb.WritePriorityBackground,
+		boltdb.WritePriorityInteractive,
+	}
+	for i, p := range priorities {
+		wg.Add(1)
+		go func(p boltdb.WritePriority) {
+			defer wg.Done()
+			ctx := boltdb.WithWritePriority(context.Background(), p)
+			assert.NoError(t, s.UpdateContext(ctx, func(w boltdb.Writer) error {
+				mu.Lock()
+				order = append(order, p)
+				mu.Unlock()
+				return nil
+			}))
+		}(p)
+		waitForQueue(t, s, i+1)
+	}
+
+	closer()
+	wg.Wait()
+
+	assert.Equal(t, []boltdb.WritePriority{
+		boltdb.WritePriorityInteractive,
+		boltdb.WritePriorityInteractive,
+		boltdb.WritePriorityNormal,
+		boltdb.WritePriorityBackground,
+		boltdb.WritePriorityBackground,
+	}, order)
+	assert.Zero(t, s.WriteQueueStats().Waiting)
+}
+
+func TestWriteQueuePriorityCanceled(t *testing.T) {
+	s := newTestStore(t)
+
+	_, closer, err := s.WriteSession()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(boltdb.WithWritePriority(context.Background(), boltdb.WritePriorityInteractive))
+	canceled := make(chan error)
+	go func() {
+		canceled <- s.UpdateContext(ctx, func(w boltdb.Writer) error { return nil })
+	}()
+	waitForQueue(t, s, 1)
+
+	done := make(chan error)
+	go func() {
+		ctx := boltdb.WithWritePriority(context.Background(), boltdb.WritePriorityBackground)
+		done <- s.UpdateContext(ctx, func(w boltdb.Writer) error {
+			return w.Write([]string{"a"}, "k", []byte("v"))
+		})
+	}()
+	waitForQueue(t, s, 2)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+	waitForQueue(t, s, 1)
+
+	closer()
+	require.NoError(t, <-done)
+	assert.Equal(t, "v", readValue(t, s, []string{"a"}, "k"))
+	assert.Equal(t, uint64(1), s.WriteQueueStats().Canceled)
+}